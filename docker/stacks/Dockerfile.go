@@ -1,9 +1,15 @@
 # Orion Agent — Go stack image
 # Pre-baked with Go 1.22
+# Multi-arch: build with scripts/build_stacks.sh (docker buildx, amd64 + arm64)
 FROM ubuntu:22.04
 
 LABEL maintainer="Phoenix Link (Pty) Ltd"
 LABEL orion.stack="go"
+LABEL orion.toolchain="go"
+
+# Set by buildx per target platform; plain `docker build` falls back to dpkg
+ARG TARGETARCH
+ARG GO_VERSION=1.22.5
 
 ENV DEBIAN_FRONTEND=noninteractive
 ENV GOPATH=/home/orion/go
@@ -15,10 +21,12 @@ RUN apt-get update && apt-get install -y --no-install-recommends \
     git \
     jq \
     make \
-    && curl -fsSL https://go.dev/dl/go1.22.5.linux-amd64.tar.gz \
+    && ARCH="${TARGETARCH:-$(dpkg --print-architecture)}" \
+    && curl -fsSL "https://go.dev/dl/go${GO_VERSION}.linux-${ARCH}.tar.gz" \
        | tar -C /usr/local -xzf - \
     && rm -rf /var/lib/apt/lists/*
 
-RUN useradd -m -s /bin/bash orion
+# UID must match ORION_UID in src/orion/security/container_runtime.py
+RUN useradd -m -u 1000 -s /bin/bash orion
 USER orion
 WORKDIR /workspace
@@ -0,0 +1,61 @@
+# Orion Agent — Java / JVM stack image
+# Pre-baked with Temurin JDK 21, Maven and Gradle
+# JDK 17 is selected per job via `toolchain: "17"` and cached
+# Multi-arch: build with scripts/build_stacks.sh (docker buildx, amd64 + arm64)
+#
+# Heap: the JVM sizes itself from the container's memory limit
+# (resources.memory), by default 25% of it.  Set -Xmx (or
+# -XX:MaxRAMPercentage) to at most ~75% of the limit for the build's JVMs
+# combined -- Gradle daemons and forked test workers are separate JVMs --
+# and leave the rest for metaspace, threads and native memory.  Going over
+# gets the job OOM-killed (oom_killed), not a java.lang.OutOfMemoryError.
+FROM ubuntu:22.04
+
+LABEL maintainer="Phoenix Link (Pty) Ltd"
+LABEL orion.stack="java"
+LABEL orion.toolchain="java"
+
+# Set by buildx per target platform; plain `docker build` falls back to dpkg
+ARG TARGETARCH
+ARG JDK_VERSION=21.0.4+7
+ARG MAVEN_VERSION=3.9.8
+ARG GRADLE_VERSION=8.8
+
+ENV DEBIAN_FRONTEND=noninteractive
+ENV JAVA_HOME=/opt/java/jdk
+ENV MAVEN_HOME=/opt/maven
+# Persistent Maven repository and Gradle caches / wrapper distributions
+# (bind-mounted when the build cache is enabled)
+ENV GRADLE_USER_HOME=/home/orion/.gradle
+# One build per container: no daemon left running after the command
+ENV GRADLE_OPTS=-Dorg.gradle.daemon=false
+ENV PATH=/opt/java/jdk/bin:/opt/maven/bin:/opt/gradle/bin:$PATH
+
+RUN apt-get update && apt-get install -y --no-install-recommends \
+    ca-certificates \
+    curl \
+    git \
+    jq \
+    make \
+    unzip \
+    && ARCH="${TARGETARCH:-$(dpkg --print-architecture)}" \
+    && case "$ARCH" in amd64) JDK_ARCH=x64 ;; arm64) JDK_ARCH=aarch64 ;; *) JDK_ARCH="$ARCH" ;; esac \
+    && JDK_MAJOR="${JDK_VERSION%%.*}" \
+    && JDK_TAG="jdk-$(echo "$JDK_VERSION" | sed 's/+/%2B/')" \
+    && JDK_FILE="OpenJDK${JDK_MAJOR}U-jdk_${JDK_ARCH}_linux_hotspot_$(echo "$JDK_VERSION" | tr + _).tar.gz" \
+    && mkdir -p /opt/java/jdk /opt/maven \
+    && curl -fsSL "https://github.com/adoptium/temurin${JDK_MAJOR}-binaries/releases/download/${JDK_TAG}/${JDK_FILE}" \
+       | tar -C /opt/java/jdk --strip-components=1 -xzf - \
+    && curl -fsSL "https://archive.apache.org/dist/maven/maven-3/${MAVEN_VERSION}/binaries/apache-maven-${MAVEN_VERSION}-bin.tar.gz" \
+       | tar -C /opt/maven --strip-components=1 -xzf - \
+    && curl -fsSL -o /tmp/gradle.zip "https://services.gradle.org/distributions/gradle-${GRADLE_VERSION}-bin.zip" \
+    && unzip -q /tmp/gradle.zip -d /opt \
+    && mv "/opt/gradle-${GRADLE_VERSION}" /opt/gradle \
+    && rm /tmp/gradle.zip \
+    && rm -rf /var/lib/apt/lists/*
+
+# UID must match ORION_UID in src/orion/security/container_runtime.py
+RUN useradd -m -u 1000 -s /bin/bash orion
+USER orion
+RUN mkdir -p /home/orion/.m2 /home/orion/.gradle
+WORKDIR /workspace
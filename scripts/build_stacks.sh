@@ -0,0 +1,55 @@
+#!/usr/bin/env bash
+# Orion Agent -- Build multi-arch stack images (amd64 + arm64)
+#
+# Builds every docker/stacks/Dockerfile.<stack> with docker buildx as a
+# manifest list, so one tag (orion-stack-<stack>:latest) works on both
+# x86_64 and Graviton / Apple Silicon hosts.
+#
+# Usage:
+#   scripts/build_stacks.sh                 # build + load host-arch images locally
+#   scripts/build_stacks.sh --push REGISTRY # build + push manifest lists
+#   STACKS="go python" scripts/build_stacks.sh
+#
+# buildx cannot --load a manifest list into the local image store, so local
+# builds also tag single-arch variants (orion-stack-<stack>:latest-<arch>),
+# which the job executor prefers when present.  Each local build is then
+# verified (orion.stack label, orion user, /workspace, toolchain on PATH)
+# by orion.security.jobs.buildstack; a failed check stops the script.
+
+set -euo pipefail
+
+PLATFORMS="${PLATFORMS:-linux/amd64,linux/arm64}"
+ROOT="$(cd "$(dirname "$0")/.." && pwd)"
+STACKS_DIR="$ROOT/docker/stacks"
+
+PUSH=0
+REGISTRY=""
+if [[ "${1:-}" == "--push" ]]; then
+    PUSH=1
+    REGISTRY="${2:?Usage: $0 --push REGISTRY}"
+fi
+
+if [[ -z "${STACKS:-}" ]]; then
+    STACKS="$(for f in "$STACKS_DIR"/Dockerfile.*; do basename "$f" | cut -d. -f2; done)"
+fi
+
+case "$(uname -m)" in
+    x86_64|amd64) HOST_ARCH=amd64 ;;
+    aarch64|arm64) HOST_ARCH=arm64 ;;
+    *) HOST_ARCH="$(uname -m)" ;;
+esac
+
+for stack in $STACKS; do
+    dockerfile="$STACKS_DIR/Dockerfile.$stack"
+    image="orion-stack-$stack:latest"
+    echo "=== $stack ($dockerfile) ==="
+
+    if [[ "$PUSH" == 1 ]]; then
+        docker buildx build --platform "$PLATFORMS" \
+            -f "$dockerfile" -t "$REGISTRY/$image" --push "$STACKS_DIR"
+    else
+        docker buildx build --platform "linux/$HOST_ARCH" \
+            -f "$dockerfile" -t "$image" -t "$image-$HOST_ARCH" --load "$STACKS_DIR"
+        PYTHONPATH="$ROOT/src" python3 -m orion.security.jobs.buildstack --no-build "$stack"
+    fi
+done
@@ -72,7 +72,7 @@ class RateLimitMiddleware(BaseHTTPMiddleware):
     async def dispatch(self, request: Request, call_next):
         # Skip rate limiting for health probes
         path = request.url.path
-        if path in ("/health", "/ready"):
+        if path in ("/health", "/ready", "/healthz", "/readyz"):
             return await call_next(request)
 
         client_ip = request.client.host if request.client else "unknown"
@@ -108,7 +108,7 @@ class OptionalAuthMiddleware(BaseHTTPMiddleware):
     """
 
     # Paths that never require auth
-    _EXEMPT_PATHS = {"/health", "/ready", "/api/oauth/callback"}
+    _EXEMPT_PATHS = {"/health", "/ready", "/healthz", "/readyz", "/api/oauth/callback"}
 
     def __init__(self, app):
         super().__init__(app)
@@ -157,6 +157,8 @@ class OptionalAuthMiddleware(BaseHTTPMiddleware):
         if auth_header.startswith("Bearer "):
             token = auth_header[7:]
             if token == server_key:
+                # What the job API's rate limiter tells authenticated clients apart by
+                request.state.api_key = token
                 return await call_next(request)
 
         logger.warning(
@@ -14,17 +14,35 @@
 #    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
 #
 # Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
-"""Orion Agent -- Health & Runtime Routes."""
+"""Orion Agent -- Health & Runtime Routes.
+
+    GET /healthz  — Job agent liveness: the process is up
+    GET /readyz   — Job agent readiness: 503 with a reason when it cannot take a job
+"""
 
 import json
 from pathlib import Path
 
 from fastapi import APIRouter
+from fastapi.responses import JSONResponse
 
 from orion._version import __version__
 
 router = APIRouter()
 
+_readiness_probe = None
+
+
+def _get_readiness_probe():
+    """Get or create the ReadinessProbe of the singleton JobExecutor."""
+    global _readiness_probe
+    if _readiness_probe is None:
+        from orion.api.routes.jobs import _get_executor
+        from orion.security.jobs.health import ReadinessProbe
+
+        _readiness_probe = ReadinessProbe(_get_executor())
+    return _readiness_probe
+
 
 @router.get("/health")
 async def health_check():
@@ -53,6 +71,21 @@ async def readiness_check():
     return {"status": "ready", "version": __version__}
 
 
+@router.get("/healthz")
+async def job_agent_liveness():
+    """Liveness probe for the job agent -- no dependency checks."""
+    return {"status": "ok", "version": __version__}
+
+
+@router.get("/readyz")
+async def job_agent_readiness():
+    """Readiness probe for the job agent (runtime, disk, queue)."""
+    readiness = await _get_readiness_probe().check()
+    if not readiness.ready:
+        return JSONResponse(status_code=503, content=readiness.to_dict())
+    return readiness.to_dict()
+
+
 @router.get("/api/runtime")
 async def get_runtime_info():
     """Get runtime information."""
@@ -0,0 +1,455 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job API routes.
+
+Provides endpoints for:
+  - Submitting a job manifest (queued FIFO behind ``scheduler.maxConcurrent``),
+    rate limited per client by ``submissions:`` (429 with ``Retry-After``);
+    one whose ``requires`` the agent's ``agent.labels`` do not satisfy gets
+    409 ("No matching capability ...") so a dispatcher can try another agent;
+    with ``signing.enabled`` one without a valid ``signature`` gets 401
+  - Uploading a tar / tar.gz archive source for a later submission
+  - Viewing scheduler status (running / queued counts, capability labels)
+  - Listing jobs by state, stack and submission time (paginated), and
+    describing one in full (manifest summary, timestamps, exit state)
+  - Validating a manifest without running it (dry run)
+  - Viewing a job's status and result
+  - Streaming a job's log lines live (Server-Sent Events)
+  - Downloading a job's artifacts as one streamed ``.tar.gz``
+  - Cancelling a queued or running job
+
+Disconnecting from the log stream never stops the job; only
+``POST /api/jobs/{job_id}/cancel`` does.
+
+On startup, job containers left behind by an earlier run are removed
+(:func:`reap_leftover_containers`, unless ``cleanup.reapOnStartup`` is off).
+On SIGTERM / SIGINT the executor starts draining (see
+:func:`install_shutdown_handlers`): submissions get 503 and ``/readyz``
+reports ``shutting_down`` while running jobs finish.  SIGHUP reloads
+the jobs config (:func:`install_reload_handler`).
+"""
+
+from __future__ import annotations
+
+import asyncio
+import base64
+import binascii
+import hashlib
+import json
+import logging
+import re
+import signal
+from datetime import datetime, timezone
+from pathlib import Path
+
+from fastapi import APIRouter, HTTPException, Request
+from fastapi.responses import StreamingResponse
+from pydantic import BaseModel
+
+from orion.security.jobs.archive import ArchiveError, UploadsFullError
+from orion.security.jobs.artifacts import archive_artifacts
+from orion.security.jobs.executor import (
+    JobStatus,
+    NoMatchingCapabilityError,
+    QueueFullError,
+    ShuttingDownError,
+)
+from orion.security.jobs.manifest import ManifestError, parse_manifest
+from orion.security.jobs.ratelimit import SubmissionLimiter, client_key, retry_after
+from orion.security.jobs.signing import SignatureError, require_pinned_archive, verify_manifest
+
+logger = logging.getLogger("orion.api.routes.jobs")
+
+router = APIRouter(prefix="/api/jobs", tags=["jobs"])
+
+# ---------------------------------------------------------------------------
+# Request / Response models
+# ---------------------------------------------------------------------------
+
+
+class JobSubmitRequest(BaseModel):
+    manifest: str  # YAML or JSON manifest text
+    archive: str = ""  # base64 tar / tar.gz for a ``source: {type: archive}`` manifest
+    signature: str = ""  # base64 Ed25519 signature of the canonical manifest (signing.py)
+    key_id: str = ""  # the ``signing.keys`` entry that made it; empty = try each
+
+
+# ---------------------------------------------------------------------------
+# Executor
+# ---------------------------------------------------------------------------
+
+# Singleton executor instance (created on first use)
+_executor = None
+
+
+def _get_executor():
+    """Get or create the singleton JobExecutor."""
+    global _executor
+    if _executor is None:
+        from orion.security.jobs.agent_log import configure_logging
+        from orion.security.jobs.config import load_config
+        from orion.security.jobs.executor import JobExecutor
+
+        config = load_config()
+        configure_logging(config.log)
+        _executor = JobExecutor(config=config)
+    return _executor
+
+
+_limiter = None
+
+
+def _get_limiter() -> SubmissionLimiter:
+    """Get or create the submission rate limiter, from the executor's config."""
+    global _limiter
+    if _limiter is None:
+        _limiter = SubmissionLimiter(_get_executor().config.submissions)
+    return _limiter
+
+
+async def reap_leftover_containers() -> int:
+    """Remove job containers an earlier (crashed) run left behind; see
+    :meth:`JobExecutor.reap_orphans`.  Returns how many were removed."""
+    return await _get_executor().reap_orphans()
+
+
+def start_warm_up() -> None:
+    """Warm the configured ``images.warmStacks`` in the background; see
+    :meth:`JobExecutor.warm_up`.  ``/readyz`` reports not ready meanwhile."""
+    _get_executor().begin_warm_up()
+
+
+async def shutdown_executor() -> None:
+    """Drain the executor, if it was ever created (see :meth:`JobExecutor.shutdown`)."""
+    if _executor is not None:
+        await _executor.shutdown()
+
+
+def install_shutdown_handlers() -> None:
+    """Start draining jobs as soon as SIGTERM or SIGINT arrives.
+
+    Must be called from the server's event loop.  The previous handlers
+    (the server's own) are chained, so it still stops listening at once;
+    the drain runs meanwhile and :func:`shutdown_executor` awaits it.
+    """
+    loop = asyncio.get_running_loop()
+
+    def begin_drain() -> None:
+        if _executor is not None:
+            _executor.begin_shutdown()
+
+    for signum in (signal.SIGTERM, signal.SIGINT):
+        try:
+            previous = signal.getsignal(signum)
+        except (ValueError, OSError, AttributeError):
+            continue
+
+        def handler(received, frame, previous=previous):
+            loop.call_soon_threadsafe(begin_drain)
+            if callable(previous):
+                previous(received, frame)
+            elif previous == signal.SIG_DFL:
+                signal.signal(received, signal.SIG_DFL)
+                signal.raise_signal(received)
+
+        try:
+            signal.signal(signum, handler)
+        except (ValueError, OSError):
+            pass  # Not the main thread
+
+
+def install_reload_handler() -> None:
+    """Reload the jobs config when SIGHUP arrives (see reload.py).
+
+    Must be called from the server's event loop.  Before the executor's
+    first use there is nothing to reload: it reads the file when created.
+    """
+    loop = asyncio.get_running_loop()
+
+    def reload() -> None:
+        if _executor is not None:
+            from orion.security.jobs.reload import reload_config
+
+            reload_config(_executor, limiter=_limiter)
+
+    def handler(received, frame):
+        loop.call_soon_threadsafe(reload)
+
+    try:
+        signal.signal(signal.SIGHUP, handler)
+    except (ValueError, OSError, AttributeError):
+        pass  # Not the main thread, or no SIGHUP on this platform
+
+
+# Why the executor may refuse a submission, and the status each gets
+_REFUSED = {QueueFullError: 429, NoMatchingCapabilityError: 409, ShuttingDownError: 503}
+
+# Page size bounds for GET /api/jobs
+_DEFAULT_PAGE = 50
+_MAX_PAGE = 500
+
+
+def _timestamp(value: str | None, name: str) -> float | None:
+    """Epoch seconds, or an ISO-8601 time (UTC unless it has an offset)."""
+    if value is None or value == "":
+        return None
+    try:
+        return float(value)
+    except ValueError:
+        pass
+    try:
+        moment = datetime.fromisoformat(value.replace("Z", "+00:00"))
+    except ValueError:
+        raise HTTPException(
+            status_code=400, detail=f"'{name}' must be epoch seconds or an ISO-8601 time"
+        )
+    if moment.tzinfo is None:
+        moment = moment.replace(tzinfo=timezone.utc)
+    return moment.timestamp()
+
+
+def _get_handle(job_id: str):
+    handle = _get_executor().get(job_id)
+    if handle is None:
+        raise HTTPException(status_code=404, detail=f"Unknown job: {job_id}")
+    return handle
+
+
+# ---------------------------------------------------------------------------
+# Endpoints
+# ---------------------------------------------------------------------------
+
+
+@router.post("")
+async def submit_job(request: JobSubmitRequest, http: Request) -> dict:
+    """Submit a manifest; the job starts immediately in the background."""
+    # Only a key the auth middleware checked: any other header would buy a fresh bucket
+    client = client_key(
+        getattr(http.state, "api_key", ""), http.client.host if http.client else ""
+    )
+    wait = _get_limiter().acquire(client)
+    if wait:
+        logger.warning("Job submission rate limit exceeded for %s", client)
+        raise HTTPException(
+            status_code=429,
+            detail="Too many job submissions; retry later",
+            headers={"Retry-After": retry_after(wait)},
+        )
+
+    try:
+        manifest = parse_manifest(request.manifest)
+    except ManifestError as exc:
+        raise HTTPException(status_code=400, detail=str(exc))
+    executor = _get_executor()
+    try:
+        signed_by = verify_manifest(
+            executor.config.signing, request.manifest, request.signature, request.key_id
+        )
+        if signed_by:
+            require_pinned_archive(manifest.source)
+    except SignatureError as exc:
+        logger.warning("Job submission refused from %s: %s", client, exc)
+        raise HTTPException(status_code=401, detail=str(exc))
+
+    saved = ""  # an upload this request stored, dropped again if the job is refused
+    if request.archive:
+        if manifest.source.kind != "archive" or manifest.source.upload:
+            raise HTTPException(
+                status_code=400,
+                detail="'archive' needs a manifest source of type archive without an upload",
+            )
+        # Size it from its base64 length before decoding it all into memory
+        max_bytes = executor.config.source.max_archive_size
+        if len(request.archive) * 3 // 4 - request.archive[-2:].count("=") > max_bytes:
+            raise HTTPException(
+                status_code=400,
+                detail=f"Archive is larger than {max_bytes} bytes (source.maxArchiveSize)",
+            )
+        try:
+            data = base64.b64decode(request.archive, validate=True)
+        except binascii.Error:
+            raise HTTPException(status_code=400, detail="'archive' is not valid base64")
+        digest = hashlib.sha256(data).hexdigest()
+        if manifest.source.sha256 and digest != manifest.source.sha256:
+            raise HTTPException(
+                status_code=400,
+                detail=f"'archive' has SHA-256 {digest}, not the manifest's source.sha256",
+            )
+        saved = manifest.source.upload = await _save_upload(_single(data))
+
+    try:
+        handle = executor.submit(manifest, signed_by=signed_by)
+    except tuple(_REFUSED) as exc:
+        if saved:
+            executor.discard_upload(saved)  # no job will ever claim it
+        raise HTTPException(status_code=_REFUSED[type(exc)], detail=str(exc))
+    return {"job_id": handle.job_id, "status": handle.result.status}
+
+
+@router.post("/uploads")
+async def upload_archive(http: Request) -> dict:
+    """Store the request body, a tar or tar.gz, as an archive source.
+
+    Submit it with ``source: {type: archive, upload: <upload_id>}``.  An
+    upload is for one job and is deleted once that job has run.  Past
+    ``source.maxUploadsSize`` of stored uploads, more get 507.
+    """
+    return {"upload_id": await _save_upload(http.stream())}
+
+
+async def _save_upload(chunks) -> str:
+    try:
+        return await _get_executor().save_upload(chunks)
+    except UploadsFullError as exc:
+        raise HTTPException(status_code=507, detail=str(exc))
+    except ArchiveError as exc:
+        raise HTTPException(status_code=400, detail=str(exc))
+
+
+async def _single(data: bytes):
+    yield data
+
+
+@router.get("")
+async def list_jobs(
+    state: str = "",
+    stack: str = "",
+    since: str | None = None,
+    until: str | None = None,
+    limit: int = _DEFAULT_PAGE,
+    cursor: int | None = None,
+) -> dict:
+    """List jobs, newest first: ``{jobs: [...], next_cursor}``.
+
+    ``state`` is queued, running or finished; ``since`` / ``until`` bound
+    the submission time.  Pass ``next_cursor`` back as ``cursor`` for the
+    next page; it is null on the last one.  Finished jobs are listed
+    until ``history.maxJobs`` / ``history.maxAge`` evicts them.
+    """
+    if not 1 <= limit <= _MAX_PAGE:
+        raise HTTPException(status_code=400, detail=f"'limit' must be 1-{_MAX_PAGE}")
+    try:
+        handles, next_cursor = _get_executor().list_jobs(
+            state=state,
+            stack=stack,
+            since=_timestamp(since, "since"),
+            until=_timestamp(until, "until"),
+            limit=limit,
+            before=cursor,
+        )
+    except ValueError as exc:
+        raise HTTPException(status_code=400, detail=str(exc))
+    return {"jobs": [handle.summary() for handle in handles], "next_cursor": next_cursor}
+
+
+@router.get("/status")
+async def get_scheduler_status() -> dict:
+    """Get the number of running and queued jobs, the configured limits and the agent's labels."""
+    executor = _get_executor()
+    return {**executor.stats(), "labels": dict(executor.config.agent.labels)}
+
+
+@router.post("/validate")
+async def validate_job(request: JobSubmitRequest) -> dict:
+    """Dry-run a manifest: report every problem, or ok with the image it would use."""
+    report = await _get_executor().validate(request.manifest)
+    return report.to_dict()
+
+
+@router.get("/{job_id}")
+async def get_job(job_id: str) -> dict:
+    """Get a job's current status, or its result once finished."""
+    return _get_handle(job_id).result.to_dict()
+
+
+@router.get("/{job_id}/describe")
+async def describe_job(job_id: str) -> dict:
+    """Get everything known about a job: its result, timestamps and manifest summary.
+
+    The manifest summary keeps ``env`` / ``envFile`` names but not their
+    values, and URLs without credentials.
+    """
+    return _get_handle(job_id).describe()
+
+
+@router.get("/{job_id}/logs")
+async def stream_job_logs(job_id: str, since: int = 0) -> StreamingResponse:
+    """Stream a job's output as Server-Sent Events.
+
+    Each ``data:`` event is one line: ``{seq, stream, line, timestamp}``.
+    Lines already produced are replayed first (from ``since``).  A final
+    ``event: end`` carries the job result once it finishes.  A job whose
+    output passed ``output.maxLogBytes`` sends one ``[orion] Output
+    truncated`` stderr line in its place, and its result has
+    ``logs_truncated`` set.
+    """
+    handle = _get_handle(job_id)
+
+    async def _events():
+        async for line in handle.logs.follow(since):
+            yield f"id: {line.seq}\ndata: {json.dumps(line.to_dict())}\n\n"
+        # The channel closes just before the task finishes.  Shield it so a
+        # client disconnect here cancels only this stream, not the job.
+        if not handle.done:
+            await asyncio.shield(handle.task)
+        yield f"event: end\ndata: {json.dumps(handle.result.to_dict())}\n\n"
+
+    return StreamingResponse(
+        _events(),
+        media_type="text/event-stream",
+        headers={"Cache-Control": "no-cache"},
+    )
+
+
+@router.get("/{job_id}/artifacts.tar.gz")
+async def download_artifacts(job_id: str) -> StreamingResponse:
+    """Download every artifact of a job as one gzipped tar, streamed.
+
+    Members are named by their path under ``/workspace`` and keep their
+    file modes.  404 if the job is unknown (or evicted from history) or
+    produced no artifacts.
+    """
+    result = _get_handle(job_id).result
+    if not result.artifacts or not Path(result.artifacts_dir).is_dir():
+        raise HTTPException(status_code=404, detail=f"Job {job_id} produced no artifacts")
+    filename = re.sub(r"[^A-Za-z0-9._-]", "_", f"job-{job_id}-artifacts.tar.gz")
+    # A plain iterator: Starlette runs it in a thread, off the event loop
+    return StreamingResponse(
+        archive_artifacts(Path(result.artifacts_dir), result.artifacts),
+        media_type="application/gzip",
+        headers={"Content-Disposition": f'attachment; filename="{filename}"'},
+    )
+
+
+@router.post("/{job_id}/cancel")
+async def cancel_job(job_id: str) -> dict:
+    """Cancel a job: drop it from the queue, or stop it and tear down its container.
+
+    Waits for the job to reach its terminal state and returns it.  Safe to
+    repeat: cancelling a finished job changes nothing and reports how it
+    ended (``cancelled`` is False if it finished on its own).
+    """
+    handle = _get_handle(job_id)
+    _get_executor().cancel(job_id)
+    # asyncio.wait never cancels the job if this request is dropped
+    if not handle.done:
+        await asyncio.wait({handle.task})
+    return {
+        "job_id": job_id,
+        "cancelled": handle.result.status == JobStatus.CANCELLED.value,
+        "status": handle.result.status,
+    }
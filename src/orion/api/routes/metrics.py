@@ -0,0 +1,46 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Prometheus metrics endpoint.
+
+    GET /metrics   — Job lifecycle metrics in Prometheus text format
+
+Disabled (404) when ``metrics.enabled: false`` in jobs_config.yaml.
+"""
+
+from __future__ import annotations
+
+import logging
+
+from fastapi import APIRouter, HTTPException
+from fastapi.responses import PlainTextResponse
+
+from orion.api.routes.jobs import _get_executor
+
+logger = logging.getLogger("orion.api.routes.metrics")
+
+router = APIRouter(tags=["metrics"])
+
+
+@router.get("/metrics", response_class=PlainTextResponse)
+async def get_metrics() -> PlainTextResponse:
+    """Export job metrics for Prometheus scraping."""
+    executor = _get_executor()
+    if executor.metrics is None:
+        raise HTTPException(status_code=404, detail="Metrics are disabled")
+    stats = executor.stats()
+    body = executor.metrics.to_prometheus(running=stats["running"], queued=stats["queued"])
+    return PlainTextResponse(body, media_type="text/plain; version=0.0.4")
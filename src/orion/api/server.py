@@ -75,7 +75,7 @@ class RequestLoggingMiddleware(BaseHTTPMiddleware):
         if log:
             # Skip noisy health checks from log
             path = request.url.path
-            if path not in ("/api/health",):
+            if path not in ("/api/health", "/healthz", "/readyz"):
                 log.http_request(
                     method=request.method,
                     path=path,
@@ -115,6 +115,38 @@ async def _on_startup():
     except Exception as exc:
         logger.warning("Sandbox lifecycle not available: %s", exc)
 
+    # Drain jobs on SIGTERM / SIGINT; the server still stops listening at once
+    try:
+        from orion.api.routes.jobs import install_shutdown_handlers
+
+        install_shutdown_handlers()
+    except Exception as exc:
+        logger.warning("Job shutdown handlers not installed: %s", exc)
+
+    # Reload the jobs config on SIGHUP
+    try:
+        from orion.api.routes.jobs import install_reload_handler
+
+        install_reload_handler()
+    except Exception as exc:
+        logger.warning("Jobs config reload handler not installed: %s", exc)
+
+    # Remove job containers a crashed earlier run left behind
+    try:
+        from orion.api.routes.jobs import reap_leftover_containers
+
+        await reap_leftover_containers()
+    except Exception as exc:
+        logger.warning("Leftover job containers not reaped: %s", exc)
+
+    # Pull the configured warm stacks; the agent is not ready until done
+    try:
+        from orion.api.routes.jobs import start_warm_up
+
+        start_warm_up()
+    except Exception as exc:
+        logger.warning("Stack warm-up not started: %s", exc)
+
 
 @app.on_event("shutdown")
 async def _on_shutdown():
@@ -132,6 +164,14 @@ async def _on_shutdown():
     except Exception as exc:
         logger.warning("Sandbox lifecycle shutdown error: %s", exc)
 
+    # Drain the job agent: running jobs get scheduler.shutdownGracePeriod
+    try:
+        from orion.api.routes.jobs import shutdown_executor
+
+        await shutdown_executor()
+    except Exception as exc:
+        logger.warning("Job executor shutdown error: %s", exc)
+
 
 # =============================================================================
 # AEGIS INVARIANT 6: Web Approval Queue (HARDCODED -- NOT CONFIGURABLE)
@@ -262,6 +302,8 @@ from orion.api.routes.egress import router as egress_router
 from orion.api.routes.gdpr import router as gdpr_router
 from orion.api.routes.google import router as google_router
 from orion.api.routes.health import router as health_router
+from orion.api.routes.jobs import router as jobs_router
+from orion.api.routes.metrics import router as metrics_router
 from orion.api.routes.models import router as models_router
 from orion.api.routes.performance import router as performance_router
 from orion.api.routes.platforms import router as platforms_router
@@ -282,6 +324,8 @@ app.include_router(ara_router)
 app.include_router(egress_router)
 app.include_router(google_router)
 app.include_router(performance_router)
+app.include_router(jobs_router)
+app.include_router(metrics_router)
 
 
 # =============================================================================
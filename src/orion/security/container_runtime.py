@@ -0,0 +1,669 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Container runtime interface -- Docker or Podman.
+
+SessionContainer and the job agent perform every container operation
+(create, start, exec, stop, remove, copy, networking, image inspect /
+pull) through a :class:`ContainerRuntime`, so the engine is a config
+choice (``runtime.driver`` in jobs_config.yaml) rather than a code change.
+
+Both implementations drive the engine's CLI, whose command sets are
+compatible.  They differ in:
+
+  Socket:  Docker's CLI always talks to a daemon (``/var/run/docker.sock``
+           unless ``DOCKER_HOST`` says otherwise).  Podman needs no daemon;
+           a configured socket switches it to ``--remote`` mode against
+           ``podman system service`` (rootless default
+           ``$XDG_RUNTIME_DIR/podman/podman.sock``).
+  UIDs:    Stack images run as ``orion`` (UID 1000).  Rootful engines map
+           UIDs 1:1, so host UID 1000 owns what the job writes to
+           ``/workspace``.  Rootless Podman maps container UID 0 to the
+           invoking user and 1000 to a subordinate UID that cannot write
+           the user's bind mount; ``--userns=keep-id`` maps the invoking
+           user onto ``orion`` (or the job's ``runAs`` user) instead, so
+           both sides own the workspace (``keep-id:uid=`` needs Podman 4.3+).
+  Hardening:  ``--cap-drop`` / ``--cap-add``, ``--security-opt
+           no-new-privileges``, ``--security-opt seccomp=`` and ``--ulimit``
+           (:class:`ContainerSecurity`) are spelled the same by both.
+  Logins:  A configured registry login is passed to a single pull through
+           a throwaway auth file (``DOCKER_CONFIG`` / ``--authfile``), so
+           the host never needs a prior ``login`` and its own credential
+           store is left untouched.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import base64
+import json
+import logging
+import os
+import shutil
+import subprocess
+import tempfile
+from collections.abc import Callable
+from dataclasses import dataclass, field
+from pathlib import Path
+
+logger = logging.getLogger("orion.security.container_runtime")
+
+# The ``orion`` user of every stack image (docker/stacks/)
+ORION_USER = "orion"
+ORION_UID = 1000
+ORION_GID = 1000
+
+DRIVERS = ("docker", "podman")
+
+DEFAULT_REGISTRY = "docker.io"
+
+# Streamed output is read this much at a time; longer lines are reported in pieces
+STREAM_CHUNK = 64 * 1024
+
+
+# ---------------------------------------------------------------------------
+# ContainerSpec dataclass
+# ---------------------------------------------------------------------------
+@dataclass
+class ContainerSecurity:
+    """Kernel-level restrictions of a container, on top of its resource limits.
+
+    The defaults add none: the engine's own capability set and seccomp
+    profile apply.
+    """
+
+    no_new_privileges: bool = False  # setuid binaries / file capabilities grant nothing
+    cap_drop: list[str] = field(default_factory=list)  # e.g. ['ALL']
+    cap_add: list[str] = field(default_factory=list)  # given back after cap_drop
+    ulimits: dict[str, str] = field(default_factory=dict)  # name -> 'soft[:hard]'
+    seccomp_profile: str = ""  # host path of a JSON profile; '' = the engine's default
+
+    def create_args(self) -> list[str]:
+        """The ``create`` flags applying these restrictions."""
+        args: list[str] = []
+        for cap in self.cap_drop:
+            args += ["--cap-drop", cap]
+        for cap in self.cap_add:
+            args += ["--cap-add", cap]
+        if self.no_new_privileges:
+            args += ["--security-opt", "no-new-privileges"]
+        if self.seccomp_profile:
+            args += ["--security-opt", f"seccomp={self.seccomp_profile}"]
+        for name, value in self.ulimits.items():
+            args += ["--ulimit", f"{name}={value}"]
+        return args
+
+
+@dataclass
+class ContainerSpec:
+    """Everything needed to create a session container."""
+
+    name: str
+    image: str
+    command: list[str]
+    workspace: str  # host directory mounted read-write at /workspace
+    volumes: list[str] = field(default_factory=list)  # other ``host:container[:mode]``
+    env_names: list[str] = field(default_factory=list)  # values come from the CLI's env
+    workdir: str = "/workspace"
+    network: str = "none"
+    memory: str = ""
+    cpus: str = ""
+    pids: str = ""
+    pull: str = ""  # ``--pull`` policy for create; engine default when empty
+    user: str = ""  # 'uid:gid' instead of the image's user
+    labels: dict[str, str] = field(default_factory=dict)
+    security: ContainerSecurity = field(default_factory=ContainerSecurity)
+
+
+@dataclass
+class RegistryLogin:
+    """Credentials for pulling from one registry."""
+
+    username: str
+    password: str
+
+    def __repr__(self) -> str:
+        return f"RegistryLogin(username={self.username!r}, password='***')"
+
+
+def registry_of(image: str) -> str:
+    """The registry host of an image reference (``docker.io`` if none)."""
+    first, sep, _ = image.partition("/")
+    if sep and ("." in first or ":" in first or first == "localhost"):
+        return first
+    return DEFAULT_REGISTRY
+
+
+# Pull output that means the registry rejected (or wanted) credentials.
+# Docker Hub's "requested access to the resource is denied" is left out: it
+# is also what a repository that does not exist looks like.
+_AUTH_FAILURE_MARKERS = (
+    "unauthorized",
+    "authentication required",
+    "authentication failed",
+    "no basic auth credentials",
+    "incorrect username or password",
+    "invalid username/password",
+)
+
+
+def is_auth_failure(output: str) -> bool:
+    """True if a failed pull's output says the registry refused its login."""
+    text = output.lower()
+    return any(marker in text for marker in _AUTH_FAILURE_MARKERS)
+
+
+def _repository(image: str) -> str:
+    """``image`` without its tag or digest."""
+    name = image.split("@", 1)[0]
+    if name.rfind(":") > name.rfind("/"):
+        name = name.rsplit(":", 1)[0]
+    return name
+
+
+# ---------------------------------------------------------------------------
+# Interface
+# ---------------------------------------------------------------------------
+class ContainerRuntime:
+    """Container operations used by the agent.  Subclasses implement each.
+
+    Operations return the engine's ``CompletedProcess`` (returncode,
+    stdout, stderr) and raise ``asyncio.TimeoutError`` past ``timeout``.
+    """
+
+    name = ""
+
+    def is_available(self) -> bool:
+        raise NotImplementedError
+
+    async def create(
+        self, spec: ContainerSpec, env: dict[str, str] | None = None
+    ) -> subprocess.CompletedProcess:
+        """Create (but do not start) a container.  ``env`` holds the values
+        of ``spec.env_names``; they never appear in argv."""
+        raise NotImplementedError
+
+    async def start(self, name: str) -> subprocess.CompletedProcess:
+        raise NotImplementedError
+
+    async def exec(
+        self,
+        name: str,
+        argv: list[str],
+        timeout: int = 60,
+        env: dict[str, str] | None = None,
+        input_data: str | None = None,
+        workdir: str | None = None,
+        on_output: Callable[[str, str], None] | None = None,
+        user: str | None = None,
+        max_output: int | None = None,
+        secret_env: dict[str, str] | None = None,
+    ) -> subprocess.CompletedProcess:
+        """Run ``argv`` in a running container, attached to its output.
+
+        With ``on_output``, each line is reported as ``(stream, line)`` as
+        soon as it is produced; the full output is still returned.  ``user``
+        overrides the image's default user.  ``max_output`` caps the bytes
+        of output returned, stdout and stderr together; lines past it are
+        still reported but not kept.  ``secret_env`` is passed like ``env``
+        but by name only, its values through the CLI's own environment, so
+        they never appear in its argv.
+        """
+        raise NotImplementedError
+
+    async def stop(self, name: str, grace: int = 5) -> subprocess.CompletedProcess:
+        raise NotImplementedError
+
+    async def remove(self, name: str) -> subprocess.CompletedProcess:
+        """Force-remove a container, running or not."""
+        raise NotImplementedError
+
+    async def list_containers(self, label: str) -> subprocess.CompletedProcess:
+        """Name every container carrying ``label`` (any value), running or not, one per line."""
+        raise NotImplementedError
+
+    async def copy_from(
+        self, name: str, path: str, dest: Path | str, timeout: int = 300
+    ) -> subprocess.CompletedProcess:
+        raise NotImplementedError
+
+    async def diff(self, name: str) -> subprocess.CompletedProcess:
+        """List the container's filesystem changes, one ``<A|C|D> <path>`` per line.
+
+        Bind mounts are not part of the container and never appear.
+        """
+        raise NotImplementedError
+
+    async def connect_network(self, name: str, network: str) -> subprocess.CompletedProcess:
+        raise NotImplementedError
+
+    async def disconnect_network(self, name: str, network: str) -> subprocess.CompletedProcess:
+        raise NotImplementedError
+
+    async def image_arch(self, image: str) -> str | None:
+        """Return the architecture of a local image, or None if not present."""
+        raise NotImplementedError
+
+    async def image_digest(self, image: str) -> str | None:
+        """Return the digest of a local image, or None if not present.
+
+        The registry (manifest) digest when the image was pulled, else the
+        image ID of a locally built one.
+        """
+        raise NotImplementedError
+
+    async def pull(
+        self, image: str, timeout: int = 600, login: RegistryLogin | None = None
+    ) -> subprocess.CompletedProcess:
+        """Pull ``image`` for the host platform.
+
+        Never raises: a timeout or a missing CLI is reported as a failed
+        process (returncode -1) whose stderr says what happened.
+        """
+        raise NotImplementedError
+
+
+# ---------------------------------------------------------------------------
+# CLI implementation (shared by Docker and Podman)
+# ---------------------------------------------------------------------------
+class _CliRuntime(ContainerRuntime):
+    """Drives a Docker-compatible CLI."""
+
+    binary = ""
+
+    def __init__(self, socket: str = "") -> None:
+        self.socket = socket
+
+    @property
+    def socket_path(self) -> str:
+        """The socket the engine is reached on (configured or default)."""
+        return self.socket or self.default_socket()
+
+    def default_socket(self) -> str:
+        raise NotImplementedError
+
+    def _global_args(self) -> list[str]:
+        """CLI flags selecting a configured socket."""
+        return []
+
+    def _create_args(self, spec: ContainerSpec) -> list[str]:
+        """Engine-specific ``create`` flags."""
+        return []
+
+    def command(self, *args: str) -> list[str]:
+        """Full argv for a CLI subcommand."""
+        return [self.binary, *self._global_args(), *args]
+
+    def is_available(self) -> bool:
+        if not shutil.which(self.binary):
+            return False
+        try:
+            result = subprocess.run(self.command("info"), capture_output=True, timeout=10)
+            return result.returncode == 0
+        except (subprocess.TimeoutExpired, FileNotFoundError, OSError):
+            return False
+
+    async def create(
+        self, spec: ContainerSpec, env: dict[str, str] | None = None
+    ) -> subprocess.CompletedProcess:
+        args = ["create", "--name", spec.name]
+        if spec.memory:
+            args += ["--memory", spec.memory]
+        if spec.cpus:
+            args += ["--cpus", spec.cpus]
+        if spec.pids:
+            args += ["--pids-limit", spec.pids]
+        if spec.pull:
+            args += ["--pull", spec.pull]
+        if spec.user:
+            args += ["--user", spec.user]
+        args += ["--network", spec.network]
+        for key, value in spec.labels.items():
+            args += ["--label", f"{key}={value}"]
+        args += spec.security.create_args()
+        args += self._create_args(spec)
+        args += ["-v", f"{spec.workspace}:/workspace:rw"]
+        for volume in spec.volumes:
+            args += ["-v", volume]
+        for env_name in spec.env_names:
+            args += ["-e", env_name]  # value comes from the CLI's env
+        args += ["-w", spec.workdir, spec.image, *spec.command]
+        cli_env = {**os.environ, **env} if env else None
+        return await self._run(self.command(*args), timeout=60, env=cli_env)
+
+    async def start(self, name: str) -> subprocess.CompletedProcess:
+        return await self._run(self.command("start", name), timeout=60)
+
+    async def exec(
+        self,
+        name: str,
+        argv: list[str],
+        timeout: int = 60,
+        env: dict[str, str] | None = None,
+        input_data: str | None = None,
+        workdir: str | None = None,
+        on_output: Callable[[str, str], None] | None = None,
+        user: str | None = None,
+        max_output: int | None = None,
+        secret_env: dict[str, str] | None = None,
+    ) -> subprocess.CompletedProcess:
+        args = ["exec"]
+        if input_data is not None:
+            args.append("-i")
+        if workdir:
+            args += ["-w", workdir]
+        if user:
+            args += ["-u", user]
+        for key, value in (env or {}).items():
+            args += ["-e", f"{key}={value}"]
+        for key in secret_env or {}:
+            args += ["-e", key]  # value comes from the CLI's env
+        cmd = self.command(*args, name, *argv)
+        cli_env = {**os.environ, **secret_env} if secret_env else None
+        if on_output is not None or max_output is not None:
+            return await self._stream(
+                cmd, timeout=timeout, on_output=on_output, max_output=max_output, env=cli_env
+            )
+        return await self._run(cmd, timeout=timeout, input_data=input_data, env=cli_env)
+
+    async def stop(self, name: str, grace: int = 5) -> subprocess.CompletedProcess:
+        return await self._run(self.command("stop", "-t", str(grace), name), timeout=grace + 10)
+
+    async def remove(self, name: str) -> subprocess.CompletedProcess:
+        return await self._run(self.command("rm", "-f", name), timeout=15)
+
+    async def list_containers(self, label: str) -> subprocess.CompletedProcess:
+        return await self._run(
+            self.command("ps", "-a", "--filter", f"label={label}", "--format", "{{.Names}}"),
+            timeout=30,
+        )
+
+    async def copy_from(
+        self, name: str, path: str, dest: Path | str, timeout: int = 300
+    ) -> subprocess.CompletedProcess:
+        return await self._run(self.command("cp", f"{name}:{path}", str(dest)), timeout=timeout)
+
+    async def diff(self, name: str) -> subprocess.CompletedProcess:
+        return await self._run(self.command("diff", name), timeout=30)
+
+    async def connect_network(self, name: str, network: str) -> subprocess.CompletedProcess:
+        return await self._run(self.command("network", "connect", network, name), timeout=15)
+
+    async def disconnect_network(self, name: str, network: str) -> subprocess.CompletedProcess:
+        return await self._run(self.command("network", "disconnect", network, name), timeout=15)
+
+    async def image_arch(self, image: str) -> str | None:
+        cmd = self.command("image", "inspect", "--format", "{{.Architecture}}", image)
+        try:
+            result = await self._run(cmd, timeout=15)
+        except (OSError, asyncio.TimeoutError) as exc:
+            logger.debug("%s image inspect failed for %s: %s", self.binary, image, exc)
+            return None
+        if result.returncode != 0:
+            return None
+        return result.stdout.strip() or None
+
+    async def image_digest(self, image: str) -> str | None:
+        cmd = self.command("image", "inspect", "--format", "{{.Id}} {{json .RepoDigests}}", image)
+        try:
+            result = await self._run(cmd, timeout=15)
+        except (OSError, asyncio.TimeoutError) as exc:
+            logger.debug("%s image inspect failed for %s: %s", self.binary, image, exc)
+            return None
+        if result.returncode != 0 or not result.stdout.strip():
+            return None
+        image_id, _, digests = result.stdout.strip().partition(" ")
+        try:
+            repo_digests = json.loads(digests) or []
+        except ValueError:
+            repo_digests = []
+        # Prefer the entry for this repository; an image may carry several
+        repository = _repository(image)
+        repo_digests.sort(key=lambda entry: not entry.startswith(repository + "@"))
+        if repo_digests:
+            return repo_digests[0].rpartition("@")[2]
+        return image_id or None
+
+    async def pull(
+        self, image: str, timeout: int = 600, login: RegistryLogin | None = None
+    ) -> subprocess.CompletedProcess:
+        with tempfile.TemporaryDirectory(prefix="orion-pull-") as auth_dir:
+            args, env = (
+                self._login_args(auth_dir, registry_of(image), login) if login else ([], {})
+            )
+            cmd = self.command("pull", *args, image)
+            try:
+                result = await self._run(
+                    cmd, timeout=timeout, env={**os.environ, **env} if env else None
+                )
+            except asyncio.TimeoutError:
+                result = subprocess.CompletedProcess(cmd, -1, "", f"timed out after {timeout}s")
+            except OSError as exc:
+                result = subprocess.CompletedProcess(cmd, -1, "", str(exc))
+        if result.returncode != 0:
+            stderr = (result.stderr or "").strip()[:300]
+            if login and login.password:
+                stderr = stderr.replace(login.password, "***")
+            logger.warning("%s pull %s failed: %s", self.binary, image, stderr)
+        return result
+
+    def _login_args(
+        self, auth_dir: str, registry: str, login: RegistryLogin
+    ) -> tuple[list[str], dict[str, str]]:
+        """``pull`` flags and environment that authenticate to ``registry``."""
+        raise NotImplementedError
+
+    @staticmethod
+    def _write_auth_file(path: str, key: str, login: RegistryLogin) -> None:
+        token = base64.b64encode(f"{login.username}:{login.password}".encode()).decode()
+        fd = os.open(path, os.O_WRONLY | os.O_CREAT | os.O_TRUNC, 0o600)
+        with os.fdopen(fd, "w") as fh:
+            json.dump({"auths": {key: {"auth": token}}}, fh)
+
+    # ------------------------------------------------------------------
+    # Subprocess helpers
+    # ------------------------------------------------------------------
+    @staticmethod
+    async def _run(
+        cmd: list[str],
+        timeout: int = 60,
+        input_data: str | None = None,
+        env: dict[str, str] | None = None,
+    ) -> subprocess.CompletedProcess:
+        """Run a CLI command without blocking the event loop."""
+        loop = asyncio.get_event_loop()
+
+        def _run() -> subprocess.CompletedProcess:
+            return subprocess.run(
+                cmd,
+                capture_output=True,
+                text=True,
+                timeout=timeout,
+                input=input_data,
+                env=env,
+            )
+
+        try:
+            return await asyncio.wait_for(
+                loop.run_in_executor(None, _run),
+                timeout=timeout + 5,  # Slightly longer than subprocess timeout
+            )
+        except asyncio.TimeoutError:
+            raise
+        except subprocess.TimeoutExpired:
+            raise asyncio.TimeoutError(f"Command timed out ({timeout}s): {' '.join(cmd)}")
+
+    @staticmethod
+    async def _stream(
+        cmd: list[str],
+        timeout: int,
+        on_output: Callable[[str, str], None] | None,
+        max_output: int | None = None,
+        env: dict[str, str] | None = None,
+    ) -> subprocess.CompletedProcess:
+        """Run a CLI command, reporting output line by line.
+
+        stdout and stderr are read concurrently, each in order, and every
+        line is handed to ``on_output`` as soon as it arrives.  A line
+        longer than :data:`STREAM_CHUNK` is reported in pieces of at most
+        that size.  Past ``max_output`` bytes (both streams together) lines
+        are read and reported but no longer kept, so a chatty command cannot
+        fill memory.  Whatever ends the wait, the CLI process is killed.
+        """
+        proc = await asyncio.create_subprocess_exec(
+            *cmd,
+            stdout=asyncio.subprocess.PIPE,
+            stderr=asyncio.subprocess.PIPE,
+            env=env,
+        )
+        captured: dict[str, list[str]] = {"stdout": [], "stderr": []}
+        kept = 0
+        full = False
+
+        async def _pump(reader: asyncio.StreamReader, stream: str) -> None:
+            nonlocal kept, full
+            async for raw in _lines(reader):
+                text = raw.decode("utf-8", errors="replace")
+                if max_output is not None and kept + len(raw) > max_output:
+                    full = True  # nothing after the first line that does not fit
+                if not full:
+                    kept += len(raw)
+                    captured[stream].append(text)
+                if on_output is None:
+                    continue
+                try:
+                    on_output(stream, text.rstrip("\n"))
+                except Exception as exc:
+                    logger.debug("Output callback failed: %s", exc)
+
+        async def _communicate() -> int:
+            await asyncio.gather(_pump(proc.stdout, "stdout"), _pump(proc.stderr, "stderr"))
+            return await proc.wait()
+
+        try:
+            returncode = await asyncio.wait_for(_communicate(), timeout=timeout)
+        except BaseException:
+            try:
+                proc.kill()
+            except ProcessLookupError:
+                pass  # already gone
+            await proc.wait()
+            raise
+        return subprocess.CompletedProcess(
+            cmd, returncode, "".join(captured["stdout"]), "".join(captured["stderr"])
+        )
+
+
+async def _lines(reader: asyncio.StreamReader):
+    """Yield ``reader``'s lines as bytes, newline included.
+
+    Reads bounded chunks rather than ``readline()``, which fails on a line
+    over the stream's limit.  A line longer than :data:`STREAM_CHUNK` is
+    cut into pieces, each ending on a UTF-8 character boundary.
+    """
+    buffer = b""
+    while True:
+        chunk = await reader.read(STREAM_CHUNK)
+        if not chunk:
+            if buffer:
+                yield buffer
+            return
+        buffer += chunk
+        while buffer:
+            end = buffer.find(b"\n", 0, STREAM_CHUNK)
+            if end != -1:
+                cut = end + 1
+            elif len(buffer) > STREAM_CHUNK:
+                cut = STREAM_CHUNK
+                while cut > 1 and buffer[cut] & 0xC0 == 0x80:
+                    cut -= 1  # not inside a multi-byte character
+            else:
+                break
+            yield buffer[:cut]
+            buffer = buffer[cut:]
+
+
+class DockerRuntime(_CliRuntime):
+    """Docker Engine via the ``docker`` CLI."""
+
+    name = "docker"
+    binary = "docker"
+
+    def default_socket(self) -> str:
+        host = os.environ.get("DOCKER_HOST", "")
+        return host.removeprefix("unix://") if host else "/var/run/docker.sock"
+
+    def _global_args(self) -> list[str]:
+        return ["--host", _socket_url(self.socket)] if self.socket else []
+
+    def _login_args(
+        self, auth_dir: str, registry: str, login: RegistryLogin
+    ) -> tuple[list[str], dict[str, str]]:
+        # Docker keys Docker Hub credentials by its legacy index URL
+        key = "https://index.docker.io/v1/" if registry == DEFAULT_REGISTRY else registry
+        self._write_auth_file(os.path.join(auth_dir, "config.json"), key, login)
+        return [], {"DOCKER_CONFIG": auth_dir}
+
+
+class PodmanRuntime(_CliRuntime):
+    """Podman via the ``podman`` CLI, rootful or rootless."""
+
+    name = "podman"
+    binary = "podman"
+
+    def __init__(self, socket: str = "", rootless: bool | None = None) -> None:
+        super().__init__(socket)
+        self.rootless = os.geteuid() != 0 if rootless is None else rootless
+
+    def default_socket(self) -> str:
+        if not self.rootless:
+            return "/run/podman/podman.sock"
+        runtime_dir = os.environ.get("XDG_RUNTIME_DIR") or f"/run/user/{os.geteuid()}"
+        return f"{runtime_dir}/podman/podman.sock"
+
+    def _global_args(self) -> list[str]:
+        return ["--remote", "--url", _socket_url(self.socket)] if self.socket else []
+
+    def _login_args(
+        self, auth_dir: str, registry: str, login: RegistryLogin
+    ) -> tuple[list[str], dict[str, str]]:
+        path = os.path.join(auth_dir, "auth.json")
+        self._write_auth_file(path, registry, login)
+        return ["--authfile", path], {}
+
+    def _create_args(self, spec: ContainerSpec) -> list[str]:
+        if not self.rootless:
+            return []
+        # Map the invoking user onto the container user so both own /workspace
+        uid, _, gid = spec.user.partition(":") if spec.user else (str(ORION_UID), "", "")
+        return [f"--userns=keep-id:uid={uid},gid={gid or ORION_GID}"]
+
+
+def _socket_url(socket: str) -> str:
+    return socket if "://" in socket else f"unix://{socket}"
+
+
+def make_runtime(driver: str = "docker", socket: str = "") -> ContainerRuntime:
+    """Build the runtime for a configured driver name.
+
+    Raises:
+        ValueError: If the driver is not one of :data:`DRIVERS`.
+    """
+    if driver == "docker":
+        return DockerRuntime(socket)
+    if driver == "podman":
+        return PodmanRuntime(socket)
+    raise ValueError(f"Unknown container runtime driver: {driver!r}")
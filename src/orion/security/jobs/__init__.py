@@ -0,0 +1,25 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Orion Jobs -- manifest-driven build jobs in stack containers.
+
+A job manifest declares which stack image to run and what to run in it.
+The agent resolves the stack against the labelled Dockerfiles in
+``docker/stacks/`` and executes the job inside a governed container.
+
+Architecture:
+  Job manifest (YAML/JSON) --> stack resolution --> stack container
+"""
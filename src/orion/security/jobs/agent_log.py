@@ -0,0 +1,138 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""The job agent's own operational logs, as text or JSON lines.
+
+These are the agent's lifecycle events (job accepted, image pull started,
+container started, ...).  Job *output* is separate: it travels through
+:class:`orion.security.jobs.logs.LogChannel` and never reaches these logs.
+
+Every record emitted while a job is being handled carries its ``job_id``
+and ``stack``.  The executor enters :func:`job_context` for each job and
+asyncio copies the context into the job's task, so lines logged by
+SessionContainer, the build cache, the runtime etc. are tagged without
+passing IDs around.
+
+``log.format: json`` writes one object per line::
+
+    {"timestamp": "2026-02-09T17:30:45.123Z", "level": "INFO",
+     "logger": "orion.security.jobs.executor", "message": "Job accepted",
+     "job_id": "3f9c0a1b2c4d", "stack": "go"}
+
+``job_id`` and ``stack`` are null outside a job.  ``text`` (the default)
+keeps one human-readable line per record.
+"""
+
+from __future__ import annotations
+
+import contextlib
+import contextvars
+import json
+import logging
+import sys
+from collections.abc import Iterator
+from datetime import datetime, timezone
+from typing import TextIO
+
+from orion.security.jobs.config import LogConfig
+
+# Parent of every agent logger (orion.security.*, orion.api.routes.*)
+AGENT_LOGGER = "orion"
+
+_job_id: contextvars.ContextVar[str] = contextvars.ContextVar("orion_job_id", default="")
+_stack: contextvars.ContextVar[str] = contextvars.ContextVar("orion_job_stack", default="")
+
+
+@contextlib.contextmanager
+def job_context(job_id: str, stack: str) -> Iterator[None]:
+    """Tag every record logged inside the block (and tasks it creates)."""
+    id_token = _job_id.set(job_id)
+    stack_token = _stack.set(stack)
+    try:
+        yield
+    finally:
+        _stack.reset(stack_token)
+        _job_id.reset(id_token)
+
+
+class JobContextFilter(logging.Filter):
+    """Adds ``job_id`` and ``stack`` attributes from the current job context."""
+
+    def filter(self, record: logging.LogRecord) -> bool:
+        if not getattr(record, "job_id", ""):
+            record.job_id = _job_id.get()
+        if not getattr(record, "stack", ""):
+            record.stack = _stack.get()
+        return True
+
+
+def _timestamp(created: float) -> str:
+    moment = datetime.fromtimestamp(created, timezone.utc)
+    return moment.strftime("%Y-%m-%dT%H:%M:%S.") + f"{moment.microsecond // 1000:03d}Z"
+
+
+class JsonFormatter(logging.Formatter):
+    """One JSON object per record."""
+
+    def format(self, record: logging.LogRecord) -> str:
+        entry = {
+            "timestamp": _timestamp(record.created),
+            "level": record.levelname,
+            "logger": record.name,
+            "message": record.getMessage(),
+            "job_id": getattr(record, "job_id", "") or None,
+            "stack": getattr(record, "stack", "") or None,
+        }
+        if record.exc_info:
+            entry["exception"] = self.formatException(record.exc_info)
+        return json.dumps(entry, default=str)
+
+
+class TextFormatter(logging.Formatter):
+    """``TIMESTAMP LEVEL LOGGER [job=ID stack=STACK] MESSAGE``"""
+
+    def format(self, record: logging.LogRecord) -> str:
+        job_id = getattr(record, "job_id", "")
+        tag = f" [job={job_id} stack={getattr(record, 'stack', '') or '-'}]" if job_id else ""
+        line = (
+            f"{_timestamp(record.created)} {record.levelname:<7} {record.name}{tag} "
+            f"{record.getMessage()}"
+        )
+        if record.exc_info:
+            line += "\n" + self.formatException(record.exc_info)
+        return line
+
+
+def configure_logging(config: LogConfig, stream: TextIO | None = None) -> logging.Handler:
+    """Send the agent's logs to ``stream`` (stderr) in the configured format.
+
+    Replaces a handler installed by an earlier call, so reconfiguring
+    never duplicates lines.
+    """
+    logger = logging.getLogger(AGENT_LOGGER)
+    for existing in list(logger.handlers):
+        if getattr(existing, "_orion_agent_log", False):
+            logger.removeHandler(existing)
+
+    handler = logging.StreamHandler(stream or sys.stderr)
+    handler._orion_agent_log = True
+    handler.addFilter(JobContextFilter())
+    handler.setFormatter(JsonFormatter() if config.format == "json" else TextFormatter())
+    logger.addHandler(handler)
+    logger.setLevel(config.level)
+    # The handler above is the agent's output; don't repeat lines via root
+    logger.propagate = False
+    return handler
@@ -0,0 +1,252 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Archive job sources -- a tar / tar.gz snapshot instead of a repository.
+
+A manifest with ``source: {type: archive}`` gets its workspace from an
+archive the caller uploads, either with the submission itself or
+beforehand (``POST /api/jobs/uploads``, then ``upload: <id>``).  Uploads
+are kept under ``<jobs_dir>/uploads`` until the job that names one has
+run, at most ``source.maxArchiveSize`` each and ``source.maxUploadsSize``
+all together; one no job claims within :data:`UPLOAD_TTL` is dropped.
+
+With ``source.sha256`` the upload's digest is checked first, and an
+archive with another one is refused.
+
+The archive is unpacked on the host into the job's fresh workspace
+before its container starts, entry by entry and refusing:
+
+  - absolute names and names with a ``..`` component
+  - symlinks and hard links whose target lies outside the workspace
+  - devices, FIFOs and other special files
+  - anything that would take the unpacked files past the workspace
+    quota (``workspace.maxSizeGB``) -- checked before each file is
+    written, so an archive bomb never fills the disk
+
+Modes keep only the permission bits (no setuid / setgid / sticky).  The
+executor then hands the files to the job's user (``orion``, or
+``runAs``) inside the container.
+"""
+
+from __future__ import annotations
+
+import hashlib
+import logging
+import os
+import tarfile
+import time
+import uuid
+import zlib
+from collections.abc import AsyncIterable
+from pathlib import Path
+
+logger = logging.getLogger("orion.security.jobs.archive")
+
+UPLOADS_DIR = "uploads"  # under the jobs dir
+UPLOAD_TTL = 24 * 3600  # seconds an unclaimed upload is kept
+_CHUNK = 1024 * 1024
+
+
+class ArchiveError(ValueError):
+    """Raised when an archive cannot be stored or safely unpacked."""
+
+
+class ArchiveQuotaError(ArchiveError):
+    """Raised when unpacking would exceed the workspace quota."""
+
+
+class UploadsFullError(ArchiveError):
+    """Raised when an upload would take the stored uploads past their total cap."""
+
+
+# ---------------------------------------------------------------------------
+# Uploads
+# ---------------------------------------------------------------------------
+async def save_upload(
+    directory: Path, chunks: AsyncIterable[bytes], max_bytes: int, max_total: int = 0
+) -> str:
+    """Store an uploaded archive and return its ID.
+
+    ``max_total`` caps every stored upload together, this one included
+    (0 = no cap).
+
+    Raises:
+        ArchiveError: If the upload is empty or larger than ``max_bytes``.
+        UploadsFullError: If it would take the stored uploads past ``max_total``.
+    """
+    directory.mkdir(parents=True, exist_ok=True)
+    prune_uploads(directory)
+    room = max_total - uploads_size(directory) if max_total else None
+    upload_id = uuid.uuid4().hex
+    partial = directory / f".{upload_id}.part"
+    size = 0
+    try:
+        with open(partial, "wb") as out:
+            async for chunk in chunks:
+                size += len(chunk)
+                if size > max_bytes:
+                    raise ArchiveError(
+                        f"Archive is larger than {max_bytes} bytes (source.maxArchiveSize)"
+                    )
+                if room is not None and size > room:
+                    raise UploadsFullError(
+                        f"Stored uploads would exceed {max_total} bytes "
+                        "(source.maxUploadsSize); retry once queued jobs have run"
+                    )
+                out.write(chunk)
+        if size == 0:
+            raise ArchiveError("Archive is empty")
+        partial.rename(upload_path(directory, upload_id))
+    finally:
+        partial.unlink(missing_ok=True)
+    logger.info("Stored archive upload %s (%d bytes)", upload_id, size)
+    return upload_id
+
+
+def upload_path(directory: Path, upload_id: str) -> Path:
+    return directory / f"{upload_id}.tar"
+
+
+def uploads_size(directory: Path) -> int:
+    """Bytes held by the stored uploads, those still being written included."""
+    size = 0
+    for path in [*directory.glob("*.tar"), *directory.glob(".*.part")]:
+        try:
+            size += path.stat().st_size
+        except OSError:
+            pass  # consumed or pruned meanwhile
+    return size
+
+
+def archive_digest(path: Path) -> str:
+    """The SHA-256 of a stored upload, as 64 hex digits."""
+    digest = hashlib.sha256()
+    with open(path, "rb") as data:
+        while chunk := data.read(_CHUNK):
+            digest.update(chunk)
+    return digest.hexdigest()
+
+
+def prune_uploads(directory: Path, ttl: float = UPLOAD_TTL) -> None:
+    """Remove uploads older than ``ttl`` seconds that no job claimed."""
+    cutoff = time.time() - ttl
+    for path in directory.glob("*.tar"):
+        try:
+            if path.stat().st_mtime < cutoff:
+                path.unlink()
+                logger.info("Removed unclaimed archive upload %s", path.stem)
+        except OSError:
+            pass
+
+
+# ---------------------------------------------------------------------------
+# Unpacking
+# ---------------------------------------------------------------------------
+def extract_archive(archive: Path, dest: Path, max_bytes: int = 0) -> int:
+    """Unpack a tar or tar.gz ``archive`` into ``dest``; returns the bytes written.
+
+    ``max_bytes`` caps the total size of the unpacked files (0 = no cap).
+
+    Raises:
+        ArchiveQuotaError: If the files would exceed ``max_bytes``.
+        ArchiveError: If the archive is unreadable or has an unsafe entry.
+    """
+    dest.mkdir(parents=True, exist_ok=True)
+    root = os.path.realpath(dest)
+    total = 0
+    try:
+        with tarfile.open(archive, mode="r:*") as tar:
+            for member in tar:
+                name = _checked_name(member.name)
+                if not name:
+                    continue  # the archive's own top directory, './'
+                target = os.path.join(root, name)
+                if not _inside(root, os.path.realpath(os.path.dirname(target))):
+                    raise ArchiveError(f"Entry '{member.name}' is written through a link")
+                if member.isdir():
+                    os.makedirs(target, exist_ok=True)
+                    os.chmod(target, (member.mode & 0o777) | 0o700)
+                elif member.isfile():
+                    if max_bytes and total + member.size > max_bytes:
+                        raise ArchiveQuotaError(
+                            f"Archive unpacks to more than the {max_bytes}-byte workspace "
+                            f"quota (workspace.maxSizeGB), at '{member.name}'"
+                        )
+                    total += _write(tar, member, target)
+                elif member.issym() or member.islnk():
+                    _link(member, name, root, target)
+                else:
+                    raise ArchiveError(
+                        f"Entry '{member.name}' is a device or other special file"
+                    )
+    except (tarfile.TarError, EOFError, zlib.error) as exc:
+        raise ArchiveError(f"Archive is not a readable tar or tar.gz: {exc}") from exc
+    except OSError as exc:
+        raise ArchiveError(f"Archive could not be unpacked: {exc}") from exc
+    return total
+
+
+def _checked_name(name: str) -> str:
+    """``name`` relative to the workspace, '' for its root.  Raises ArchiveError."""
+    if name.startswith("/"):
+        raise ArchiveError(f"Entry '{name}' has an absolute path")
+    parts = [part for part in name.split("/") if part not in ("", ".")]
+    if ".." in parts:
+        raise ArchiveError(f"Entry '{name}' has a '..' component")
+    return "/".join(parts)
+
+
+def _inside(root: str, path: str) -> bool:
+    return path == root or path.startswith(root + os.sep)
+
+
+def _write(tar: tarfile.TarFile, member: tarfile.TarInfo, target: str) -> int:
+    source = tar.extractfile(member)
+    if os.path.islink(target) or os.path.isdir(target):
+        raise ArchiveError(f"Entry '{member.name}' replaces a link or directory")
+    os.makedirs(os.path.dirname(target), exist_ok=True)
+    written = 0
+    with source, open(target, "wb") as out:
+        while chunk := source.read(_CHUNK):
+            written += len(chunk)
+            out.write(chunk)
+    os.chmod(target, (member.mode & 0o777) | 0o600)
+    os.utime(target, (member.mtime, member.mtime))
+    return written
+
+
+def _link(member: tarfile.TarInfo, name: str, root: str, target: str) -> None:
+    if member.linkname.startswith("/"):
+        raise ArchiveError(f"Link '{member.name}' has an absolute target")
+    # A symlink's target is relative to its directory, a hard link's to the archive
+    base = os.path.dirname(name) if member.issym() else ""
+    linked = os.path.normpath(os.path.join(base, member.linkname))
+    if linked == ".." or linked.startswith("../"):
+        raise ArchiveError(f"Link '{member.name}' points outside the workspace")
+    if os.path.lexists(target):
+        raise ArchiveError(f"Link '{member.name}' replaces an earlier entry")
+    os.makedirs(os.path.dirname(target), exist_ok=True)
+    if member.issym():
+        os.symlink(member.linkname, target)
+        # Through an earlier link, '..' may lead elsewhere than it reads
+        if not _inside(root, os.path.realpath(target)):
+            os.unlink(target)
+            raise ArchiveError(f"Link '{member.name}' points outside the workspace")
+        return
+    source = os.path.join(root, linked)
+    if not _inside(root, os.path.realpath(source)) or not os.path.isfile(source):
+        raise ArchiveError(f"Hard link '{member.name}' does not name an earlier file")
+    os.link(source, target)
@@ -0,0 +1,193 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job artifacts -- files copied out of the container once the command ends.
+
+A manifest lists glob patterns relative to ``/workspace``::
+
+    artifacts:
+      - dist/**
+      - "*.junit.xml"
+
+``*`` and ``?`` match within one path segment; ``**`` matches any number
+of segments, so ``dist/**`` is everything under ``dist/`` and
+``**/*.junit.xml`` is a report at any depth.  Only regular files are
+collected -- symlinks are never followed out of the workspace.
+
+Artifacts are collected whatever the command's outcome (a failing test
+run still yields its reports) and before the container is removed.  A
+pattern that matches nothing is a warning, not a failure.  Files are
+taken in path order until ``artifacts.maxTotalSize`` would be exceeded;
+the rest are skipped and reported, so one job cannot fill the host disk.
+
+:func:`archive_artifacts` streams a job's collected artifacts back out as
+one ``.tar.gz`` (paths relative to ``/workspace``, with their file modes),
+compressing as it reads so the archive is never held in memory whole.
+"""
+
+from __future__ import annotations
+
+import logging
+import os
+import re
+import stat
+import tarfile
+import zlib
+from collections.abc import Iterator
+from dataclasses import dataclass
+from pathlib import Path
+
+logger = logging.getLogger("orion.security.jobs.artifacts")
+
+ARCHIVE_CHUNK = 64 * 1024
+
+
+@dataclass(frozen=True)
+class Artifact:
+    """One file copied out of a job container."""
+
+    path: str  # relative to /workspace and to the job's artifacts directory
+    size: int  # bytes
+
+    def to_dict(self) -> dict[str, str | int]:
+        return {"path": self.path, "size": self.size}
+
+
+def validate_patterns(patterns: list[str]) -> list[str]:
+    """Return problems with a manifest's ``artifacts`` list (empty if fine)."""
+    problems = []
+    for pattern in patterns:
+        if not pattern.strip():
+            problems.append("patterns must not be empty")
+        elif pattern.startswith("/"):
+            problems.append(f"'{pattern}' must be relative to /workspace")
+        elif ".." in pattern.split("/"):
+            problems.append(f"'{pattern}' must not contain '..'")
+    return problems
+
+
+def compile_pattern(pattern: str) -> re.Pattern[str]:
+    """Translate an artifact glob into a regex over workspace-relative paths."""
+    parts = []
+    segments = pattern.strip().strip("/").split("/")
+    for i, segment in enumerate(segments):
+        last = i == len(segments) - 1
+        if segment == "**":
+            # Any number of whole segments; as the last one, at least one file
+            parts.append(".+" if last else "(?:[^/]+/)*")
+            continue
+        regex = ""
+        for char in segment:
+            if char == "*":
+                regex += "[^/]*"
+            elif char == "?":
+                regex += "[^/]"
+            else:
+                regex += re.escape(char)
+        parts.append(regex if last else regex + "/")
+    return re.compile("".join(parts) + r"\Z")
+
+
+def parse_listing(text: str) -> list[tuple[str, int]]:
+    """Parse ``<size> ./<path>`` lines (``find -exec stat``) into (path, size)."""
+    files = []
+    for line in text.splitlines():
+        size, _, path = line.strip().partition(" ")
+        if not path or not size.isdigit():
+            continue
+        files.append((path[2:] if path.startswith("./") else path, int(size)))
+    return files
+
+
+def select_artifacts(
+    files: list[tuple[str, int]], patterns: list[str], max_total_size: int
+) -> tuple[list[Artifact], list[str]]:
+    """Pick the files to copy out.
+
+    Returns:
+        (artifacts in path order within the size cap, warnings).
+    """
+    warnings = []
+    matched: dict[str, int] = {}
+    for pattern in patterns:
+        regex = compile_pattern(pattern)
+        hits = [(path, size) for path, size in files if regex.match(path)]
+        if not hits:
+            warnings.append(f"Artifact pattern '{pattern}' matched no files")
+        matched.update(hits)
+
+    selected = []
+    total = 0
+    skipped = []
+    for path in sorted(matched):
+        size = matched[path]
+        if total + size > max_total_size:
+            skipped.append(path)
+            continue
+        selected.append(Artifact(path=path, size=size))
+        total += size
+    if skipped:
+        warnings.append(
+            f"Artifacts exceed the {max_total_size} byte limit; "
+            f"skipped {len(skipped)} file(s): {', '.join(skipped[:5])}"
+            + (" ..." if len(skipped) > 5 else "")
+        )
+    return selected, warnings
+
+
+def archive_artifacts(root: Path, artifacts: list[Artifact]) -> Iterator[bytes]:
+    """Yield a gzipped tar of ``artifacts`` under ``root``, chunk by chunk.
+
+    Each member is named by its workspace-relative path and keeps the
+    copied file's mode and mtime.  Files that are gone, no longer regular
+    or resolve outside ``root`` are left out with a warning.
+    """
+    gzip = zlib.compressobj(wbits=31)  # gzip framing
+
+    def compressed(data: bytes) -> Iterator[bytes]:
+        chunk = gzip.compress(data)
+        if chunk:
+            yield chunk
+
+    root = root.resolve()
+    for artifact in artifacts:
+        path = root / artifact.path
+        try:
+            info = os.lstat(path)
+            if not stat.S_ISREG(info.st_mode) or not path.resolve().is_relative_to(root):
+                raise OSError("not a regular file in the artifacts directory")
+            fh = open(path, "rb")
+        except OSError as exc:
+            logger.warning("Artifact %s left out of the archive: %s", artifact.path, exc)
+            continue
+        with fh:
+            member = tarfile.TarInfo(artifact.path)
+            member.size = info.st_size
+            member.mode = stat.S_IMODE(info.st_mode)
+            member.mtime = int(info.st_mtime)
+            yield from compressed(member.tobuf(tarfile.PAX_FORMAT))
+            # The header fixed the size, so a file changed since is cut or zero-padded
+            remaining = member.size
+            while remaining:
+                data = fh.read(min(ARCHIVE_CHUNK, remaining))
+                if not data:
+                    data = b"\0" * min(ARCHIVE_CHUNK, remaining)
+                remaining -= len(data)
+                yield from compressed(data)
+            yield from compressed(b"\0" * (-member.size % tarfile.BLOCKSIZE))
+    # Two empty blocks end the archive, padded to a whole record as tarfile does
+    yield from compressed(b"\0" * tarfile.RECORDSIZE)
+    yield gzip.flush()
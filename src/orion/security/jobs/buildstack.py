@@ -0,0 +1,249 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Build a stack image and verify what the job agent relies on.
+
+A stack image that builds can still be unusable: without its
+``orion.stack`` LABEL the stack does not resolve, and without the
+``orion`` user or ``/workspace`` the job fails at runtime.  This builds
+``docker/stacks/Dockerfile.<name>`` and checks the fresh image before it
+is published:
+
+  label:      ``orion.stack`` is set, non-empty and matches ``<name>``
+  user:       the image runs as ``orion``, which exists with UID
+              :data:`~orion.security.container_runtime.ORION_UID` (not root)
+  workdir:    the working directory is ``/workspace``
+  toolchain:  the binary named by the ``orion.toolchain`` LABEL is on
+              ``PATH`` (stacks without one, like ``base``, skip this)
+
+Labels are read with ``image inspect``; everything else runs inside a
+throwaway container of the image (no network), as the image's own user
+and environment, which is how jobs see it.
+
+Usage:
+    python -m orion.security.jobs.buildstack go python
+    python -m orion.security.jobs.buildstack --no-build go   # verify only
+"""
+
+from __future__ import annotations
+
+import argparse
+import json
+import logging
+import subprocess
+import sys
+from collections.abc import Callable
+from dataclasses import dataclass, field
+from pathlib import Path
+
+from orion.security.container_runtime import DRIVERS, ORION_UID, ORION_USER
+from orion.security.stack_detector import IMAGE_PREFIX, STACKS_DIR
+
+logger = logging.getLogger("orion.security.jobs.buildstack")
+
+STACK_LABEL = "orion.stack"
+TOOLCHAIN_LABEL = "orion.toolchain"
+WORKSPACE = "/workspace"
+
+BUILD_TIMEOUT = 1800
+CHECK_TIMEOUT = 60
+
+# Printed by the in-image probe as ``key=value`` lines
+_PROBE = """\
+printf 'user=%s\\n' "$(id -un)"
+printf 'orion_uid=%s\\n' "$(id -u {user} 2>/dev/null)"
+printf 'workdir=%s\\n' "$PWD"
+[ -z "$1" ] || printf 'toolchain=%s\\n' "$(command -v "$1")"
+"""
+
+Runner = Callable[[list[str], int], subprocess.CompletedProcess]
+
+
+class StackBuildError(RuntimeError):
+    """Raised when a stack image cannot be built or inspected."""
+
+
+@dataclass
+class StackReport:
+    """Outcome of verifying one stack image."""
+
+    stack: str
+    image: str
+    problems: list[str] = field(default_factory=list)  # one per violated invariant
+
+    @property
+    def ok(self) -> bool:
+        return not self.problems
+
+
+# ---------------------------------------------------------------------------
+# Build and verify
+# ---------------------------------------------------------------------------
+def build_stack(
+    name: str,
+    stacks_dir: Path | str | None = None,
+    binary: str = "docker",
+    run: Runner | None = None,
+    build: bool = True,
+) -> StackReport:
+    """Build ``Dockerfile.<name>`` as ``orion-stack-<name>:latest`` and verify it.
+
+    Args:
+        name: Stack name, the Dockerfile's suffix.
+        stacks_dir: Override the ``docker/stacks/`` directory.
+        binary: Container CLI (``docker`` or ``podman``).
+        run: Runs an argv with a timeout; defaults to :func:`subprocess.run`.
+        build: False to verify an already built image.
+
+    Raises:
+        StackBuildError: If the Dockerfile is missing or the build fails.
+    """
+    directory = Path(stacks_dir) if stacks_dir else STACKS_DIR
+    dockerfile = directory / f"Dockerfile.{name}"
+    image = f"{IMAGE_PREFIX}{name}:latest"
+    run = run or _run
+    if build:
+        if not dockerfile.is_file():
+            raise StackBuildError(f"No {dockerfile.name} in {directory}")
+        logger.info("Building %s from %s", image, dockerfile)
+        result = run(
+            [binary, "build", "-f", str(dockerfile), "-t", image, str(directory)], BUILD_TIMEOUT
+        )
+        if result.returncode != 0:
+            raise StackBuildError(f"Building {image} failed: {_tail(result.stderr)}")
+    return verify_image(name, image, binary=binary, run=run)
+
+
+def verify_image(
+    name: str, image: str, binary: str = "docker", run: Runner | None = None
+) -> StackReport:
+    """Check a built stack image against the invariants in the module doc.
+
+    Raises:
+        StackBuildError: If the image cannot be inspected or started.
+    """
+    run = run or _run
+    report = StackReport(stack=name, image=image)
+
+    result = run([binary, "image", "inspect", "--format", "{{json .Config.Labels}}", image], 30)
+    if result.returncode != 0:
+        raise StackBuildError(f"Cannot inspect {image}: {_tail(result.stderr)}")
+    try:
+        labels = json.loads(result.stdout or "null") or {}
+    except ValueError:
+        labels = {}
+
+    stack = str(labels.get(STACK_LABEL) or "").strip()
+    if not stack:
+        report.problems.append(
+            f"label: LABEL {STACK_LABEL} is missing or empty, so the stack cannot be resolved"
+        )
+    elif stack != name:
+        report.problems.append(
+            f"label: LABEL {STACK_LABEL}={stack!r} does not match Dockerfile.{name}"
+        )
+
+    toolchain = str(labels.get(TOOLCHAIN_LABEL) or "").strip()
+    probe = _PROBE.format(user=ORION_USER)
+    argv = [binary, "run", "--rm", "--network", "none", "--entrypoint", "sh", image]
+    result = run([*argv, "-c", probe, "probe", toolchain], CHECK_TIMEOUT)
+    if result.returncode != 0:
+        raise StackBuildError(f"Cannot run {image}: {_tail(result.stderr)}")
+    facts = dict(
+        line.partition("=")[::2] for line in (result.stdout or "").splitlines() if "=" in line
+    )
+
+    user = facts.get("user", "")
+    uid = facts.get("orion_uid", "")
+    if not uid:
+        report.problems.append(f"user: no '{ORION_USER}' user (add one with UID {ORION_UID})")
+    elif uid == "0":
+        report.problems.append(f"user: '{ORION_USER}' is root (UID 0); it must be UID {ORION_UID}")
+    elif uid != str(ORION_UID):
+        report.problems.append(
+            f"user: '{ORION_USER}' has UID {uid}, not {ORION_UID} (ORION_UID in "
+            "container_runtime.py)"
+        )
+    if user != ORION_USER:
+        report.problems.append(
+            f"user: the image runs as {user or 'an unknown user'!r}; add 'USER {ORION_USER}'"
+        )
+
+    workdir = facts.get("workdir", "")
+    if workdir != WORKSPACE:
+        report.problems.append(f"workdir: WORKDIR is {workdir or '/'!r}, not {WORKSPACE}")
+
+    if toolchain and not facts.get("toolchain"):
+        report.problems.append(
+            f"toolchain: {toolchain!r} (LABEL {TOOLCHAIN_LABEL}) is not on PATH for "
+            f"'{user or ORION_USER}'"
+        )
+    return report
+
+
+def _run(argv: list[str], timeout: int) -> subprocess.CompletedProcess:
+    try:
+        return subprocess.run(argv, capture_output=True, text=True, timeout=timeout)
+    except subprocess.TimeoutExpired:
+        return subprocess.CompletedProcess(argv, -1, "", f"timed out after {timeout}s")
+    except OSError as exc:
+        return subprocess.CompletedProcess(argv, -1, "", str(exc))
+
+
+def _tail(output: str | None, lines: int = 20) -> str:
+    return "\n".join((output or "").strip().splitlines()[-lines:]) or "no output"
+
+
+# ---------------------------------------------------------------------------
+# CLI
+# ---------------------------------------------------------------------------
+def main(argv: list[str] | None = None) -> int:
+    """Build and verify the named stacks (all when none); 1 if any fails."""
+    parser = argparse.ArgumentParser(
+        prog="orion-buildstack",
+        description="Build docker/stacks/Dockerfile.<name> and verify the image",
+    )
+    parser.add_argument("stacks", nargs="*", help="Stack names (default: every Dockerfile.*)")
+    parser.add_argument("--stacks-dir", default=None, help="Override docker/stacks/")
+    parser.add_argument("--driver", default="docker", choices=DRIVERS, help="Container CLI")
+    parser.add_argument(
+        "--no-build", action="store_true", help="Verify existing orion-stack-<name> images"
+    )
+    args = parser.parse_args(argv)
+    logging.basicConfig(level=logging.INFO, format="%(message)s")
+
+    directory = Path(args.stacks_dir) if args.stacks_dir else STACKS_DIR
+    names = args.stacks or sorted(
+        path.name.partition(".")[2] for path in directory.glob("Dockerfile.*")
+    )
+    failed = False
+    for name in names:
+        try:
+            report = build_stack(name, directory, binary=args.driver, build=not args.no_build)
+        except StackBuildError as exc:
+            print(f"FAIL {name}: {exc}", file=sys.stderr)
+            failed = True
+            continue
+        for problem in report.problems:
+            print(f"FAIL {name}: {problem}", file=sys.stderr)
+        if report.ok:
+            print(f"ok   {name} ({report.image})")
+        failed = failed or not report.ok
+    return 1 if failed else 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -0,0 +1,257 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Persistent build caches shared across jobs.
+
+Each stack gets a host directory under ``cache.path/<stack>/`` whose
+subdirectories are bind-mounted over the toolchain's cache locations, so
+downloads and compiled objects survive container teardown.  Jobs that
+``runAs`` a UID other than ``orion``'s get their own ``<stack>-uid<uid>/``,
+so no job meets cache entries another UID created and it cannot write.
+
+Concurrency:
+  Jobs of the same stack share one cache.  This is safe because the
+  toolchains lock their own caches: Go guards the module cache with file
+  locks and writes build-cache entries atomically, npm's cacache store
+  is content-addressed with atomic moves, and cargo locks both its home
+  (``.package-cache``, inside the mount) and its target directory, so
+  concurrent Rust builds wait their turn.  The agent's only job
+  is to never evict a cache another job is using -- active jobs hold a
+  lease, and leased stacks are skipped by :meth:`BuildCache.evict`.
+
+Offline jobs:
+  A job with ``network: none`` gets :data:`OFFLINE_ENV` for its stack, so
+  the toolchain resolves dependencies from the mounted cache right away
+  instead of failing on (or waiting for) a network it does not have.  A
+  cache warmed by an earlier online job is all such a build needs.
+
+Eviction:
+  When the total size exceeds ``cache.maxSizeGB``, whole stack caches are
+  removed least-recently-used first until the total fits again.  It walks
+  every cache, so the executor runs it in a worker thread.  A cache is
+  renamed aside under the lease lock before it is deleted: a job that
+  leases the stack meanwhile gets a fresh, empty cache.
+"""
+
+from __future__ import annotations
+
+import logging
+import os
+import shutil
+import stat
+import threading
+import uuid
+from pathlib import Path
+
+from orion.security.container_runtime import ORION_UID
+from orion.security.jobs.config import CacheConfig
+
+logger = logging.getLogger("orion.security.jobs.cache")
+
+# ---------------------------------------------------------------------------
+# Cache mount points per stack: subdirectory name -> container path
+# ---------------------------------------------------------------------------
+CACHE_MOUNTS: dict[str, dict[str, str]] = {
+    "go": {
+        "mod": "/home/orion/go/pkg/mod",
+        "go-build": "/home/orion/.cache/go-build",
+    },
+    "node": {
+        # npm_config_cache in Dockerfile.node
+        "npm": "/home/orion/.npm",
+    },
+    "rust": {
+        # CARGO_HOME / CARGO_TARGET_DIR in Dockerfile.rust
+        "cargo": "/home/orion/.cargo",
+        "target": "/home/orion/.cache/cargo-target",
+    },
+    "java": {
+        # Maven's default local repository; GRADLE_USER_HOME in Dockerfile.java
+        "m2": "/home/orion/.m2",
+        "gradle": "/home/orion/.gradle",
+    },
+}
+
+# ---------------------------------------------------------------------------
+# Environment for jobs without a network: resolve from the cache only
+# ---------------------------------------------------------------------------
+OFFLINE_ENV: dict[str, dict[str, str]] = {
+    "go": {"GOPROXY": "off", "GOSUMDB": "off"},
+    "node": {"npm_config_offline": "true"},
+    "rust": {"CARGO_NET_OFFLINE": "true"},
+    # Read by Maven 3.9+; Gradle has no equivalent and falls back to its cache
+    "java": {"MAVEN_ARGS": "--offline"},
+}
+
+_LAST_USED_MARKER = ".last_used"
+_EVICTING_PREFIX = ".evicting-"  # a cache renamed aside, being deleted
+
+_GB = 1024**3
+
+
+class BuildCache:
+    """Host-side build caches with leases and size-capped eviction."""
+
+    def __init__(self, config: CacheConfig) -> None:
+        self.config = config
+        self.root = Path(config.path)
+        self._leases: dict[str, int] = {}
+        self._lock = threading.Lock()
+
+    @property
+    def enabled(self) -> bool:
+        return self.config.enabled
+
+    @property
+    def max_bytes(self) -> int:
+        return int(self.config.max_size_gb * _GB)
+
+    # ------------------------------------------------------------------
+    # Leases
+    # ------------------------------------------------------------------
+    def acquire(self, stack: str, uid: int | None = None) -> list[str]:
+        """Lease the cache for ``stack`` and return its ``-v`` mount specs.
+
+        Returns an empty list when caching is disabled or the stack has no
+        cacheable locations.  Every successful acquire must be paired with
+        :meth:`release` (with the same ``uid``).
+        """
+        mounts = CACHE_MOUNTS.get(stack)
+        if not self.enabled or not mounts:
+            return []
+
+        key = cache_name(stack, uid)
+        stack_dir = self.root / key
+        # Leased first: from here on evict() leaves the directory alone
+        with self._lock:
+            self._leases[key] = self._leases.get(key, 0) + 1
+        volumes = []
+        for name, target in mounts.items():
+            host = stack_dir / name
+            # Pre-create so Docker doesn't create it root-owned
+            host.mkdir(parents=True, exist_ok=True)
+            volumes.append(f"{host}:{target}:rw")
+        (stack_dir / _LAST_USED_MARKER).touch()
+        return volumes
+
+    def release(self, stack: str, uid: int | None = None) -> None:
+        """Release a lease taken by :meth:`acquire`."""
+        key = cache_name(stack, uid)
+        with self._lock:
+            count = self._leases.get(key, 0) - 1
+            if count > 0:
+                self._leases[key] = count
+            else:
+                self._leases.pop(key, None)
+
+    def is_leased(self, stack: str) -> bool:
+        with self._lock:
+            return self._leases.get(stack, 0) > 0
+
+    # ------------------------------------------------------------------
+    # Size accounting / eviction
+    # ------------------------------------------------------------------
+    def usage_bytes(self, stack: str | None = None) -> int:
+        """Total bytes used by one stack's cache, or by all caches."""
+        target = self.root / stack if stack else self.root
+        return _dir_size(target)
+
+    def evict(self) -> list[str]:
+        """Evict least-recently-used stack caches until under ``maxSizeGB``.
+
+        Stacks with an active lease are never evicted, even if that
+        leaves the cache over the cap.
+
+        Returns:
+            Names of the evicted stacks.
+        """
+        if not self.root.is_dir():
+            return []
+
+        sizes = {}
+        for entry in self.root.iterdir():
+            if entry.name.startswith(_EVICTING_PREFIX):
+                _force_rmtree(entry)  # left by an eviction that was cut short
+            elif entry.is_dir():
+                sizes[entry.name] = _dir_size(entry)
+        total = sum(sizes.values())
+        if total <= self.max_bytes:
+            return []
+
+        evicted = []
+        for stack in sorted(sizes, key=self._last_used):
+            if total <= self.max_bytes:
+                break
+            with self._lock:
+                if self._leases.get(stack, 0) > 0:
+                    logger.debug("Cache for %s is in use -- not evicting", stack)
+                    continue
+                doomed = self.root / f"{_EVICTING_PREFIX}{stack}-{uuid.uuid4().hex[:8]}"
+                (self.root / stack).rename(doomed)
+            _force_rmtree(doomed)
+            total -= sizes[stack]
+            evicted.append(stack)
+            logger.info("Evicted %s build cache (%.1f MB)", stack, sizes[stack] / 1024**2)
+
+        if total > self.max_bytes:
+            logger.warning(
+                "Build cache still over limit (%.1f GB > %.1f GB): remaining caches in use",
+                total / _GB,
+                self.config.max_size_gb,
+            )
+        return evicted
+
+    def _last_used(self, stack: str) -> float:
+        marker = self.root / stack / _LAST_USED_MARKER
+        try:
+            return marker.stat().st_mtime
+        except OSError:
+            return 0.0
+
+
+# ---------------------------------------------------------------------------
+# Helpers
+# ---------------------------------------------------------------------------
+def cache_name(stack: str, uid: int | None = None) -> str:
+    """Directory name of the cache ``stack`` jobs running as ``uid`` share."""
+    if uid is None or uid == ORION_UID:
+        return stack
+    return f"{stack}-uid{uid}"
+
+
+def _dir_size(path: Path) -> int:
+    total = 0
+    for dirpath, _dirnames, filenames in os.walk(path):
+        for name in filenames:
+            try:
+                total += os.lstat(os.path.join(dirpath, name)).st_size
+            except OSError:
+                pass
+    return total
+
+
+def _force_rmtree(path: Path) -> None:
+    """Remove a tree, including Go's read-only module cache directories."""
+
+    def _onerror(func, target, _exc_info):
+        try:
+            os.chmod(os.path.dirname(target), stat.S_IRWXU)
+            os.chmod(target, stat.S_IRWXU)
+            func(target)
+        except OSError as exc:
+            logger.warning("Could not remove %s: %s", target, exc)
+
+    shutil.rmtree(path, onerror=_onerror)
@@ -0,0 +1,130 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job completion webhooks (manifest ``callbackUrl``).
+
+When a job reaches a terminal state -- succeeded, failed or cancelled,
+queued jobs dropped by a shutdown included -- the agent POSTs::
+
+    {"job_id": "3f9c0a1b2c4d", "status": "failed", "error_code": "command_failed",
+     "exit_code": 2, "duration_seconds": 41.3,
+     "artifacts": [{"path": "dist/app", "size": 1048576}]}
+
+With ``callbacks.secret`` set, the ``X-Orion-Signature`` header carries
+``sha256=<hex>``: the HMAC-SHA256 of the raw request body keyed with that
+secret.  Receivers recompute it over the bytes they received and compare
+in constant time (``hmac.compare_digest``).
+
+Unreachable endpoints, timeouts, 429 and 5xx responses are retried with
+doubling backoff up to ``callbacks.maxAttempts``; any other response is
+final.  Delivery never changes the job's result: a callback that cannot
+be delivered is only logged.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import hashlib
+import hmac
+import json
+import logging
+from collections.abc import Awaitable, Callable
+from typing import TYPE_CHECKING, Any
+
+from orion.security.jobs.config import CallbacksConfig
+from orion.security.jobs.manifest import display_url
+from orion.security.jobs.retry import backoff_delay
+
+if TYPE_CHECKING:
+    from orion.security.jobs.executor import JobResult
+
+logger = logging.getLogger("orion.security.jobs.callbacks")
+
+SIGNATURE_HEADER = "X-Orion-Signature"
+
+# (url, body, headers, timeout) -> HTTP status; raises if the endpoint is unreachable
+Poster = Callable[[str, bytes, dict[str, str], float], Awaitable[int]]
+
+
+def callback_payload(result: JobResult) -> dict[str, Any]:
+    """The JSON document POSTed for a finished job."""
+    return {
+        "job_id": result.job_id,
+        "status": result.status,
+        "error_code": result.error_code,
+        "exit_code": result.exit_code,
+        "duration_seconds": result.duration_seconds,
+        "artifacts": [artifact.to_dict() for artifact in result.artifacts],
+    }
+
+
+def sign(body: bytes, secret: str) -> str:
+    """The ``X-Orion-Signature`` value of ``body``."""
+    return "sha256=" + hmac.new(secret.encode(), body, hashlib.sha256).hexdigest()
+
+
+async def http_post(url: str, body: bytes, headers: dict[str, str], timeout: float) -> int:
+    """POST ``body`` to ``url`` and return the response status."""
+    import httpx
+
+    async with httpx.AsyncClient(timeout=timeout, follow_redirects=False) as client:
+        response = await client.post(url, content=body, headers=headers)
+    return response.status_code
+
+
+async def deliver(
+    url: str,
+    payload: dict[str, Any],
+    config: CallbacksConfig,
+    secret: str = "",
+    post: Poster = http_post,
+    sleep: Callable[[float], Awaitable[None]] = asyncio.sleep,
+) -> bool:
+    """POST ``payload`` to ``url``, retrying transient failures.  True once accepted (2xx)."""
+    body = json.dumps(payload, sort_keys=True).encode()
+    headers = {"Content-Type": "application/json", "User-Agent": "orion-agent"}
+    if secret:
+        headers[SIGNATURE_HEADER] = sign(body, secret)
+
+    where = display_url(url)
+    attempts = config.retry.max_attempts
+    for attempt in range(1, attempts + 1):
+        try:
+            status = await post(url, body, headers, config.timeout)
+        except Exception as exc:
+            error = str(exc) or type(exc).__name__
+        else:
+            if 200 <= status < 300:
+                logger.info("Callback to %s delivered (HTTP %d)", where, status)
+                return True
+            error = f"HTTP {status}"
+            if status != 429 and status < 500:
+                logger.warning("Callback to %s rejected: %s", where, error)
+                return False
+        if attempt == attempts:
+            break
+        delay = backoff_delay(attempt - 1, config.retry)
+        logger.warning(
+            "Callback to %s failed on attempt %d/%d, retrying in %.1fs: %s",
+            where,
+            attempt,
+            attempts,
+            delay,
+            error,
+        )
+        await sleep(delay)
+    logger.warning("Callback to %s failed after %d attempts: %s", where, attempts, error)
+    return False
@@ -0,0 +1,70 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Agent capability labels and the manifest selectors matched against them.
+
+An agent advertises labels in its config (``agent.labels``), e.g.
+``gpu: "true"`` and ``mem: high``.  A manifest's ``requires`` lists
+selectors, every one of which the agent must satisfy to take the job:
+
+  - ``key=value`` -- the agent has label ``key`` set to exactly ``value``
+  - ``key`` -- the agent has label ``key``, whatever its value
+
+A job the agent does not satisfy is refused when submitted (409,
+``no_matching_capability``) so that a dispatcher in front of several
+agents can send it to another.  This is admission control at one agent
+only; an agent never forwards a job itself.
+"""
+
+from __future__ import annotations
+
+import re
+from collections.abc import Mapping
+
+LABEL_KEY_RE = re.compile(r"^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$")
+LABEL_VALUE_RE = re.compile(r"^[A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?$")
+
+
+def parse_selector(text: str) -> tuple[str, str | None]:
+    """Split a selector into ``(key, value)``; value None for a presence check.
+
+    Raises:
+        ValueError: If the key or value is not a valid label.
+    """
+    key, sep, value = text.strip().partition("=")
+    key, value = key.strip(), value.strip()
+    if not LABEL_KEY_RE.match(key):
+        raise ValueError(f"'{text}' does not start with a label name")
+    if sep and not LABEL_VALUE_RE.match(value):
+        raise ValueError(f"'{text}' has an invalid value; use 'key=value' or 'key'")
+    return key, value if sep else None
+
+
+def label_value(value: object) -> str:
+    """A config label value as a string; YAML booleans become 'true' / 'false'."""
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    return str(value).strip()
+
+
+def unmatched(requires: list[str], labels: Mapping[str, str]) -> list[str]:
+    """The selectors in ``requires`` that ``labels`` do not satisfy."""
+    missing = []
+    for selector in requires:
+        key, value = parse_selector(selector)
+        if key not in labels or (value is not None and labels[key] != value):
+            missing.append(selector)
+    return missing
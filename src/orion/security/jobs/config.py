@@ -0,0 +1,1211 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job agent configuration.
+
+Operator-side settings for the job executor.  Like the egress config,
+this file lives on the HOST and is never visible to job containers.
+
+Config location: ~/.orion/jobs_config.yaml  (host-side)
+
+Example::
+
+    cache:
+      enabled: true
+      maxSizeGB: 20
+    resources:
+      defaultCpu: 2        # applied when a manifest omits resources.cpu
+      defaultMemory: 2Gi
+      maxCpu: 4            # manifests asking for more are rejected
+      maxMemory: 8Gi
+    timeout:
+      default: 1h          # applied when a manifest omits timeout
+      gracePeriod: 10s     # SIGTERM -> SIGKILL delay
+    secrets:
+      source: store        # store (SecureStore) or env
+      envPrefix: ORION_SECRET_
+    scheduler:
+      maxConcurrent: 4     # running job containers
+      maxQueued: 100       # waiting jobs; beyond this submissions are rejected
+      shutdownGracePeriod: 5m   # on SIGTERM, running jobs may finish for this long
+    metrics:
+      enabled: true        # serve Prometheus metrics on /metrics
+    artifacts:
+      maxTotalSize: 1Gi    # per job; files beyond this are not copied out
+    runtime:
+      driver: docker       # docker or podman
+      socket: ""           # optional; engine default when empty
+    source:
+      allowedHostPaths:    # host dirs manifests may bind-mount; none by default
+        - /srv/checkouts
+      cloneTimeout: 10m
+      repoDefaults: true   # merge a cloned repo's .orion-agent.yaml under the manifest
+      maxArchiveSize: 1Gi  # largest archive source a submission may upload
+      maxUploadsSize: 10Gi # all stored archives not yet consumed by their job
+    log:
+      format: text         # text or json (one object per line)
+      level: INFO          # DEBUG, INFO, WARNING or ERROR
+    images:
+      pullPolicy: IfNotPresent   # Always, IfNotPresent or Never
+      stacks:
+        go:
+          pullPolicy: Always     # overrides the global policy for one stack
+      registries:              # keyed by the host in the image reference
+        ghcr.io:
+          username: ci-bot
+          passwordSecret: ghcr-token   # looked up in secrets.source
+        registry.acme.dev:
+          username: deploy
+          passwordFile: /run/secrets/acme-registry   # or passwordEnv: VAR
+        quay.io:
+          tokenEnv: QUAY_TOKEN   # tokenSecret / tokenFile / tokenEnv; username optional
+      allowedPrefixes:         # custom manifest ``image``s allowed; none by default
+        - ghcr.io/acme/        # a prefix...
+        - registry.acme.dev    # ...or a whole registry
+      warmStacks:              # pulled at startup; /readyz waits for them
+        - go
+        - stack: node
+          pool: true           # also start its pool.stacks.node warm containers
+          required: true       # a failure keeps the agent not ready
+      warmTimeout: 10m         # ready anyway after this, unless a required stack is unwarmed
+    health:
+      probeTtl: 10s        # /readyz reuses a runtime probe for this long
+      minFreeDisk: 1Gi     # below this free under the jobs dir -> not ready
+      probeImage: ""       # image of the throwaway probe container; base stack when empty
+    runAs:
+      allowRoot: false     # manifests asking for runAs uid 0 are refused unless true
+    retry:                 # image pulls and container starts only, never the command
+      maxAttempts: 3       # 1 disables retries
+      initialBackoff: 1s   # doubled after each failed attempt...
+      maxBackoff: 30s      # ...up to this
+    workspace:
+      maxSizeGB: 50        # per job; exceeding it stops the command (unlimited when unset)
+      checkInterval: 10s   # how often /workspace is measured while the command runs
+    output:
+      maxLogBytes: 100Mi   # per job, stdout and stderr together; later output is
+                           # dropped after a truncation marker (unlimited when unset)
+      killOnLimit: false   # true = also stop the job once it is reached
+    pool:
+      maxIdle: 5m          # warm containers idle longer than this are removed
+      stacks:
+        go: 2              # warm containers kept per stack; none when unset
+    callbacks:             # POSTs to a manifest's callbackUrl when its job ends
+      secret: hook-key     # secret (in secrets.source) keying the HMAC signature
+      timeout: 10s         # per attempt
+      maxAttempts: 5       # unreachable endpoints, 429 and 5xx are retried...
+      initialBackoff: 1s
+      maxBackoff: 1m       # ...with the same doubling backoff as retry:
+    mounts:
+      allowedHostPaths:    # host dirs manifest ``mounts`` may come from; none by default
+        - /opt/toolchains
+    envFiles:
+      allowedHostPaths:    # host dirs a manifest ``envFile.path`` may name; none by default
+        - /srv/env
+    history:               # finished jobs kept for GET /api/jobs and /describe
+      maxJobs: 1000        # oldest evicted beyond this many...
+      maxAge: 24h          # ...or once finished this long ago
+    cleanup:
+      reapOnStartup: true  # remove job containers a previous run left behind; turn
+                           # off when several agents share one container engine
+    networks:
+      allowed:             # named networks a manifest ``network`` may ask for;
+        - ci-services      # none by default ('none' and 'bridge' always work)
+    store:                 # job history across restarts; memory only by default
+      backend: sqlite      # none or sqlite
+      path: ~/.orion/jobs.db
+    submissions:           # POST /api/jobs only; off unless ratePerSecond is set
+      ratePerSecond: 2     # sustained submissions allowed...
+      burst: 20            # ...and how many may arrive at once
+      perClient: true      # one bucket per API key / source IP; false = one for all
+    agent:
+      labels:              # capabilities a manifest's ``requires`` is matched against;
+        gpu: "true"        # none by default (see orion.security.jobs.capabilities)
+        mem: high
+    signing:               # only run manifests one of these keys signed (see signing.py)
+      enabled: true        # off by default; then unsigned submissions run as before
+      keys:                # key ID -> Ed25519 public key, base64 or PEM...
+        ci-prod:
+          publicKey: 3F1l0zPZ2zM0r4d4VbLXuD6m1a1pS0bQh2yD3Jm0Y9U=
+        release:
+          publicKeyFile: /etc/orion/release.pub   # ...or a file holding it
+    hardening:             # every job container; these are the defaults
+      noNewPrivileges: true    # setuid binaries (sudo, su) cannot raise privileges
+      dropCapabilities: true   # --cap-drop ALL, less the four the agent's own root
+                               # steps need (see AGENT_CAPABILITIES)
+      addCapabilities: []      # given back on top, e.g. [NET_RAW] for ping
+      ulimits:                 # per process: a number, or soft:hard; null removes one
+        nofile: 65536
+        nproc: 4096            # counted per UID across the host (see DEPLOYMENT.md)
+      seccompProfile: ""       # host path of a seccomp JSON profile; engine default when empty
+
+The agent re-reads this file on SIGHUP.  The new file is validated as a
+whole and, if any of it is invalid, rejected with the old config left in
+force.  Otherwise it takes effect at once, except for the settings in
+:data:`RESTART_REQUIRED`, which keep their current values until the
+agent restarts.
+"""
+
+from __future__ import annotations
+
+import logging
+import os
+import re
+from dataclasses import dataclass, field, fields
+from pathlib import Path
+from typing import Any
+
+import yaml
+
+from orion.security.container_runtime import (
+    DEFAULT_REGISTRY,
+    DRIVERS,
+    ContainerSecurity,
+    registry_of,
+)
+from orion.security.jobs.capabilities import LABEL_KEY_RE, LABEL_VALUE_RE, label_value
+from orion.security.jobs.signing import public_key_bytes
+from orion.security.sandbox_config import parse_memory_bytes
+
+logger = logging.getLogger("orion.security.jobs.config")
+
+# ---------------------------------------------------------------------------
+# Default paths
+# ---------------------------------------------------------------------------
+_ORION_HOME = Path(os.environ.get("ORION_HOME", Path.home() / ".orion"))
+DEFAULT_CONFIG_PATH = _ORION_HOME / "jobs_config.yaml"
+DEFAULT_CACHE_DIR = _ORION_HOME / "cache"
+DEFAULT_STORE_PATH = _ORION_HOME / "jobs.db"
+
+_DURATION_RE = re.compile(r"^\s*(\d+(?:\.\d+)?)\s*(ms|s|m|h)?\s*$")
+_DURATION_UNITS = {"ms": 0.001, "s": 1, "m": 60, "h": 3600}
+_LOG_LEVELS = ("DEBUG", "INFO", "WARNING", "ERROR")
+
+PULL_ALWAYS = "Always"
+PULL_IF_NOT_PRESENT = "IfNotPresent"
+PULL_NEVER = "Never"
+PULL_POLICIES = (PULL_ALWAYS, PULL_IF_NOT_PRESENT, PULL_NEVER)
+
+STORE_NONE = "none"
+STORE_SQLITE = "sqlite"
+STORE_BACKENDS = (STORE_NONE, STORE_SQLITE)
+
+# Sent with an access token when no username is configured; registries that
+# authenticate by token alone (ghcr.io, ...) ignore it
+TOKEN_USERNAME = "token"
+
+
+@dataclass
+class CacheConfig:
+    """Persistent build-cache settings (``cache:`` section)."""
+
+    enabled: bool = False
+    max_size_gb: float = 10.0
+    path: str = str(DEFAULT_CACHE_DIR)
+
+
+@dataclass
+class ResourcesConfig:
+    """Operator defaults and caps for per-job limits (``resources:`` section).
+
+    None leaves the container's resource profile in charge.
+    """
+
+    default_cpu: float | None = None
+    default_memory: int | None = None  # bytes
+    max_cpu: float | None = None
+    max_memory: int | None = None  # bytes
+
+
+@dataclass
+class TimeoutConfig:
+    """Job wall-clock limits (``timeout:`` section), in seconds."""
+
+    default: float = 3600.0
+    grace_period: float = 10.0
+
+
+@dataclass
+class SecretsConfig:
+    """Where job secrets are looked up (``secrets:`` section)."""
+
+    source: str = "store"  # 'store' or 'env'
+    env_prefix: str = "ORION_SECRET_"
+
+
+@dataclass
+class SchedulerConfig:
+    """Concurrency limits and shutdown drain of background jobs (``scheduler:``)."""
+
+    max_concurrent: int = 4
+    max_queued: int = 100
+    shutdown_grace_period: float = 30.0  # seconds running jobs get on shutdown; 0 = none
+
+
+@dataclass
+class MetricsConfig:
+    """Prometheus metrics (``metrics:`` section)."""
+
+    enabled: bool = True
+
+
+@dataclass
+class ArtifactsConfig:
+    """Limits on files copied out of job containers (``artifacts:`` section)."""
+
+    max_total_size: int = 1024**3  # bytes, per job
+
+
+@dataclass
+class RuntimeConfig:
+    """Container engine (``runtime:`` section)."""
+
+    driver: str = "docker"  # 'docker' or 'podman'
+    socket: str = ""  # '' = the engine's default socket
+
+
+@dataclass
+class SourceConfig:
+    """How jobs get their source code (``source:`` section)."""
+
+    # Manifest ``hostPath`` mounts must be inside one of these; empty = none
+    allowed_host_paths: list[str] = field(default_factory=list)
+    clone_timeout: float = 600.0  # seconds
+    repo_defaults: bool = True  # read .orion-agent.yaml from git sources
+    max_archive_size: int = 1024**3  # bytes, as uploaded (compressed or not)
+    max_uploads_size: int = 10 * 1024**3  # bytes, every stored upload together
+
+
+@dataclass
+class LogConfig:
+    """The agent's own operational logs (``log:`` section), not job output."""
+
+    format: str = "text"  # 'text' or 'json'
+    level: str = "INFO"
+
+
+@dataclass
+class RegistryCredentials:
+    """Login for one registry.
+
+    The password (or access token) is never a value in the config: it is
+    read at pull time from exactly one of a secret in ``secrets.source``, a
+    file or an environment variable.
+    """
+
+    username: str
+    password_secret: str = ""
+    password_file: str = ""
+    password_env: str = ""
+    token: bool = False  # an access token; the username is then optional
+
+    @property
+    def origin(self) -> tuple[str, str]:
+        """Where the password comes from: ('secret' | 'file' | 'env', name)."""
+        if self.password_file:
+            return "file", self.password_file
+        if self.password_env:
+            return "env", self.password_env
+        return "secret", self.password_secret
+
+
+@dataclass
+class WarmStack:
+    """A stack primed at startup (an ``images.warmStacks`` entry)."""
+
+    stack: str
+    pool: bool = False  # also fill its warm pool (``pool.stacks.<stack>``)
+    required: bool = False  # failing to warm it keeps the agent not ready
+
+
+@dataclass
+class ImagesConfig:
+    """Stack image pulls (``images:`` section)."""
+
+    pull_policy: str = PULL_IF_NOT_PRESENT
+    stack_pull_policies: dict[str, str] = field(default_factory=dict)
+    registries: dict[str, RegistryCredentials] = field(default_factory=dict)
+    # Image reference prefixes a manifest ``image`` may start with; empty = none
+    allowed_prefixes: list[str] = field(default_factory=list)
+    warm_stacks: list[WarmStack] = field(default_factory=list)
+    warm_timeout: float = 600.0  # seconds readiness waits for warmStacks
+
+    def policy_for(self, stack: str) -> str:
+        """The pull policy for ``stack``: its own, else the global one."""
+        return self.stack_pull_policies.get(stack, self.pull_policy)
+
+    def allows(self, image: str) -> bool:
+        """Whether a manifest may run ``image`` directly.
+
+        A prefix matches at a path boundary (``ghcr.io/acme`` allows
+        ``ghcr.io/acme/api:1`` but not ``ghcr.io/acme-evil/api``), against
+        the reference as written or with Docker Hub's implicit
+        ``docker.io/[library/]`` spelled out.
+        """
+        candidates = {image, _qualified(image)}
+        for prefix in self.allowed_prefixes:
+            for candidate in candidates:
+                if candidate == prefix or (
+                    candidate.startswith(prefix)
+                    and (prefix.endswith("/") or candidate[len(prefix)] in "/:@")
+                ):
+                    return True
+        return False
+
+
+@dataclass
+class HealthConfig:
+    """Readiness checks (``health:`` section)."""
+
+    probe_ttl: float = 10.0  # seconds a runtime probe result is reused
+    min_free_disk: int = 1024**3  # bytes
+    probe_image: str = ""  # empty = the base stack image
+
+
+@dataclass
+class RunAsConfig:
+    """Manifest ``runAs`` overrides (``runAs:`` section)."""
+
+    allow_root: bool = False
+
+
+@dataclass
+class RetryConfig:
+    """Retries of transient pull / container start failures (``retry:``)."""
+
+    max_attempts: int = 3
+    initial_backoff: float = 1.0  # seconds
+    max_backoff: float = 30.0  # seconds
+
+
+@dataclass
+class WorkspaceConfig:
+    """Per-job ``/workspace`` size quota (``workspace:`` section)."""
+
+    max_size_gb: float = 0.0  # 0 = unlimited
+    check_interval: float = 10.0  # seconds
+
+    @property
+    def max_bytes(self) -> int:
+        return int(self.max_size_gb * 1024**3)
+
+
+@dataclass
+class OutputConfig:
+    """Per-job log size limit (``output:`` section)."""
+
+    max_log_bytes: int = 0  # the command's stdout and stderr together; 0 = unlimited
+    kill_on_limit: bool = False  # stop the job at the limit, not only the capture
+
+
+@dataclass
+class PoolConfig:
+    """Warm container pool (``pool:`` section)."""
+
+    sizes: dict[str, int] = field(default_factory=dict)  # stack -> idle containers
+    max_idle: float = 300.0  # seconds
+
+    def size_for(self, stack: str) -> int:
+        """Warm containers to keep for ``stack``; 0 disables pooling for it."""
+        return self.sizes.get(stack, 0)
+
+
+@dataclass
+class CallbacksConfig:
+    """Job completion webhooks (``callbacks:`` section)."""
+
+    secret: str = ""  # secret name; empty = callbacks are not signed
+    timeout: float = 10.0  # seconds per attempt
+    retry: RetryConfig = field(
+        default_factory=lambda: RetryConfig(max_attempts=5, initial_backoff=1.0, max_backoff=60.0)
+    )
+
+
+@dataclass
+class MountsConfig:
+    """Extra bind mounts requested by manifests (``mounts:`` section)."""
+
+    # Manifest ``mounts`` sources must be inside one of these; empty = none
+    allowed_host_paths: list[str] = field(default_factory=list)
+
+
+@dataclass
+class EnvFilesConfig:
+    """Host env files named by manifests (``envFiles:`` section)."""
+
+    # Manifest ``envFile.path`` must be inside one of these; empty = none
+    allowed_host_paths: list[str] = field(default_factory=list)
+
+
+@dataclass
+class HistoryConfig:
+    """How long finished background jobs stay listable (``history:`` section)."""
+
+    max_jobs: int = 1000
+    max_age: float = 86400.0  # seconds since the job finished
+
+
+@dataclass
+class CleanupConfig:
+    """Leftover job containers (``cleanup:`` section)."""
+
+    reap_on_startup: bool = True
+
+
+@dataclass
+class NetworksConfig:
+    """Engine networks jobs may join (``networks:`` section)."""
+
+    # Named networks a manifest ``network`` may ask for; empty = none
+    allowed: list[str] = field(default_factory=list)
+
+
+@dataclass
+class StoreConfig:
+    """Where job history is persisted (``store:`` section)."""
+
+    backend: str = STORE_NONE  # 'none' keeps history in memory only
+    path: str = str(DEFAULT_STORE_PATH)  # the sqlite file
+
+
+@dataclass
+class SubmissionsConfig:
+    """Rate limit of the job submission endpoint (``submissions:`` section)."""
+
+    rate_per_second: float = 0.0  # 0 = unlimited
+    burst: int = 10
+    per_client: bool = True
+
+
+@dataclass
+class AgentConfig:
+    """This agent's capabilities (``agent:`` section)."""
+
+    labels: dict[str, str] = field(default_factory=dict)  # matched by manifest ``requires``
+
+
+@dataclass
+class SigningKey:
+    """A public key that may sign manifests; exactly one field is set."""
+
+    public_key: str = ""  # base64 or PEM
+    public_key_file: str = ""  # read on every check
+
+
+@dataclass
+class SigningConfig:
+    """Manifest signature verification (``signing:`` section)."""
+
+    enabled: bool = False  # when on, unsigned submissions are refused
+    keys: dict[str, SigningKey] = field(default_factory=dict)  # key ID -> key
+
+
+# Given back after ``--cap-drop ALL``: the agent's own steps run as root in
+# the container (chown of mounts and archives, the warm pool's reset and
+# the workspace purge) and need them.  A job's non-root user holds none.
+AGENT_CAPABILITIES = ("CHOWN", "DAC_OVERRIDE", "FOWNER", "KILL")
+
+# ``--ulimit`` names both engines accept
+ULIMITS = (
+    "core",
+    "cpu",
+    "data",
+    "fsize",
+    "locks",
+    "memlock",
+    "msgqueue",
+    "nice",
+    "nofile",
+    "nproc",
+    "rss",
+    "rtprio",
+    "rttime",
+    "sigpending",
+    "stack",
+)
+
+
+@dataclass
+class HardeningConfig:
+    """Kernel-level restrictions of job containers (``hardening:`` section)."""
+
+    no_new_privileges: bool = True
+    drop_capabilities: bool = True  # all but AGENT_CAPABILITIES
+    add_capabilities: list[str] = field(default_factory=list)
+    ulimits: dict[str, str] = field(
+        default_factory=lambda: {"nofile": "65536", "nproc": "4096"}
+    )  # name -> 'soft[:hard]'
+    seccomp_profile: str = ""  # '' = the engine's default profile
+
+    def container_security(self) -> ContainerSecurity:
+        """The restrictions a job container is created with."""
+        cap_drop = ["ALL"] if self.drop_capabilities else []
+        cap_add = [*AGENT_CAPABILITIES] if self.drop_capabilities else []
+        cap_add += [cap for cap in self.add_capabilities if cap not in cap_add]
+        return ContainerSecurity(
+            no_new_privileges=self.no_new_privileges,
+            cap_drop=cap_drop,
+            cap_add=cap_add,
+            ulimits=dict(self.ulimits),
+            seccomp_profile=self.seccomp_profile,
+        )
+
+
+@dataclass
+class JobsConfig:
+    """Full job agent configuration."""
+
+    cache: CacheConfig = field(default_factory=CacheConfig)
+    resources: ResourcesConfig = field(default_factory=ResourcesConfig)
+    timeout: TimeoutConfig = field(default_factory=TimeoutConfig)
+    secrets: SecretsConfig = field(default_factory=SecretsConfig)
+    scheduler: SchedulerConfig = field(default_factory=SchedulerConfig)
+    metrics: MetricsConfig = field(default_factory=MetricsConfig)
+    artifacts: ArtifactsConfig = field(default_factory=ArtifactsConfig)
+    runtime: RuntimeConfig = field(default_factory=RuntimeConfig)
+    source: SourceConfig = field(default_factory=SourceConfig)
+    log: LogConfig = field(default_factory=LogConfig)
+    images: ImagesConfig = field(default_factory=ImagesConfig)
+    health: HealthConfig = field(default_factory=HealthConfig)
+    run_as: RunAsConfig = field(default_factory=RunAsConfig)
+    retry: RetryConfig = field(default_factory=RetryConfig)
+    workspace: WorkspaceConfig = field(default_factory=WorkspaceConfig)
+    output: OutputConfig = field(default_factory=OutputConfig)
+    pool: PoolConfig = field(default_factory=PoolConfig)
+    callbacks: CallbacksConfig = field(default_factory=CallbacksConfig)
+    mounts: MountsConfig = field(default_factory=MountsConfig)
+    env_files: EnvFilesConfig = field(default_factory=EnvFilesConfig)
+    history: HistoryConfig = field(default_factory=HistoryConfig)
+    cleanup: CleanupConfig = field(default_factory=CleanupConfig)
+    networks: NetworksConfig = field(default_factory=NetworksConfig)
+    store: StoreConfig = field(default_factory=StoreConfig)
+    submissions: SubmissionsConfig = field(default_factory=SubmissionsConfig)
+    agent: AgentConfig = field(default_factory=AgentConfig)
+    signing: SigningConfig = field(default_factory=SigningConfig)
+    hardening: HardeningConfig = field(default_factory=HardeningConfig)
+
+
+class ConfigError(ValueError):
+    """Raised when the jobs config file is invalid."""
+
+
+def load_config(path: Path | str | None = None) -> JobsConfig:
+    """Load the jobs config from YAML.
+
+    A missing file yields the defaults.  An unreadable or invalid file is
+    logged and also yields the defaults, so a typo never takes the agent
+    down -- it just runs with conservative settings.
+    """
+    config_path = Path(path) if path else DEFAULT_CONFIG_PATH
+    if not config_path.exists():
+        return JobsConfig()
+
+    try:
+        return read_config(config_path)
+    except ConfigError as exc:
+        logger.warning("Failed to load jobs config %s: %s -- using defaults", config_path, exc)
+        return JobsConfig()
+
+
+def read_config(path: Path | str | None = None) -> JobsConfig:
+    """Read and validate the jobs config, without :func:`load_config`'s fallbacks.
+
+    Raises:
+        ConfigError: If the file is missing, unreadable, not YAML or invalid.
+    """
+    config_path = Path(path) if path else DEFAULT_CONFIG_PATH
+    try:
+        raw = yaml.safe_load(config_path.read_text(encoding="utf-8")) or {}
+    except FileNotFoundError as exc:
+        raise ConfigError(f"Jobs config {config_path} does not exist") from exc
+    except (OSError, yaml.YAMLError) as exc:
+        raise ConfigError(f"Cannot read jobs config {config_path}: {exc}") from exc
+    return parse_config(raw)
+
+
+def parse_config(raw: Any) -> JobsConfig:
+    """Build a JobsConfig from a decoded YAML mapping.
+
+    Raises:
+        ConfigError: If a section or field has the wrong type.
+    """
+    if not isinstance(raw, dict):
+        raise ConfigError("Jobs config must be a mapping")
+
+    config = JobsConfig()
+
+    cache = _section(raw, "cache")
+    if "enabled" in cache:
+        config.cache.enabled = bool(cache["enabled"])
+    if "maxSizeGB" in cache:
+        config.cache.max_size_gb = _positive_number(cache["maxSizeGB"], "cache.maxSizeGB")
+    if "path" in cache:
+        config.cache.path = str(Path(str(cache["path"])).expanduser())
+
+    resources = _section(raw, "resources")
+    if "defaultCpu" in resources:
+        config.resources.default_cpu = _positive_number(
+            resources["defaultCpu"], "resources.defaultCpu"
+        )
+    if "maxCpu" in resources:
+        config.resources.max_cpu = _positive_number(resources["maxCpu"], "resources.maxCpu")
+    if "defaultMemory" in resources:
+        config.resources.default_memory = _memory(
+            resources["defaultMemory"], "resources.defaultMemory"
+        )
+    if "maxMemory" in resources:
+        config.resources.max_memory = _memory(resources["maxMemory"], "resources.maxMemory")
+
+    timeout = _section(raw, "timeout")
+    if "default" in timeout:
+        config.timeout.default = _duration(timeout["default"], "timeout.default")
+    if "gracePeriod" in timeout:
+        config.timeout.grace_period = _duration(timeout["gracePeriod"], "timeout.gracePeriod")
+
+    secrets = _section(raw, "secrets")
+    if "source" in secrets:
+        if secrets["source"] not in ("store", "env"):
+            raise ConfigError("Config field 'secrets.source' must be 'store' or 'env'")
+        config.secrets.source = secrets["source"]
+    if "envPrefix" in secrets:
+        config.secrets.env_prefix = str(secrets["envPrefix"])
+
+    scheduler = _section(raw, "scheduler")
+    if "maxConcurrent" in scheduler:
+        config.scheduler.max_concurrent = _count(
+            scheduler["maxConcurrent"], "scheduler.maxConcurrent", minimum=1
+        )
+    if "maxQueued" in scheduler:
+        config.scheduler.max_queued = _count(scheduler["maxQueued"], "scheduler.maxQueued")
+    if "shutdownGracePeriod" in scheduler:
+        value = scheduler["shutdownGracePeriod"]
+        # 0 cancels running jobs as soon as the agent is asked to stop
+        config.scheduler.shutdown_grace_period = (
+            0.0
+            if value == 0 and not isinstance(value, bool)
+            else _duration(value, "scheduler.shutdownGracePeriod")
+        )
+
+    metrics = _section(raw, "metrics")
+    if "enabled" in metrics:
+        config.metrics.enabled = bool(metrics["enabled"])
+
+    artifacts = _section(raw, "artifacts")
+    if "maxTotalSize" in artifacts:
+        config.artifacts.max_total_size = _memory(
+            artifacts["maxTotalSize"], "artifacts.maxTotalSize"
+        )
+
+    runtime = _section(raw, "runtime")
+    if "driver" in runtime:
+        if runtime["driver"] not in DRIVERS:
+            raise ConfigError("Config field 'runtime.driver' must be 'docker' or 'podman'")
+        config.runtime.driver = runtime["driver"]
+    if "socket" in runtime:
+        config.runtime.socket = os.path.expanduser(str(runtime["socket"]))
+
+    source = _section(raw, "source")
+    if "allowedHostPaths" in source:
+        config.source.allowed_host_paths = _paths(
+            source["allowedHostPaths"], "source.allowedHostPaths"
+        )
+    if "cloneTimeout" in source:
+        config.source.clone_timeout = _duration(source["cloneTimeout"], "source.cloneTimeout")
+    if "repoDefaults" in source:
+        if not isinstance(source["repoDefaults"], bool):
+            raise ConfigError("Config field 'source.repoDefaults' must be true or false")
+        config.source.repo_defaults = source["repoDefaults"]
+    if "maxArchiveSize" in source:
+        config.source.max_archive_size = _memory(
+            source["maxArchiveSize"], "source.maxArchiveSize"
+        )
+    if "maxUploadsSize" in source:
+        config.source.max_uploads_size = _memory(
+            source["maxUploadsSize"], "source.maxUploadsSize"
+        )
+
+    log = _section(raw, "log")
+    if "format" in log:
+        if log["format"] not in ("text", "json"):
+            raise ConfigError("Config field 'log.format' must be 'text' or 'json'")
+        config.log.format = log["format"]
+    if "level" in log:
+        level = str(log["level"]).upper()
+        if level not in _LOG_LEVELS:
+            raise ConfigError(f"Config field 'log.level' must be one of {', '.join(_LOG_LEVELS)}")
+        config.log.level = level
+
+    images = _section(raw, "images")
+    if "pullPolicy" in images:
+        config.images.pull_policy = _pull_policy(images["pullPolicy"], "images.pullPolicy")
+    stacks = _section(images, "stacks", "images.stacks")
+    for stack, settings in stacks.items():
+        path = f"images.stacks.{stack}"
+        settings = _section(stacks, stack, path)
+        if "pullPolicy" in settings:
+            config.images.stack_pull_policies[str(stack)] = _pull_policy(
+                settings["pullPolicy"], f"{path}.pullPolicy"
+            )
+    if "allowedPrefixes" in images:
+        prefixes = images["allowedPrefixes"] or []
+        if not isinstance(prefixes, list) or not all(
+            isinstance(p, str) and p.strip() for p in prefixes
+        ):
+            raise ConfigError(
+                "Config field 'images.allowedPrefixes' must be a list of image name prefixes"
+            )
+        config.images.allowed_prefixes = [p.strip() for p in prefixes]
+    registries = _section(images, "registries", "images.registries")
+    for registry in registries:
+        path = f"images.registries.{registry}"
+        config.images.registries[str(registry)] = _registry_credentials(
+            _section(registries, registry, path), path
+        )
+    if "warmStacks" in images:
+        config.images.warm_stacks = _warm_stacks(images["warmStacks"])
+    if "warmTimeout" in images:
+        config.images.warm_timeout = _duration(images["warmTimeout"], "images.warmTimeout")
+
+    health = _section(raw, "health")
+    if "probeTtl" in health:
+        config.health.probe_ttl = _duration(health["probeTtl"], "health.probeTtl")
+    if "minFreeDisk" in health:
+        config.health.min_free_disk = _memory(health["minFreeDisk"], "health.minFreeDisk")
+    if "probeImage" in health:
+        config.health.probe_image = str(health["probeImage"] or "")
+
+    run_as = _section(raw, "runAs")
+    if "allowRoot" in run_as:
+        if not isinstance(run_as["allowRoot"], bool):
+            raise ConfigError("Config field 'runAs.allowRoot' must be true or false")
+        config.run_as.allow_root = run_as["allowRoot"]
+
+    retry = _section(raw, "retry")
+    if "maxAttempts" in retry:
+        config.retry.max_attempts = _count(retry["maxAttempts"], "retry.maxAttempts", minimum=1)
+    if "initialBackoff" in retry:
+        config.retry.initial_backoff = _duration(retry["initialBackoff"], "retry.initialBackoff")
+    if "maxBackoff" in retry:
+        config.retry.max_backoff = _duration(retry["maxBackoff"], "retry.maxBackoff")
+
+    workspace = _section(raw, "workspace")
+    if "maxSizeGB" in workspace:
+        config.workspace.max_size_gb = _positive_number(
+            workspace["maxSizeGB"], "workspace.maxSizeGB"
+        )
+    if "checkInterval" in workspace:
+        config.workspace.check_interval = _duration(
+            workspace["checkInterval"], "workspace.checkInterval"
+        )
+
+    output = _section(raw, "output")
+    if "maxLogBytes" in output:
+        config.output.max_log_bytes = _memory(output["maxLogBytes"], "output.maxLogBytes")
+    if "killOnLimit" in output:
+        if not isinstance(output["killOnLimit"], bool):
+            raise ConfigError("Config field 'output.killOnLimit' must be true or false")
+        config.output.kill_on_limit = output["killOnLimit"]
+
+    pool = _section(raw, "pool")
+    if "maxIdle" in pool:
+        config.pool.max_idle = _duration(pool["maxIdle"], "pool.maxIdle")
+    stacks = _section(pool, "stacks", "pool.stacks")
+    for stack, size in stacks.items():
+        config.pool.sizes[str(stack)] = _count(size, f"pool.stacks.{stack}")
+    for i, warm in enumerate(config.images.warm_stacks):
+        if warm.pool and not config.pool.size_for(warm.stack):
+            raise ConfigError(
+                f"Config field 'images.warmStacks[{i}].pool' needs a pool for {warm.stack} "
+                f"(pool.stacks.{warm.stack})"
+            )
+
+    callbacks = _section(raw, "callbacks")
+    if "secret" in callbacks:
+        if not isinstance(callbacks["secret"], str):
+            raise ConfigError("Config field 'callbacks.secret' must be a secret name")
+        config.callbacks.secret = callbacks["secret"]
+    if "timeout" in callbacks:
+        config.callbacks.timeout = _duration(callbacks["timeout"], "callbacks.timeout")
+    retry = config.callbacks.retry
+    if "maxAttempts" in callbacks:
+        retry.max_attempts = _count(callbacks["maxAttempts"], "callbacks.maxAttempts", minimum=1)
+    if "initialBackoff" in callbacks:
+        retry.initial_backoff = _duration(callbacks["initialBackoff"], "callbacks.initialBackoff")
+    if "maxBackoff" in callbacks:
+        retry.max_backoff = _duration(callbacks["maxBackoff"], "callbacks.maxBackoff")
+
+    mounts = _section(raw, "mounts")
+    if "allowedHostPaths" in mounts:
+        config.mounts.allowed_host_paths = _paths(
+            mounts["allowedHostPaths"], "mounts.allowedHostPaths"
+        )
+
+    env_files = _section(raw, "envFiles")
+    if "allowedHostPaths" in env_files:
+        config.env_files.allowed_host_paths = _paths(
+            env_files["allowedHostPaths"], "envFiles.allowedHostPaths"
+        )
+
+    history = _section(raw, "history")
+    if "maxJobs" in history:
+        config.history.max_jobs = _count(history["maxJobs"], "history.maxJobs", minimum=1)
+    if "maxAge" in history:
+        config.history.max_age = _duration(history["maxAge"], "history.maxAge")
+
+    cleanup = _section(raw, "cleanup")
+    if "reapOnStartup" in cleanup:
+        if not isinstance(cleanup["reapOnStartup"], bool):
+            raise ConfigError("Config field 'cleanup.reapOnStartup' must be true or false")
+        config.cleanup.reap_on_startup = cleanup["reapOnStartup"]
+
+    networks = _section(raw, "networks")
+    if "allowed" in networks:
+        allowed = networks["allowed"] or []
+        if not isinstance(allowed, list) or not all(isinstance(n, str) for n in allowed):
+            raise ConfigError("Config field 'networks.allowed' must be a list of network names")
+        config.networks.allowed = [name.strip() for name in allowed]
+
+    store = _section(raw, "store")
+    if "backend" in store:
+        if store["backend"] not in STORE_BACKENDS:
+            raise ConfigError("Config field 'store.backend' must be 'none' or 'sqlite'")
+        config.store.backend = store["backend"]
+    if "path" in store:
+        if not isinstance(store["path"], str) or not store["path"].strip():
+            raise ConfigError("Config field 'store.path' must be a file path")
+        config.store.path = str(Path(store["path"]).expanduser())
+
+    submissions = _section(raw, "submissions")
+    if "ratePerSecond" in submissions:
+        config.submissions.rate_per_second = _positive_number(
+            submissions["ratePerSecond"], "submissions.ratePerSecond"
+        )
+    if "burst" in submissions:
+        config.submissions.burst = _count(submissions["burst"], "submissions.burst", minimum=1)
+    if "perClient" in submissions:
+        if not isinstance(submissions["perClient"], bool):
+            raise ConfigError("Config field 'submissions.perClient' must be true or false")
+        config.submissions.per_client = submissions["perClient"]
+
+    agent = _section(raw, "agent")
+    if "labels" in agent:
+        labels = agent["labels"] or {}
+        if not isinstance(labels, dict):
+            raise ConfigError("Config field 'agent.labels' must map label names to values")
+        for key, value in labels.items():
+            if not isinstance(key, str) or not LABEL_KEY_RE.match(key):
+                raise ConfigError(f"Config field 'agent.labels' has an invalid label name {key!r}")
+            if isinstance(value, (dict, list)) or not LABEL_VALUE_RE.match(label_value(value)):
+                raise ConfigError(
+                    f"Config field 'agent.labels.{key}' must be a value of letters, digits, "
+                    "'.', '_' or '-'"
+                )
+        config.agent.labels = {key: label_value(value) for key, value in labels.items()}
+
+    signing = _section(raw, "signing")
+    if "enabled" in signing:
+        if not isinstance(signing["enabled"], bool):
+            raise ConfigError("Config field 'signing.enabled' must be true or false")
+        config.signing.enabled = signing["enabled"]
+    keys = _section(signing, "keys", "signing.keys")
+    for key_id in keys:
+        path = f"signing.keys.{key_id}"
+        config.signing.keys[str(key_id)] = _signing_key(_section(keys, key_id, path), path)
+    if config.signing.enabled and not config.signing.keys:
+        raise ConfigError("Config field 'signing.keys' needs at least one key when enabled")
+
+    hardening = _section(raw, "hardening")
+    for key, attr in (
+        ("noNewPrivileges", "no_new_privileges"),
+        ("dropCapabilities", "drop_capabilities"),
+    ):
+        if key in hardening:
+            if not isinstance(hardening[key], bool):
+                raise ConfigError(f"Config field 'hardening.{key}' must be true or false")
+            setattr(config.hardening, attr, hardening[key])
+    if "addCapabilities" in hardening:
+        config.hardening.add_capabilities = _capabilities(hardening["addCapabilities"])
+    ulimits = _section(hardening, "ulimits", "hardening.ulimits")
+    for name, value in ulimits.items():
+        if name not in ULIMITS:
+            raise ConfigError(
+                f"Config field 'hardening.ulimits.{name}' is not a ulimit "
+                f"(one of {', '.join(ULIMITS)})"
+            )
+        if value is None:
+            config.hardening.ulimits.pop(name, None)
+        else:
+            config.hardening.ulimits[name] = _ulimit(value, f"hardening.ulimits.{name}")
+    if hardening.get("seccompProfile"):
+        profile = hardening["seccompProfile"]
+        if not isinstance(profile, str):
+            raise ConfigError("Config field 'hardening.seccompProfile' must be a path")
+        path = Path(profile).expanduser()
+        if not path.is_file():
+            raise ConfigError(
+                f"Config field 'hardening.seccompProfile': {profile} is not a readable file"
+            )
+        config.hardening.seccomp_profile = str(path.resolve())
+
+    return config
+
+
+# ---------------------------------------------------------------------------
+# Reload
+# ---------------------------------------------------------------------------
+
+# Settings the running agent took in once at startup; a reload leaves them
+# as they are.  (The API's listen address is the server's, not in this file.)
+RESTART_REQUIRED = frozenset(
+    {
+        "runtime.driver",
+        "runtime.socket",
+        "cache.enabled",
+        "cache.path",
+        "secrets.source",
+        "secrets.envPrefix",
+        "metrics.enabled",
+        "store.backend",
+        "store.path",
+        "cleanup.reapOnStartup",
+        "images.warmStacks",
+        "images.warmTimeout",
+    }
+)
+
+# Field names whose YAML key is not simply their camelCase
+_KEYS = {"max_size_gb": "maxSizeGB", "stack_pull_policies": "stacks", "sizes": "stacks"}
+
+
+@dataclass
+class ConfigChange:
+    """One setting that differs between two configs."""
+
+    key: str  # as in the YAML, e.g. 'scheduler.maxConcurrent'
+    old: Any
+    new: Any
+
+    @property
+    def live(self) -> bool:
+        """Whether a reload applies it (else it waits for a restart)."""
+        return self.key not in RESTART_REQUIRED
+
+
+def diff_config(old: JobsConfig, new: JobsConfig) -> list[ConfigChange]:
+    """Every setting ``new`` changes, in the order of :class:`JobsConfig`."""
+    changes = []
+    for section in fields(JobsConfig):
+        before, after = getattr(old, section.name), getattr(new, section.name)
+        for setting in fields(before):
+            value, new_value = getattr(before, setting.name), getattr(after, setting.name)
+            if value != new_value:
+                key = f"{_yaml_key(section.name)}.{_yaml_key(setting.name)}"
+                changes.append(ConfigChange(key, value, new_value))
+    return changes
+
+
+def keep_restart_settings(current: JobsConfig, new: JobsConfig) -> None:
+    """Copy the :data:`RESTART_REQUIRED` settings of ``current`` into ``new``."""
+    for section in fields(JobsConfig):
+        before, after = getattr(current, section.name), getattr(new, section.name)
+        for setting in fields(before):
+            if f"{_yaml_key(section.name)}.{_yaml_key(setting.name)}" in RESTART_REQUIRED:
+                setattr(after, setting.name, getattr(before, setting.name))
+
+
+def _yaml_key(name: str) -> str:
+    if name in _KEYS:
+        return _KEYS[name]
+    first, *rest = name.split("_")
+    return first + "".join(word.capitalize() for word in rest)
+
+
+def parse_duration(value: str | int | float) -> float:
+    """Parse a duration into seconds.
+
+    Accepts a number of seconds or a string with a unit: ``500ms``,
+    ``90s``, ``10m``, ``1h``.  Bare numbers are seconds.
+
+    Raises:
+        ValueError: If the value is not a positive duration.
+    """
+    if isinstance(value, bool):
+        raise ValueError(f"Invalid duration: {value!r}")
+    if isinstance(value, (int, float)):
+        seconds = float(value)
+    else:
+        match = _DURATION_RE.match(str(value))
+        if not match:
+            raise ValueError(f"Invalid duration: {value!r} (use e.g. 90s, 10m, 1h)")
+        seconds = float(match.group(1)) * _DURATION_UNITS[match.group(2) or "s"]
+    if seconds <= 0:
+        raise ValueError(f"Duration must be positive: {value!r}")
+    return seconds
+
+
+def _qualified(image: str) -> str:
+    """``image`` with an implicit Docker Hub registry (and ``library/``) spelled out."""
+    if image.startswith(f"{DEFAULT_REGISTRY}/") or registry_of(image) != DEFAULT_REGISTRY:
+        return image
+    return f"{DEFAULT_REGISTRY}/{image}" if "/" in image else f"{DEFAULT_REGISTRY}/library/{image}"
+
+
+def _section(raw: dict, name: str, path: str = "") -> dict:
+    value = raw.get(name, {})
+    if value is None:
+        return {}
+    if not isinstance(value, dict):
+        raise ConfigError(f"Config section '{path or name}' must be a mapping")
+    return value
+
+
+def _pull_policy(value: Any, name: str) -> str:
+    if value not in PULL_POLICIES:
+        raise ConfigError(f"Config field '{name}' must be one of {', '.join(PULL_POLICIES)}")
+    return value
+
+
+def _warm_stacks(value: Any) -> list[WarmStack]:
+    if not isinstance(value or [], list):
+        raise ConfigError("Config field 'images.warmStacks' must be a list of stacks")
+    warm_stacks = []
+    for i, entry in enumerate(value or []):
+        path = f"images.warmStacks[{i}]"
+        if isinstance(entry, str):
+            entry = {"stack": entry}
+        if not isinstance(entry, dict):
+            raise ConfigError(f"Config field '{path}' must be a stack name or a mapping")
+        stack = entry.get("stack")
+        if not isinstance(stack, str) or not stack.strip():
+            raise ConfigError(f"Config field '{path}.stack' must be a stack name")
+        for key in ("pool", "required"):
+            if not isinstance(entry.get(key, False), bool):
+                raise ConfigError(f"Config field '{path}.{key}' must be true or false")
+        if any(warm.stack == stack.strip() for warm in warm_stacks):
+            raise ConfigError(f"Config field '{path}' repeats stack {stack.strip()!r}")
+        warm_stacks.append(
+            WarmStack(stack.strip(), entry.get("pool", False), entry.get("required", False))
+        )
+    return warm_stacks
+
+
+def _registry_credentials(login: dict, path: str) -> RegistryCredentials:
+    given = [
+        (kind, origin)
+        for kind in ("password", "token")
+        for origin in ("Secret", "File", "Env")
+        if f"{kind}{origin}" in login
+    ]
+    if len(given) != 1:
+        raise ConfigError(
+            f"Config field '{path}' needs exactly one of passwordSecret, passwordFile, "
+            "passwordEnv, tokenSecret, tokenFile or tokenEnv"
+        )
+    ((kind, origin),) = given
+    key = f"{kind}{origin}"
+    if not isinstance(login[key], str) or not login[key]:
+        raise ConfigError(f"Config field '{path}.{key}' must be a non-empty string")
+    username = login.get("username", TOKEN_USERNAME if kind == "token" else None)
+    if not isinstance(username, str) or not username:
+        raise ConfigError(f"Config field '{path}.username' must be a non-empty string")
+    return RegistryCredentials(
+        username=username, token=kind == "token", **{f"password_{origin.lower()}": login[key]}
+    )
+
+
+def _signing_key(key: dict, path: str) -> SigningKey:
+    given = [name for name in ("publicKey", "publicKeyFile") if name in key]
+    if len(given) != 1:
+        raise ConfigError(f"Config field '{path}' needs exactly one of publicKey or publicKeyFile")
+    (name,) = given
+    if not isinstance(key[name], str) or not key[name].strip():
+        raise ConfigError(f"Config field '{path}.{name}' must be a non-empty string")
+    if name == "publicKeyFile":
+        return SigningKey(public_key_file=key[name])
+    try:
+        public_key_bytes(key[name])
+    except ValueError as exc:
+        raise ConfigError(f"Config field '{path}.publicKey' is not an Ed25519 key: {exc}") from None
+    return SigningKey(public_key=key[name])
+
+
+def _capabilities(value: Any) -> list[str]:
+    if not isinstance(value, list) or not all(isinstance(cap, str) for cap in value):
+        raise ConfigError("Config field 'hardening.addCapabilities' must be a list of names")
+    caps = []
+    for cap in value:
+        name = cap.upper().removeprefix("CAP_")
+        if not re.fullmatch(r"[A-Z][A-Z0-9_]*", name):
+            raise ConfigError(
+                f"Config field 'hardening.addCapabilities': '{cap}' is not a capability"
+            )
+        if name == "ALL":
+            raise ConfigError(
+                "Config field 'hardening.addCapabilities' cannot be ALL; "
+                "set hardening.dropCapabilities: false instead"
+            )
+        caps.append(name)
+    return caps
+
+
+def _ulimit(value: Any, name: str) -> str:
+    """A ``--ulimit`` value: ``n`` (soft and hard) or ``soft:hard``."""
+    if isinstance(value, int) and not isinstance(value, bool):
+        parts = [str(value)]
+    else:
+        parts = value.split(":") if isinstance(value, str) else []
+    limits = [int(part) for part in parts if part.strip().isdigit()]
+    if len(limits) != len(parts) or len(limits) not in (1, 2) or limits[0] > limits[-1]:
+        raise ConfigError(
+            f"Config field '{name}' must be a number or 'soft:hard' (soft <= hard), not {value!r}"
+        )
+    return ":".join(str(limit) for limit in limits)
+
+
+def _memory(value: Any, name: str) -> int:
+    try:
+        return parse_memory_bytes(value)
+    except ValueError as exc:
+        raise ConfigError(f"Config field '{name}': {exc}") from None
+
+
+def _duration(value: Any, name: str) -> float:
+    try:
+        return parse_duration(value)
+    except ValueError as exc:
+        raise ConfigError(f"Config field '{name}': {exc}") from None
+
+
+def _paths(value: Any, name: str) -> list[str]:
+    paths = value or []
+    if not isinstance(paths, list) or not all(isinstance(p, str) for p in paths):
+        raise ConfigError(f"Config field '{name}' must be a list of paths")
+    return [str(Path(p).expanduser()) for p in paths]
+
+
+def _count(value: Any, name: str, minimum: int = 0) -> int:
+    if isinstance(value, bool) or not isinstance(value, int) or value < minimum:
+        raise ConfigError(f"Config field '{name}' must be an integer >= {minimum}")
+    return value
+
+
+def _positive_number(value: Any, name: str) -> float:
+    try:
+        number = float(value)
+    except (TypeError, ValueError):
+        raise ConfigError(f"Config field '{name}' must be a number") from None
+    if number <= 0:
+        raise ConfigError(f"Config field '{name}' must be positive")
+    return number
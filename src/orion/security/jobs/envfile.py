@@ -0,0 +1,126 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""``KEY=VALUE`` env files for a manifest's ``envFile``.
+
+One variable per line::
+
+    # comments and blank lines are skipped
+    export GOFLAGS=-mod=mod        # 'export ' is optional; so is this comment
+    GREETING="hello\\nworld"      # double quotes: \\n \\t \\" \\\\ \\$ escapes
+    PATTERN='literal $HOME \\n'    # single quotes: taken as written
+    EMPTY=
+
+An unquoted value ends at `` #``.  Anything else -- no ``=``, a bad
+name, an unterminated quote, text after a closing quote -- is an error
+naming the line, rather than a variable silently set to something odd.
+Values are never expanded (``$OTHER`` stays as written).
+"""
+
+from __future__ import annotations
+
+import re
+from pathlib import Path
+
+ENV_NAME_RE = re.compile(r"^[A-Za-z_][A-Za-z0-9_]*$")
+
+# Host env files larger than this are refused rather than read into memory
+MAX_ENV_FILE_SIZE = 1024 * 1024
+
+_ESCAPES = {"n": "\n", "t": "\t", "r": "\r", '"': '"', "\\": "\\", "$": "$"}
+
+
+class EnvFileError(ValueError):
+    """An env file that cannot be read or parsed.  ``line`` is 1-based, 0 if none."""
+
+    def __init__(self, message: str, line: int = 0) -> None:
+        super().__init__(f"line {line}: {message}" if line else message)
+        self.line = line
+
+
+def parse_env_file(text: str) -> dict[str, str]:
+    """Parse env file ``text``; a name set twice keeps its last value.
+
+    Raises:
+        EnvFileError: On the first malformed line.
+    """
+    values: dict[str, str] = {}
+    for number, raw in enumerate(text.splitlines(), start=1):
+        line = raw.strip()
+        if not line or line.startswith("#"):
+            continue
+        if line.startswith(("export ", "export\t")):
+            line = line[len("export") :].lstrip()
+        name, sep, value = line.partition("=")
+        name = name.rstrip()
+        if not sep:
+            raise EnvFileError("expected KEY=VALUE", number)
+        if not ENV_NAME_RE.match(name):
+            raise EnvFileError(f"'{name}' is not a valid environment variable name", number)
+        values[name] = _value(value.lstrip(), number)
+    return values
+
+
+def read_env_file(path: Path | str) -> dict[str, str]:
+    """Read and parse the env file at ``path`` (UTF-8).
+
+    Raises:
+        EnvFileError: If the file is missing, too large, not text or malformed.
+    """
+    path = Path(path)
+    try:
+        if path.stat().st_size > MAX_ENV_FILE_SIZE:
+            raise EnvFileError(f"Env file {path} is larger than {MAX_ENV_FILE_SIZE} bytes")
+        text = path.read_text(encoding="utf-8")
+    except FileNotFoundError:
+        raise EnvFileError(f"Env file {path} does not exist") from None
+    except UnicodeDecodeError:
+        raise EnvFileError(f"Env file {path} is not UTF-8 text") from None
+    except OSError as exc:
+        raise EnvFileError(f"Could not read env file {path}: {exc.strerror or exc}") from None
+    try:
+        return parse_env_file(text)
+    except EnvFileError as exc:
+        error = EnvFileError(f"Env file {path}: {exc}")
+        error.line = exc.line
+        raise error from None
+
+
+def _value(text: str, number: int) -> str:
+    if not text or text[0] not in "'\"":
+        # Unquoted: up to an inline comment
+        for marker in (" #", "\t#"):
+            text = text.split(marker, 1)[0]
+        return text.rstrip()
+
+    quote = text[0]
+    chars: list[str] = []
+    i = 1
+    while i < len(text):
+        char = text[i]
+        if char == quote:
+            rest = text[i + 1 :].strip()
+            if rest and not rest.startswith("#"):
+                raise EnvFileError(f"unexpected text after the closing {quote}", number)
+            return "".join(chars)
+        if char == "\\" and quote == '"' and i + 1 < len(text):
+            escaped = text[i + 1]
+            chars.append(_ESCAPES.get(escaped, "\\" + escaped))
+            i += 2
+            continue
+        chars.append(char)
+        i += 1
+    raise EnvFileError(f"unterminated {quote} quote", number)
@@ -0,0 +1,2597 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job Executor -- run one manifest inside a stack container.
+
+Lifecycle of a job:
+  1. Resolve the manifest's stack to a labelled image for the host arch
+     (or take its ``image``, if ``images.allowedPrefixes`` permits it)
+  2. Start a SessionContainer for the stack (with build caches and the
+     manifest's ``mounts`` mounted, secrets injected and the job's CPU /
+     memory limits applied), or take a matching warm one from the pool
+     (``pool.stacks``, see pool.py)
+  3. Check out the job's source (git clone, a host checkout mounted at
+     step 2, or an uploaded archive unpacked into the workspace before
+     it), then resolve the requested toolchain (if any)
+  4. Run the job command, with SIGTERM then SIGKILL once its timeout passes
+     (or its workspace outgrows ``workspace.maxSizeGB``).  Past
+     ``output.maxLogBytes`` its output is dropped after a truncation
+     marker (``logs_truncated``), and with ``output.killOnLimit`` the
+     command is stopped the same way.  With ``report: gotest`` its
+     ``go test -json`` events become ``test_report`` (see gotest.py)
+  5. Copy the manifest's artifacts out, whatever the command's outcome,
+     and read the container's CPU time and peak memory (``cpu_seconds``,
+     ``peak_memory_bytes``; null when the runtime cannot tell)
+  6. Stop the container (a warm one is reset and goes back to the pool),
+     release the cache lease and evict if over size, then remove the job's
+     workspace and check it is gone (see workspace.py)
+
+``run()`` awaits a job to completion.  ``submit()`` queues it in the
+background and returns a :class:`JobHandle` whose log channel can be
+followed live; dropping a follower never affects the job, only
+:meth:`JobExecutor.cancel` does.
+
+Cancelling drops a queued job, interrupts one still being prepared, and
+stops a running command like a timeout does (SIGTERM, then SIGKILL after
+``timeout.gracePeriod``).  A container that is starting finishes starting
+first, and a job already tearing down finishes on its own terms, so the
+result always ends in exactly one terminal state.
+
+Background jobs are scheduled FIFO with at most ``scheduler.maxConcurrent``
+running at once.  At most ``scheduler.maxQueued`` may wait; further
+submissions raise :class:`QueueFullError` instead of piling up in memory.
+A manifest whose ``requires`` this agent's ``agent.labels`` do not
+satisfy raises :class:`NoMatchingCapabilityError` (see capabilities.py).
+Finished ones stay listable (:meth:`JobExecutor.list_jobs`) until
+``history.maxJobs`` / ``history.maxAge`` evicts them.
+
+:meth:`JobExecutor.reload_config` swaps in a new config while jobs run
+(see reload.py); a lower ``scheduler.maxConcurrent`` lets running jobs
+finish and starts fewer ones after them.
+
+:meth:`JobExecutor.shutdown` drains the executor: new submissions raise
+:class:`ShuttingDownError`, queued jobs are dropped, and running jobs get
+``scheduler.shutdownGracePeriod`` to finish before they are cancelled.
+Jobs ended by the drain report ``error_code`` ``agent_shutdown`` so
+callers know to resubmit them.
+
+:meth:`JobExecutor.warm_up` pulls the ``images.warmStacks`` images at
+startup (and fills the warm pool of those with ``pool: true``) while
+readiness reports ``warming_up``; see health.py.
+
+A manifest's ``callbackUrl`` is POSTed the outcome once the job ends
+(see callbacks.py); delivery runs in the background and never alters
+the result.
+
+Every failure before the command runs is reported with a distinct
+``error_code`` so callers can tell infrastructure problems (unknown
+stack, unavailable toolchain) apart from a failing build.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import collections
+import enum
+import itertools
+import json
+import logging
+import os
+import shlex
+import shutil
+import subprocess
+import time
+import uuid
+from collections.abc import AsyncIterable, Awaitable, Callable
+from dataclasses import dataclass, field, fields
+from pathlib import Path
+from typing import Any
+
+from orion.security.container_runtime import (
+    ORION_GID,
+    ORION_UID,
+    ORION_USER,
+    ContainerRuntime,
+    RegistryLogin,
+    is_auth_failure,
+    make_runtime,
+    registry_of,
+)
+from orion.security.jobs.agent_log import job_context
+from orion.security.jobs.archive import (
+    UPLOADS_DIR,
+    ArchiveError,
+    ArchiveQuotaError,
+    archive_digest,
+    extract_archive,
+    save_upload,
+    upload_path,
+)
+from orion.security.jobs.artifacts import Artifact, parse_listing, select_artifacts
+from orion.security.jobs.cache import OFFLINE_ENV, BuildCache, cache_name
+from orion.security.jobs.callbacks import Poster, callback_payload, deliver, http_post
+from orion.security.jobs.capabilities import unmatched
+from orion.security.jobs.config import (
+    PULL_ALWAYS,
+    PULL_NEVER,
+    ConfigChange,
+    JobsConfig,
+    WarmStack,
+    diff_config,
+    keep_restart_settings,
+)
+from orion.security.jobs.envfile import EnvFileError, parse_env_file, read_env_file
+from orion.security.jobs.gotest import (
+    GOTEST_REPORT,
+    GoTestParser,
+    GoTestReport,
+    event_text,
+    json_command,
+    plain_output,
+)
+from orion.security.jobs.exit_state import ExitState
+from orion.security.jobs.logs import LogChannel
+from orion.security.jobs.manifest import (
+    NETWORK_BRIDGE,
+    NETWORK_NONE,
+    JobEnvFile,
+    JobManifest,
+    JobSource,
+    JobStep,
+    ManifestError,
+    ManifestProblem,
+    display_url,
+    parse_manifest,
+    resolve_image,
+)
+from orion.security.jobs.metrics import JobMetrics
+from orion.security.jobs.mounts import MountError, agent_targets, resolve_mount, resolve_mounts
+from orion.security.jobs.platform import (
+    host_arch,
+    image_arch,
+    image_digest,
+    pull_image,
+    select_image,
+)
+from orion.security.jobs.pool import WarmContainer, WarmPool
+from orion.security.jobs.repo_defaults import (
+    DEFAULTS_ABSENT,
+    REPO_DEFAULTS_FILE,
+    RepoDefaultsError,
+    lookup_script,
+    merge_repo_defaults,
+)
+from orion.security.jobs.reaper import ContainerTracker, job_labels, pool_labels, reap_orphans
+from orion.security.jobs.retry import retry_transient
+from orion.security.jobs.secrets import (
+    Redactor,
+    SecretError,
+    SecretSource,
+    make_secret_source,
+    resolve_secrets,
+)
+from orion.security.jobs.source import (
+    AUTH,
+    CLONE_ENV,
+    NETWORK,
+    SourceError,
+    allowed_real_path,
+    clone_error,
+    clone_script,
+    resolve_host_path,
+)
+from orion.security.jobs.store import JobStore, JobStoreError, make_job_store
+from orion.security.jobs.toolchains import (
+    PROBE_BAKED,
+    PROBE_CACHED,
+    TOOLCHAINS_DIR,
+    ToolchainError,
+    ToolchainPlan,
+    plan_toolchain,
+)
+from orion.security.jobs.workspace import PURGE_SCRIPT, create_workspace, remove_workspace
+from orion.security.session_container import ExecResult, ResourceUsage, SessionContainer
+from orion.security.stack_detector import StackResolutionError, resolve_stack
+
+logger = logging.getLogger("orion.security.jobs.executor")
+
+# ---------------------------------------------------------------------------
+# Paths
+# ---------------------------------------------------------------------------
+_ORION_HOME = Path(os.environ.get("ORION_HOME", Path.home() / ".orion"))
+DEFAULT_JOBS_DIR = _ORION_HOME / "jobs"
+DEFAULT_TOOLCHAIN_CACHE_DIR = _ORION_HOME / "toolchains"
+
+# Written by the job's process-group leader so it can be signalled
+_JOB_PIDFILE = "/tmp/.orion-job.pid"
+
+# How JobExecutor.cancel() may stop a background job at each point
+_STAGE_INTERRUPTIBLE = "interruptible"  # cancel the task
+_STAGE_STARTING = "starting"  # let the container start, then tear it down
+_STAGE_COMMAND = "command"  # signal the command's process group
+_STAGE_FINISHING = "finishing"  # too late: the outcome is already decided
+
+
+# ---------------------------------------------------------------------------
+# Enums
+# ---------------------------------------------------------------------------
+class JobStatus(enum.Enum):
+    """State of a job."""
+
+    QUEUED = "queued"
+    RUNNING = "running"
+    SUCCEEDED = "succeeded"
+    FAILED = "failed"
+    CANCELLED = "cancelled"
+    INTERRUPTED = "interrupted"  # the agent restarted before the job finished
+
+
+class JobErrorCode(enum.Enum):
+    """Why a job failed.  Empty for successful jobs."""
+
+    NONE = ""
+    STACK_RESOLUTION_FAILED = "stack_resolution_failed"
+    IMAGE_NOT_PRESENT = "image_not_present"
+    IMAGE_PULL_FAILED = "image_pull_failed"
+    IMAGE_AUTH_FAILED = "image_auth_failed"
+    IMAGE_NOT_ALLOWED = "image_not_allowed"
+    CONTAINER_START_FAILED = "container_start_failed"
+    TOOLCHAIN_RESOLUTION_FAILED = "toolchain_resolution_failed"
+    RESOURCE_LIMIT_EXCEEDED = "resource_limit_exceeded"
+    RUN_AS_REFUSED = "run_as_refused"
+    NETWORK_REFUSED = "network_refused"
+    NO_MATCHING_CAPABILITY = "no_matching_capability"
+    SECRET_RESOLUTION_FAILED = "secret_resolution_failed"
+    ENV_FILE_INVALID = "env_file_invalid"
+    REPO_DEFAULTS_INVALID = "repo_defaults_invalid"
+    SOURCE_AUTH_FAILED = "source_auth_failed"
+    SOURCE_NETWORK_FAILED = "source_network_failed"
+    SOURCE_CHECKOUT_FAILED = "source_checkout_failed"
+    SOURCE_ARCHIVE_INVALID = "source_archive_invalid"
+    MOUNT_REJECTED = "mount_rejected"
+    COMMAND_FAILED = "command_failed"
+    OOM_KILLED = "oom_killed"
+    TIMED_OUT = "timed_out"
+    WORKSPACE_QUOTA_EXCEEDED = "workspace_quota_exceeded"
+    LOG_LIMIT_EXCEEDED = "log_limit_exceeded"
+    CANCELLED = "cancelled"
+    AGENT_SHUTDOWN = "agent_shutdown"
+    AGENT_RESTARTED = "agent_restarted"
+    INTERNAL_ERROR = "internal_error"
+
+
+# SourceError.kind -> error code; anything else is a checkout failure
+_SOURCE_ERROR_CODES = {
+    AUTH: JobErrorCode.SOURCE_AUTH_FAILED,
+    NETWORK: JobErrorCode.SOURCE_NETWORK_FAILED,
+}
+
+
+# StepResult.status of a step that never ran
+STEP_SKIPPED = "skipped"
+
+
+# ---------------------------------------------------------------------------
+# JobResult dataclass
+# ---------------------------------------------------------------------------
+@dataclass
+class StepResult:
+    """Outcome of one step of a multi-step job."""
+
+    name: str
+    status: str = STEP_SKIPPED  # JobStatus SUCCEEDED / FAILED once it ran
+    exit_code: int = -1
+    exit: ExitState = field(default_factory=ExitState)
+    duration_seconds: float = 0.0
+    continue_on_error: bool = False
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "name": self.name,
+            "status": self.status,
+            "exit_code": self.exit_code,
+            "exit": self.exit.to_dict(),
+            "duration_seconds": self.duration_seconds,
+            "continue_on_error": self.continue_on_error,
+        }
+
+    @classmethod
+    def from_dict(cls, data: dict[str, Any]) -> StepResult:
+        """The inverse of :meth:`to_dict`."""
+        return cls(**{**data, "exit": ExitState.from_dict(data["exit"])})
+
+
+@dataclass
+class JobResult:
+    """Outcome of a single job run."""
+
+    job_id: str
+    stack: str
+    image: str = ""
+    image_digest: str = ""  # digest of the image the job ran in
+    pull_duration_seconds: float = 0.0  # 0 when no pull was needed
+    pull_retries: int = 0  # transient pull failures retried
+    start_retries: int = 0  # transient container start failures retried
+    pool_reused: bool = False  # ran in a warm container from the pool
+    signed_by: str = ""  # ID of the signing key that verified the manifest; '' = unsigned
+    status: str = JobStatus.FAILED.value
+    error_code: str = JobErrorCode.NONE.value
+    error: str = ""
+    exit_code: int = -1
+    exit: ExitState = field(default_factory=ExitState)  # exit_code decoded; set once it ran
+    stdout: str = ""  # every step's, in order
+    stderr: str = ""
+    steps: list[StepResult] = field(default_factory=list)  # empty for a plain command
+    toolchain: str = ""
+    # manifest keys taken from the repository's .orion-agent.yaml
+    repo_defaults: list[str] = field(default_factory=list)
+    timed_out: bool = False
+    killed_by: str = ""  # '', 'SIGTERM' or 'SIGKILL' (timeout escalation)
+    logs_truncated: bool = False  # output past output.maxLogBytes was dropped
+    test_report: GoTestReport | None = None  # manifest ``report: gotest`` only
+    run_as: str = ""  # 'uid:gid' the job ran as; empty = the image's user
+    run_as_note: str = ""  # how runAs met the mounts' ownership
+    # manifest env / envFile names a secret won
+    env_overridden: list[str] = field(default_factory=list)
+    artifacts: list[Artifact] = field(default_factory=list)
+    artifacts_dir: str = ""  # host directory the artifacts were copied to
+    artifact_warnings: list[str] = field(default_factory=list)
+    duration_seconds: float = 0.0
+    # The container's totals, read before teardown; None = the runtime could not tell
+    cpu_seconds: float | None = None
+    peak_memory_bytes: int | None = None
+
+    @property
+    def succeeded(self) -> bool:
+        return self.status == JobStatus.SUCCEEDED.value
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "job_id": self.job_id,
+            "stack": self.stack,
+            "image": self.image,
+            "image_digest": self.image_digest,
+            "pull_duration_seconds": self.pull_duration_seconds,
+            "pull_retries": self.pull_retries,
+            "start_retries": self.start_retries,
+            "pool_reused": self.pool_reused,
+            "signed_by": self.signed_by,
+            "status": self.status,
+            "error_code": self.error_code,
+            "error": self.error,
+            "exit_code": self.exit_code,
+            "exit": self.exit.to_dict(),
+            "stdout": self.stdout,
+            "stderr": self.stderr,
+            "steps": [step.to_dict() for step in self.steps],
+            "toolchain": self.toolchain,
+            "repo_defaults": list(self.repo_defaults),
+            "timed_out": self.timed_out,
+            "killed_by": self.killed_by,
+            "logs_truncated": self.logs_truncated,
+            "test_report": self.test_report.to_dict() if self.test_report else None,
+            "run_as": self.run_as,
+            "run_as_note": self.run_as_note,
+            "env_overridden": list(self.env_overridden),
+            "artifacts": [a.to_dict() for a in self.artifacts],
+            "artifacts_dir": self.artifacts_dir,
+            "artifact_warnings": list(self.artifact_warnings),
+            "duration_seconds": self.duration_seconds,
+            "cpu_seconds": self.cpu_seconds,
+            "peak_memory_bytes": self.peak_memory_bytes,
+        }
+
+    @classmethod
+    def from_dict(cls, data: dict[str, Any]) -> JobResult:
+        """The inverse of :meth:`to_dict`; unknown keys are ignored."""
+        known = {f.name for f in fields(cls)}
+        return cls(
+            **{
+                **{k: v for k, v in data.items() if k in known},
+                "exit": ExitState.from_dict(data["exit"]),
+                "steps": [StepResult.from_dict(step) for step in data.get("steps", [])],
+                "artifacts": [Artifact(**a) for a in data.get("artifacts", [])],
+                "test_report": (
+                    GoTestReport.from_dict(data["test_report"])
+                    if data.get("test_report")
+                    else None
+                ),
+            }
+        )
+
+
+class QueueFullError(RuntimeError):
+    """Raised by :meth:`JobExecutor.submit` when the job queue is saturated."""
+
+
+class ShuttingDownError(RuntimeError):
+    """Raised by :meth:`JobExecutor.submit` once the executor is draining."""
+
+
+class NoMatchingCapabilityError(RuntimeError):
+    """Raised by :meth:`JobExecutor.submit` for a job this agent's labels do not satisfy."""
+
+
+@dataclass
+class ValidationReport:
+    """Outcome of :meth:`JobExecutor.validate` -- nothing was run."""
+
+    ok: bool
+    image: str = ""  # the image the job would run in, when resolvable
+    problems: list[ManifestProblem] = field(default_factory=list)
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "ok": self.ok,
+            "image": self.image,
+            "problems": [p.to_dict() for p in self.problems],
+        }
+
+
+# JobHandle.state values, the list API's ``state`` filter
+JOB_STATES = ("queued", "running", "finished")
+
+
+@dataclass
+class JobHandle:
+    """A job submitted to run in the background."""
+
+    result: JobResult
+    logs: LogChannel
+    task: asyncio.Task | None  # None for a job reloaded from the store
+    manifest: JobManifest | None = None
+    seq: int = 0  # submission order; the cursor of JobExecutor.list_jobs
+    submitted_at: float = 0.0  # time.time()
+    started_at: float | None = None
+    finished_at: float | None = None
+    # A reloaded job's manifest summary, as stored; its manifest is gone
+    manifest_summary: dict[str, Any] | None = None
+
+    @property
+    def job_id(self) -> str:
+        return self.result.job_id
+
+    @property
+    def done(self) -> bool:
+        return self.task is None or self.task.done()
+
+    @property
+    def state(self) -> str:
+        """'queued', 'running' or 'finished' (any terminal status)."""
+        if self.result.status in (JobStatus.QUEUED.value, JobStatus.RUNNING.value):
+            return self.result.status
+        return "finished"
+
+    def summary(self) -> dict[str, Any]:
+        """One row of the job list."""
+        return {
+            "job_id": self.job_id,
+            "stack": self.result.stack,
+            "state": self.state,
+            "status": self.result.status,
+            "error_code": self.result.error_code,
+            "exit_code": self.result.exit_code,
+            "submitted_at": self.submitted_at,
+            "started_at": self.started_at,
+            "finished_at": self.finished_at,
+            "duration_seconds": self.result.duration_seconds,
+        }
+
+    def describe(self) -> dict[str, Any]:
+        """The full result plus timestamps and the (redacted) manifest."""
+        return {
+            **self.result.to_dict(),
+            **self.summary(),
+            "manifest": self.manifest.summary() if self.manifest else self.manifest_summary,
+        }
+
+    def to_record(self) -> dict[str, Any]:
+        """What a :class:`JobStore` keeps of the job; nothing a summary would omit."""
+        return {
+            "job_id": self.job_id,
+            "seq": self.seq,
+            "submitted_at": self.submitted_at,
+            "started_at": self.started_at,
+            "finished_at": self.finished_at,
+            "result": self.result.to_dict(),
+            "manifest": self.manifest.summary() if self.manifest else self.manifest_summary,
+        }
+
+    @classmethod
+    def from_record(cls, record: dict[str, Any]) -> JobHandle:
+        """A finished handle for a stored job.  It has no logs to stream."""
+        logs = LogChannel()
+        logs.close()
+        return cls(
+            result=JobResult.from_dict(record["result"]),
+            logs=logs,
+            task=None,
+            seq=record["seq"],
+            submitted_at=record["submitted_at"],
+            started_at=record["started_at"],
+            finished_at=record["finished_at"],
+            manifest_summary=record["manifest"],
+        )
+
+
+@dataclass
+class _Cancellation:
+    """A background job's cancellation state, shared with :meth:`JobExecutor.cancel`."""
+
+    stage: str = _STAGE_INTERRUPTIBLE
+    requested: asyncio.Event = field(default_factory=asyncio.Event)
+    shutdown: str = ""  # set when the drain cancels the job: why, for its result
+
+
+class _OutputLimit:
+    """A job's output, stdout and stderr together, counted against ``output.maxLogBytes``."""
+
+    def __init__(self, max_bytes: int, kill: bool) -> None:
+        self.max_bytes = max_bytes
+        self.kill = kill
+        self.used = 0
+        self.reached = asyncio.Event()
+
+    @property
+    def remaining(self) -> int:
+        return 0 if self.reached.is_set() else self.max_bytes - self.used
+
+    @property
+    def marker(self) -> str:
+        then = "stopping the job" if self.kill else "later output is dropped"
+        return (
+            f"[orion] Output truncated: the job's logs reached {self.max_bytes} bytes "
+            f"(output.maxLogBytes); {then}"
+        )
+
+    def admit(self, line: str) -> bool:
+        """Count ``line``; False for it and every line after the first that does not fit."""
+        if self.reached.is_set():
+            return False
+        size = len(line.encode("utf-8")) + 1  # and its newline
+        if self.used + size > self.max_bytes:
+            self.reached.set()
+            return False
+        self.used += size
+        return True
+
+
+class _Slots:
+    """FIFO concurrency limit (``scheduler.maxConcurrent``) that can be resized.
+
+    Raising the limit starts waiting jobs at once; lowering it never
+    interrupts a running job, the excess slots just retire as they free.
+    """
+
+    def __init__(self, limit: int) -> None:
+        self.limit = limit
+        self.held = 0
+        self._waiters: collections.deque[asyncio.Future] = collections.deque()
+
+    async def acquire(self) -> None:
+        if self.held < self.limit and not self._waiters:
+            self.held += 1
+            return
+        waiter = asyncio.get_running_loop().create_future()
+        self._waiters.append(waiter)
+        try:
+            await waiter
+        except asyncio.CancelledError:
+            if not waiter.cancelled():
+                self.release()  # granted just as it was cancelled: pass it on
+            elif waiter in self._waiters:
+                self._waiters.remove(waiter)
+            raise
+
+    def release(self) -> None:
+        self.held -= 1
+        self._grant()
+
+    def resize(self, limit: int) -> None:
+        self.limit = limit
+        self._grant()
+
+    def _grant(self) -> None:
+        while self._waiters and self.held < self.limit:
+            waiter = self._waiters.popleft()
+            if not waiter.done():  # skip one cancelled while still queued
+                self.held += 1
+                waiter.set_result(None)
+
+
+# ---------------------------------------------------------------------------
+# JobExecutor
+# ---------------------------------------------------------------------------
+class JobExecutor:
+    """Runs job manifests in governed stack containers.
+
+    Usage::
+
+        executor = JobExecutor()
+        result = await executor.run(parse_manifest("stack: go\\ncommand: go test ./..."))
+    """
+
+    def __init__(
+        self,
+        jobs_dir: Path | None = None,
+        toolchain_cache_dir: Path | None = None,
+        stacks_dir: Path | None = None,
+        profile: str = "standard",
+        container_factory: Callable[..., SessionContainer] = SessionContainer,
+        config: JobsConfig | None = None,
+        image_inspector: Callable[[str], Awaitable[str | None]] | None = None,
+        secret_source: SecretSource | None = None,
+        image_puller: (
+            Callable[[str, RegistryLogin | None], Awaitable[subprocess.CompletedProcess]] | None
+        ) = None,
+        runtime: ContainerRuntime | None = None,
+        callback_poster: Poster | None = None,
+        job_store: JobStore | None = None,
+    ) -> None:
+        self.jobs_dir = jobs_dir or DEFAULT_JOBS_DIR
+        self.toolchain_cache_dir = toolchain_cache_dir or DEFAULT_TOOLCHAIN_CACHE_DIR
+        self.uploads_dir = self.jobs_dir / UPLOADS_DIR
+        self.stacks_dir = stacks_dir
+        self.profile = profile
+        self.config = config or JobsConfig()
+        self.cache = BuildCache(self.config.cache)
+        self.secret_source = secret_source or make_secret_source(self.config.secrets)
+        self.runtime = runtime or make_runtime(
+            self.config.runtime.driver, self.config.runtime.socket
+        )
+        self.arch = host_arch()
+        self._container_factory = container_factory
+        self._inspect_image = image_inspector or (lambda image: image_arch(image, self.runtime))
+        self._pull_image = image_puller or (
+            lambda image, login: pull_image(image, self.runtime, login=login)
+        )
+        self._image_digest = lambda image: image_digest(image, self.runtime)
+        self._post_callback = callback_poster or http_post
+        self._callbacks: set[asyncio.Task] = set()
+        # None when metrics are disabled in the config
+        self.metrics: JobMetrics | None = JobMetrics() if self.config.metrics.enabled else None
+        # Every container created and not yet confirmed removed
+        self.containers = ContainerTracker()
+        self.pool = WarmPool(self.config.pool, discard=self.containers.discard)
+        # Submission order; finished ones leave once _history evicts them
+        self._jobs: dict[str, JobHandle] = {}
+        self._history: collections.deque[str] = collections.deque()  # finish order
+        self._seq = itertools.count(1)
+        self._slots = _Slots(self.config.scheduler.max_concurrent)
+        self._queued: set[str] = set()
+        self._running: set[str] = set()
+        self._cancellations: dict[str, _Cancellation] = {}
+        self._drain: asyncio.Task | None = None
+        self._warm_up: asyncio.Task | None = None
+        # Build cache eviction in a worker thread; one pass at a time
+        self._cache_eviction: asyncio.Task | None = None
+        self._evict_again = False
+        # images.warmStacks marked required that could not be warmed -> why
+        self.warm_failures: dict[str, str] = {}
+        # None keeps the history in memory only
+        self.store = job_store or self._open_store()
+        if self.store is not None:
+            self._load_history()
+
+    async def run(
+        self,
+        manifest: JobManifest,
+        job_id: str | None = None,
+        logs: LogChannel | None = None,
+    ) -> JobResult:
+        """Run a manifest to completion and return its result.
+
+        Output lines are published to ``logs`` as they are produced.
+        """
+        result = self._new_result(manifest, job_id)
+        with job_context(result.job_id, manifest.stack):
+            await self._execute(manifest, result, logs)
+        return result
+
+    def submit(
+        self, manifest: JobManifest, job_id: str | None = None, signed_by: str = ""
+    ) -> JobHandle:
+        """Queue a manifest to run in the background and return its handle.
+
+        The job starts as soon as a concurrency slot is free.  Must be
+        called from a running event loop.  ``signed_by`` records the key
+        the manifest's signature was verified with (see signing.py).
+
+        Raises:
+            QueueFullError: If ``scheduler.maxQueued`` jobs are already waiting.
+            ShuttingDownError: If :meth:`shutdown` has been called.
+            NoMatchingCapabilityError: If the manifest ``requires`` labels
+                this agent lacks (``agent.labels``).
+        """
+        if self.draining:
+            raise ShuttingDownError("Agent is shutting down and not accepting jobs")
+        error = self._capability_problem(manifest)
+        if error:
+            raise NoMatchingCapabilityError(error)
+        max_queued = self.config.scheduler.max_queued
+        if len(self._queued) >= max_queued:
+            raise QueueFullError(f"Job queue full ({max_queued} jobs waiting)")
+
+        result = self._new_result(manifest, job_id)
+        result.status = JobStatus.QUEUED.value
+        result.signed_by = signed_by
+        self._queued.add(result.job_id)
+        self._cancellations[result.job_id] = _Cancellation()
+        logs = LogChannel()
+        # The task copies the context, so everything it logs is tagged
+        with job_context(result.job_id, manifest.stack):
+            if signed_by:
+                logger.info("Job %s manifest signed by key %s", result.job_id, signed_by)
+            logger.info("Job %s accepted (%d queued)", result.job_id, len(self._queued))
+            task = asyncio.create_task(self._background(manifest, result, logs))
+        handle = JobHandle(
+            result=result,
+            logs=logs,
+            task=task,
+            manifest=manifest,
+            seq=next(self._seq),
+            submitted_at=time.time(),
+        )
+        self._jobs[result.job_id] = handle
+        self._persist(handle)
+        return handle
+
+    async def save_upload(self, chunks: AsyncIterable[bytes]) -> str:
+        """Store an archive source for a later submission; returns its upload ID.
+
+        Raises:
+            ArchiveError: If it is empty or over ``source.maxArchiveSize``.
+            UploadsFullError: If the stored uploads would pass ``source.maxUploadsSize``.
+        """
+        return await save_upload(
+            self.uploads_dir,
+            chunks,
+            self.config.source.max_archive_size,
+            self.config.source.max_uploads_size,
+        )
+
+    def discard_upload(self, upload_id: str) -> None:
+        """Delete a stored upload, if it is still there."""
+        upload_path(self.uploads_dir, upload_id).unlink(missing_ok=True)
+
+    def reload_config(self, config: JobsConfig) -> list[ConfigChange]:
+        """Switch to ``config``, already validated, while jobs keep running.
+
+        Settings in :data:`~orion.security.jobs.config.RESTART_REQUIRED`
+        keep their current values.  A job reads the config as it reaches
+        each step, so one already past a step is unaffected by the change.
+        Returns every difference, applied (``live``) or not.
+        """
+        changes = diff_config(self.config, config)
+        keep_restart_settings(self.config, config)
+        self.config = config
+        self.cache.config = config.cache
+        self.pool.config = config.pool
+        self._slots.resize(config.scheduler.max_concurrent)
+        return changes
+
+    @property
+    def draining(self) -> bool:
+        """True once :meth:`shutdown` has been called."""
+        return self._drain is not None
+
+    def begin_shutdown(self, grace: float | None = None) -> asyncio.Task:
+        """Start draining in the background; see :meth:`shutdown`.
+
+        Safe to call from a signal handler scheduled onto the loop, and
+        more than once: later calls return the drain already under way.
+        """
+        if self._drain is None:
+            if grace is None:
+                grace = self.config.scheduler.shutdown_grace_period
+            self._drain = asyncio.ensure_future(self._shutdown(grace))
+        return self._drain
+
+    async def shutdown(self, grace: float | None = None) -> None:
+        """Drain the executor, then stop the warm pool's containers.
+
+        Stops accepting jobs and drops the queued ones at once, waits up to
+        ``grace`` seconds (``scheduler.shutdownGracePeriod``) for the
+        running ones and cancels whatever is left, as :meth:`cancel` would.
+        Returns once every background job has ended.
+        """
+        await self.begin_shutdown(grace)
+
+    async def _shutdown(self, grace: float) -> None:
+        queued = [self._jobs[job_id] for job_id in sorted(self._queued)]
+        running = [self._jobs[job_id] for job_id in sorted(self._running)]
+        logger.info(
+            "Shutting down: dropping %d queued jobs, draining %d running (grace %gs)",
+            len(queued),
+            len(running),
+            grace,
+        )
+        for handle in queued:
+            self._cancel_for_shutdown(
+                handle.job_id, "Agent shut down before the job started; resubmit it"
+            )
+
+        tasks = {handle.task for handle in running}
+        if tasks and grace > 0:
+            _, pending = await asyncio.wait(tasks, timeout=grace)
+        else:
+            pending = tasks
+        for handle in running:
+            if handle.task in pending:
+                self._cancel_for_shutdown(
+                    handle.job_id,
+                    f"Agent shut down and the job did not finish within its {grace:g}s "
+                    "grace period (scheduler.shutdownGracePeriod); resubmit it",
+                )
+
+        # History reloaded from the store has no task
+        everything = {h.task for h in self._jobs.values() if h.task is not None}
+        if everything:
+            await asyncio.wait(everything)
+        # Callers of the jobs the drain ended still hear about it
+        if self._callbacks:
+            await asyncio.wait(set(self._callbacks))
+        if self._cache_eviction is not None:
+            await self._cache_eviction
+        await self.pool.drain()
+        if self.containers:
+            leftover = len(self.containers)
+            removed = await self.containers.discard_all()
+            logger.warning("Removed %d of %d containers left after the drain", removed, leftover)
+        if self.store is not None:
+            self.store.close()
+        logger.info("Shutdown complete")
+
+    async def reap_orphans(self) -> int:
+        """Remove job containers an earlier run of the agent left behind.
+
+        Called once at startup; skipped when ``cleanup.reapOnStartup`` is
+        off.  Returns how many were removed.
+        """
+        if not self.config.cleanup.reap_on_startup:
+            logger.info("Startup reaping of leftover job containers disabled")
+            return 0
+        reaped = await reap_orphans(self.runtime, keep=self.containers)
+        logger.info("Reaped %d leftover job containers from an earlier run", reaped)
+        return reaped
+
+    @property
+    def warming(self) -> bool:
+        """True while :meth:`warm_up` is still priming ``images.warmStacks``."""
+        return self._warm_up is not None and not self._warm_up.done()
+
+    def begin_warm_up(self) -> asyncio.Task | None:
+        """Start :meth:`warm_up` in the background; None if no stack is configured."""
+        if self._warm_up is None and self.config.images.warm_stacks:
+            self._warm_up = asyncio.ensure_future(self.warm_up())
+        return self._warm_up
+
+    async def warm_up(self) -> None:
+        """Pull the ``images.warmStacks`` images, and fill their warm pools.
+
+        Called once at startup; the agent is not ready until it returns.
+        Stacks are warmed concurrently; those not done within
+        ``images.warmTimeout`` are abandoned.  A failure is logged and, for
+        a stack marked ``required``, recorded in :attr:`warm_failures`.
+        """
+        warm_stacks = self.config.images.warm_stacks
+        started = time.monotonic()
+        logger.info("Warming up %d stacks", len(warm_stacks))
+        tasks = {asyncio.ensure_future(self._warm_stack(warm)): warm for warm in warm_stacks}
+        done, pending = await asyncio.wait(tasks, timeout=self.config.images.warm_timeout)
+        for task in pending:
+            task.cancel()
+        await asyncio.gather(*pending, return_exceptions=True)
+
+        failed = 0
+        for task, warm in tasks.items():
+            if task in pending:
+                error = f"did not finish within {self.config.images.warm_timeout:g}s"
+                error += " (images.warmTimeout)"
+            elif task.exception() is not None:
+                error = f"internal error: {task.exception()}"
+            else:
+                error = task.result()
+            if not error:
+                continue
+            failed += 1
+            if warm.required:
+                self.warm_failures[warm.stack] = error
+                logger.error("Required stack %s could not be warmed: %s", warm.stack, error)
+            else:
+                logger.warning("Stack %s could not be warmed: %s", warm.stack, error)
+        logger.info(
+            "Warm-up finished: %d of %d stacks warmed (%.1fs)",
+            len(warm_stacks) - failed,
+            len(warm_stacks),
+            time.monotonic() - started,
+        )
+
+    async def _warm_stack(self, warm: WarmStack) -> str:
+        """Warm one stack; returns why it could not be ('' when it was)."""
+        manifest = JobManifest(stack=warm.stack, command="true")
+        result = self._new_result(manifest, f"warmup-{warm.stack}")
+        with job_context(result.job_id, warm.stack):
+            if warm.pool:
+                # A cold no-op job: its container start fills the pool behind it
+                if await self._resolve_manifest(manifest, result) is not None:
+                    await self._run(manifest, result)
+                if not result.succeeded:
+                    return result.error or f"warm-up job ended {result.status}"
+                await self.pool.settle()
+                idle = self.pool.idle(warm.stack)
+                if not idle:
+                    return "no warm container could be started"
+                logger.info("Stack %s warmed (%d warm containers)", warm.stack, idle)
+                return ""
+            try:
+                stack_image = resolve_image(manifest, self.stacks_dir)
+                result.image = await select_image(
+                    stack_image.image, self.arch, self._inspect_image
+                )
+            except StackResolutionError as exc:
+                return str(exc)
+            failure = await self._ensure_image(manifest, result)
+            if failure:
+                return failure[1]
+            if await self._inspect_image(result.image) is None:
+                return f"Image {result.image} could not be pulled"
+            logger.info("Stack %s warmed (image %s)", warm.stack, result.image)
+            return ""
+
+    def _cancel_for_shutdown(self, job_id: str, message: str) -> None:
+        cancellation = self._cancellations[job_id]
+        cancellation.shutdown = message
+        if not self.cancel(job_id):
+            # Finishing, or already cancelled by a caller: keep that outcome
+            cancellation.shutdown = ""
+
+    def get(self, job_id: str) -> JobHandle | None:
+        """Return the handle of a submitted job, if known (and not yet evicted)."""
+        self._evict_history()
+        return self._jobs.get(job_id)
+
+    def list_jobs(
+        self,
+        state: str = "",
+        stack: str = "",
+        since: float | None = None,
+        until: float | None = None,
+        limit: int = 50,
+        before: int | None = None,
+    ) -> tuple[list[JobHandle], int | None]:
+        """Background jobs, newest first, and the cursor of the next page.
+
+        ``since`` / ``until`` bound the submission time (epoch seconds,
+        inclusive).  Pass the returned cursor as ``before`` for the next
+        page; it is None on the last one.  Finished jobs stay listed until
+        ``history.maxJobs`` / ``history.maxAge`` evicts them.
+
+        Raises:
+            ValueError: If ``state`` is not one of :data:`JOB_STATES`.
+        """
+        if state and state not in JOB_STATES:
+            raise ValueError(f"Unknown job state {state!r} (use {', '.join(JOB_STATES)})")
+        self._evict_history()
+        page: list[JobHandle] = []
+        for handle in reversed(self._jobs.values()):
+            if before is not None and handle.seq >= before:
+                continue
+            if (state and handle.state != state) or (stack and handle.result.stack != stack):
+                continue
+            if since is not None and handle.submitted_at < since:
+                continue
+            if until is not None and handle.submitted_at > until:
+                continue
+            if len(page) == limit:
+                return page, page[-1].seq
+            page.append(handle)
+        return page, None
+
+    def cancel(self, job_id: str) -> bool:
+        """Cancel a queued or running job.  Returns True if this call cancelled it.
+
+        A queued job is dropped without ever launching a container; a
+        running command is sent SIGTERM, then SIGKILL after the grace
+        period.  Cancelling a job that is unknown, finished (or finishing)
+        or already being cancelled does nothing and returns False.
+        """
+        handle = self._jobs.get(job_id)
+        cancellation = self._cancellations.get(job_id)
+        if handle is None or cancellation is None or handle.done:
+            return False
+        if cancellation.requested.is_set() or cancellation.stage == _STAGE_FINISHING:
+            return False
+        cancellation.requested.set()
+        logger.info("Cancelling job %s (%s)", job_id, cancellation.stage)
+        if cancellation.stage == _STAGE_INTERRUPTIBLE:
+            handle.task.cancel()
+        return True
+
+    async def validate(self, manifest: JobManifest | str | dict) -> ValidationReport:
+        """Check a manifest as :meth:`run` would, without launching anything.
+
+        Parses the manifest, resolves its stack image, and checks the
+        toolchain, resource caps and referenced secrets.  All problems are
+        collected; once parsing succeeds every later check still runs.
+        """
+        report = ValidationReport(ok=False)
+        try:
+            if isinstance(manifest, str):
+                manifest = parse_manifest(manifest)
+            elif isinstance(manifest, dict):
+                manifest = JobManifest.from_dict(manifest)
+        except ManifestError as exc:
+            report.problems = exc.problems
+            return report
+
+        # Without a stack it is left to the repository's defaults file, read only by a run
+        if manifest.image:
+            report.image = manifest.image
+            error = self._image_problem(manifest)
+            if error:
+                report.problems.append(ManifestProblem("image", error))
+            elif (
+                self._pull_policy(manifest) == PULL_NEVER
+                and await self._inspect_image(report.image) is None
+            ):
+                report.problems.append(ManifestProblem("image", self._not_present(report.image)))
+        elif manifest.stack:
+            try:
+                stack_image = resolve_image(manifest, self.stacks_dir)
+                report.image = await select_image(
+                    stack_image.image, self.arch, self._inspect_image
+                )
+            except StackResolutionError as exc:
+                report.problems.append(ManifestProblem("stack", str(exc)))
+            else:
+                if (
+                    self.config.images.policy_for(manifest.stack) == PULL_NEVER
+                    and await self._inspect_image(report.image) is None
+                ):
+                    report.problems.append(
+                        ManifestProblem("stack", self._not_present(report.image))
+                    )
+
+        if manifest.toolchain and manifest.stack:
+            try:
+                plan_toolchain(manifest.stack, manifest.toolchain)
+            except ToolchainError as exc:
+                report.problems.append(ManifestProblem("toolchain", str(exc)))
+
+        report.problems.extend(self._resource_problems(manifest))
+
+        error = self._run_as_problem(manifest)
+        if error:
+            report.problems.append(ManifestProblem("runAs", error))
+
+        error = self._network_problem(manifest)
+        if error:
+            report.problems.append(ManifestProblem("network", error))
+
+        error = self._capability_problem(manifest)
+        if error:
+            report.problems.append(ManifestProblem("requires", error))
+
+        if manifest.source.kind == "bind":
+            try:
+                resolve_host_path(manifest.source, self.config.source.allowed_host_paths)
+            except SourceError as exc:
+                report.problems.append(ManifestProblem("source.hostPath", str(exc)))
+        # A dry run has no archive with it; only a named upload can be checked
+        if manifest.source.upload:
+            error = self._archive_problem(manifest)
+            if error:
+                report.problems.append(ManifestProblem("source.upload", error))
+
+        reserved = agent_targets(manifest.stack)
+        for i, mount in enumerate(manifest.mounts):
+            try:
+                resolve_mount(mount, i, self.config.mounts.allowed_host_paths, reserved)
+            except MountError as exc:
+                report.problems.append(ManifestProblem(exc.field, str(exc)))
+
+        try:
+            self._read_env_file(manifest.env_file)
+        except EnvFileError as exc:
+            report.problems.append(ManifestProblem("envFile.path", str(exc)))
+
+        for env_name, secret_name in manifest.secrets.items():
+            if not self.secret_source.has(secret_name):
+                report.problems.append(
+                    ManifestProblem(f"secrets.{env_name}", f"Secret '{secret_name}' is not set")
+                )
+
+        report.ok = not report.problems
+        return report
+
+    @staticmethod
+    def _new_result(manifest: JobManifest, job_id: str | None) -> JobResult:
+        return JobResult(
+            job_id=job_id or uuid.uuid4().hex[:12],
+            stack=manifest.stack,
+            toolchain=manifest.toolchain,
+        )
+
+    def stats(self) -> dict[str, int]:
+        """Scheduler counters for the status API."""
+        return {
+            "running": len(self._running),
+            "queued": len(self._queued),
+            "max_concurrent": self.config.scheduler.max_concurrent,
+            "max_queued": self.config.scheduler.max_queued,
+        }
+
+    async def _background(
+        self, manifest: JobManifest, result: JobResult, logs: LogChannel
+    ) -> None:
+        job_id = result.job_id
+        try:
+            await self._slots.acquire()
+        except asyncio.CancelledError:
+            self._cancelled(result, "Job was cancelled before it started")
+            if manifest.source.upload:
+                self.discard_upload(manifest.source.upload)
+            logs.close()
+            if self.metrics is not None:
+                self.metrics.job_finished(result.stack, result.error_code, None)
+            self._send_callback(manifest, result)
+            self._finished(job_id)
+            return
+        finally:
+            self._queued.discard(job_id)
+
+        self._running.add(job_id)
+        result.status = JobStatus.RUNNING.value
+        self._jobs[job_id].started_at = time.time()
+        self._persist(self._jobs[job_id])
+        logger.info("Job %s started", job_id)
+        try:
+            await self._execute(manifest, result, logs)
+        except asyncio.CancelledError:
+            # Cancellation is an outcome for a background job, not an error
+            pass
+        finally:
+            self._running.discard(job_id)
+            self._slots.release()
+            self._cancellations[job_id].stage = _STAGE_FINISHING
+            self._finished(job_id)
+
+    def _finished(self, job_id: str) -> None:
+        """Record a background job's end in the history, evicting the oldest."""
+        self._jobs[job_id].finished_at = time.time()
+        self._persist(self._jobs[job_id])
+        self._history.append(job_id)
+        self._evict_history()
+
+    def _evict_history(self) -> None:
+        """Forget finished jobs beyond ``history.maxJobs`` or older than ``maxAge``."""
+        limits = self.config.history
+        cutoff = time.time() - limits.max_age
+        evicted = []
+        while self._history and (
+            len(self._history) > limits.max_jobs
+            or self._jobs[self._history[0]].finished_at < cutoff
+        ):
+            job_id = self._history.popleft()
+            del self._jobs[job_id]
+            self._cancellations.pop(job_id, None)
+            self._remove_job_dir(job_id)
+            evicted.append(job_id)
+        if evicted and self.store is not None:
+            try:
+                self.store.delete(evicted)
+            except JobStoreError as exc:
+                logger.warning("Evicted jobs not removed from the job store: %s", exc)
+
+    def _remove_job_dir(self, job_id: str) -> None:
+        """Delete an evicted job's directory (its artifacts), off the event loop if one runs."""
+        job_dir = self.jobs_dir / job_id
+        if not job_dir.exists():
+            return
+        try:
+            loop = asyncio.get_running_loop()
+        except RuntimeError:
+            shutil.rmtree(job_dir, ignore_errors=True)
+            return
+        loop.run_in_executor(None, shutil.rmtree, job_dir, True)  # ignore_errors
+
+    def _open_store(self) -> JobStore | None:
+        """The configured store; None (memory only) if it cannot be opened."""
+        try:
+            return make_job_store(self.config.store)
+        except JobStoreError as exc:
+            logger.error("%s; job history is kept in memory only", exc)
+            return None
+
+    def _load_history(self) -> None:
+        """Reload the jobs of earlier runs; those that never finished become interrupted."""
+        try:
+            records = self.store.load()
+        except JobStoreError as exc:
+            logger.error("Job history not reloaded: %s", exc)
+            return
+        handles = []
+        for record in records:
+            try:
+                handles.append(JobHandle.from_record(record))
+            except (KeyError, TypeError, ValueError) as exc:
+                logger.warning("Skipping stored job %s: %s", record.get("job_id", "?"), exc)
+        handles.sort(key=lambda handle: handle.seq)
+
+        interrupted = 0
+        for handle in handles:
+            if handle.state != "finished":
+                self._interrupted(handle)
+                interrupted += 1
+            self._jobs[handle.job_id] = handle
+        if handles:
+            self._seq = itertools.count(handles[-1].seq + 1)
+        for handle in sorted(handles, key=lambda handle: handle.finished_at):
+            self._history.append(handle.job_id)
+        self._evict_history()
+        logger.info(
+            "Reloaded %d jobs from the job store (%d interrupted by the restart)",
+            len(self._jobs),
+            interrupted,
+        )
+
+    def _interrupted(self, handle: JobHandle) -> None:
+        result = handle.result
+        result.error = (
+            f"Agent restarted while the job was {handle.state}; its outcome is unknown, resubmit it"
+        )
+        result.status = JobStatus.INTERRUPTED.value
+        result.error_code = JobErrorCode.AGENT_RESTARTED.value
+        # When the restarted agent noticed, which keeps it listed for history.maxAge
+        handle.finished_at = time.time()
+        self._persist(handle)
+
+    def _persist(self, handle: JobHandle) -> None:
+        """Write the job's record to the store, if any.  A failed write never fails the job."""
+        if self.store is None:
+            return
+        try:
+            self.store.save(handle.to_record())
+        except JobStoreError as exc:
+            logger.warning("Job %s not persisted: %s", handle.job_id, exc)
+
+    async def _execute(
+        self,
+        manifest: JobManifest,
+        result: JobResult,
+        logs: LogChannel | None,
+    ) -> None:
+        start = time.time()
+        try:
+            try:
+                resolved = await self._resolve_manifest(manifest, result)
+            finally:
+                # Counted under the stack it runs as, repository defaults included
+                if self.metrics is not None:
+                    self.metrics.job_started(result.stack)
+            if resolved is not None:
+                await self._run(resolved, result, logs)
+        except asyncio.CancelledError:
+            # Teardown already ran in the finally blocks below us
+            self._cancelled(result, "Job was cancelled")
+            raise
+        except Exception as exc:
+            self._fail(result, JobErrorCode.INTERNAL_ERROR, f"Internal error: {exc}")
+            raise
+        finally:
+            result.duration_seconds = round(time.time() - start, 3)
+            if manifest.source.upload:
+                # Each upload is one job's; a resubmission uploads again
+                self.discard_upload(manifest.source.upload)
+            if logs is not None:
+                logs.close()
+            # Exactly one terminal transition per started job, however it ended
+            if self.metrics is not None:
+                reason = "succeeded" if result.succeeded else result.error_code
+                self.metrics.job_finished(result.stack, reason, result.duration_seconds)
+            self._send_callback(manifest, result)
+
+        logger.info(
+            "Job %s finished: status=%s error_code=%s exit=%d (%.1fs)",
+            result.job_id,
+            result.status,
+            result.error_code or "-",
+            result.exit_code,
+            result.duration_seconds,
+        )
+
+    async def _resolve_manifest(
+        self, manifest: JobManifest, result: JobResult
+    ) -> JobManifest | None:
+        """The manifest the job runs, its repository's defaults merged in.
+
+        Returns None once the job has failed.  ``result.stack`` is final
+        from here on.
+        """
+        error = self._capability_problem(manifest)
+        if error:
+            self._fail(result, JobErrorCode.NO_MATCHING_CAPABILITY, error)
+            return None
+        # Repository defaults: they may name the stack, so before anything else
+        if manifest.source.git and self.config.source.repo_defaults:
+            manifest = await self._apply_repo_defaults(manifest, result)
+            if manifest is None:
+                return None
+        if not manifest.stack and not manifest.image:
+            self._fail(
+                result,
+                JobErrorCode.STACK_RESOLUTION_FAILED,
+                f"No stack: the manifest sets none and neither does {REPO_DEFAULTS_FILE} "
+                f"in {display_url(manifest.source.git)}",
+            )
+            return None
+        return manifest
+
+    async def _run(
+        self,
+        manifest: JobManifest,
+        result: JobResult,
+        logs: LogChannel | None = None,
+    ) -> None:
+        """Run a job whose manifest :meth:`_resolve_manifest` returned."""
+        # 1. Stack image, or the manifest's own
+        if manifest.image:
+            error = self._image_problem(manifest)
+            if error:
+                self._fail(result, JobErrorCode.IMAGE_NOT_ALLOWED, error)
+                return
+            result.image = manifest.image
+        else:
+            try:
+                stack_image = resolve_image(manifest, self.stacks_dir)
+                result.image = await select_image(
+                    stack_image.image, self.arch, self._inspect_image
+                )
+            except StackResolutionError as exc:
+                self._fail(result, JobErrorCode.STACK_RESOLUTION_FAILED, str(exc))
+                return
+        failure = await self._ensure_image(manifest, result)
+        if failure:
+            self._fail(result, *failure)
+            return
+
+        resources, error = self._resolve_resources(manifest)
+        if error:
+            self._fail(result, JobErrorCode.RESOURCE_LIMIT_EXCEEDED, error)
+            return
+
+        error = self._run_as_problem(manifest)
+        if error:
+            self._fail(result, JobErrorCode.RUN_AS_REFUSED, error)
+            return
+        error = self._network_problem(manifest)
+        if error:
+            self._fail(result, JobErrorCode.NETWORK_REFUSED, error)
+            return
+        error = self._archive_problem(manifest)
+        if error:
+            self._fail(result, JobErrorCode.SOURCE_ARCHIVE_INVALID, error)
+            return
+        ids = manifest.run_as_ids
+        uid = ids[0] if ids else None
+        if ids and ids[0] == 0:
+            logger.warning("Job %s runs as root (runAs %s)", result.job_id, manifest.run_as)
+
+        try:
+            secret_env = resolve_secrets(manifest.secrets, self.secret_source)
+        except SecretError as exc:
+            self._fail(result, JobErrorCode.SECRET_RESOLUTION_FAILED, str(exc))
+            return
+        try:
+            file_env = self._read_env_file(manifest.env_file)
+        except EnvFileError as exc:
+            self._fail(result, JobErrorCode.ENV_FILE_INVALID, str(exc))
+            return
+        sensitive = manifest.env_file.sensitive if manifest.env_file else []
+        redactor = Redactor(
+            list(secret_env.values()) + [file_env[name] for name in sensitive if name in file_env]
+        )
+
+        workspace: Path | None = None  # a fresh one unless a host checkout is bound there
+        volumes: list[str] = []
+        # Agent-created mount points a runAs user must own
+        handoff = ["/workspace"]
+        host = ""
+        if manifest.source.kind == "bind":
+            try:
+                host = resolve_host_path(manifest.source, self.config.source.allowed_host_paths)
+            except SourceError as exc:
+                self._fail(result, JobErrorCode.SOURCE_CHECKOUT_FAILED, str(exc))
+                return
+            if manifest.source.path:
+                volumes.append(f"{host}:{manifest.source.container_path}:rw")
+            else:
+                workspace = Path(host)
+                handoff = []
+        try:
+            volumes += resolve_mounts(
+                manifest.mounts, manifest.stack, self.config.mounts.allowed_host_paths
+            )
+        except MountError as exc:
+            self._fail(result, JobErrorCode.MOUNT_REJECTED, str(exc))
+            return
+
+        # 2. Toolchain plan (validated before spending a container on it)
+        plan = None
+        if manifest.toolchain:
+            try:
+                plan = plan_toolchain(manifest.stack, manifest.toolchain)
+            except ToolchainError as exc:
+                self._fail(result, JobErrorCode.TOOLCHAIN_RESOLUTION_FAILED, str(exc))
+                return
+            cache = self.toolchain_cache_dir / cache_name(manifest.stack, uid)
+            cache.mkdir(parents=True, exist_ok=True)
+            volumes.append(f"{cache}:{TOOLCHAINS_DIR}:rw")
+            handoff.append(TOOLCHAINS_DIR)
+
+        cache_volumes = self.cache.acquire(manifest.stack, uid)
+        handoff += [volume.split(":")[1] for volume in cache_volumes]
+        if ids:
+            result.run_as = manifest.run_as
+            result.run_as_note = self._run_as_note(manifest, host)
+        owned = workspace is None
+        if owned:
+            workspace = create_workspace(self.jobs_dir / result.job_id)
+        try:
+            if manifest.source.kind == "archive":
+                if not await self._unpack_archive(manifest, result, workspace):
+                    return
+            await self._run_container(
+                manifest,
+                result,
+                plan,
+                volumes + cache_volumes,
+                resources,
+                logs,
+                secret_env,
+                redactor,
+                workspace,
+                handoff if ids else [],
+                {**self._offline_env(manifest), **file_env, **manifest.env},
+            )
+        finally:
+            if cache_volumes:
+                self.cache.release(manifest.stack, uid)
+                self._evict_cache()
+            if owned:
+                await asyncio.shield(self._remove_workspace(workspace, result.job_id))
+
+    async def _run_container(
+        self,
+        manifest: JobManifest,
+        result: JobResult,
+        plan: ToolchainPlan | None,
+        volumes: list[str],
+        resources: dict[str, str | int],
+        logs: LogChannel | None = None,
+        secret_env: dict[str, str] | None = None,
+        redactor: Redactor | None = None,
+        workspace: Path | None = None,
+        handoff: list[str] | None = None,
+        plain_env: dict[str, str] | None = None,
+    ) -> None:
+        redactor = redactor or Redactor()
+        on_output = None
+        if logs is not None:
+
+            def on_output(stream: str, line: str) -> None:
+                logs.publish(stream, redactor.redact(line))
+
+        spec = dict(
+            session_id=f"job-{result.job_id}",
+            stack=manifest.stack,
+            image=result.image,
+            profile=self.profile,
+            workspace_path=workspace or self.jobs_dir / result.job_id / "workspace",
+            extra_volumes=volumes,
+            resources=resources,
+            secret_env=secret_env or {},
+            runtime=self.runtime,
+            user=manifest.run_as or None,
+            labels=job_labels(result.job_id),
+            network=manifest.network,
+            security=self.config.hardening.container_security(),
+        )
+        pool_key = self._pool_key(manifest, result, spec)
+        warm = self.pool.take(pool_key) if pool_key else None
+        if warm is not None:
+            container = warm.container
+            result.pool_reused = True
+            logger.info("Job %s reuses warm container %s", result.job_id, container.container_name)
+        else:
+            container = self._container_factory(**spec)
+            self.containers.track(container)
+
+        # A foreground run() cannot be cancelled by ID; its stand-in is never set
+        registered = self._cancellations.get(result.job_id)
+        cancellation = registered or _Cancellation()
+
+        # From here on teardown (6.) runs however the job ends
+        started = warm is not None
+        # A warm container's counters include the jobs it ran before
+        baseline = await container.resource_usage() if started else None
+        try:
+            # 3. Container (start() removes a failed container, so it can be retried).
+            # Never interrupted midway: that could leave a half-created container.
+            if not started:
+                cancellation.stage = _STAGE_STARTING
+                started, result.start_retries = await retry_transient(
+                    container.start,
+                    lambda ok: None if ok else container.start_error,
+                    f"Start of job {result.job_id}'s container",
+                    self.config.retry,
+                )
+                cancellation.stage = _STAGE_INTERRUPTIBLE
+            if not started and cancellation.requested.is_set():
+                raise asyncio.CancelledError
+            if not started:
+                message = "Failed to start job container"
+                if container.start_error:
+                    message += f": {redactor.redact(container.start_error)}"
+                self._fail(result, JobErrorCode.CONTAINER_START_FAILED, message)
+                return
+            if pool_key:
+                self.pool.fill(
+                    manifest.stack,
+                    pool_key,
+                    lambda: self._start_warm(manifest.stack, pool_key, spec),
+                )
+
+            if cancellation.requested.is_set():
+                raise asyncio.CancelledError
+
+            if handoff:
+                error = await self._hand_off_mounts(container, manifest.run_as, handoff)
+                if error:
+                    self._fail(result, JobErrorCode.CONTAINER_START_FAILED, error)
+                    return
+
+            if manifest.source.kind == "archive":
+                error = await self._hand_off_archive(container, manifest)
+                if error:
+                    self._fail(result, JobErrorCode.SOURCE_ARCHIVE_INVALID, error)
+                    return
+
+            if manifest.source.kind == "git":
+                user = manifest.run_as or ORION_USER
+                error = await self._clone_source(container, manifest.source, on_output, user)
+                if error is not None:
+                    code = _SOURCE_ERROR_CODES.get(error.kind, JobErrorCode.SOURCE_CHECKOUT_FAILED)
+                    self._fail(result, code, redactor.redact(str(error)))
+                    return
+
+            prefix = ""
+            if plan is not None:
+                error = await self._resolve_toolchain(
+                    container, plan, on_output, offline=manifest.network == NETWORK_NONE
+                )
+                if error:
+                    self._fail(
+                        result, JobErrorCode.TOOLCHAIN_RESOLUTION_FAILED, redactor.redact(error)
+                    )
+                    return
+                result.toolchain = plan.version
+                prefix = plan.activate_prefix
+
+            # 4. Command, or each step in turn.  Secrets are the container's own
+            # environment and win over an env / envFile entry of the same name.
+            plain_env = manifest.env if plain_env is None else plain_env
+            steps = manifest.plan
+            deadline = time.monotonic() + self._timeout_for(manifest)
+            overridden: set[str] = set()
+            stdout: list[str] = []
+            stderr: list[str] = []
+            output = None
+            if self.config.output.max_log_bytes:
+                output = _OutputLimit(
+                    self.config.output.max_log_bytes, self.config.output.kill_on_limit
+                )
+            report = GoTestParser() if manifest.report == GOTEST_REPORT else None
+            last: tuple[JobStep, ExecResult, bool] | None = None  # the step that ran last
+            for step in steps:
+                env = {**plain_env, **step.env}
+                step_env = {k: v for k, v in env.items() if k not in (secret_env or {})}
+                overridden |= set(env) - set(step_env)
+                exec_result, oom_killed = await self._run_step(
+                    container,
+                    step,
+                    manifest,
+                    result,
+                    prefix,
+                    logs,
+                    redactor,
+                    registered,
+                    step_env,
+                    max(deadline - time.monotonic(), 0.0),
+                    output,
+                    report,
+                )
+                stdout.append(
+                    exec_result.stdout if report is None else plain_output(exec_result.stdout)
+                )
+                stderr.append(exec_result.stderr)
+                if step.name:
+                    result.steps.append(
+                        StepResult(
+                            name=step.name,
+                            status=(
+                                JobStatus.SUCCEEDED.value
+                                if exec_result.exit_code == 0
+                                else JobStatus.FAILED.value
+                            ),
+                            exit_code=exec_result.exit_code,
+                            exit=ExitState.decode(
+                                exec_result.exit_code, oom_killed, result.timed_out
+                            ),
+                            duration_seconds=exec_result.duration_seconds,
+                            continue_on_error=step.continue_on_error,
+                        )
+                    )
+                last = (step, exec_result, oom_killed)
+                if result.error_code or result.timed_out:
+                    break  # cancelled, over a quota or limit, or out of time
+                if exec_result.exit_code != 0 and not step.continue_on_error:
+                    break
+            cancellation.stage = _STAGE_FINISHING
+            result.steps += [
+                StepResult(name=step.name, continue_on_error=step.continue_on_error)
+                for step in steps[len(result.steps) :]
+                if step.name
+            ]
+            result.env_overridden = sorted(overridden)
+            if result.env_overridden:
+                logger.warning(
+                    "Job %s: secrets override env %s",
+                    result.job_id,
+                    ", ".join(result.env_overridden),
+                )
+
+            # The job ends as its last step did, unless only continueOnError
+            # let it get past a failure there
+            step, exec_result, oom_killed = last
+            exit_code = exec_result.exit_code
+            if step.continue_on_error and not (result.error_code or result.timed_out):
+                exit_code, oom_killed = 0, False
+            result.exit = ExitState.decode(exit_code, oom_killed, result.timed_out)
+            result.exit_code = exit_code
+            result.stdout = redactor.redact("".join(stdout))
+            result.stderr = redactor.redact("".join(stderr))
+            if result.logs_truncated:
+                result.stderr += output.marker + "\n"
+            if report is not None:
+                result.test_report = report.report()
+                for test in result.test_report.tests:
+                    test.output = redactor.redact(test.output)
+            if result.error_code:
+                pass  # stopped by cancel(), the workspace quota or the log limit
+            elif result.timed_out:
+                result.error_code = JobErrorCode.TIMED_OUT.value
+                result.error = (
+                    f"Job exceeded its {self._timeout_for(manifest):g}s timeout "
+                    f"and was stopped with {result.killed_by}"
+                )
+                if step.name:
+                    result.error += f" during step '{step.name}'"
+            elif exit_code == 0:
+                result.status = JobStatus.SUCCEEDED.value
+            elif oom_killed:
+                result.error_code = JobErrorCode.OOM_KILLED.value
+                result.error = (
+                    f"Job exceeded its memory limit and was OOM-killed ({result.exit.describe()})"
+                )
+                if step.name:
+                    result.error += f" during step '{step.name}'"
+            else:
+                result.error_code = JobErrorCode.COMMAND_FAILED.value
+                what = f"Step '{step.name}'" if step.name else "Command"
+                result.error = f"{what} {result.exit.describe()}"
+        finally:
+            cancellation.stage = _STAGE_FINISHING
+            try:
+                # 5. Artifacts -- before teardown, and even if the command failed
+                if started:
+                    await self._collect_artifacts(container, manifest, result)
+                    self._record_usage(result, await container.resource_usage(), baseline)
+            finally:
+                # 6. Teardown; shielded so a cancellation cannot cut it short
+                if warm is not None:
+                    self.pool.give_back(warm)
+                else:
+                    await asyncio.shield(self.containers.discard(container))
+
+    @staticmethod
+    def _record_usage(
+        result: JobResult, usage: ResourceUsage, baseline: ResourceUsage | None = None
+    ) -> None:
+        """Set the job's CPU time and peak memory from the container's counters.
+
+        ``baseline`` is a warm container's reading from before the job.
+        """
+        if baseline is None:
+            result.cpu_seconds = usage.cpu_seconds
+            result.peak_memory_bytes = usage.peak_memory_bytes
+            return
+        if usage.cpu_seconds is not None and baseline.cpu_seconds is not None:
+            result.cpu_seconds = max(usage.cpu_seconds - baseline.cpu_seconds, 0.0)
+        # A peak is not cumulative: it is this job's only if it exceeds the earlier one
+        if usage.peak_memory_bytes is not None and baseline.peak_memory_bytes is not None:
+            if usage.peak_memory_bytes > baseline.peak_memory_bytes:
+                result.peak_memory_bytes = usage.peak_memory_bytes
+
+    async def _run_step(
+        self,
+        container: SessionContainer,
+        step: JobStep,
+        manifest: JobManifest,
+        result: JobResult,
+        prefix: str,
+        logs: LogChannel | None,
+        redactor: Redactor,
+        registered: _Cancellation | None,
+        env: dict[str, str],
+        timeout: float,
+        output: _OutputLimit | None = None,
+        report: GoTestParser | None = None,
+    ) -> tuple[ExecResult, bool]:
+        """Run one step (or the plain command) with ``timeout`` seconds left.
+
+        Returns its result and whether it was OOM-killed.  Its log lines
+        are tagged with the step's name; ``output`` is what the job may
+        still print.  With a ``report`` its ``go test`` runs get ``-json``
+        and their events are parsed instead of logged.
+        """
+        on_output = None
+        if logs is not None or output is not None or report is not None:
+
+            def on_output(stream: str, line: str) -> None:
+                if report is not None and stream == "stdout":
+                    report.feed(line)
+                    line = event_text(line)
+                    if line is None:
+                        return
+                if output is not None:
+                    reached = output.reached.is_set()
+                    if not output.admit(line):
+                        if not reached:
+                            self._truncate_output(result, output, logs, step)
+                        return
+                if logs is not None:
+                    logs.publish(stream, redactor.redact(line), step.name)
+
+        command: str | list[str] = step.command
+        if report is not None:
+            command = json_command(command)
+        if isinstance(command, list):
+            command = list(command)
+            if prefix:
+                # The shell only activates the toolchain; argv reaches exec as "$@"
+                command = ["sh", "-c", prefix + 'exec "$@"', "sh", *command]
+        else:
+            command = prefix + command
+        if step.name:
+            logger.info("Job %s: step %s started", result.job_id, step.name)
+        oom_before = await container.oom_kill_count()
+        cancellation = registered or _Cancellation()
+        cancellation.stage = _STAGE_COMMAND
+        started = time.monotonic()
+        exec_result = await self._exec_with_timeout(
+            container,
+            command,
+            manifest,
+            result,
+            on_output,
+            registered.requested if registered else None,
+            env,
+            timeout,
+            output,
+        )
+        exec_result.duration_seconds = round(time.monotonic() - started, 3)
+        # Cancellable again until the next step signals its own process group
+        cancellation.stage = _STAGE_INTERRUPTIBLE
+        oom_killed = False
+        if exec_result.exit_code != 0:
+            oom_after = await container.oom_kill_count()
+            oom_killed = oom_before is not None and oom_after is not None and oom_after > oom_before
+        if step.name:
+            logger.info(
+                "Job %s: step %s exited %d (%.1fs)",
+                result.job_id,
+                step.name,
+                exec_result.exit_code,
+                exec_result.duration_seconds,
+            )
+        return exec_result, oom_killed
+
+    @staticmethod
+    def _truncate_output(
+        result: JobResult, output: _OutputLimit, logs: LogChannel | None, step: JobStep
+    ) -> None:
+        """Mark the job's logs incomplete, and tell whoever follows them."""
+        result.logs_truncated = True
+        logger.warning(
+            "Job %s: output reached output.maxLogBytes (%d bytes), dropping the rest",
+            result.job_id,
+            output.max_bytes,
+        )
+        if logs is not None:
+            logs.publish("stderr", output.marker, step.name)
+
+    def _send_callback(self, manifest: JobManifest, result: JobResult) -> None:
+        """POST the outcome to the manifest's ``callbackUrl`` in the background."""
+        if not manifest.callback_url:
+            return
+        secret = ""
+        if self.config.callbacks.secret:
+            secret = self.secret_source.get(self.config.callbacks.secret)
+            if secret is None:
+                # Receivers expect a signature; never send one without it
+                logger.error(
+                    "Callback of job %s not sent: secret '%s' (callbacks.secret) is not set",
+                    result.job_id,
+                    self.config.callbacks.secret,
+                )
+                return
+        task = asyncio.ensure_future(
+            deliver(
+                manifest.callback_url,
+                callback_payload(result),
+                self.config.callbacks,
+                secret,
+                self._post_callback,
+            )
+        )
+        self._callbacks.add(task)
+        task.add_done_callback(self._callbacks.discard)
+
+    def _pool_key(self, manifest: JobManifest, result: JobResult, spec: dict[str, Any]) -> str:
+        """The warm pool key of the job's container; '' if it needs a fresh one."""
+        # Warm containers are sized per stack image; a custom one always starts fresh
+        if manifest.image or not self.pool.enabled(manifest.stack):
+            return ""
+        # Bind and archive sources are in the job's own host workspace
+        if spec["secret_env"] or spec["user"] or manifest.source.kind in ("bind", "archive"):
+            return ""
+        # A reset empties /tmp etc. in place, through any writable mount there
+        if manifest.mounts:
+            return ""
+        shape = {
+            k: v for k, v in spec.items() if k not in ("session_id", "workspace_path", "labels")
+        }
+        shape["runtime"] = self.runtime.name
+        shape["image_digest"] = result.image_digest
+        return json.dumps(shape, sort_keys=True, default=str)
+
+    async def _start_warm(self, stack: str, key: str, spec: dict[str, Any]) -> WarmContainer | None:
+        """Start a container for the pool and record its pristine filesystem state."""
+        session_id = f"pool-{uuid.uuid4().hex[:12]}"
+        home = self.jobs_dir / "pool" / session_id
+        container = self._container_factory(
+            **{
+                **spec,
+                "session_id": session_id,
+                "workspace_path": home / "workspace",
+                "labels": pool_labels(stack),
+            }
+        )
+        self.containers.track(container)
+        # Held for as long as the container mounts the build cache
+        self.cache.acquire(stack)
+
+        def release() -> None:
+            self.cache.release(stack)
+            shutil.rmtree(home, ignore_errors=True)
+
+        baseline = await container.layer_changes() if await container.start() else None
+        if baseline is None:
+            logger.warning(
+                "Failed to start a warm %s container: %s",
+                stack,
+                container.start_error or "could not list its filesystem changes",
+            )
+            await self.containers.discard(container)
+            release()
+            return None
+        return WarmContainer(container, stack, key, baseline, release)
+
+    async def _apply_repo_defaults(
+        self, manifest: JobManifest, result: JobResult
+    ) -> JobManifest | None:
+        """``manifest`` with its repository's defaults file merged under it.
+
+        Returns None once the job has failed: the file could not be read
+        (as the clone would fail) or is invalid.
+        """
+        source = manifest.source
+        try:
+            image = resolve_stack("base", self.stacks_dir).image
+        except StackResolutionError as exc:
+            self._fail(
+                result,
+                JobErrorCode.STACK_RESOLUTION_FAILED,
+                f"No image to read {REPO_DEFAULTS_FILE} with: {exc}",
+            )
+            return None
+        workspace: Path | None = None
+        container: SessionContainer | None = None
+        try:
+            workspace = create_workspace(self.jobs_dir / result.job_id)
+            container = self._container_factory(
+                session_id=f"defaults-{result.job_id}",
+                stack="base",
+                image=image,
+                profile=self.profile,
+                workspace_path=workspace,
+                runtime=self.runtime,
+                labels=job_labels(result.job_id),
+                network=manifest.network,
+                security=self.config.hardening.container_security(),
+            )
+            self.containers.track(container)
+            if not await container.start():
+                self._fail(
+                    result,
+                    JobErrorCode.CONTAINER_START_FAILED,
+                    f"Could not start a container to read {REPO_DEFAULTS_FILE}: "
+                    f"{container.start_error or 'unknown error'}",
+                )
+                return None
+            lookup = await container.exec_install(
+                lookup_script(source),
+                timeout=int(self.config.source.clone_timeout),
+                env=CLONE_ENV,
+            )
+        finally:
+            if container is not None:
+                await asyncio.shield(self.containers.discard(container))
+            if workspace is not None:
+                await asyncio.shield(self._remove_workspace(workspace, result.job_id))
+
+        if lookup.exit_code == DEFAULTS_ABSENT:
+            logger.debug("%s has no %s", display_url(source.git), REPO_DEFAULTS_FILE)
+            return manifest
+        if lookup.exit_code != 0:
+            error = clone_error(source, lookup.stderr or lookup.stdout)
+            code = _SOURCE_ERROR_CODES.get(error.kind, JobErrorCode.SOURCE_CHECKOUT_FAILED)
+            self._fail(result, code, str(error))
+            return None
+        try:
+            merged, taken = merge_repo_defaults(manifest, lookup.stdout)
+        except RepoDefaultsError as exc:
+            self._fail(
+                result,
+                JobErrorCode.REPO_DEFAULTS_INVALID,
+                f"Invalid {REPO_DEFAULTS_FILE} in {display_url(source.git)}: {exc}",
+            )
+            return None
+        if taken:
+            logger.info(
+                "Job %s takes %s from %s", result.job_id, ", ".join(taken), REPO_DEFAULTS_FILE
+            )
+        result.repo_defaults = taken
+        result.stack = merged.stack
+        result.toolchain = merged.toolchain
+        return merged
+
+    def _evict_cache(self) -> None:
+        """Start a build cache eviction pass, or queue one after the running pass.
+
+        Eviction walks every cache (Go and Cargo caches hold hundreds of
+        thousands of files), so it runs in a worker thread.
+        """
+        if self._cache_eviction is not None and not self._cache_eviction.done():
+            self._evict_again = True
+            return
+        self._evict_again = False
+        self._cache_eviction = asyncio.ensure_future(self._run_cache_eviction())
+
+    async def _run_cache_eviction(self) -> None:
+        while True:
+            try:
+                await asyncio.to_thread(self.cache.evict)
+            except OSError as exc:
+                logger.warning("Build cache eviction failed: %s", exc)
+            if not self._evict_again:
+                return
+            self._evict_again = False
+
+    async def _remove_workspace(self, workspace: Path, job_id: str) -> None:
+        """Remove a job's workspace once its container is gone, as root if need be."""
+        if remove_workspace(workspace):
+            return
+        logger.error(
+            "Workspace %s of job %s could not be removed (files the agent may not delete, "
+            "e.g. from a runAs root job); emptying it as root",
+            workspace,
+            job_id,
+        )
+        try:
+            image = resolve_stack("base", self.stacks_dir).image
+        except StackResolutionError as exc:
+            logger.error("Workspace %s of job %s left behind: %s", workspace, job_id, exc)
+            return
+        container = self._container_factory(
+            session_id=f"cleanup-{job_id}-{uuid.uuid4().hex[:6]}",
+            stack="base",
+            image=image,
+            profile=self.profile,
+            workspace_path=workspace,
+            runtime=self.runtime,
+            labels=job_labels(job_id),
+            security=self.config.hardening.container_security(),
+        )
+        self.containers.track(container)
+        try:
+            if await container.start():
+                purge = await container.exec(PURGE_SCRIPT, timeout=300, phase="cleanup", user="0")
+                if purge.exit_code != 0:
+                    logger.debug("Purge of %s exited %d", workspace, purge.exit_code)
+            else:
+                logger.debug("Cleanup container for %s did not start", workspace)
+        finally:
+            await self.containers.discard(container)
+        if remove_workspace(workspace):
+            logger.info("Workspace %s of job %s removed as root", workspace, job_id)
+        else:
+            logger.error(
+                "Workspace %s of job %s is still present after a root cleanup; remove it by hand",
+                workspace,
+                job_id,
+            )
+
+    async def _clone_source(
+        self,
+        container: SessionContainer,
+        source: JobSource,
+        on_output: Callable[[str, str], None] | None = None,
+        user: str = ORION_USER,
+    ) -> SourceError | None:
+        """Clone the job's git source as ``user``.  Returns an error or None."""
+        logger.info("Cloning %s into %s", source.git, source.container_path)
+        clone = await container.exec_install(
+            clone_script(source),
+            timeout=int(self.config.source.clone_timeout),
+            env=CLONE_ENV,
+            on_output=on_output,
+            user=user,
+        )
+        if clone.exit_code == 0:
+            return None
+        return clone_error(source, clone.stderr or clone.stdout)
+
+    def _read_env_file(self, env_file: JobEnvFile | None) -> dict[str, str]:
+        """The variables of a manifest's ``envFile``; {} without one.
+
+        Raises:
+            EnvFileError: If a host file is not allowed, unreadable or malformed.
+        """
+        if env_file is None:
+            return {}
+        if not env_file.path:
+            return parse_env_file(env_file.content)
+        real = allowed_real_path(env_file.path, self.config.env_files.allowed_host_paths)
+        if real is None:
+            raise EnvFileError(
+                f"Env file {env_file.path} is not under an allowed directory "
+                "(envFiles.allowedHostPaths in jobs_config.yaml)"
+            )
+        return read_env_file(real)
+
+    async def _hand_off_mounts(
+        self, container: SessionContainer, run_as: str, paths: list[str]
+    ) -> str:
+        """Give the runAs user the agent-created mount points.  Returns an error.
+
+        Not recursive: the mounts are fresh (caches are per UID), so what
+        the job creates in them is already its own.
+        """
+        targets = " ".join(shlex.quote(path) for path in paths)
+        handed = await container.exec(
+            f"chown {run_as} {targets}", timeout=30, phase="prepare", user="0:0"
+        )
+        if handed.exit_code == 0:
+            return ""
+        detail = (handed.stderr or handed.stdout).strip()[:300]
+        return f"Could not hand the job's mounts to runAs {run_as}: {detail}"
+
+    async def _hand_off_archive(self, container: SessionContainer, manifest: JobManifest) -> str:
+        """Give the job's user the unpacked archive (the agent wrote it).  Returns an error."""
+        owner = manifest.run_as or f"{ORION_UID}:{ORION_GID}"
+        target = shlex.quote(manifest.source.container_path)
+        handed = await container.exec(
+            f"chown -R {owner} {target}", timeout=300, phase="prepare", user="0:0"
+        )
+        if handed.exit_code == 0:
+            return ""
+        detail = (handed.stderr or handed.stdout).strip()[:300]
+        return f"Could not give the unpacked archive to {owner}: {detail}"
+
+    def _archive_problem(self, manifest: JobManifest) -> str:
+        if manifest.source.kind != "archive":
+            return ""
+        if not manifest.source.upload:
+            return "The archive source has no archive: send one with the submission"
+        if not upload_path(self.uploads_dir, manifest.source.upload).is_file():
+            return (
+                f"Upload {manifest.source.upload} does not exist (each upload is for one job, "
+                "and unclaimed ones expire)"
+            )
+        return ""
+
+    async def _unpack_archive(
+        self, manifest: JobManifest, result: JobResult, workspace: Path
+    ) -> bool:
+        """Unpack the job's archive source into its workspace; False once failed."""
+        archive = upload_path(self.uploads_dir, manifest.source.upload)
+        dest = workspace / manifest.source.path if manifest.source.path else workspace
+        if manifest.source.sha256:
+            digest = await asyncio.to_thread(archive_digest, archive)
+            if digest != manifest.source.sha256:
+                self._fail(
+                    result,
+                    JobErrorCode.SOURCE_ARCHIVE_INVALID,
+                    f"Archive has SHA-256 {digest}, not the manifest's source.sha256 "
+                    f"{manifest.source.sha256}",
+                )
+                return False
+        try:
+            size = await asyncio.to_thread(
+                extract_archive, archive, dest, self.config.workspace.max_bytes
+            )
+        except ArchiveQuotaError as exc:
+            self._fail(result, JobErrorCode.WORKSPACE_QUOTA_EXCEEDED, str(exc))
+            return False
+        except ArchiveError as exc:
+            self._fail(result, JobErrorCode.SOURCE_ARCHIVE_INVALID, str(exc))
+            return False
+        logger.info(
+            "Job %s: unpacked %d bytes from upload %s",
+            result.job_id,
+            size,
+            manifest.source.upload,
+        )
+        return True
+
+    def _run_as_problem(self, manifest: JobManifest) -> str:
+        ids = manifest.run_as_ids
+        if ids is None or ids[0] != 0 or self.config.run_as.allow_root:
+            return ""
+        return (
+            "runAs uid 0 (root) is not allowed: stack images deliberately run "
+            "unprivileged (set runAs.allowRoot in jobs_config.yaml to permit it)"
+        )
+
+    def _image_problem(self, manifest: JobManifest) -> str:
+        if self.config.images.allows(manifest.image):
+            return ""
+        return (
+            f"Image '{manifest.image}' is not allowed "
+            "(add a prefix of it to images.allowedPrefixes in jobs_config.yaml to permit it)"
+        )
+
+    def _pull_policy(self, manifest: JobManifest) -> str:
+        """The stack's pull policy; the global one for a custom ``image``."""
+        if manifest.image:
+            return self.config.images.pull_policy
+        return self.config.images.policy_for(manifest.stack)
+
+    def _network_problem(self, manifest: JobManifest) -> str:
+        network = manifest.network
+        if network in ("", NETWORK_NONE, NETWORK_BRIDGE) or network in self.config.networks.allowed:
+            return ""
+        return (
+            f"Network '{network}' is not allowed "
+            "(add it to networks.allowed in jobs_config.yaml to permit it)"
+        )
+
+    def _capability_problem(self, manifest: JobManifest) -> str:
+        missing = unmatched(manifest.requires, self.config.agent.labels)
+        if not missing:
+            return ""
+        labels = ", ".join(f"{k}={v}" for k, v in sorted(self.config.agent.labels.items()))
+        return (
+            f"No matching capability: this agent does not satisfy {', '.join(missing)} "
+            f"(its agent.labels: {labels or 'none'})"
+        )
+
+    @staticmethod
+    def _offline_env(manifest: JobManifest) -> dict[str, str]:
+        """Cache-only settings for the stack's toolchain when the job has no network."""
+        if manifest.network != NETWORK_NONE:
+            return {}
+        return dict(OFFLINE_ENV.get(manifest.stack, {}))
+
+    def _run_as_note(self, manifest: JobManifest, host: str) -> str:
+        """Explain how ``runAs`` meets the ownership of the job's mounts."""
+        run_as = manifest.run_as
+        if not host:
+            return f"/workspace and the build caches were handed to {run_as} (caches are per UID)"
+        if getattr(self.runtime, "rootless", False):
+            created = "by the host user running the agent (rootless Podman maps it onto the job)"
+        else:
+            created = f"by {run_as} on the host"
+        return (
+            f"{manifest.source.container_path} is the host directory {host} with its "
+            f"ownership unchanged: the job can write only where {run_as} may, and files it "
+            f"creates are owned {created}"
+        )
+
+    async def _collect_artifacts(
+        self, container: SessionContainer, manifest: JobManifest, result: JobResult
+    ) -> None:
+        """Copy files matching the manifest's artifact patterns to the host.
+
+        Never fails the job: missing matches, the size cap and copy errors
+        are all reported in ``result.artifact_warnings``.
+        """
+        if not manifest.artifacts:
+            return
+        max_size = self.config.artifacts.max_total_size
+        listing = parse_listing(await container.list_file_sizes("/workspace"))
+        selected, warnings = select_artifacts(listing, manifest.artifacts, max_size)
+
+        out_dir = self.jobs_dir / result.job_id / "artifacts"
+        total = 0
+        try:
+            out_dir.mkdir(parents=True, exist_ok=True)
+            result.artifacts_dir = str(out_dir)
+            for artifact in selected:
+                dest = out_dir / artifact.path
+                dest.parent.mkdir(parents=True, exist_ok=True)
+                if not await container.copy_from(f"/workspace/{artifact.path}", dest):
+                    warnings.append(f"Failed to copy artifact {artifact.path}")
+                    continue
+                # Re-measure: the cap holds even if a file grew after listing
+                size = dest.stat().st_size
+                if total + size > max_size:
+                    dest.unlink()
+                    warnings.append(
+                        f"Artifact {artifact.path} grew past the {max_size} byte limit; skipped"
+                    )
+                    continue
+                total += size
+                result.artifacts.append(Artifact(path=artifact.path, size=size))
+        except OSError as exc:
+            warnings.append(f"Failed to collect artifacts: {exc}")
+
+        result.artifact_warnings = warnings
+        for warning in warnings:
+            logger.warning("Job %s: %s", result.job_id, warning)
+
+    async def _ensure_image(
+        self, manifest: JobManifest, result: JobResult
+    ) -> tuple[JobErrorCode, str] | None:
+        """Apply the manifest's pull policy to ``result.image``.
+
+        Records the pull duration and the digest that will run on
+        ``result``.  Returns the failure if the job cannot run.
+
+          Always:        pull before every run; a failed pull fails the job
+          IfNotPresent:  pull only when missing; a failed pull is left for
+                         the container start to report
+          Never:         never pull; a missing image fails the job
+        """
+        image = result.image
+        policy = self._pull_policy(manifest)
+        present = await self._inspect_image(image) is not None
+        if policy == PULL_NEVER and not present:
+            return JobErrorCode.IMAGE_NOT_PRESENT, self._not_present(image)
+
+        previous = None
+        if policy == PULL_ALWAYS or not present:
+            try:
+                login = self._registry_login(image)
+            except SecretError as exc:
+                return JobErrorCode.IMAGE_PULL_FAILED, str(exc)
+            if present:
+                previous = await self._image_digest(image)
+            logger.info("Pulling image %s (pullPolicy %s)", image, policy)
+            started = time.monotonic()
+            pull, result.pull_retries = await retry_transient(
+                lambda: self._pull_image(image, login),
+                lambda done: None if done.returncode == 0 else done.stderr or done.stdout,
+                f"Pull of {image}",
+                self.config.retry,
+            )
+            if pull.returncode != 0:
+                output = pull.stderr or pull.stdout or ""
+                if is_auth_failure(output):
+                    # The container start would only repeat the pull without the login
+                    return JobErrorCode.IMAGE_AUTH_FAILED, self._auth_failed(image, login)
+                if policy == PULL_ALWAYS:
+                    detail = output.strip()[:300]
+                    if login:
+                        detail = detail.replace(login.password, "***")
+                    return JobErrorCode.IMAGE_PULL_FAILED, f"Failed to pull image {image}: {detail}"
+                return None
+            elapsed = time.monotonic() - started
+            result.pull_duration_seconds = round(elapsed, 3)
+            logger.info("Pulled image %s in %.1fs", image, elapsed)
+            if self.metrics is not None:
+                self.metrics.image_pulled(manifest.stack, elapsed)
+
+        result.image_digest = await self._image_digest(image) or ""
+        if previous and result.image_digest and previous != result.image_digest:
+            logger.info("Image %s updated: %s -> %s", image, previous, result.image_digest)
+        return None
+
+    def _registry_login(self, image: str) -> RegistryLogin | None:
+        """The configured login for ``image``'s registry, if any.
+
+        The password is read on every pull, so a rotated file, variable or
+        secret is picked up without a restart.
+
+        Raises:
+            SecretError: If the login's password cannot be read.
+        """
+        registry = registry_of(image)
+        credentials = self.config.images.registries.get(registry)
+        if credentials is None:
+            return None
+        kind, name = credentials.origin
+        what = "token" if credentials.token else "password"
+        if kind == "file":
+            try:
+                password = Path(name).read_text(encoding="utf-8").strip()
+            except (OSError, UnicodeDecodeError) as exc:
+                reason = getattr(exc, "strerror", None) or "not UTF-8 text"
+                raise SecretError(
+                    f"The {what} file {name} for registry {registry} cannot be read: {reason}"
+                ) from None
+        elif kind == "env":
+            password = os.environ.get(name, "")
+        else:
+            password = self.secret_source.get(name) or ""
+        if not password:
+            if kind == "file":
+                raise SecretError(f"The {what} file {name} for registry {registry} is empty")
+            source = "Environment variable" if kind == "env" else "Secret"
+            raise SecretError(f"{source} '{name}' for registry {registry} is not set")
+        return RegistryLogin(credentials.username, password)
+
+    @staticmethod
+    def _auth_failed(image: str, login: RegistryLogin | None) -> str:
+        registry = registry_of(image)
+        reason = (
+            f"the login for user {login.username} was rejected"
+            if login
+            else "no login is configured for it under images.registries"
+        )
+        return f"Failed to pull image {image}: authentication failed for {registry} ({reason})"
+
+    @staticmethod
+    def _not_present(image: str) -> str:
+        return f"Image {image} is not present locally and pullPolicy is Never"
+
+    def _timeout_for(self, manifest: JobManifest) -> float:
+        return manifest.timeout or self.config.timeout.default
+
+    async def _exec_with_timeout(
+        self,
+        container: SessionContainer,
+        command: str | list[str],
+        manifest: JobManifest,
+        result: JobResult,
+        on_output: Callable[[str, str], None] | None,
+        cancel: asyncio.Event | None = None,
+        env: dict[str, str] | None = None,
+        timeout: float | None = None,
+        output: _OutputLimit | None = None,
+    ) -> ExecResult:
+        """Run the job command; on timeout, ``cancel``, a full workspace or
+        (with ``output.killOnLimit``) its logs reaching ``output.maxLogBytes``
+        SIGTERM it, then SIGKILL after grace.
+
+        ``timeout`` is what is left of the job's; its whole timeout if None.
+
+        The command runs in its own process group (``setsid``) so signals
+        reach everything it spawned.  All waiting is on the event loop, so
+        a job in its grace period never holds up other jobs, and nothing
+        is left scheduled once the command ends.  A command that exits on
+        its own before any of these keeps its own outcome.
+        """
+        if timeout is None:
+            timeout = self._timeout_for(manifest)
+        grace = self.config.timeout.grace_period
+        # Sensitive envFile values go by name, out of the runtime CLI's argv
+        sensitive = set(manifest.env_file.sensitive) if manifest.env_file else set()
+        plain = {k: v for k, v in (env or {}).items() if k not in sensitive}
+        hidden = {k: v for k, v in (env or {}).items() if k in sensitive}
+        # The exec's own timeout is only a backstop behind the escalation below
+        task = asyncio.ensure_future(
+            container.exec(
+                command,
+                timeout=int(timeout + grace) + 30,
+                phase="execute",
+                env=plain or None,
+                on_output=on_output,
+                pidfile=_JOB_PIDFILE,
+                workdir=manifest.workdir,
+                max_output=output.remaining if output is not None else None,
+                secret_env=hidden or None,
+            )
+        )
+        cancelled = asyncio.ensure_future(cancel.wait()) if cancel is not None else None
+        quota = None
+        if self.config.workspace.max_size_gb:
+            quota = asyncio.ensure_future(self._watch_workspace(container))
+        overflow = None
+        if output is not None and output.kill:
+            overflow = asyncio.ensure_future(output.reached.wait())
+        try:
+            done, _ = await asyncio.wait(
+                {task, cancelled, quota, overflow} - {None},
+                timeout=timeout,
+                return_when=asyncio.FIRST_COMPLETED,
+            )
+            if task in done:
+                return task.result()
+
+            result.killed_by = "SIGTERM"
+            if cancelled in done:
+                self._cancelled(result, "Job was cancelled while running")
+                logger.info("Job %s cancelled, sending SIGTERM", result.job_id)
+            elif quota in done:
+                result.status = JobStatus.FAILED.value
+                result.error_code = JobErrorCode.WORKSPACE_QUOTA_EXCEEDED.value
+                result.error = (
+                    f"Workspace grew to {quota.result() / 1024**3:.2f} GiB, over its "
+                    f"{self.config.workspace.max_size_gb:g} GiB quota (workspace.maxSizeGB)"
+                )
+                logger.warning("Job %s: %s; sending SIGTERM", result.job_id, result.error)
+            elif overflow in done:
+                result.status = JobStatus.FAILED.value
+                result.error_code = JobErrorCode.LOG_LIMIT_EXCEEDED.value
+                result.error = (
+                    f"Job output reached its {output.max_bytes}-byte limit "
+                    "(output.maxLogBytes) and the job was stopped (output.killOnLimit)"
+                )
+                logger.warning("Job %s: %s; sending SIGTERM", result.job_id, result.error)
+            else:
+                result.timed_out = True
+                logger.warning(
+                    "Job %s timed out after %gs, sending SIGTERM",
+                    result.job_id,
+                    self._timeout_for(manifest),
+                )
+            await container.signal_group(_JOB_PIDFILE, "TERM")
+            done, _ = await asyncio.wait({task}, timeout=grace)
+            if not done:
+                result.killed_by = "SIGKILL"
+                logger.warning(
+                    "Job %s ignored SIGTERM for %gs, sending SIGKILL", result.job_id, grace
+                )
+                await container.signal_group(_JOB_PIDFILE, "KILL")
+            return await task
+        finally:
+            for watcher in (cancelled, quota, overflow):
+                if watcher is not None:
+                    watcher.cancel()
+            if not task.done():
+                task.cancel()
+
+    async def _watch_workspace(self, container: SessionContainer) -> int:
+        """Measure /workspace every ``workspace.checkInterval``; return its size
+        once it exceeds ``workspace.maxSizeGB``.
+
+        Polled rather than enforced by the mount: the workspace is a host
+        bind mount, which neither Docker nor Podman can size-limit.
+        """
+        limit = self.config.workspace.max_bytes
+        while True:
+            await asyncio.sleep(self.config.workspace.check_interval)
+            used = await container.disk_usage()
+            if used is not None and used > limit:
+                return used
+
+    def _resolve_resources(self, manifest: JobManifest) -> tuple[dict[str, str | int], str]:
+        """Apply config defaults and caps to the manifest's limits.
+
+        Returns:
+            (SessionContainer resource overrides, error message or '').
+        """
+        problems = self._resource_problems(manifest)
+        if problems:
+            return {}, "; ".join(p.message for p in problems)
+
+        limits = self.config.resources
+        cpu = manifest.resources.cpu or limits.default_cpu
+        memory = manifest.resources.memory or limits.default_memory
+        overrides: dict[str, str | int] = {}
+        if cpu is not None:
+            overrides["cpus"] = f"{cpu:g}"
+        if memory is not None:
+            overrides["memory"] = f"{memory}b"
+        return overrides, ""
+
+    def _resource_problems(self, manifest: JobManifest) -> list[ManifestProblem]:
+        """Limits (after defaults) that exceed the configured caps."""
+        limits = self.config.resources
+        cpu = manifest.resources.cpu or limits.default_cpu
+        memory = manifest.resources.memory or limits.default_memory
+
+        problems = []
+        if cpu is not None and limits.max_cpu is not None and cpu > limits.max_cpu:
+            problems.append(
+                ManifestProblem(
+                    "resources.cpu",
+                    f"Requested {cpu:g} CPUs exceeds the configured maximum of {limits.max_cpu:g}",
+                )
+            )
+        if memory is not None and limits.max_memory is not None and memory > limits.max_memory:
+            problems.append(
+                ManifestProblem(
+                    "resources.memory",
+                    f"Requested memory {memory} bytes exceeds the configured maximum "
+                    f"of {limits.max_memory} bytes",
+                )
+            )
+        return problems
+
+    @staticmethod
+    async def _resolve_toolchain(
+        container: SessionContainer,
+        plan: ToolchainPlan,
+        on_output: Callable[[str, str], None] | None = None,
+        offline: bool = False,
+    ) -> str:
+        """Activate a toolchain inside the container.  Returns an error or ''."""
+        probe = await container.exec(plan.probe_script, timeout=30, phase="toolchain")
+        if probe.exit_code == PROBE_BAKED:
+            logger.debug("Toolchain %s is baked into the image", plan.version)
+            return ""
+        if probe.exit_code == PROBE_CACHED:
+            logger.debug("Toolchain %s found in cache", plan.version)
+            return ""
+        if offline:
+            return (
+                f"Toolchain {plan.version} is neither baked into the image nor cached, "
+                "and cannot be downloaded with network 'none'"
+            )
+
+        logger.info("Downloading toolchain %s", plan.version)
+        install = await container.exec_install(
+            plan.install_script, timeout=600, on_output=on_output
+        )
+        if install.exit_code != 0:
+            detail = (install.stderr or install.stdout).strip()[:300]
+            return (
+                f"Toolchain {plan.version} is not baked into the image "
+                f"and could not be downloaded: {detail}"
+            )
+        return ""
+
+    def _cancelled(self, result: JobResult, message: str) -> None:
+        result.status = JobStatus.CANCELLED.value
+        result.error_code = JobErrorCode.CANCELLED.value
+        result.error = message
+        cancellation = self._cancellations.get(result.job_id)
+        if cancellation is not None and cancellation.shutdown:
+            result.error_code = JobErrorCode.AGENT_SHUTDOWN.value
+            result.error = message = cancellation.shutdown
+        logger.info("Job %s cancelled: %s", result.job_id, message)
+
+    @staticmethod
+    def _fail(result: JobResult, code: JobErrorCode, message: str) -> None:
+        result.status = JobStatus.FAILED.value
+        result.error_code = code.value
+        result.error = message
+        logger.warning("Job %s failed (%s): %s", result.job_id, code.value, message)
@@ -0,0 +1,139 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""How a job command ended, decoded from its exit status.
+
+The job command runs under ``sh -c`` via ``docker exec`` / ``podman exec``,
+so a process killed by signal *N* is reported as exit status ``128 + N``
+(137 = SIGKILL, 143 = SIGTERM, 139 = SIGSEGV).  The bare number cannot say
+*who* sent the signal, so :class:`ExitState` pairs the decoded signal with
+what the agent itself observed: whether it hit the timeout (and signalled
+the job) and whether the container's cgroup recorded an OOM kill.  An exit
+137 then reads as one of OOM-killed, killed at the timeout, or a SIGKILL
+from somewhere else.
+
+A command that calls ``exit 137`` itself is indistinguishable from one
+killed by SIGKILL; the exit status is all the engine reports.
+"""
+
+from __future__ import annotations
+
+from dataclasses import dataclass
+from typing import Any
+
+# Signals are numbered as on Linux, where the job ran -- not as on the agent host
+_LINUX_SIGNALS = {
+    1: "SIGHUP",
+    2: "SIGINT",
+    3: "SIGQUIT",
+    4: "SIGILL",
+    5: "SIGTRAP",
+    6: "SIGABRT",
+    7: "SIGBUS",
+    8: "SIGFPE",
+    9: "SIGKILL",
+    10: "SIGUSR1",
+    11: "SIGSEGV",
+    12: "SIGUSR2",
+    13: "SIGPIPE",
+    14: "SIGALRM",
+    15: "SIGTERM",
+    16: "SIGSTKFLT",
+    17: "SIGCHLD",
+    18: "SIGCONT",
+    19: "SIGSTOP",
+    20: "SIGTSTP",
+    21: "SIGTTIN",
+    22: "SIGTTOU",
+    23: "SIGURG",
+    24: "SIGXCPU",
+    25: "SIGXFSZ",
+    26: "SIGVTALRM",
+    27: "SIGPROF",
+    28: "SIGWINCH",
+    29: "SIGIO",
+    30: "SIGPWR",
+    31: "SIGSYS",
+}
+_SIGRTMIN = 34  # glibc
+_SIGRTMAX = 64
+
+# Statuses the shell / engine use for their own failures, not signals
+_SHELL_STATUSES = {126: "command not executable", 127: "command not found"}
+
+
+def signal_name(number: int) -> str:
+    """Linux name of signal ``number`` (``SIGRTMIN+2`` for real-time ones)."""
+    if number in _LINUX_SIGNALS:
+        return _LINUX_SIGNALS[number]
+    if _SIGRTMIN <= number <= _SIGRTMAX:
+        return "SIGRTMIN" if number == _SIGRTMIN else f"SIGRTMIN+{number - _SIGRTMIN}"
+    return f"SIG{number}"
+
+
+@dataclass
+class ExitState:
+    """The job command's exit status and what killed it, if anything."""
+
+    exit_code: int = -1  # -1 = no exit status (the command never ran or the exec failed)
+    signal: str = ""  # e.g. 'SIGKILL' when the process died of a signal
+    signal_number: int = 0
+    oom_killed: bool = False  # the cgroup's OOM killer fired during the command
+    timed_out: bool = False  # the agent signalled it at the job timeout
+
+    @classmethod
+    def decode(
+        cls, exit_code: int, oom_killed: bool = False, timed_out: bool = False
+    ) -> ExitState:
+        """Build the state for a raw exit status plus the agent's observations."""
+        number = exit_code - 128 if 128 < exit_code <= 128 + _SIGRTMAX else 0
+        return cls(
+            exit_code=exit_code,
+            signal=signal_name(number) if number else "",
+            signal_number=number,
+            oom_killed=oom_killed,
+            timed_out=timed_out,
+        )
+
+    @classmethod
+    def from_dict(cls, data: dict[str, Any]) -> ExitState:
+        """The inverse of :meth:`to_dict`."""
+        return cls(
+            exit_code=data["exit_code"],
+            signal=data["signal"] or "",
+            signal_number=data["signal_number"] or 0,
+            oom_killed=data["oom_killed"],
+            timed_out=data["timed_out"],
+        )
+
+    def describe(self) -> str:
+        """Completes "Command ...", e.g. ``was killed by SIGSEGV (exit 139)``."""
+        if self.exit_code < 0:
+            return "ended without an exit status"
+        if self.signal:
+            return f"was killed by {self.signal} (exit {self.exit_code})"
+        if self.exit_code in _SHELL_STATUSES:
+            return f"could not run: {_SHELL_STATUSES[self.exit_code]} (exit {self.exit_code})"
+        return f"exited with code {self.exit_code}"
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "exit_code": self.exit_code,
+            "signal": self.signal or None,
+            "signal_number": self.signal_number or None,
+            "oom_killed": self.oom_killed,
+            "timed_out": self.timed_out,
+        }
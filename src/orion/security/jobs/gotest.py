@@ -0,0 +1,244 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Structured ``go test`` reports (manifest ``report: gotest``).
+
+With ``report: gotest`` the job's ``go test`` runs get ``-json``
+(:func:`json_command`) and their event stream is parsed as it arrives
+(:class:`GoTestParser`) into a :class:`GoTestReport` on the job result,
+``test_report``: every test with its package, outcome, duration and, for
+a failure, its output.  Steps add to the same report.
+
+The events never reach the log: followers and ``stdout`` see the text
+``go test -v`` would have printed, so the raw log reads as before.
+Lines that are not events (the command's own output) pass through.
+
+A test binary that dies mid-suite (a panic, ``os.Exit``, the job's
+timeout) still yields every test that finished.  The report is then
+``aborted``, listing the packages that did not run to the end; tests
+that never finished are reported ``incomplete``.
+
+Only ``go test`` run by the command itself is affected, not one a
+``make`` target or script runs; give those ``-json`` yourself.
+"""
+
+from __future__ import annotations
+
+import json
+from dataclasses import dataclass, field
+from typing import Any
+
+GOTEST_REPORT = "gotest"
+REPORTS = (GOTEST_REPORT,)
+
+PASS = "pass"
+FAIL = "fail"
+SKIP = "skip"
+INCOMPLETE = "incomplete"  # started, never finished
+
+_MAX_TEST_OUTPUT = 64 * 1024  # characters kept per failing test
+
+# Defined ahead of a shell command: ``go test`` becomes ``go test -json``
+_GO_JSON_SHIM = (
+    'go() { if [ "$1" = test ]; then shift; command go test -json "$@"; '
+    'else command go "$@"; fi; }; '
+)
+
+
+@dataclass
+class GoTestCase:
+    """One test (or subtest, ``TestA/sub``) of a report."""
+
+    package: str
+    name: str
+    status: str = INCOMPLETE
+    duration_seconds: float = 0.0
+    output: str = ""  # only for fail / incomplete
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "package": self.package,
+            "name": self.name,
+            "status": self.status,
+            "duration_seconds": self.duration_seconds,
+            "output": self.output,
+        }
+
+
+@dataclass
+class GoTestPackage:
+    """One package's outcome."""
+
+    package: str
+    status: str = INCOMPLETE
+    duration_seconds: float = 0.0
+    aborted: bool = False  # its test binary stopped before the suite ended
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "package": self.package,
+            "status": self.status,
+            "duration_seconds": self.duration_seconds,
+            "aborted": self.aborted,
+        }
+
+
+@dataclass
+class GoTestReport:
+    """What a job's ``go test -json`` runs reported."""
+
+    packages: list[GoTestPackage] = field(default_factory=list)
+    tests: list[GoTestCase] = field(default_factory=list)
+
+    @property
+    def aborted(self) -> bool:
+        return any(package.aborted for package in self.packages)
+
+    def count(self, status: str) -> int:
+        return sum(1 for test in self.tests if test.status == status)
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "format": GOTEST_REPORT,
+            "passed": self.count(PASS),
+            "failed": self.count(FAIL),
+            "skipped": self.count(SKIP),
+            "incomplete": self.count(INCOMPLETE),
+            "aborted": self.aborted,
+            "aborted_packages": [p.package for p in self.packages if p.aborted],
+            "packages": [package.to_dict() for package in self.packages],
+            "tests": [test.to_dict() for test in self.tests],
+        }
+
+    @classmethod
+    def from_dict(cls, data: dict[str, Any]) -> GoTestReport:
+        """The inverse of :meth:`to_dict`."""
+        return cls(
+            packages=[GoTestPackage(**package) for package in data.get("packages", [])],
+            tests=[GoTestCase(**test) for test in data.get("tests", [])],
+        )
+
+
+def json_command(command: str | list[str]) -> str | list[str]:
+    """``command`` with ``-json`` added to its ``go test`` runs."""
+    if isinstance(command, list):
+        if command[:2] == ["go", "test"]:
+            return ["go", "test", "-json", *command[2:]]
+        return list(command)
+    return _GO_JSON_SHIM + command
+
+
+def event_text(line: str) -> str | None:
+    """A stdout line as the log shows it: an event's output, or the line
+    itself if it is no event.  None for an event without output."""
+    event = _event(line)
+    if event is None:
+        return line
+    output = event.get("Output")
+    return output.rstrip("\n") if isinstance(output, str) else None
+
+
+def plain_output(text: str) -> str:
+    """Captured stdout with every event replaced by its output."""
+    lines = (event_text(line) for line in text.splitlines())
+    return "".join(line + "\n" for line in lines if line is not None)
+
+
+class GoTestParser:
+    """Builds a :class:`GoTestReport` from ``go test -json`` lines, one at a time."""
+
+    def __init__(self) -> None:
+        self._packages: dict[str, GoTestPackage] = {}
+        self._tests: dict[tuple[str, str], GoTestCase] = {}
+        self._output: dict[tuple[str, str], list[str]] = {}
+        self._sizes: dict[tuple[str, str], int] = {}
+
+    def feed(self, line: str) -> None:
+        event = _event(line)
+        if event is None:
+            return
+        action = event["Action"]
+        package, name = str(event.get("Package", "")), str(event.get("Test", ""))
+        if not package:
+            return
+        if package not in self._packages:
+            self._packages[package] = GoTestPackage(package)
+        output = event.get("Output")
+        if isinstance(output, str):
+            if output.startswith("panic: "):
+                self._packages[package].aborted = True
+            if name:
+                self._keep_output((package, name), output)
+        if action == "run" and name:
+            self._test(package, name)
+        elif action in (PASS, FAIL, SKIP):
+            elapsed = event.get("Elapsed")
+            duration = round(float(elapsed), 3) if isinstance(elapsed, (int, float)) else 0.0
+            if name:
+                self._finish_test(package, name, action, duration)
+            else:
+                self._finish_package(package, action, duration)
+
+    def report(self) -> GoTestReport:
+        """The report so far; a package still running counts as aborted."""
+        for package in self._packages.values():
+            if package.status == INCOMPLETE:
+                package.aborted = True
+        for key, test in self._tests.items():
+            if test.status in (FAIL, INCOMPLETE):
+                test.output = "".join(self._output.get(key, []))
+        return GoTestReport(list(self._packages.values()), list(self._tests.values()))
+
+    def _test(self, package: str, name: str) -> GoTestCase:
+        key = (package, name)
+        if key not in self._tests:
+            self._tests[key] = GoTestCase(package, name)
+        return self._tests[key]
+
+    def _finish_test(self, package: str, name: str, status: str, duration: float) -> None:
+        test = self._test(package, name)
+        test.status, test.duration_seconds = status, duration
+        if status != FAIL:
+            # Only a failure's output is reported
+            self._output.pop((package, name), None)
+            self._sizes.pop((package, name), None)
+
+    def _finish_package(self, package: str, status: str, duration: float) -> None:
+        result = self._packages[package]
+        result.status, result.duration_seconds = status, duration
+        if any(t.package == package and t.status == INCOMPLETE for t in self._tests.values()):
+            result.aborted = True  # the binary exited with tests still running
+
+    def _keep_output(self, key: tuple[str, str], output: str) -> None:
+        size = self._sizes.get(key, 0)
+        if size >= _MAX_TEST_OUTPUT:
+            return
+        output = output[: _MAX_TEST_OUTPUT - size]
+        self._sizes[key] = size + len(output)
+        self._output.setdefault(key, []).append(output)
+
+
+def _event(line: str) -> dict[str, Any] | None:
+    line = line.strip()
+    if not line.startswith("{"):
+        return None
+    try:
+        event = json.loads(line)
+    except ValueError:
+        return None
+    if not isinstance(event, dict) or not isinstance(event.get("Action"), str):
+        return None
+    return event
@@ -0,0 +1,187 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job agent readiness -- can it take a job right now?
+
+``GET /readyz`` reports not-ready (503) with one of these reasons:
+
+  runtime_unreachable  the container engine does not answer on its socket,
+                       or cannot create a container from the probe image
+  disk_full            less than ``health.minFreeDisk`` is free under the
+                       jobs directory
+  at_capacity          the job queue is full, so submissions are rejected
+  shutting_down        the agent is draining its jobs before it exits
+  warming_up           the ``images.warmStacks`` are still being pulled
+                       (for at most ``images.warmTimeout``)
+  warm_up_failed       a warm stack marked ``required`` could not be warmed
+
+The runtime probe creates (never starts) a throwaway container and removes
+it again.  Its result is reused for ``health.probeTtl`` so a load balancer
+can poll every few seconds; the disk and capacity checks are cheap and run
+on every call.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import logging
+import shutil
+import tempfile
+import time
+import uuid
+from collections.abc import Callable
+from dataclasses import dataclass
+from pathlib import Path
+from typing import TYPE_CHECKING, Any
+
+from orion.security.container_runtime import ContainerSpec
+from orion.security.stack_detector import image_name
+
+if TYPE_CHECKING:
+    from orion.security.jobs.executor import JobExecutor
+
+logger = logging.getLogger("orion.security.jobs.health")
+
+RUNTIME_UNREACHABLE = "runtime_unreachable"
+DISK_FULL = "disk_full"
+AT_CAPACITY = "at_capacity"
+SHUTTING_DOWN = "shutting_down"
+WARMING_UP = "warming_up"
+WARM_UP_FAILED = "warm_up_failed"
+
+
+@dataclass
+class Readiness:
+    """Outcome of a readiness check."""
+
+    ready: bool
+    reason: str = ""  # one of the constants above when not ready
+    detail: str = ""
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "status": "ready" if self.ready else "not_ready",
+            "reason": self.reason,
+            "detail": self.detail,
+        }
+
+
+class ReadinessProbe:
+    """Checks an executor's runtime, disk and queue for ``/readyz``."""
+
+    def __init__(
+        self, executor: JobExecutor, clock: Callable[[], float] = time.monotonic
+    ) -> None:
+        self.executor = executor
+        self._clock = clock
+        self._probed_at: float | None = None
+        self._runtime_error = ""
+        # Concurrent polls share one in-flight runtime probe
+        self._lock = asyncio.Lock()
+
+    async def check(self) -> Readiness:
+        """Run the checks, most fundamental first."""
+        if self.executor.draining:
+            running = self.executor.stats()["running"]
+            return Readiness(False, SHUTTING_DOWN, f"Draining {running} running jobs")
+
+        error = await self._runtime_status()
+        if error:
+            return Readiness(False, RUNTIME_UNREACHABLE, error)
+
+        if self.executor.warming:
+            stacks = ", ".join(warm.stack for warm in self.executor.config.images.warm_stacks)
+            return Readiness(False, WARMING_UP, f"Warming up stacks: {stacks}")
+        if self.executor.warm_failures:
+            failures = "; ".join(
+                f"{stack}: {error}" for stack, error in self.executor.warm_failures.items()
+            )
+            return Readiness(False, WARM_UP_FAILED, f"Required stacks not warmed: {failures}")
+
+        health = self.executor.config.health
+        jobs_dir = self.executor.jobs_dir
+        free = shutil.disk_usage(_existing_parent(jobs_dir)).free
+        if free < health.min_free_disk:
+            return Readiness(
+                False,
+                DISK_FULL,
+                f"{_gib(free)} free under {jobs_dir}; at least {_gib(health.min_free_disk)} "
+                "is required (health.minFreeDisk)",
+            )
+
+        stats = self.executor.stats()
+        if stats["queued"] >= stats["max_queued"]:
+            return Readiness(
+                False,
+                AT_CAPACITY,
+                f"Job queue full ({stats['queued']} waiting, {stats['running']} running)",
+            )
+        return Readiness(True)
+
+    async def _runtime_status(self) -> str:
+        """The runtime probe's error ('' when healthy), cached for probeTtl."""
+        async with self._lock:
+            ttl = self.executor.config.health.probe_ttl
+            if self._probed_at is None or self._clock() - self._probed_at >= ttl:
+                self._runtime_error = await self._probe_runtime()
+                self._probed_at = self._clock()
+                if self._runtime_error:
+                    logger.warning("Readiness probe failed: %s", self._runtime_error)
+            return self._runtime_error
+
+    async def _probe_runtime(self) -> str:
+        runtime = self.executor.runtime
+        loop = asyncio.get_running_loop()
+        if not await loop.run_in_executor(None, runtime.is_available):
+            return f"Container runtime {runtime.name} is not responding"
+
+        image = self.executor.config.health.probe_image or image_name("base")
+        name = f"orion-probe-{uuid.uuid4().hex[:8]}"
+        with tempfile.TemporaryDirectory(prefix="orion-probe-") as workspace:
+            # ``--pull never``: a probe must not turn into an image download
+            # Hardened like a job's, so an option the engine rejects shows here
+            spec = ContainerSpec(
+                name=name,
+                image=image,
+                command=["true"],
+                workspace=workspace,
+                pull="never",
+                security=self.executor.config.hardening.container_security(),
+            )
+            try:
+                created = await runtime.create(spec)
+            except (OSError, asyncio.TimeoutError) as exc:
+                return f"Could not create a probe container: {exc}"
+            if created.returncode != 0:
+                stderr = (created.stderr or "").strip()[:200]
+                return f"Could not create a probe container from {image}: {stderr}"
+            try:
+                await runtime.remove(name)
+            except (OSError, asyncio.TimeoutError) as exc:
+                logger.debug("Could not remove probe container %s: %s", name, exc)
+        return ""
+
+
+def _existing_parent(path: Path) -> Path:
+    """``path`` or its nearest existing ancestor (the jobs dir may not exist yet)."""
+    for candidate in (path, *path.parents):
+        if candidate.exists():
+            return candidate
+    return path
+
+
+def _gib(size: int) -> str:
+    return f"{size / 1024**3:.1f} GiB"
@@ -0,0 +1,113 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Live job log channel.
+
+The executor publishes each line the container writes, tagged with its
+stream and a monotonic timestamp.  Any number of readers can follow the
+channel; each one first replays the lines it missed, then waits for new
+ones.  A reader going away only drops its subscription -- the job keeps
+running and the channel keeps recording.
+
+Lines from one stream are published in the order they were read, so
+ordering within stdout (or within stderr) is preserved.  There is no
+ordering guarantee *between* the two streams beyond their timestamps.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import time
+from collections.abc import AsyncIterator
+from dataclasses import dataclass
+
+
+@dataclass(frozen=True)
+class LogLine:
+    """One line of job output."""
+
+    seq: int
+    stream: str  # 'stdout' or 'stderr'
+    line: str
+    timestamp: float  # time.monotonic()
+    step: str = ""  # the step that printed it; '' outside steps
+
+    def to_dict(self) -> dict:
+        return {
+            "seq": self.seq,
+            "stream": self.stream,
+            "line": self.line,
+            "timestamp": self.timestamp,
+            "step": self.step or None,
+        }
+
+
+class LogChannel:
+    """Append-only log of a job's output with async followers."""
+
+    def __init__(self) -> None:
+        self._lines: list[LogLine] = []
+        self._closed = False
+        self._changed = asyncio.Event()
+
+    @property
+    def closed(self) -> bool:
+        return self._closed
+
+    @property
+    def lines(self) -> list[LogLine]:
+        return list(self._lines)
+
+    def publish(self, stream: str, line: str, step: str = "") -> None:
+        """Record a line (of ``step``, if in one) and wake every follower."""
+        if self._closed:
+            return
+        self._lines.append(
+            LogLine(
+                seq=len(self._lines),
+                stream=stream,
+                line=line,
+                timestamp=time.monotonic(),
+                step=step,
+            )
+        )
+        self._wake()
+
+    def close(self) -> None:
+        """Mark the channel complete; followers drain and stop."""
+        self._closed = True
+        self._wake()
+
+    async def follow(self, since: int = 0) -> AsyncIterator[LogLine]:
+        """Yield lines from ``since`` onwards until the channel is closed.
+
+        Abandoning the iterator (e.g. a client disconnect) has no effect
+        on the job.
+        """
+        index = since
+        while True:
+            while index < len(self._lines):
+                yield self._lines[index]
+                index += 1
+            if self._closed:
+                return
+            event = self._changed
+            await event.wait()
+
+    def _wake(self) -> None:
+        # Swap in a fresh event so followers that woke up re-arm cleanly.
+        event, self._changed = self._changed, asyncio.Event()
+        event.set()
@@ -0,0 +1,916 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job manifest schema.
+
+A manifest is a small YAML (or JSON) document submitted by a caller::
+
+    stack: go
+    image: ghcr.io/acme/builder:1.4   # optional, instead of the stack's image
+    command: go test ./...  # run by sh; or an argv list, run without a shell
+    workdir: api           # optional, under /workspace (the default)
+    env:                   # optional, over the image's environment
+      CGO_ENABLED: "0"
+    envFile:               # optional KEY=VALUE lines, under ``env``
+      path: /srv/env/api.env      # host file (``envFiles.allowedHostPaths``), or
+      # content: |                # the lines inline
+      #   LOG_LEVEL=debug
+      sensitive: [DB_PASSWORD]    # values redacted from output like secrets
+    toolchain: "1.21.13"   # optional, activated at container start
+    resources:             # optional, capped by the operator's config
+      cpu: 2               # cores
+      memory: 512Mi        # bytes or a human size
+    timeout: 10m           # optional, seconds or a duration; default from config
+    secrets:               # optional, env var -> secret name (never a value)
+      NPM_TOKEN: npm-publish-token
+    artifacts:             # optional, globs under /workspace copied out afterwards
+      - dist/**
+      - "*.junit.xml"
+    source:                # optional, cloned into /workspace before the command
+      git: https://github.com/acme/api.git
+      ref: main            # branch or tag; default the remote's HEAD
+      commit: 3f2a9c1e...  # optional exact commit
+      depth: 1             # optional shallow clone
+      path: api            # optional subdirectory of /workspace
+    runAs: "1500:1500"     # optional uid[:gid] instead of the image's ``orion``
+    callbackUrl: https://ci.acme.dev/hooks/orion   # optional, POSTed the outcome
+    mounts:                # optional extra host paths (``mounts.allowedHostPaths``)
+      - source: /opt/toolchains/android-sdk
+        target: /opt/android-sdk
+        readOnly: true     # default false
+    network: none          # optional: none, bridge or a named network
+    requires: [gpu=true, cuda]   # optional agent labels: key=value or key present
+    report: gotest         # optional: parse ``go test -json`` into ``test_report``
+
+A ``git`` source may carry its own defaults in ``.orion-agent.yaml`` at
+the repository root, merged under the manifest (see
+:mod:`orion.security.jobs.repo_defaults`); ``stack`` may then come from
+there instead.
+
+``source`` may instead bind-mount a host checkout (``hostPath: /srv/api``)
+if the operator allows that directory (``source.allowedHostPaths``), or
+be a tar / tar.gz snapshot (``type: archive``) sent with the submission
+or uploaded beforehand (``upload: <id>``), which is unpacked into
+``/workspace`` (see :mod:`orion.security.jobs.archive`).  ``sha256``
+pins the archive: one with another digest is refused.  A signed
+manifest's archive must be pinned (see :mod:`orion.security.jobs.signing`).
+
+``runAs`` must be quoted: YAML reads e.g. unquoted ``1000:50`` as a
+base-60 number.  Without a gid the job runs with gid = uid.
+
+``command`` as a list (``["go", "test", "-run", "Test Foo"]``) is passed
+to the runtime token by token: nothing is word-split, globbed or
+expanded, so arguments may hold spaces and quotes.
+
+``steps`` replaces ``command`` with a pipeline run one after another in
+the same container, so ``/workspace`` carries over::
+
+    steps:
+      - name: deps         # optional, default step-<n>; tags its log lines
+        command: go mod download
+      - name: test
+        command: go test ./...
+        env: {GOFLAGS: -count=1}   # over the job's env, for this step only
+        continueOnError: true      # a failure here does not stop the job
+      - name: package
+        command: [make, dist]
+
+A failing step skips the rest unless it has ``continueOnError``.  The
+``timeout`` covers all steps together.
+
+``env`` applies to the command only.  A name that is also in ``secrets``
+takes the secret's value; the result lists such names in ``env_overridden``.
+``envFile`` (see :mod:`orion.security.jobs.envfile` for the format) sits
+between the image's own environment and ``env``, so precedence is image
+< ``envFile`` < ``env`` < ``secrets``.  A bare string is taken as ``path``.
+
+``mounts`` targets may not overlap each other, ``/workspace`` or the
+AEGIS config at ``/etc/orion``.  ``readOnly`` mounts are read-only in the
+runtime itself (``:ro``), for root in the container too.
+
+``network`` puts the container on one network for its whole life.
+Without it the command runs with no network and only source clones and
+toolchain downloads go out, through the egress proxy.  ``none`` cuts
+those too: the source must be a ``hostPath`` or an archive (or baked
+into the image),
+toolchains must be cached and the build caches are used offline.  A
+named network must be listed in the operator's ``networks.allowed``.
+
+``requires`` is checked against the agent's ``agent.labels``; one that
+does not satisfy every selector refuses the job (see
+:mod:`orion.security.jobs.capabilities`).
+
+``report: gotest`` (``stack: go`` only) adds ``-json`` to the command's
+``go test`` runs and reports each test's outcome on the result (see
+:mod:`orion.security.jobs.gotest`); the log still reads as plain text.
+
+``callbackUrl`` receives the job's outcome once it ends, however it ends
+(see :mod:`orion.security.jobs.callbacks`).
+
+``stack`` must match the ``orion.stack`` LABEL of a Dockerfile in
+``docker/stacks/``.  Resolution never falls back to ``base`` -- a job
+asking for an unknown stack fails with a clear error instead of silently
+running in the wrong image.
+
+``image`` runs the job in that image as given, bypassing the stack
+catalog; it wins over ``stack``, which may then be left out and
+otherwise still selects the build caches and ``toolchain``.  Limits,
+mounts, ``runAs`` and every other setting apply unchanged.  The image must
+match one of the operator's ``images.allowedPrefixes``.
+"""
+
+from __future__ import annotations
+
+import logging
+import posixpath
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+from urllib.parse import urlsplit
+
+import yaml
+
+from orion.security.jobs.artifacts import validate_patterns
+from orion.security.jobs.capabilities import parse_selector
+from orion.security.jobs.config import parse_duration
+from orion.security.jobs.envfile import ENV_NAME_RE, EnvFileError, parse_env_file
+from orion.security.jobs.gotest import REPORTS
+from orion.security.jobs.secrets import validate_secret_refs
+from orion.security.sandbox_config import parse_memory_bytes
+from orion.security.stack_detector import StackImage, resolve_stack
+
+logger = logging.getLogger("orion.security.jobs.manifest")
+
+_COMMIT_RE = re.compile(r"^[0-9a-fA-F]{7,40}$")
+_UPLOAD_RE = re.compile(r"^[0-9a-f]{32}$")
+_SHA256_RE = re.compile(r"^[0-9a-f]{64}$")
+_RUN_AS_RE = re.compile(r"^(\d{1,10})(?::(\d{1,10}))?$")
+_MAX_ID = 2**31 - 1
+_STEP_NAME_RE = re.compile(r"^[A-Za-z0-9][A-Za-z0-9._-]{0,62}$")
+_NETWORK_RE = re.compile(r"^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$")
+# An image reference: [registry[:port]/]repository[:tag][@digest]
+_IMAGE_RE = re.compile(r"^[A-Za-z0-9][A-Za-z0-9._/:@-]{0,254}$")
+
+NETWORK_NONE = "none"
+NETWORK_BRIDGE = "bridge"
+# Shares the host's network stack, bypassing every isolation the agent sets up
+_HOST_NETWORK = "host"
+
+WORKSPACE = "/workspace"
+# Container paths the agent mounts itself; manifest mounts must stay clear
+_RESERVED_TARGETS = (WORKSPACE, "/etc/orion")
+
+
+@dataclass(frozen=True)
+class ManifestProblem:
+    """One validation problem, located by its dotted field path."""
+
+    path: str  # e.g. 'resources.memory'; '' for the document as a whole
+    message: str
+
+    def __str__(self) -> str:
+        if not self.path:
+            return self.message
+        return f"Manifest field '{self.path}' {self.message}"
+
+    def to_dict(self) -> dict[str, str]:
+        return {"path": self.path, "message": self.message}
+
+
+class ManifestError(ValueError):
+    """Raised when a job manifest is malformed.
+
+    ``problems`` lists every problem found, not just the first.
+    """
+
+    def __init__(self, message: str | list[ManifestProblem], path: str = "") -> None:
+        if isinstance(message, list):
+            self.problems = list(message)
+        else:
+            self.problems = [ManifestProblem(path, message)]
+        super().__init__("; ".join(str(p) for p in self.problems))
+
+
+class _Problems:
+    """Collects ManifestProblems while parsing continues past each one."""
+
+    def __init__(self) -> None:
+        self.items: list[ManifestProblem] = []
+
+    def add(self, path: str, message: str) -> None:
+        self.items.append(ManifestProblem(path, message))
+
+    def raise_if_any(self) -> None:
+        if self.items:
+            raise ManifestError(self.items)
+
+
+@dataclass
+class JobResources:
+    """Per-job container limits.  None means "use the configured default"."""
+
+    cpu: float | None = None  # cores
+    memory: int | None = None  # bytes
+
+    @classmethod
+    def from_dict(cls, data: Any) -> JobResources:
+        problems = _Problems()
+        resources = cls._parse(data, problems)
+        problems.raise_if_any()
+        return resources
+
+    @classmethod
+    def _parse(cls, data: Any, problems: _Problems) -> JobResources:
+        resources = cls()
+        if data is None:
+            return resources
+        if not isinstance(data, dict):
+            problems.add("resources", "must be a mapping")
+            return resources
+
+        cpu = data.get("cpu")
+        if cpu is not None:
+            if isinstance(cpu, bool) or not isinstance(cpu, (int, float, str)):
+                problems.add("resources.cpu", "must be a number of cores")
+            else:
+                try:
+                    value = float(cpu)
+                except ValueError:
+                    problems.add("resources.cpu", "must be a number")
+                else:
+                    if value <= 0:
+                        problems.add("resources.cpu", "must be positive")
+                    else:
+                        resources.cpu = value
+        if data.get("memory") is not None:
+            try:
+                resources.memory = parse_memory_bytes(data["memory"])
+            except ValueError as exc:
+                problems.add("resources.memory", f"is invalid: {exc}")
+        return resources
+
+    def to_dict(self) -> dict[str, Any]:
+        return {"cpu": self.cpu, "memory": self.memory}
+
+
+@dataclass
+class JobSource:
+    """Where the job's source code comes from.  Empty means no source."""
+
+    git: str = ""  # clone URL
+    ref: str = ""  # branch or tag; '' = the remote's HEAD
+    commit: str = ""  # exact commit to check out
+    depth: int | None = None  # shallow clone depth; None = full history
+    host_path: str = ""  # host directory to bind-mount instead of cloning
+    archive: bool = False  # a tar / tar.gz unpacked into the workspace
+    upload: str = ""  # the archive's upload ID; '' until the submission supplies it
+    sha256: str = ""  # the archive's digest, checked before unpacking; '' = unchecked
+    path: str = ""  # subdirectory of /workspace; '' = the workspace root
+
+    @property
+    def kind(self) -> str:
+        """'git', 'bind', 'archive' or '' (no source)."""
+        if self.git:
+            return "git"
+        if self.archive:
+            return "archive"
+        return "bind" if self.host_path else ""
+
+    @property
+    def container_path(self) -> str:
+        return f"/workspace/{self.path}" if self.path else "/workspace"
+
+    @classmethod
+    def _parse(cls, data: Any, problems: _Problems) -> JobSource:
+        source = cls()
+        if data is None:
+            return source
+        if not isinstance(data, dict):
+            problems.add("source", "must be a mapping")
+            return source
+
+        for key in ("type", "git", "ref", "commit", "hostPath", "upload", "sha256", "path"):
+            if not isinstance(data.get(key, ""), str):
+                problems.add(f"source.{key}", "must be a string")
+                return source
+        git = data.get("git", "").strip()
+        host_path = data.get("hostPath", "").strip()
+        kind = data.get("type", "").strip()
+        upload = data.get("upload", "").strip()
+        sha256 = data.get("sha256", "").strip().lower()
+        if kind == "archive":
+            if git or host_path or any(k in data for k in ("ref", "commit", "depth")):
+                problems.add("source", "an archive takes only 'upload', 'sha256' and 'path'")
+            if upload and not _UPLOAD_RE.match(upload):
+                problems.add("source.upload", "must be an upload ID (32 hex digits)")
+            if sha256 and not _SHA256_RE.match(sha256):
+                problems.add("source.sha256", "must be a SHA-256 digest (64 hex digits)")
+        else:
+            given = "git" if git else "bind" if host_path else ""
+            if kind not in ("", "git", "bind"):
+                problems.add("source.type", "must be git, bind or archive")
+            elif kind and given and kind != given:
+                problems.add("source.type", f"is {kind!r} but the source is {given!r}")
+            if bool(git) == bool(host_path):
+                problems.add("source", "needs exactly one of 'git' or 'hostPath'")
+            for key in ("upload", "sha256"):
+                if key in data:
+                    problems.add(f"source.{key}", "only applies to 'type: archive'")
+
+        if host_path and not host_path.startswith("/"):
+            problems.add("source.hostPath", "must be an absolute path")
+        if host_path and any(k in data for k in ("ref", "commit", "depth")):
+            problems.add("source", "'ref', 'commit' and 'depth' only apply to 'git'")
+
+        commit = data.get("commit", "").strip()
+        if commit and not _COMMIT_RE.match(commit):
+            problems.add("source.commit", "must be a commit SHA (7-40 hex digits)")
+
+        depth = data.get("depth")
+        if depth is not None and (
+            isinstance(depth, bool) or not isinstance(depth, int) or depth < 1
+        ):
+            problems.add("source.depth", "must be a positive integer")
+            depth = None
+
+        path = data.get("path", "").strip().strip("/")
+        if ".." in path.split("/"):
+            problems.add("source.path", "must not contain '..'")
+
+        source.git = git
+        source.ref = data.get("ref", "").strip()
+        source.commit = commit
+        source.depth = depth
+        source.host_path = host_path.rstrip("/") or host_path
+        source.archive = kind == "archive"
+        source.upload = upload
+        source.sha256 = sha256
+        source.path = path
+        return source
+
+    def to_dict(self) -> dict[str, Any]:
+        if self.kind == "bind":
+            return {"hostPath": self.host_path, "path": self.path}
+        if self.kind == "archive":
+            return {
+                "type": "archive",
+                "upload": self.upload,
+                "sha256": self.sha256,
+                "path": self.path,
+            }
+        if self.kind == "git":
+            return {
+                "git": self.git,
+                "ref": self.ref,
+                "commit": self.commit,
+                "depth": self.depth,
+                "path": self.path,
+            }
+        return {}
+
+
+@dataclass
+class JobEnvFile:
+    """Variables for the command from a host file or inline ``KEY=VALUE`` lines."""
+
+    path: str = ""  # absolute host path, read when the job runs
+    content: str = ""  # inline lines when there is no path
+    sensitive: list[str] = field(default_factory=list)  # names whose values are redacted
+
+    @classmethod
+    def _parse(cls, data: Any, problems: _Problems) -> JobEnvFile | None:
+        if data is None:
+            return None
+        if isinstance(data, str):
+            data = {"path": data}
+        if not isinstance(data, dict):
+            problems.add("envFile", "must be a host path or a mapping with 'path' or 'content'")
+            return None
+
+        path, content = data.get("path", ""), data.get("content", "")
+        if not isinstance(path, str) or not isinstance(content, str):
+            problems.add("envFile", "'path' and 'content' must be strings")
+            return None
+        path = path.strip()
+        if bool(path) == bool("content" in data):
+            problems.add("envFile", "needs exactly one of 'path' or 'content'")
+            return None
+        if path and not path.startswith("/"):
+            problems.add("envFile.path", "must be an absolute path")
+        if not path:
+            try:
+                parse_env_file(content)
+            except EnvFileError as exc:
+                problems.add("envFile.content", f"is invalid: {exc}")
+
+        sensitive = data.get("sensitive") or []
+        if not isinstance(sensitive, list) or not all(
+            isinstance(name, str) and ENV_NAME_RE.match(name) for name in sensitive
+        ):
+            problems.add("envFile.sensitive", "must be a list of environment variable names")
+            sensitive = []
+        return cls(path=path, content=content if not path else "", sensitive=list(sensitive))
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "path": self.path or None,
+            "content": None if self.path else self.content,
+            "sensitive": list(self.sensitive),
+        }
+
+
+@dataclass
+class JobMount:
+    """An extra host path bind-mounted into the job's container."""
+
+    source: str  # absolute host path
+    target: str  # absolute container path
+    read_only: bool = False
+
+    def to_dict(self) -> dict[str, Any]:
+        return {"source": self.source, "target": self.target, "readOnly": self.read_only}
+
+
+def paths_overlap(a: str, b: str) -> bool:
+    """Whether one absolute path is, or is inside, the other."""
+    a, b = a.rstrip("/") + "/", b.rstrip("/") + "/"
+    return a.startswith(b) or b.startswith(a)
+
+
+def _parse_mounts(value: Any, problems: _Problems) -> list[JobMount]:
+    if value is None:
+        return []
+    if not isinstance(value, list):
+        problems.add("mounts", "must be a list of {source, target, readOnly}")
+        return []
+    mounts: list[JobMount] = []
+    for i, entry in enumerate(value):
+        where = f"mounts[{i}]"
+        if not isinstance(entry, dict):
+            problems.add(where, "must be a mapping with 'source' and 'target'")
+            continue
+        paths = {}
+        for key in ("source", "target"):
+            path = entry.get(key)
+            if not isinstance(path, str) or not path.strip():
+                problems.add(f"{where}.{key}", "is required")
+            elif not path.strip().startswith("/"):
+                problems.add(f"{where}.{key}", "must be an absolute path")
+            elif ".." in path.split("/"):
+                problems.add(f"{where}.{key}", "must not contain '..'")
+            elif ":" in path or "," in path:
+                # Both separate fields of the runtime's -v spec
+                problems.add(f"{where}.{key}", "must not contain ':' or ','")
+            else:
+                paths[key] = posixpath.normpath(path.strip())
+        read_only = entry.get("readOnly", False)
+        if not isinstance(read_only, bool):
+            problems.add(f"{where}.readOnly", "must be true or false")
+        if len(paths) < 2:
+            continue
+
+        target = paths["target"]
+        reserved = [r for r in _RESERVED_TARGETS if paths_overlap(target, r)]
+        if reserved:
+            problems.add(f"{where}.target", f"must not overlap {reserved[0]}")
+            continue
+        clash = [m for m in mounts if paths_overlap(target, m.target)]
+        if clash:
+            problems.add(f"{where}.target", f"overlaps the mount at {clash[0].target}")
+            continue
+        mounts.append(JobMount(paths["source"], target, read_only is True))
+    return mounts
+
+
+@dataclass
+class JobStep:
+    """One command of a multi-step job; every step shares the container."""
+
+    name: str  # '' only for the implicit step of a plain ``command``
+    command: str | list[str]  # a list is argv, run without a shell
+    env: dict[str, str] = field(default_factory=dict)  # over the job's ``env``
+    continue_on_error: bool = False  # a failure does not stop later steps
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "name": self.name,
+            "command": list(self.command) if isinstance(self.command, list) else self.command,
+            "env": dict(self.env),
+            "continueOnError": self.continue_on_error,
+        }
+
+
+def _parse_command(value: Any, path: str, problems: _Problems) -> str | list[str]:
+    if isinstance(value, list):
+        if not value or not all(isinstance(token, str) for token in value):
+            problems.add(path, "must be a non-empty list of strings when given as argv")
+            return ""
+        return list(value)
+    if not isinstance(value, str):
+        problems.add(path, "must be a shell string or a list of argv strings")
+        return ""
+    return value
+
+
+def _parse_env(value: Any, path: str, problems: _Problems) -> dict[str, str]:
+    env = value or {}
+    if not isinstance(env, dict) or not all(
+        isinstance(k, str) and isinstance(v, str) for k, v in env.items()
+    ):
+        problems.add(path, "must map environment variable names to strings (quote numbers)")
+        return {}
+    for env_name in env:
+        if not ENV_NAME_RE.match(env_name):
+            problems.add(f"{path}.{env_name}", "is not a valid environment variable name")
+    return dict(env)
+
+
+def _parse_steps(value: Any, problems: _Problems) -> list[JobStep]:
+    if value is None:
+        return []
+    if not isinstance(value, list):
+        problems.add("steps", "must be a list of steps")
+        return []
+    steps: list[JobStep] = []
+    for index, item in enumerate(value):
+        path = f"steps[{index}]"
+        if not isinstance(item, dict):
+            problems.add(path, "must be a mapping with a 'command'")
+            continue
+        name = item.get("name", f"step-{index + 1}")
+        if not isinstance(name, str) or not _STEP_NAME_RE.match(name):
+            problems.add(f"{path}.name", "must be letters, digits, '.', '_' or '-'")
+            name = f"step-{index + 1}"
+        elif name in {step.name for step in steps}:
+            problems.add(f"{path}.name", f"repeats step '{name}'")
+        command: str | list[str] = ""
+        if item.get("command") in (None, ""):
+            problems.add(f"{path}.command", "is required")
+        else:
+            command = _parse_command(item["command"], f"{path}.command", problems)
+        env = _parse_env(item.get("env"), f"{path}.env", problems)
+        continue_on_error = item.get("continueOnError", False)
+        if not isinstance(continue_on_error, bool):
+            problems.add(f"{path}.continueOnError", "must be true or false")
+            continue_on_error = False
+        steps.append(JobStep(name, command, env, continue_on_error))
+    return steps
+
+
+@dataclass
+class JobManifest:
+    """A parsed job manifest."""
+
+    stack: str
+    command: str | list[str] = ""  # a list is argv, run without a shell
+    steps: list[JobStep] = field(default_factory=list)  # instead of ``command``
+    workdir: str = WORKSPACE  # absolute, at or under /workspace
+    env: dict[str, str] = field(default_factory=dict)  # for the command
+    env_file: JobEnvFile | None = None  # under ``env``; None = no env file
+    toolchain: str = ""  # empty = use the version baked into the image
+    resources: JobResources = field(default_factory=JobResources)
+    timeout: float | None = None  # seconds; None = configured default
+    secrets: dict[str, str] = field(default_factory=dict)  # env var -> secret name
+    artifacts: list[str] = field(default_factory=list)  # globs relative to /workspace
+    source: JobSource = field(default_factory=JobSource)
+    run_as: str = ""  # 'uid:gid'; empty = the image's user
+    callback_url: str = ""  # POSTed the outcome when the job ends; empty = none
+    mounts: list[JobMount] = field(default_factory=list)  # extra host bind mounts
+    network: str = ""  # none, bridge or a named network; empty = phased networking
+    image: str = ""  # runs instead of the stack's image; empty = the stack's
+    requires: list[str] = field(default_factory=list)  # 'key=value' / 'key' label selectors
+    report: str = ""  # 'gotest' or '' for none
+    # Top-level keys the document set, for merging (see repo_defaults)
+    explicit: frozenset[str] = field(default=frozenset(), compare=False, repr=False)
+
+    def __post_init__(self) -> None:
+        if self.run_as and ":" not in self.run_as:
+            self.run_as = f"{self.run_as}:{self.run_as}"
+
+    @property
+    def plan(self) -> list[JobStep]:
+        """What runs, in order: :attr:`steps`, or ``command`` as one unnamed step."""
+        return list(self.steps) or [JobStep(name="", command=self.command)]
+
+    @property
+    def run_as_ids(self) -> tuple[int, int] | None:
+        """``(uid, gid)`` from :attr:`run_as`, or None for the image's user."""
+        if not self.run_as:
+            return None
+        uid, _, gid = self.run_as.partition(":")
+        return int(uid), int(gid)
+
+    @classmethod
+    def from_dict(cls, data: Any) -> JobManifest:
+        """Build a manifest from a decoded YAML/JSON mapping.
+
+        Every field is checked even after a problem is found, so the
+        error lists all of them.
+
+        Raises:
+            ManifestError: If required fields are missing or mistyped.
+        """
+        if not isinstance(data, dict):
+            raise ManifestError("Manifest must be a mapping")
+        problems = _Problems()
+
+        stack = data.get("stack")
+        image = _parse_image(data.get("image"), problems)
+        if stack in (None, "") and (image or _has_git_source(data)):
+            stack = ""  # none, or from the repository's .orion-agent.yaml
+        elif not isinstance(stack, str) or not stack.strip():
+            problems.add("stack", "is required")
+            stack = ""
+
+        command = _parse_command(data.get("command", ""), "command", problems)
+        steps = _parse_steps(data.get("steps"), problems)
+        if steps and command:
+            problems.add("steps", "cannot be combined with 'command'; make it a step")
+
+        workdir = _parse_workdir(data.get("workdir"), problems)
+        env = _parse_env(data.get("env"), "env", problems)
+
+        # Versions must be quoted: YAML reads ``1.20`` as the float 1.2.
+        toolchain = data.get("toolchain", "")
+        if not isinstance(toolchain, str):
+            problems.add("toolchain", "must be a quoted string")
+            toolchain = ""
+
+        timeout = data.get("timeout")
+        if timeout is not None:
+            try:
+                timeout = parse_duration(timeout)
+            except ValueError as exc:
+                problems.add("timeout", f"is invalid: {exc}")
+                timeout = None
+
+        secrets = data.get("secrets") or {}
+        if not isinstance(secrets, dict) or not all(
+            isinstance(k, str) and isinstance(v, str) for k, v in secrets.items()
+        ):
+            problems.add("secrets", "must map environment variable names to secret names")
+            secrets = {}
+        for env_name, secret_name in secrets.items():
+            for problem in validate_secret_refs({env_name: secret_name}):
+                problems.add(f"secrets.{env_name}", f"is invalid: {problem}")
+
+        artifacts = data.get("artifacts") or []
+        if isinstance(artifacts, str):
+            artifacts = [artifacts]
+        if not isinstance(artifacts, list) or not all(isinstance(a, str) for a in artifacts):
+            problems.add("artifacts", "must be a list of glob patterns")
+            artifacts = []
+        for problem in validate_patterns(artifacts):
+            problems.add("artifacts", f"is invalid: {problem}")
+
+        run_as = data.get("runAs", "")
+        if run_as is None:
+            run_as = ""
+        if not isinstance(run_as, str):
+            problems.add("runAs", "must be a quoted string 'uid[:gid]'")
+            run_as = ""
+        run_as = run_as.strip()
+        if run_as:
+            match = _RUN_AS_RE.match(run_as)
+            if not match or any(int(i) > _MAX_ID for i in match.groups() if i is not None):
+                problems.add("runAs", "must be a numeric 'uid' or 'uid:gid'")
+                run_as = ""
+
+        callback_url = data.get("callbackUrl") or ""
+        if not isinstance(callback_url, str):
+            problems.add("callbackUrl", "must be a URL string")
+            callback_url = ""
+        callback_url = callback_url.strip()
+        if callback_url:
+            url = urlsplit(callback_url)
+            if url.scheme not in ("http", "https") or not url.hostname:
+                problems.add("callbackUrl", "must be an http:// or https:// URL")
+                callback_url = ""
+
+        resources = JobResources._parse(data.get("resources"), problems)
+        source = JobSource._parse(data.get("source"), problems)
+        mounts = _parse_mounts(data.get("mounts"), problems)
+        env_file = JobEnvFile._parse(data.get("envFile"), problems)
+        network = _parse_network(data.get("network"), problems)
+        requires = _parse_requires(data.get("requires"), problems)
+        report = _parse_report(data.get("report"), stack, problems)
+        if network == NETWORK_NONE and source.git:
+            problems.add("source.git", "cannot be cloned with network 'none'; use 'hostPath'")
+        problems.raise_if_any()
+
+        return cls(
+            stack=stack.strip(),
+            command=command,
+            steps=steps,
+            workdir=workdir,
+            env=dict(env),
+            env_file=env_file,
+            toolchain=toolchain.strip(),
+            resources=resources,
+            timeout=timeout,
+            secrets=dict(secrets),
+            artifacts=[a.strip() for a in artifacts],
+            source=source,
+            run_as=run_as,
+            callback_url=callback_url,
+            mounts=mounts,
+            network=network,
+            image=image,
+            requires=requires,
+            report=report,
+            explicit=frozenset(key for key, value in data.items() if value is not None),
+        )
+
+    def given(self) -> dict[str, Any]:
+        """:meth:`to_dict` limited to the keys the submitter set; the rest are defaults.
+
+        Keys the parsed document had count as set, and so does any field of
+        a manifest built in code that differs from its default.
+        """
+        data = self.to_dict()
+        defaults = JobManifest(stack="").to_dict()
+        return {k: v for k, v in data.items() if k in self.explicit or v != defaults[k]}
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "stack": self.stack,
+            "command": list(self.command) if isinstance(self.command, list) else self.command,
+            "steps": [step.to_dict() for step in self.steps],
+            "workdir": self.workdir,
+            "env": dict(self.env),
+            "envFile": self.env_file.to_dict() if self.env_file else None,
+            "toolchain": self.toolchain,
+            "resources": self.resources.to_dict(),
+            "timeout": self.timeout,
+            "secrets": dict(self.secrets),
+            "artifacts": list(self.artifacts),
+            "source": self.source.to_dict() or None,
+            "runAs": self.run_as or None,
+            "callbackUrl": self.callback_url or None,
+            "mounts": [mount.to_dict() for mount in self.mounts],
+            "network": self.network or None,
+            "image": self.image or None,
+            "requires": list(self.requires),
+            "report": self.report or None,
+        }
+
+    def summary(self) -> dict[str, Any]:
+        """:meth:`to_dict` without values that may be sensitive.
+
+        ``env`` and ``envFile`` keep only variable names, and URLs lose
+        their credentials and query string.
+        """
+        data = self.to_dict()
+        data["env"] = sorted(self.env)
+        for step in data["steps"]:
+            step["env"] = sorted(step["env"])
+        if self.env_file is not None:
+            data["envFile"] = {
+                "path": self.env_file.path or None,
+                "inline": not self.env_file.path,
+                "sensitive": list(self.env_file.sensitive),
+            }
+        if self.source.git:
+            data["source"]["git"] = display_url(self.source.git)
+        data["callbackUrl"] = display_url(self.callback_url) or None
+        return data
+
+
+def display_url(url: str) -> str:
+    """``url`` without credentials or query, which may carry tokens."""
+    parts = urlsplit(url)
+    if not parts.netloc:
+        return url
+    host = parts.hostname or ""
+    if parts.port:
+        host += f":{parts.port}"
+    return f"{parts.scheme}://{host}{parts.path}"
+
+
+def _has_git_source(data: dict) -> bool:
+    source = data.get("source")
+    return isinstance(source, dict) and bool(source.get("git"))
+
+
+def _parse_network(value: Any, problems: _Problems) -> str:
+    """``network`` as an engine network name; '' when unset."""
+    if value is None or value == "":
+        return ""
+    if not isinstance(value, str) or not _NETWORK_RE.match(value.strip()):
+        problems.add("network", "must be 'none', 'bridge' or a network name")
+        return ""
+    network = value.strip()
+    if network == _HOST_NETWORK:
+        problems.add("network", "'host' is not allowed; use 'bridge' or a named network")
+        return ""
+    return network
+
+
+def _parse_requires(value: Any, problems: _Problems) -> list[str]:
+    """``requires`` as normalized 'key=value' / 'key' selectors."""
+    if value is None:
+        return []
+    if isinstance(value, str):
+        value = [value]
+    if not isinstance(value, list) or not all(isinstance(item, str) for item in value):
+        problems.add("requires", "must be a list of label selectors like 'gpu=true'")
+        return []
+    requires = []
+    for i, text in enumerate(value):
+        try:
+            key, label = parse_selector(text)
+        except ValueError as exc:
+            problems.add(f"requires[{i}]", f"is invalid: {exc}")
+            continue
+        requires.append(key if label is None else f"{key}={label}")
+    return requires
+
+
+def _parse_report(value: Any, stack: str, problems: _Problems) -> str:
+    """``report`` as one of :data:`REPORTS`; '' when unset."""
+    if value is None or value == "":
+        return ""
+    if not isinstance(value, str) or value.strip() not in REPORTS:
+        problems.add("report", f"must be one of: {', '.join(REPORTS)}")
+        return ""
+    if stack.strip() and stack.strip() != "go":
+        problems.add("report", f"'{value.strip()}' needs stack 'go', not '{stack.strip()}'")
+        return ""
+    return value.strip()
+
+
+def _parse_image(value: Any, problems: _Problems) -> str:
+    """``image`` as an image reference; '' when unset."""
+    if value is None or value == "":
+        return ""
+    if not isinstance(value, str) or not _IMAGE_RE.match(value.strip()):
+        problems.add("image", "must be an image reference like 'registry/name:tag'")
+        return ""
+    return value.strip()
+
+
+def _parse_workdir(value: Any, problems: _Problems) -> str:
+    """``workdir`` as an absolute path; relative ones are under /workspace."""
+    if value is None:
+        return WORKSPACE
+    if not isinstance(value, str):
+        problems.add("workdir", "must be a string")
+        return WORKSPACE
+    path = value.strip()
+    if ".." in path.split("/"):
+        problems.add("workdir", "must not contain '..'")
+        return WORKSPACE
+    if not path.startswith("/"):
+        path = f"{WORKSPACE}/{path}"
+    path = posixpath.normpath(path)
+    if path != WORKSPACE and not path.startswith(WORKSPACE + "/"):
+        problems.add("workdir", f"must be under {WORKSPACE}")
+        return WORKSPACE
+    return path
+
+
+def parse_manifest(text: str) -> JobManifest:
+    """Parse a manifest from YAML (JSON is valid YAML).
+
+    Raises:
+        ManifestError: If the text is not valid YAML or fails validation.
+    """
+    try:
+        data = yaml.safe_load(text)
+    except yaml.YAMLError as exc:
+        raise ManifestError(f"Invalid manifest YAML: {exc}") from exc
+    return JobManifest.from_dict(data)
+
+
+def load_manifest(path: Path | str) -> JobManifest:
+    """Load and parse a manifest file."""
+    return parse_manifest(Path(path).read_text(encoding="utf-8"))
+
+
+def resolve_image(
+    manifest: JobManifest,
+    stacks_dir: Path | str | None = None,
+) -> StackImage:
+    """Resolve the stack image a manifest will run in.
+
+    Raises:
+        StackResolutionError: If no stack Dockerfile carries a matching
+            ``orion.stack`` LABEL.
+    """
+    image = resolve_stack(manifest.stack, stacks_dir)
+    logger.debug("Manifest stack %r resolved to %s", manifest.stack, image.image)
+    return image
@@ -0,0 +1,142 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job lifecycle metrics in Prometheus exposition format.
+
+Exported series::
+
+    orion_jobs_started_total{stack}                 counter
+    orion_jobs_finished_total{stack,exit_reason}    counter
+    orion_job_duration_seconds{stack}               histogram
+    orion_image_pull_duration_seconds{stack}        histogram
+    orion_jobs_running                              gauge
+    orion_jobs_queued                               gauge
+
+``exit_reason`` is ``succeeded`` or the job's ``error_code``
+(``command_failed``, ``timed_out``, ``cancelled``, ``oom_killed``, ...).
+
+Like :class:`orion.core.production.metrics.MetricsCollector`, this renders
+the text format directly rather than depending on a client library.
+"""
+
+from __future__ import annotations
+
+import threading
+from collections import defaultdict
+
+# Job durations range from seconds (lint) to an hour (full builds)
+DURATION_BUCKETS = (1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600)
+PULL_BUCKETS = (1, 5, 10, 30, 60, 120, 300, 600)
+
+
+class _Histogram:
+    def __init__(self, buckets: tuple[float, ...]) -> None:
+        self.buckets = buckets
+        self.counts = [0] * len(buckets)
+        self.total = 0
+        self.sum = 0.0
+
+    def observe(self, value: float) -> None:
+        for i, bound in enumerate(self.buckets):
+            if value <= bound:
+                self.counts[i] += 1
+        self.total += 1
+        self.sum += value
+
+
+def _labels(**labels: str) -> str:
+    inner = ",".join(f'{k}="{_escape(v)}"' for k, v in labels.items())
+    return "{" + inner + "}" if inner else ""
+
+
+def _escape(value: str) -> str:
+    return value.replace("\\", "\\\\").replace('"', '\\"').replace("\n", "\\n")
+
+
+class JobMetrics:
+    """Thread-safe job counters and histograms."""
+
+    def __init__(self) -> None:
+        self._lock = threading.Lock()
+        self._started: dict[str, int] = defaultdict(int)
+        self._finished: dict[tuple[str, str], int] = defaultdict(int)
+        self._durations: dict[str, _Histogram] = {}
+        self._pulls: dict[str, _Histogram] = {}
+
+    def job_started(self, stack: str) -> None:
+        with self._lock:
+            self._started[stack] += 1
+
+    def job_finished(self, stack: str, exit_reason: str, duration_seconds: float | None) -> None:
+        """Record a terminal transition.  ``duration_seconds`` is None for
+        jobs that never started (cancelled while queued)."""
+        with self._lock:
+            self._finished[(stack, exit_reason)] += 1
+            if duration_seconds is not None:
+                hist = self._durations.setdefault(stack, _Histogram(DURATION_BUCKETS))
+                hist.observe(duration_seconds)
+
+    def image_pulled(self, stack: str, duration_seconds: float) -> None:
+        with self._lock:
+            hist = self._pulls.setdefault(stack, _Histogram(PULL_BUCKETS))
+            hist.observe(duration_seconds)
+
+    def to_prometheus(self, running: int = 0, queued: int = 0) -> str:
+        """Render all series; gauges are supplied by the scheduler."""
+        with self._lock:
+            lines = [
+                "# HELP orion_jobs_started_total Jobs that started running",
+                "# TYPE orion_jobs_started_total counter",
+            ]
+            for stack, count in sorted(self._started.items()):
+                lines.append(f"orion_jobs_started_total{_labels(stack=stack)} {count}")
+
+            lines += [
+                "# HELP orion_jobs_finished_total Jobs that reached a terminal state",
+                "# TYPE orion_jobs_finished_total counter",
+            ]
+            for (stack, reason), count in sorted(self._finished.items()):
+                labels = _labels(stack=stack, exit_reason=reason)
+                lines.append(f"orion_jobs_finished_total{labels} {count}")
+
+            lines += self._render_histograms(
+                "orion_job_duration_seconds", "Job wall-clock duration", self._durations
+            )
+            lines += self._render_histograms(
+                "orion_image_pull_duration_seconds", "Stack image pull duration", self._pulls
+            )
+
+        lines += [
+            "# HELP orion_jobs_running Jobs currently running",
+            "# TYPE orion_jobs_running gauge",
+            f"orion_jobs_running {running}",
+            "# HELP orion_jobs_queued Jobs waiting for a slot",
+            "# TYPE orion_jobs_queued gauge",
+            f"orion_jobs_queued {queued}",
+        ]
+        return "\n".join(lines) + "\n"
+
+    @staticmethod
+    def _render_histograms(name: str, help_text: str, hists: dict[str, _Histogram]) -> list[str]:
+        lines = [f"# HELP {name} {help_text}", f"# TYPE {name} histogram"]
+        for stack, hist in sorted(hists.items()):
+            for bound, count in zip(hist.buckets, hist.counts):
+                labels = _labels(stack=stack, le=f"{bound:g}")
+                lines.append(f"{name}_bucket{labels} {count}")
+            lines.append(f"{name}_bucket{_labels(stack=stack, le='+Inf')} {hist.total}")
+            lines.append(f"{name}_sum{_labels(stack=stack)} {hist.sum:g}")
+            lines.append(f"{name}_count{_labels(stack=stack)} {hist.total}")
+        return lines
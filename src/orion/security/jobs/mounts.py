@@ -0,0 +1,83 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Extra host bind mounts requested by a manifest's ``mounts``.
+
+The manifest already guarantees absolute, non-overlapping targets clear of
+``/workspace`` and ``/etc/orion``.  What only the host can decide is
+checked here, right before the container is created:
+
+  - the source, with symlinks resolved, is inside ``mounts.allowedHostPaths``
+    in jobs_config.yaml (none by default) and exists
+  - the target stays clear of the agent's own mounts for the job (build
+    caches, the toolchain cache)
+
+``readOnly`` becomes the runtime's ``:ro`` mode, so writes fail with
+EROFS in the kernel rather than relying on the job to behave.
+"""
+
+from __future__ import annotations
+
+import os
+
+from orion.security.jobs.cache import CACHE_MOUNTS
+from orion.security.jobs.manifest import JobMount, paths_overlap
+from orion.security.jobs.source import allowed_real_path
+from orion.security.jobs.toolchains import TOOLCHAINS_DIR
+
+
+class MountError(ValueError):
+    """A manifest mount the host refuses.  ``field`` locates it in the manifest."""
+
+    def __init__(self, message: str, field: str) -> None:
+        super().__init__(message)
+        self.field = field
+
+
+def agent_targets(stack: str) -> list[str]:
+    """Container paths the agent may mount itself for a ``stack`` job."""
+    return [TOOLCHAINS_DIR, *CACHE_MOUNTS.get(stack, {}).values()]
+
+
+def resolve_mount(mount: JobMount, index: int, allowed: list[str], reserved: list[str]) -> str:
+    """Return the runtime ``-v`` spec for ``mount``.
+
+    Raises:
+        MountError: If the source is not allowed or missing, or the target
+            overlaps one of ``reserved``.
+    """
+    for target in reserved:
+        if paths_overlap(mount.target, target):
+            raise MountError(
+                f"Mount target {mount.target} overlaps the agent's mount at {target}",
+                f"mounts[{index}].target",
+            )
+    real = allowed_real_path(mount.source, allowed)
+    if real is None:
+        raise MountError(
+            f"Mount source {mount.source} is not under an allowed directory "
+            "(mounts.allowedHostPaths in jobs_config.yaml)",
+            f"mounts[{index}].source",
+        )
+    if not os.path.exists(real):
+        raise MountError(f"Mount source {mount.source} does not exist", f"mounts[{index}].source")
+    return f"{real}:{mount.target}:{'ro' if mount.read_only else 'rw'}"
+
+
+def resolve_mounts(mounts: list[JobMount], stack: str, allowed: list[str]) -> list[str]:
+    """Return the ``-v`` specs for all of a ``stack`` job's ``mounts``."""
+    reserved = agent_targets(stack)
+    return [resolve_mount(mount, i, allowed, reserved) for i, mount in enumerate(mounts)]
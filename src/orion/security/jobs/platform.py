@@ -0,0 +1,104 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Host architecture detection and per-platform image selection.
+
+Stack images are published as manifest lists (``scripts/build_stacks.sh``),
+so ``orion-stack-go:latest`` normally resolves to the right architecture on
+its own.  Hosts may also carry single-arch variants tagged
+``<image>-<arch>`` (e.g. ``orion-stack-go:latest-arm64``).  Selection order:
+
+  1. ``<image>-<host arch>`` if present locally
+  2. ``<image>`` if present locally and built for the host arch
+  3. ``<image>`` if not present at all (Docker pulls the matching variant)
+
+A local image built only for another architecture is an error rather than
+a slow, emulated (or crashing) job.
+"""
+
+from __future__ import annotations
+
+import logging
+import platform
+import subprocess
+
+from orion.security.container_runtime import ContainerRuntime, DockerRuntime, RegistryLogin
+from orion.security.stack_detector import StackResolutionError
+
+logger = logging.getLogger("orion.security.jobs.platform")
+
+# platform.machine() -> Docker / GOARCH architecture names
+_ARCH_ALIASES = {
+    "x86_64": "amd64",
+    "amd64": "amd64",
+    "aarch64": "arm64",
+    "arm64": "arm64",
+    "armv8l": "arm64",
+}
+
+
+def host_arch() -> str:
+    """Return the host CPU architecture using Docker's naming (amd64, arm64)."""
+    machine = platform.machine().lower()
+    return _ARCH_ALIASES.get(machine, machine)
+
+
+def variant_tag(image: str, arch: str) -> str:
+    """Return the single-arch variant tag of ``image`` for ``arch``."""
+    return f"{image}-{arch}"
+
+
+async def image_arch(image: str, runtime: ContainerRuntime | None = None) -> str | None:
+    """Return the architecture of a local image, or None if not present."""
+    return await (runtime or DockerRuntime()).image_arch(image)
+
+
+async def image_digest(image: str, runtime: ContainerRuntime | None = None) -> str | None:
+    """Return the digest of a local image, or None if not present."""
+    return await (runtime or DockerRuntime()).image_digest(image)
+
+
+async def select_image(image: str, arch: str | None = None, inspect=image_arch) -> str:
+    """Pick the image tag to run for the host architecture.
+
+    Raises:
+        StackResolutionError: If the only local image is built for a
+            different architecture.
+    """
+    arch = arch or host_arch()
+
+    variant = variant_tag(image, arch)
+    if await inspect(variant) is not None:
+        return variant
+
+    local_arch = await inspect(image)
+    if local_arch is None or local_arch == arch:
+        return image
+
+    raise StackResolutionError(
+        f"Image {image} is built for {local_arch} but this host is {arch}; "
+        f"rebuild it with scripts/build_stacks.sh or provide {variant}"
+    )
+
+
+async def pull_image(
+    image: str,
+    runtime: ContainerRuntime | None = None,
+    timeout: int = 600,
+    login: RegistryLogin | None = None,
+) -> subprocess.CompletedProcess:
+    """Pull ``image`` for the host platform; see :meth:`ContainerRuntime.pull`."""
+    return await (runtime or DockerRuntime()).pull(image, timeout=timeout, login=login)
@@ -0,0 +1,219 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Warm pool -- idle, already started job containers reused by later jobs.
+
+A container is only ever reused by a job that would have created an
+identical one: same stack image (by digest), mounts, resource limits,
+no secrets (they are the container's own environment) and no ``runAs``
+(it changes the container's user and the ownership of its mounts).  The
+executor folds all of that into the pool key; any other job runs in a
+fresh container as before.
+
+Containers enter the pool only when the pool starts them itself, right
+after a cold job of the same key, so their pristine filesystem state is
+known.  After each job a container is reset before anyone else can take
+it (:meth:`SessionContainer.reset`): every process is killed, /workspace
+and the temp dirs are emptied and the container's filesystem changes
+are checked against that pristine state.  A container that cannot be
+proven clean is stopped, never reused.
+
+``pool.stacks.<stack>`` sets how many idle containers are kept per
+stack; one idle for longer than ``pool.maxIdle`` is stopped.
+:meth:`WarmPool.drain` waits for pending starts and resets, then stops
+every idle container.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import logging
+import time
+from collections.abc import Awaitable, Callable
+from dataclasses import dataclass
+
+from orion.security.jobs.config import PoolConfig
+from orion.security.session_container import SessionContainer
+
+logger = logging.getLogger("orion.security.jobs.pool")
+
+
+@dataclass
+class WarmContainer:
+    """A started container owned by the pool between jobs."""
+
+    container: SessionContainer
+    stack: str
+    key: str
+    baseline: set[str]  # layer_changes() right after start
+    release: Callable[[], None] = lambda: None  # frees what the container holds (cache lease)
+    idle_since: float = 0.0
+    jobs: int = 0  # jobs handed this container so far
+
+
+class WarmPool:
+    """Idle containers per stack, handed to jobs with a matching key."""
+
+    def __init__(
+        self,
+        config: PoolConfig,
+        clock: Callable[[], float] = time.monotonic,
+        discard: Callable[[SessionContainer], Awaitable[object]] | None = None,
+    ) -> None:
+        self.config = config
+        self._clock = clock
+        # Stops and removes a container the pool is done with
+        self._discard = discard or (lambda container: container.stop())
+        self._idle: list[WarmContainer] = []
+        # stack -> containers being started / running a job; with the idle
+        # ones they make up the containers the pool owns
+        self._starting: dict[str, int] = {}
+        self._busy: dict[str, int] = {}
+        self._tasks: set[asyncio.Task] = set()
+        self._reaper: asyncio.Task | None = None
+        self._closed = False
+
+    def enabled(self, stack: str) -> bool:
+        return not self._closed and self.config.size_for(stack) > 0
+
+    def idle(self, stack: str | None = None) -> int:
+        """Idle containers, for ``stack`` or in total."""
+        return sum(1 for warm in self._idle if stack is None or warm.stack == stack)
+
+    def take(self, key: str) -> WarmContainer | None:
+        """Hand out an idle container with ``key``, or None if there is none.
+
+        The container stays the pool's: pass it to :meth:`give_back` after the job.
+        """
+        self._expire()
+        for warm in self._idle:
+            if warm.key == key:
+                self._idle.remove(warm)
+                self._busy[warm.stack] = self._busy.get(warm.stack, 0) + 1
+                warm.jobs += 1
+                return warm
+        return None
+
+    def fill(
+        self, stack: str, key: str, start: Callable[[], Awaitable[WarmContainer | None]]
+    ) -> None:
+        """Start containers in the background until the pool owns
+        ``pool.stacks.<stack>`` of them (idle or running a job).
+
+        Idle containers of the stack with another key are stale (the image
+        or the limits changed) and make room for this one.
+        """
+        if not self.enabled(stack):
+            return
+        for warm in [w for w in self._idle if w.stack == stack and w.key != key]:
+            self._idle.remove(warm)
+            self._spawn(self._stop(warm, "superseded"))
+        missing = self.config.size_for(stack) - self._owned(stack)
+        for _ in range(max(missing, 0)):
+            self._starting[stack] = self._starting.get(stack, 0) + 1
+            self._spawn(self._start(stack, start))
+
+    def give_back(self, warm: WarmContainer) -> None:
+        """Reset ``warm`` in the background and keep it if it comes back clean."""
+        self._spawn(self._reset(warm))
+
+    async def settle(self) -> None:
+        """Wait for the starts and resets in flight, keeping the pool open."""
+        while self._tasks:
+            await asyncio.gather(*list(self._tasks), return_exceptions=True)
+
+    async def drain(self) -> None:
+        """Stop taking containers back and stop every one the pool holds."""
+        self._closed = True
+        if self._reaper is not None:
+            self._reaper.cancel()
+        # Starts and resets in flight see the pool closed and stop their container
+        await self.settle()
+        idle, self._idle = self._idle, []
+        await asyncio.gather(*(self._stop(warm, "pool drained") for warm in idle))
+
+    # ------------------------------------------------------------------
+    # Internals
+    # ------------------------------------------------------------------
+    def _owned(self, stack: str) -> int:
+        return self.idle(stack) + self._starting.get(stack, 0) + self._busy.get(stack, 0)
+
+    def _spawn(self, coro: Awaitable[None]) -> None:
+        task = asyncio.ensure_future(coro)
+        self._tasks.add(task)
+        task.add_done_callback(self._tasks.discard)
+
+    async def _start(
+        self, stack: str, start: Callable[[], Awaitable[WarmContainer | None]]
+    ) -> None:
+        try:
+            warm = await start()
+        except Exception as exc:
+            logger.warning("Failed to start a warm %s container: %s", stack, exc)
+            warm = None
+        finally:
+            self._starting[stack] -= 1
+        if warm is not None:
+            await self._park(warm)
+
+    async def _reset(self, warm: WarmContainer) -> None:
+        try:
+            reason = "pool drained" if self._closed else await warm.container.reset(warm.baseline)
+        finally:
+            self._busy[warm.stack] -= 1
+        if reason:
+            await self._stop(warm, reason)
+        else:
+            await self._park(warm)
+
+    async def _park(self, warm: WarmContainer) -> None:
+        if self._closed:
+            await self._stop(warm, "pool drained")
+            return
+        if self._owned(warm.stack) >= self.config.size_for(warm.stack):
+            await self._stop(warm, "pool full")
+            return
+        warm.idle_since = self._clock()
+        self._idle.append(warm)
+        logger.debug("Warm %s container %s idle", warm.stack, warm.container.container_name)
+        if self._reaper is None or self._reaper.done():
+            self._reaper = asyncio.ensure_future(self._reap())
+
+    async def _reap(self) -> None:
+        """Stop containers as they pass ``pool.maxIdle``."""
+        while self._idle:
+            oldest = min(warm.idle_since for warm in self._idle)
+            await asyncio.sleep(max(oldest + self.config.max_idle - self._clock(), 0))
+            self._expire()
+
+    def _expire(self) -> None:
+        now = self._clock()
+        for warm in [w for w in self._idle if now - w.idle_since >= self.config.max_idle]:
+            self._idle.remove(warm)
+            self._spawn(self._stop(warm, "idle timeout"))
+
+    async def _stop(self, warm: WarmContainer, reason: str) -> None:
+        logger.info(
+            "Removing warm %s container %s (%s, %d jobs)",
+            warm.stack,
+            warm.container.container_name,
+            reason,
+            warm.jobs,
+        )
+        try:
+            await self._discard(warm.container)
+        finally:
+            warm.release()
@@ -0,0 +1,108 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Rate limit of job submissions.
+
+A guardrail against clients that flood ``POST /api/jobs``, separate from
+the scheduler: ``scheduler.maxQueued`` bounds the jobs waiting to run,
+while this bounds how fast new ones may be submitted at all.  A refused
+submission never reaches the manifest parser or the queue and gets
+``429`` with ``Retry-After``.
+
+Each client has a token bucket holding up to ``submissions.burst``
+tokens, refilled at ``submissions.ratePerSecond``; a submission takes
+one.  Clients are told apart by the API key they authenticated with
+(hashed so keys are never held or logged) or else by source IP; an
+``Authorization`` header the API did not check counts for nothing.  At
+most :data:`_MAX_BUCKETS` buckets are kept, the least recently used
+dropped first.  With ``perClient: false`` all clients share one bucket.
+
+A config reload (reload.py) applies new limits to the existing buckets.
+"""
+
+from __future__ import annotations
+
+import hashlib
+import math
+import threading
+import time
+from collections import OrderedDict
+from collections.abc import Callable
+from dataclasses import dataclass
+
+from orion.security.jobs.config import SubmissionsConfig
+
+# Beyond this many tracked clients the least recently used bucket is
+# dropped; that client starts again with a full burst
+_MAX_BUCKETS = 10_000
+
+_SHARED = "*"
+
+
+@dataclass
+class _Bucket:
+    tokens: float
+    updated: float
+
+
+def client_key(api_key: str = "", host: str = "") -> str:
+    """The identity a client is limited by: the API key it authenticated with, else its IP."""
+    if api_key:
+        return "key:" + hashlib.sha256(api_key.encode()).hexdigest()[:16]
+    return f"ip:{host or 'unknown'}"
+
+
+class SubmissionLimiter:
+    """Token buckets per client.  Thread-safe."""
+
+    def __init__(
+        self, config: SubmissionsConfig, clock: Callable[[], float] = time.monotonic
+    ) -> None:
+        self.config = config
+        self._clock = clock
+        self._buckets: OrderedDict[str, _Bucket] = OrderedDict()
+        self._lock = threading.Lock()
+
+    @property
+    def enabled(self) -> bool:
+        return self.config.rate_per_second > 0
+
+    def acquire(self, client: str) -> float:
+        """Take a token for ``client``.  Returns 0 if allowed, else the seconds to wait."""
+        if not self.enabled:
+            return 0.0
+        key = client if self.config.per_client else _SHARED
+        rate, burst = self.config.rate_per_second, self.config.burst
+        with self._lock:
+            now = self._clock()
+            bucket = self._buckets.get(key)
+            if bucket is None:
+                while len(self._buckets) >= _MAX_BUCKETS:
+                    self._buckets.popitem(last=False)
+                bucket = self._buckets[key] = _Bucket(float(burst), now)
+            else:
+                bucket.tokens = min(burst, bucket.tokens + (now - bucket.updated) * rate)
+                bucket.updated = now
+                self._buckets.move_to_end(key)
+            if bucket.tokens >= 1:
+                bucket.tokens -= 1
+                return 0.0
+            return (1 - bucket.tokens) / rate
+
+
+def retry_after(wait: float) -> str:
+    """A ``Retry-After`` header value: whole seconds, at least 1."""
+    return str(max(1, math.ceil(wait)))
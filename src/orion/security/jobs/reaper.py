@@ -0,0 +1,132 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Container cleanup -- no job container outlives its job or the agent.
+
+Every container the executor creates is tracked by a
+:class:`ContainerTracker` from the moment it exists until its removal is
+confirmed.  Teardown runs however the job ended, and a container whose
+``stop()`` fails is force-removed.  Whatever is still tracked when the
+executor shuts down is removed then.
+
+That cannot survive a crash, so job containers also carry
+:data:`JOB_LABEL` (the job ID; empty for warm pool containers).  On
+startup :func:`reap_orphans` removes every container with that label: a
+fresh agent owns none yet, so all of them were left behind by an earlier
+run.  Turn it off (``cleanup.reapOnStartup``) when several agents share
+one container engine, since they cannot tell each other's containers
+apart.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import logging
+import subprocess
+
+from orion.security.container_runtime import ContainerRuntime
+from orion.security.session_container import SessionContainer
+
+logger = logging.getLogger("orion.security.jobs.reaper")
+
+JOB_LABEL = "orion.job"
+# Set on warm pool containers as well, to the stack they are kept for
+POOL_LABEL = "orion.pool"
+
+
+def job_labels(job_id: str) -> dict[str, str]:
+    """Labels of a container created for ``job_id``."""
+    return {JOB_LABEL: job_id}
+
+
+def pool_labels(stack: str) -> dict[str, str]:
+    """Labels of a warm pool container; it is not any one job's."""
+    return {JOB_LABEL: "", POOL_LABEL: stack}
+
+
+class ContainerTracker:
+    """The executor's containers that have not yet been confirmed removed."""
+
+    def __init__(self) -> None:
+        self._live: dict[str, SessionContainer] = {}
+
+    def __len__(self) -> int:
+        return len(self._live)
+
+    def __contains__(self, container_name: str) -> bool:
+        return container_name in self._live
+
+    def track(self, container: SessionContainer) -> None:
+        """Start tracking ``container``; call before it is started."""
+        self._live[container.container_name] = container
+
+    async def discard(self, container: SessionContainer) -> bool:
+        """Stop and remove ``container``, forcing the removal if stopping fails.
+
+        Returns True once it is gone.  Otherwise it stays tracked, for
+        :meth:`discard_all` (or the next startup's reaping) to retry.
+        """
+        name = container.container_name
+        try:
+            gone = await container.stop()
+        except Exception as exc:
+            logger.warning("Failed to stop container %s: %s", name, exc)
+            gone = False
+        if not gone:
+            gone = await container.remove()
+        if gone:
+            self._live.pop(name, None)
+        else:
+            logger.error("Container %s could not be removed; it is still tracked", name)
+        return gone
+
+    async def discard_all(self) -> int:
+        """Remove every container still tracked.  Returns how many were removed."""
+        removed = 0
+        for container in list(self._live.values()):
+            removed += await self.discard(container)
+        return removed
+
+
+async def reap_orphans(runtime: ContainerRuntime, keep: ContainerTracker | None = None) -> int:
+    """Remove every job container left behind by an earlier run.
+
+    Containers ``keep`` tracks are this agent's own and are left alone.
+    Returns how many containers were removed.
+    """
+    try:
+        listed = await runtime.list_containers(JOB_LABEL)
+    except (asyncio.TimeoutError, OSError) as exc:
+        listed = subprocess.CompletedProcess([], -1, "", str(exc) or type(exc).__name__)
+    if listed.returncode != 0:
+        stderr = (listed.stderr or "").strip()[:300]
+        logger.warning("Could not list leftover job containers: %s", stderr or "unknown error")
+        return 0
+    reaped = 0
+    for name in listed.stdout.split():
+        if keep is not None and name in keep:
+            continue
+        try:
+            removed = await runtime.remove(name)
+        except (asyncio.TimeoutError, OSError) as exc:
+            removed = subprocess.CompletedProcess([], -1, "", str(exc) or type(exc).__name__)
+        if removed.returncode == 0:
+            logger.info("Reaped leftover job container %s", name)
+            reaped += 1
+        else:
+            stderr = (removed.stderr or "").strip()[:300]
+            logger.warning("Failed to reap leftover job container %s: %s", name, stderr)
+    return reaped
@@ -0,0 +1,87 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Reload the jobs config of a running agent (on SIGHUP).
+
+The file is read and validated in full first; any error rejects the
+whole of it and the running config stays as it was.  A valid file is
+swapped in at once: the executor's settings (``scheduler.maxConcurrent``,
+``images.pullPolicy``, ``resources.*`` defaults, ...), the submission
+rate limits and the log format and level.  Settings the agent only takes
+in at startup (:data:`~orion.security.jobs.config.RESTART_REQUIRED`,
+such as ``runtime.driver``) keep their values and are logged as
+requiring a restart.
+
+Every reload logs what it changed, so the agent's log records which
+config was in force when.
+"""
+
+from __future__ import annotations
+
+import logging
+from pathlib import Path
+from typing import TYPE_CHECKING
+
+from orion.security.jobs.agent_log import configure_logging
+from orion.security.jobs.config import ConfigChange, ConfigError, read_config
+from orion.security.jobs.ratelimit import SubmissionLimiter
+
+if TYPE_CHECKING:
+    from orion.security.jobs.executor import JobExecutor
+
+logger = logging.getLogger("orion.security.jobs.reload")
+
+
+def reload_config(
+    executor: JobExecutor,
+    path: Path | str | None = None,
+    limiter: SubmissionLimiter | None = None,
+) -> list[ConfigChange] | None:
+    """Re-read the jobs config and apply it to ``executor`` (and ``limiter``).
+
+    Returns the settings that differed, or None if the file was rejected.
+    """
+    try:
+        config = read_config(path)
+    except ConfigError as exc:
+        logger.error("Jobs config reload rejected, keeping the current config: %s", exc)
+        return None
+
+    log_changed = config.log != executor.config.log
+    changes = executor.reload_config(config)
+    if limiter is not None:
+        limiter.config = executor.config.submissions
+    if log_changed:
+        configure_logging(executor.config.log)
+
+    applied = [change for change in changes if change.live]
+    for change in changes:
+        if not change.live:
+            logger.warning(
+                "Jobs config: %s changed to %r but requires restart; still %r",
+                change.key,
+                change.new,
+                change.old,
+            )
+    if applied:
+        logger.info("Jobs config reloaded: %s", "; ".join(_describe(c) for c in applied))
+    else:
+        logger.info("Jobs config reloaded: no live settings changed")
+    return changes
+
+
+def _describe(change: ConfigChange) -> str:
+    return f"{change.key} {change.old!r} -> {change.new!r}"
@@ -0,0 +1,182 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Repository-level manifest defaults (``.orion-agent.yaml``).
+
+A repository can declare how it is built once, at its root::
+
+    stack: go
+    toolchain: "1.22.5"
+    env: {CGO_ENABLED: "0"}
+    steps:
+      - {name: test, command: go test ./...}
+      - {name: build, command: make dist}
+
+When a job's ``source`` is a git repository, the file is read at the
+revision the job checks out -- before the job's container starts, since
+it may name the stack -- and merged under the submitted manifest, which
+wins wherever both set something:
+
+  maps (``env``, ``resources``):  merged key by key
+  scalars (``stack``, ``workdir``, ``toolchain``, ``timeout``):  replaced
+  lists (``artifacts``, ``steps``):  replaced whole, never concatenated
+
+``command`` and ``steps`` are one setting: a manifest with either drops
+both from the file.
+
+The file can only describe the build.  What a job may reach -- secrets,
+mounts, env files, ``runAs``, ``network``, ``image``, ``source``,
+``callbackUrl`` -- stays with whoever submits it, so a repository cannot
+grant itself any of that; such keys fail the job.  So does a malformed file, with its line
+number: a build silently run without its repository's settings is worse
+than one that does not run.
+
+The file is read from a throwaway ``base`` container with a shallow,
+blobless fetch (``git show <rev>:.orion-agent.yaml``), with the same
+network and clone timeout as the real clone.  ``source.repoDefaults:
+false`` in jobs_config.yaml skips it.
+"""
+
+from __future__ import annotations
+
+import logging
+import shlex
+import yaml
+
+from orion.security.jobs.manifest import JobManifest, JobSource, ManifestError, ManifestProblem
+
+logger = logging.getLogger("orion.security.jobs.repo_defaults")
+
+REPO_DEFAULTS_FILE = ".orion-agent.yaml"
+
+# Exit status of lookup_script() when the repository has no defaults file
+DEFAULTS_ABSENT = 3
+
+# What the file may set; everything else belongs to the submitter
+REPO_KEYS = (
+    "stack",
+    "command",
+    "steps",
+    "workdir",
+    "env",
+    "toolchain",
+    "resources",
+    "timeout",
+    "artifacts",
+)
+_MERGED_MAPS = ("env", "resources")
+_COMMAND_KEYS = ("command", "steps")
+
+
+class RepoDefaultsError(ValueError):
+    """Raised when a repository's defaults file is malformed or not allowed."""
+
+
+def lookup_script(source: JobSource) -> str:
+    """Shell script printing the defaults file at ``source``'s revision.
+
+    Exits :data:`DEFAULTS_ABSENT` when the file does not exist there.
+    Fetches one commit without file contents where the server allows it.
+    """
+    if source.commit:
+        target = rev = shlex.quote(source.commit)
+        # Servers may refuse to serve an arbitrary (or abbreviated) SHA
+        fallback = "git fetch -q origin" + (f" {shlex.quote(source.ref)}" if source.ref else "")
+    else:
+        target = shlex.quote(source.ref) if source.ref else "HEAD"
+        rev = "FETCH_HEAD"
+        fallback = f"git fetch -q --depth 1 origin {target}"
+    path = shlex.quote(REPO_DEFAULTS_FILE)
+    return "\n".join(
+        [
+            "set -e",
+            'cd "$(mktemp -d)"',
+            "git init -q .",
+            f"git remote add origin {shlex.quote(source.git)}",
+            f"git fetch -q --depth 1 --filter=blob:none origin {target} 2>/dev/null || {fallback}",
+            f"git cat-file -e {rev}:{path} 2>/dev/null || exit {DEFAULTS_ABSENT}",
+            f"git show {rev}:{path}",
+        ]
+    )
+
+
+def merge_repo_defaults(manifest: JobManifest, text: str) -> tuple[JobManifest, list[str]]:
+    """``manifest`` with the defaults file ``text`` merged under it.
+
+    Returns the merged manifest and the keys it took from the file.
+
+    Raises:
+        RepoDefaultsError: If the file is not valid YAML, sets keys it may
+            not, or makes the manifest invalid.
+    """
+    try:
+        node = yaml.compose(text)
+        repo = yaml.safe_load(text)
+    except yaml.YAMLError as exc:
+        mark = getattr(exc, "problem_mark", None)
+        where = f" line {mark.line + 1}" if mark is not None else ""
+        problem = getattr(exc, "problem", None) or str(exc)
+        raise RepoDefaultsError(f"{REPO_DEFAULTS_FILE}{where}: invalid YAML: {problem}") from None
+    if repo is None:
+        return manifest, []
+    if not isinstance(repo, dict):
+        raise RepoDefaultsError(f"{REPO_DEFAULTS_FILE} line 1: must be a mapping")
+    lines = {
+        str(key.value): key.start_mark.line + 1
+        for key, _ in node.value
+        if isinstance(key, yaml.ScalarNode)
+    }
+
+    refused = [str(key) for key in repo if key not in REPO_KEYS]
+    if refused:
+        raise RepoDefaultsError(
+            "; ".join(
+                f"{REPO_DEFAULTS_FILE} line {lines.get(key, 1)}: '{key}' cannot be set by the "
+                "repository, only in the submitted manifest"
+                for key in refused
+            )
+        )
+
+    submitted = manifest.given()
+    merged = dict(repo)
+    if any(key in submitted for key in _COMMAND_KEYS):
+        for key in _COMMAND_KEYS:
+            merged.pop(key, None)
+    for key, value in submitted.items():
+        if key in _MERGED_MAPS and isinstance(merged.get(key), dict):
+            overrides = {k: v for k, v in value.items() if v is not None}
+            merged[key] = {**merged[key], **overrides}
+        else:
+            merged[key] = value
+
+    try:
+        result = JobManifest.from_dict(merged)
+    except ManifestError as exc:
+        raise RepoDefaultsError(
+            "; ".join(_locate(problem, repo, submitted, lines) for problem in exc.problems)
+        ) from None
+    taken = sorted(
+        key for key in repo if key in merged and (key not in submitted or key in _MERGED_MAPS)
+    )
+    return result, taken
+
+
+def _locate(problem: ManifestProblem, repo: dict, submitted: dict, lines: dict[str, int]) -> str:
+    """A merged manifest's problem, pointed at the file's line when it came from there."""
+    key = problem.path.split(".")[0]
+    if key in repo and (key not in submitted or key in _MERGED_MAPS):
+        return f"{REPO_DEFAULTS_FILE} line {lines.get(key, 1)}: {problem}"
+    return f"with {REPO_DEFAULTS_FILE} merged in: {problem}"
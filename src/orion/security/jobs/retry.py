@@ -0,0 +1,128 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Retry with exponential backoff for transient engine / registry failures.
+
+Only the idempotent launch steps are retried -- image pulls and container
+starts.  The job command never is: it may have side effects.
+
+An error is retried only if its output looks transient (registry 5xx or
+429, connection resets, timeouts) and nothing marks it permanent (unknown
+image or manifest, bad reference, access denied).  Anything unrecognised
+counts as permanent, so a new failure mode fails fast rather than being
+hammered ``retry.maxAttempts`` times.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import logging
+from collections.abc import Awaitable, Callable
+from typing import TypeVar
+
+from orion.security.jobs.config import RetryConfig
+
+logger = logging.getLogger("orion.security.jobs.retry")
+
+T = TypeVar("T")
+
+# Checked first: these never get better by waiting
+_PERMANENT_MARKERS = (
+    "manifest unknown",
+    "manifest invalid",
+    "not found",
+    "no such image",
+    "does not exist",
+    "invalid reference format",
+    "unauthorized",
+    "denied",
+)
+_TRANSIENT_MARKERS = (
+    "500 internal server error",
+    "502 bad gateway",
+    "503 service unavailable",
+    "504 gateway",
+    "received unexpected http status: 5",
+    "toomanyrequests",
+    "429 too many requests",
+    "connection reset",
+    "connection refused",
+    "broken pipe",
+    "unexpected eof",
+    "timeout",
+    "timed out",
+    "deadline exceeded",
+    "temporary failure",
+    "try again",
+)
+
+
+def is_transient(output: str) -> bool:
+    """True if an engine / registry error is worth retrying."""
+    text = output.lower()
+    if any(marker in text for marker in _PERMANENT_MARKERS):
+        return False
+    return any(marker in text for marker in _TRANSIENT_MARKERS)
+
+
+def backoff_delay(retry: int, config: RetryConfig) -> float:
+    """Seconds to wait before retry number ``retry`` (0-based)."""
+    return min(config.initial_backoff * 2**retry, config.max_backoff)
+
+
+async def retry_transient(
+    attempt: Callable[[], Awaitable[T]],
+    error_of: Callable[[T], str | None],
+    what: str,
+    config: RetryConfig,
+    sleep: Callable[[float], Awaitable[None]] = asyncio.sleep,
+) -> tuple[T, int]:
+    """Run ``attempt`` until it succeeds, fails permanently or runs out.
+
+    ``error_of`` maps an attempt's result to its error output, or None on
+    success.
+
+    Returns:
+        The last attempt's result and the number of retries made.
+    """
+    retries = 0
+    while True:
+        result = await attempt()
+        error = error_of(result)
+        if error is None:
+            if retries:
+                logger.info("%s succeeded on attempt %d", what, retries + 1)
+            return result, retries
+
+        number = retries + 1
+        summary = error.strip().splitlines()[-1][:200] if error.strip() else "no output"
+        if number >= config.max_attempts or not is_transient(error):
+            logger.warning(
+                "%s failed on attempt %d/%d: %s", what, number, config.max_attempts, summary
+            )
+            return result, retries
+
+        delay = backoff_delay(retries, config)
+        logger.warning(
+            "%s failed on attempt %d/%d, retrying in %.1fs: %s",
+            what,
+            number,
+            config.max_attempts,
+            delay,
+            summary,
+        )
+        await sleep(delay)
+        retries += 1
@@ -0,0 +1,171 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job secrets -- resolution, injection and redaction.
+
+A manifest names the secrets it needs, never their values::
+
+    secrets:
+      NPM_TOKEN: npm-publish-token     # env var -> secret name
+      REGISTRY_PASSWORD: ghcr-password
+
+Values are looked up in the agent's configured secret source at launch
+and exist only in memory:
+
+  - Injected with ``docker run -e NAME`` (value read from the docker CLI's
+    environment), so they appear in neither argv nor an image layer
+  - Never stored on JobManifest / JobResult, so nothing that is
+    serialized to the API or persisted can carry them
+  - Scrubbed from every log line and from captured output by
+    :class:`Redactor` before it leaves the executor
+
+Sources (``secrets.source`` in jobs_config.yaml):
+  store: the SecureStore (keyring / encrypted file), provider
+         ``job-secret:<name>``
+  env:   host environment variable ``<envPrefix><NAME>`` (for CI agents)
+"""
+
+from __future__ import annotations
+
+import logging
+import os
+import re
+
+from orion.security.jobs.config import SecretsConfig
+
+logger = logging.getLogger("orion.security.jobs.secrets")
+
+REDACTED = "***"
+
+# Secret names as they appear in manifests and the secret store
+_SECRET_NAME_RE = re.compile(r"^[A-Za-z0-9][A-Za-z0-9_.\-]*$")
+# Environment variable names the secrets are injected as
+_ENV_NAME_RE = re.compile(r"^[A-Za-z_][A-Za-z0-9_]*$")
+
+STORE_PREFIX = "job-secret:"
+
+
+class SecretError(ValueError):
+    """Raised when a job's secrets cannot be resolved."""
+
+
+# ---------------------------------------------------------------------------
+# Sources
+# ---------------------------------------------------------------------------
+class SecretSource:
+    """Looks up secret values by name.  Subclasses implement :meth:`get`."""
+
+    def get(self, name: str) -> str | None:
+        raise NotImplementedError
+
+    def has(self, name: str) -> bool:
+        return self.get(name) is not None
+
+
+class StoreSecretSource(SecretSource):
+    """Secrets kept in the SecureStore under ``job-secret:<name>``."""
+
+    def __init__(self, store=None) -> None:
+        self._store = store
+
+    def get(self, name: str) -> str | None:
+        if self._store is None:
+            from orion.security.store import get_secure_store
+
+            self._store = get_secure_store()
+        return self._store.get_key(f"{STORE_PREFIX}{name}")
+
+
+class EnvSecretSource(SecretSource):
+    """Secrets taken from the agent's own environment."""
+
+    def __init__(self, prefix: str = "ORION_SECRET_", environ=None) -> None:
+        self.prefix = prefix
+        self._environ = os.environ if environ is None else environ
+
+    def get(self, name: str) -> str | None:
+        key = self.prefix + re.sub(r"[^A-Za-z0-9]", "_", name).upper()
+        return self._environ.get(key)
+
+
+class DictSecretSource(SecretSource):
+    """In-memory secrets (embedding and tests)."""
+
+    def __init__(self, values: dict[str, str]) -> None:
+        self._values = dict(values)
+
+    def get(self, name: str) -> str | None:
+        return self._values.get(name)
+
+
+def make_secret_source(config: SecretsConfig) -> SecretSource:
+    """Build the secret source selected in the jobs config."""
+    if config.source == "env":
+        return EnvSecretSource(config.env_prefix)
+    return StoreSecretSource()
+
+
+# ---------------------------------------------------------------------------
+# Resolution
+# ---------------------------------------------------------------------------
+def validate_secret_refs(refs: dict[str, str]) -> list[str]:
+    """Return problems with a manifest's ``secrets`` mapping (empty if fine)."""
+    problems = []
+    for env_name, secret_name in refs.items():
+        if not _ENV_NAME_RE.match(env_name):
+            problems.append(f"'{env_name}' is not a valid environment variable name")
+        if not _SECRET_NAME_RE.match(secret_name):
+            problems.append(f"'{secret_name}' is not a valid secret name")
+    return problems
+
+
+def resolve_secrets(refs: dict[str, str], source: SecretSource) -> dict[str, str]:
+    """Resolve ``{ENV_NAME: secret_name}`` to ``{ENV_NAME: value}``.
+
+    Raises:
+        SecretError: Listing every secret that is not set in the source.
+    """
+    values: dict[str, str] = {}
+    missing = []
+    for env_name, secret_name in refs.items():
+        value = source.get(secret_name)
+        if value is None:
+            missing.append(secret_name)
+        else:
+            values[env_name] = value
+    if missing:
+        raise SecretError(f"Secrets not found: {', '.join(sorted(missing))}")
+    return values
+
+
+# ---------------------------------------------------------------------------
+# Redaction
+# ---------------------------------------------------------------------------
+class Redactor:
+    """Replaces every occurrence of known secret values with ``***``."""
+
+    def __init__(self, values: list[str] | None = None) -> None:
+        # Longest first, so a secret containing another is fully masked
+        self._values = sorted({v for v in values or [] if v}, key=len, reverse=True)
+
+    def __bool__(self) -> bool:
+        return bool(self._values)
+
+    def redact(self, text: str) -> str:
+        for value in self._values:
+            if value in text:
+                text = text.replace(value, REDACTED)
+        return text
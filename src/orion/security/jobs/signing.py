@@ -0,0 +1,186 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Signed job manifests -- run only what an authorized key signed.
+
+With ``signing.enabled`` every submission must carry ``signature``, a
+detached Ed25519 signature of the manifest, base64-encoded, and may name
+the key that made it (``key_id``; otherwise each of ``signing.keys`` is
+tried).  A submission that is unsigned or does not verify is refused
+with 401 before it is queued.  The ID of the key that verified it is
+recorded on the job (``signed_by``).
+
+What is signed is the manifest's canonical form, not its text: the
+YAML / JSON parsed and serialized as compact JSON with sorted keys,
+UTF-8 encoded (:func:`canonical_manifest`).  Re-indenting the YAML,
+reordering its keys or sending it as JSON leaves the signature valid.
+
+The signature covers the manifest only.  So that a captured signed
+manifest cannot be resubmitted with another archive, a signed archive
+source must pin its archive with ``source.sha256``
+(:func:`require_pinned_archive`); the digest is checked before the
+archive is unpacked.  The commit a git ``ref`` resolves to at run time
+(unless ``source.commit`` pins it) is outside the signature, and a
+signed manifest stays valid for as long as its key is configured.
+
+Public keys are raw 32-byte Ed25519 keys, base64-encoded, or PEM
+(``-----BEGIN PUBLIC KEY-----``).  Verification needs the
+``cryptography`` package (``pip install orion-agent[security]``);
+without it every signed submission is refused.
+"""
+
+from __future__ import annotations
+
+import base64
+import binascii
+import json
+import logging
+from pathlib import Path
+from typing import TYPE_CHECKING, Any
+
+import yaml
+
+if TYPE_CHECKING:
+    from orion.security.jobs.config import SigningConfig, SigningKey
+    from orion.security.jobs.manifest import JobSource
+
+logger = logging.getLogger("orion.security.jobs.signing")
+
+# DER prefix of an Ed25519 SubjectPublicKeyInfo; the raw key follows it
+_SPKI_PREFIX = bytes.fromhex("302a300506032b6570032100")
+_KEY_SIZE = 32
+
+
+class SignatureError(ValueError):
+    """Raised when a submission's signature is missing or does not verify."""
+
+
+def canonical_manifest(text: str) -> bytes:
+    """The bytes a manifest's signature is made over.
+
+    Raises:
+        SignatureError: If ``text`` is not valid YAML.
+    """
+    try:
+        data: Any = yaml.safe_load(text)
+    except yaml.YAMLError as exc:
+        raise SignatureError(f"Invalid manifest YAML: {exc}") from exc
+    # default=str: YAML dates and timestamps, which JSON has no type for
+    return json.dumps(
+        data, sort_keys=True, separators=(",", ":"), ensure_ascii=False, default=str
+    ).encode("utf-8")
+
+
+def public_key_bytes(text: str) -> bytes:
+    """The raw Ed25519 key in ``text`` (base64 or PEM).
+
+    Raises:
+        ValueError: If ``text`` holds no Ed25519 public key.
+    """
+    text = text.strip()
+    pem = text.startswith("-----BEGIN PUBLIC KEY-----")
+    if pem:
+        text = "".join(line for line in text.splitlines() if not line.startswith("-----"))
+    try:
+        raw = base64.b64decode(text, validate=True)
+    except binascii.Error:
+        raise ValueError("not valid base64") from None
+    if pem:
+        if not raw.startswith(_SPKI_PREFIX):
+            raise ValueError("not an Ed25519 public key")
+        raw = raw[len(_SPKI_PREFIX) :]
+    if len(raw) != _KEY_SIZE:
+        raise ValueError(f"an Ed25519 public key is {_KEY_SIZE} bytes, not {len(raw)}")
+    return raw
+
+
+def verify_manifest(config: SigningConfig, text: str, signature: str, key_id: str = "") -> str:
+    """Check a submission's ``signature`` of manifest ``text``.
+
+    Returns the ID of the key that verified it, or '' when signing is
+    disabled (any signature is then ignored).
+
+    Raises:
+        SignatureError: If the submission must be refused.
+    """
+    if not config.enabled:
+        return ""
+    if not signature:
+        raise SignatureError("Manifest is not signed; this agent only runs signed manifests")
+    try:
+        raw = base64.b64decode(signature, validate=True)
+    except binascii.Error:
+        raise SignatureError("Manifest signature is not valid base64") from None
+    if key_id and key_id not in config.keys:
+        raise SignatureError(f"Unknown signing key '{key_id}'")
+
+    data = canonical_manifest(text)
+    for candidate in [key_id] if key_id else sorted(config.keys):
+        try:
+            public = _load_key(config.keys[candidate])
+        except (OSError, ValueError) as exc:
+            logger.error("Signing key %s cannot be used: %s", candidate, exc)
+            continue
+        if _verify(public, raw, data):
+            return candidate
+    named = f"key '{key_id}'" if key_id else "any of the agent's signing keys"
+    raise SignatureError(f"Manifest signature does not verify against {named}")
+
+
+def require_pinned_archive(source: JobSource) -> None:
+    """Refuse a signed manifest whose archive the signature does not cover.
+
+    Raises:
+        SignatureError: If ``source`` is an archive without ``sha256``.
+    """
+    if source.kind == "archive" and not source.sha256:
+        raise SignatureError(
+            "A signed manifest's archive source must pin the archive with source.sha256"
+        )
+
+
+def sign_manifest(text: str, private_key_pem: bytes) -> str:
+    """The base64 signature of manifest ``text`` by a PEM Ed25519 private key.
+
+    For clients and tests; the agent itself only verifies.
+    """
+    from cryptography.hazmat.primitives.serialization import load_pem_private_key
+
+    key = load_pem_private_key(private_key_pem, password=None)
+    return base64.b64encode(key.sign(canonical_manifest(text))).decode("ascii")
+
+
+def _load_key(key: SigningKey) -> bytes:
+    # A key file is read on every check, so replacing it needs no reload
+    if key.public_key_file:
+        return public_key_bytes(Path(key.public_key_file).read_text(encoding="utf-8"))
+    return public_key_bytes(key.public_key)
+
+
+def _verify(public: bytes, signature: bytes, data: bytes) -> bool:
+    try:
+        from cryptography.exceptions import InvalidSignature
+        from cryptography.hazmat.primitives.asymmetric.ed25519 import Ed25519PublicKey
+    except ImportError:
+        raise SignatureError(
+            "Manifest signatures cannot be checked: this agent lacks the 'cryptography' "
+            "package (pip install orion-agent[security])"
+        ) from None
+    try:
+        Ed25519PublicKey.from_public_bytes(public).verify(signature, data)
+    except InvalidSignature:
+        return False
+    return True
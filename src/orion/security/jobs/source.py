@@ -0,0 +1,165 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job source checkout -- git clone or host bind-mount.
+
+Clone (``source.git``):
+  Runs inside the job container with the stack's own ``git``, as the
+  ``orion`` user so the checkout is owned by the user the command runs
+  as.  It runs in the install phase, the only one with (proxy-filtered)
+  network access.  A ``commit`` is fetched directly where the server
+  allows it, otherwise via its ``ref`` (or every branch), then checked
+  out detached.  Git never prompts for credentials.
+
+Bind (``source.hostPath``):
+  Mounts an existing host checkout at ``/workspace`` (or its ``path``
+  subdirectory).  Only directories under ``source.allowedHostPaths`` in
+  jobs_config.yaml may be mounted; by default none are.
+
+Clone failures are classified from git's output so a credential problem
+(:data:`AUTH`) reads differently from an unreachable host (:data:`NETWORK`)
+or a missing ref / commit (:data:`CHECKOUT`).
+"""
+
+from __future__ import annotations
+
+import logging
+import os
+import shlex
+
+from orion.security.jobs.manifest import JobSource
+
+logger = logging.getLogger("orion.security.jobs.source")
+
+AUTH = "auth"
+NETWORK = "network"
+CHECKOUT = "checkout"
+
+_AUTH_MARKERS = (
+    "authentication failed",
+    "could not read username",
+    "could not read password",
+    "terminal prompts disabled",
+    "permission denied (publickey",
+    "host key verification failed",
+    "access denied",
+    "repository not found",
+    "returned error: 401",
+    "returned error: 403",
+)
+_NETWORK_MARKERS = (
+    "could not resolve host",
+    "could not resolve proxy",
+    "connection refused",
+    "connection timed out",
+    "connection reset",
+    "operation timed out",
+    "failed to connect",
+    "network is unreachable",
+    "no route to host",
+    "unable to access",
+    "early eof",
+    "timed out after",  # the clone's own timeout
+)
+
+# Environment for the clone: never block on a credential prompt
+CLONE_ENV = {"GIT_TERMINAL_PROMPT": "0", "GIT_SSH_COMMAND": "ssh -o BatchMode=yes"}
+
+
+class SourceError(ValueError):
+    """Raised when a job's source cannot be prepared.  ``kind`` is
+    :data:`AUTH`, :data:`NETWORK` or :data:`CHECKOUT`."""
+
+    def __init__(self, message: str, kind: str = CHECKOUT) -> None:
+        super().__init__(message)
+        self.kind = kind
+
+
+def clone_script(source: JobSource) -> str:
+    """Shell script that checks ``source`` out into its container path."""
+    dest = shlex.quote(source.container_path)
+    depth = f" --depth {source.depth}" if source.depth else ""
+    lines = [
+        "set -e",
+        f"mkdir -p {dest}",
+        f"cd {dest}",
+        "git init -q .",
+        f"git remote add origin {shlex.quote(source.git)}",
+    ]
+    if source.commit:
+        commit = shlex.quote(source.commit)
+        # Servers may refuse to serve an arbitrary (or abbreviated) SHA
+        fallback = "git fetch -q origin" + (f" {shlex.quote(source.ref)}" if source.ref else "")
+        lines.append(f"git fetch -q{depth} origin {commit} 2>/dev/null || {fallback}")
+        lines.append(f"git checkout -q --detach {commit}")
+    else:
+        target = shlex.quote(source.ref) if source.ref else "HEAD"
+        lines.append(f"git fetch -q{depth} origin {target}")
+        lines.append("git checkout -q --detach FETCH_HEAD")
+    return "\n".join(lines)
+
+
+def classify_clone_error(output: str) -> str:
+    """Return :data:`AUTH`, :data:`NETWORK` or :data:`CHECKOUT` for git's output."""
+    text = output.lower()
+    if any(marker in text for marker in _AUTH_MARKERS):
+        return AUTH
+    if any(marker in text for marker in _NETWORK_MARKERS):
+        return NETWORK
+    return CHECKOUT
+
+
+def clone_error(source: JobSource, output: str) -> SourceError:
+    """Build the SourceError for a failed clone of ``source``."""
+    kind = classify_clone_error(output)
+    detail = output.strip().splitlines()[-1][:300] if output.strip() else "no output"
+    if kind == AUTH:
+        message = f"Access to {source.git} was denied (missing or invalid credentials): {detail}"
+    elif kind == NETWORK:
+        message = f"Could not reach {source.git}: {detail}"
+    else:
+        wanted = source.commit or source.ref or "the default branch"
+        message = f"Could not check out {wanted} from {source.git}: {detail}"
+    return SourceError(message, kind)
+
+
+def allowed_real_path(path: str, allowed: list[str]) -> str | None:
+    """``path`` with symlinks resolved, or None unless it is inside ``allowed``."""
+    real = os.path.realpath(path)
+    roots = [os.path.realpath(root) for root in allowed]
+    if any(real == root or real.startswith(root.rstrip("/") + "/") for root in roots):
+        return real
+    return None
+
+
+def resolve_host_path(source: JobSource, allowed: list[str]) -> str:
+    """Return the real host directory to bind-mount for ``source``.
+
+    Symlinks are resolved before the allow-list check, so a link inside
+    an allowed directory cannot expose one outside it.
+
+    Raises:
+        SourceError: If the directory is missing or not allowed.
+    """
+    real = allowed_real_path(source.host_path, allowed)
+    if real is None:
+        raise SourceError(
+            f"Host path {source.host_path} is not under an allowed source directory "
+            "(source.allowedHostPaths in jobs_config.yaml)"
+        )
+    if not os.path.isdir(real):
+        raise SourceError(f"Host path {source.host_path} is not a directory")
+    return real
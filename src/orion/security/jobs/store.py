@@ -0,0 +1,161 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Job history that survives agent restarts.
+
+The executor hands the store a record on every state transition of a
+background job -- accepted, started, finished -- and reads them all back
+once at startup, so the list and describe APIs still show earlier runs.
+A record is a JSON-safe mapping (see :meth:`JobHandle.to_record`); stores
+keep it as is and index it only by ``job_id``.
+
+What is persisted is what the API already serves: the result (output is
+redacted before it reaches the result) and the manifest summary with env
+values and URL credentials stripped.  Secret values are never on either.
+
+Queued and running jobs cannot outlive the process that ran them.  Their
+records are reloaded as ``interrupted`` instead of disappearing.
+
+Backends (``store.backend`` in jobs_config.yaml):
+  none:   history lives in memory only (the default)
+  sqlite: one SQLite file at ``store.path``; written synchronously, a
+          record at a time, which is cheap next to a container start
+
+Other backends (a SQL server, an object store) subclass :class:`JobStore`
+and are returned by :func:`make_job_store`.
+"""
+
+from __future__ import annotations
+
+import json
+import logging
+import sqlite3
+import threading
+from pathlib import Path
+from typing import Any
+
+from orion.security.jobs.config import STORE_SQLITE, StoreConfig
+
+logger = logging.getLogger("orion.security.jobs.store")
+
+
+class JobStoreError(RuntimeError):
+    """Raised when a store cannot read or write its records."""
+
+
+# ---------------------------------------------------------------------------
+# Backends
+# ---------------------------------------------------------------------------
+class JobStore:
+    """Keeps job records by ID.  Subclasses implement :meth:`save`, :meth:`load`
+    and :meth:`delete`."""
+
+    def save(self, record: dict[str, Any]) -> None:
+        """Insert or replace the record of ``record['job_id']``."""
+        raise NotImplementedError
+
+    def load(self) -> list[dict[str, Any]]:
+        """Every record, in no particular order."""
+        raise NotImplementedError
+
+    def delete(self, job_ids: list[str]) -> None:
+        """Forget these jobs; unknown IDs are ignored."""
+        raise NotImplementedError
+
+    def close(self) -> None:
+        """Release the backend's resources.  Later calls may fail."""
+
+
+class MemoryJobStore(JobStore):
+    """Records in a dict (embedding and tests)."""
+
+    def __init__(self) -> None:
+        self.records: dict[str, dict[str, Any]] = {}
+
+    def save(self, record: dict[str, Any]) -> None:
+        # Round-trip as a real backend would: nothing shared with the caller
+        self.records[record["job_id"]] = json.loads(json.dumps(record))
+
+    def load(self) -> list[dict[str, Any]]:
+        return [json.loads(json.dumps(record)) for record in self.records.values()]
+
+    def delete(self, job_ids: list[str]) -> None:
+        for job_id in job_ids:
+            self.records.pop(job_id, None)
+
+
+class SQLiteJobStore(JobStore):
+    """Records as JSON in a single-table SQLite file."""
+
+    def __init__(self, path: Path | str) -> None:
+        self.path = Path(path)
+        self._lock = threading.Lock()
+        try:
+            self.path.parent.mkdir(parents=True, exist_ok=True)
+            self._db = sqlite3.connect(self.path, check_same_thread=False)
+            with self._db:
+                self._db.execute(
+                    "CREATE TABLE IF NOT EXISTS jobs "
+                    "(job_id TEXT PRIMARY KEY, record TEXT NOT NULL)"
+                )
+        except (OSError, sqlite3.Error) as exc:
+            raise JobStoreError(f"Cannot open job store {self.path}: {exc}") from exc
+
+    def save(self, record: dict[str, Any]) -> None:
+        self._write(
+            "INSERT OR REPLACE INTO jobs (job_id, record) VALUES (?, ?)",
+            [(record["job_id"], json.dumps(record))],
+        )
+
+    def load(self) -> list[dict[str, Any]]:
+        with self._lock:
+            try:
+                rows = self._db.execute("SELECT job_id, record FROM jobs").fetchall()
+            except sqlite3.Error as exc:
+                raise JobStoreError(f"Cannot read job store {self.path}: {exc}") from exc
+        records = []
+        for job_id, text in rows:
+            try:
+                records.append(json.loads(text))
+            except ValueError:
+                logger.warning("Skipping unreadable record of job %s in %s", job_id, self.path)
+        return records
+
+    def delete(self, job_ids: list[str]) -> None:
+        self._write("DELETE FROM jobs WHERE job_id = ?", [(job_id,) for job_id in job_ids])
+
+    def close(self) -> None:
+        with self._lock:
+            self._db.close()
+
+    def _write(self, sql: str, rows: list[tuple]) -> None:
+        with self._lock:
+            try:
+                with self._db:
+                    self._db.executemany(sql, rows)
+            except sqlite3.Error as exc:
+                raise JobStoreError(f"Cannot write job store {self.path}: {exc}") from exc
+
+
+def make_job_store(config: StoreConfig) -> JobStore | None:
+    """Build the store selected in the jobs config; None keeps history in memory.
+
+    Raises:
+        JobStoreError: If the backend cannot be opened.
+    """
+    if config.backend == STORE_SQLITE:
+        return SQLiteJobStore(config.path)
+    return None
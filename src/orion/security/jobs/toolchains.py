@@ -0,0 +1,275 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Per-job toolchain selection.
+
+Stack images bake one toolchain version (e.g. Go 1.22.5, Node 20, Rust
+1.79.0, JDK 21).  A manifest can ask for a different one with
+``toolchain: 1.21.13`` (Go), ``toolchain: "18"`` (Node), ``toolchain:
+"1.75.0"`` (Rust) or ``toolchain: "17"`` (Java); the executor then
+activates it at container start instead of rebuilding the image.
+
+Downloaded toolchains live under ``/home/orion/toolchains/<version>``
+(``$GOPATH/..`` for Go), which is bind-mounted from a host cache so
+repeat jobs do not re-fetch.  A Rust download is a rustup home of its own
+holding just that release; a JDK becomes ``JAVA_HOME``, which Maven and
+Gradle follow.
+
+Resolution is a two-step protocol so that only the download touches the
+network:
+
+1. ``probe_script`` runs in the execute phase (no network) and exits with
+   :data:`PROBE_BAKED`, :data:`PROBE_CACHED` or :data:`PROBE_MISSING`.
+2. On a miss, ``install_script`` runs in the install phase (egress proxy).
+"""
+
+from __future__ import annotations
+
+import re
+import shlex
+from dataclasses import dataclass
+
+# In-container toolchain cache root (bind-mounted from the host)
+TOOLCHAINS_DIR = "/home/orion/toolchains"
+
+# Probe exit codes
+PROBE_BAKED = 0
+PROBE_CACHED = 10
+PROBE_MISSING = 11
+
+_GO_VERSION_RE = re.compile(r"^(?:go)?(\d+\.\d+(?:\.\d+)?(?:(?:rc|beta)\d+)?)$")
+_NODE_VERSION_RE = re.compile(r"^v?(\d+)(?:\.(\d+)\.(\d+))?$")
+_RUST_VERSION_RE = re.compile(r"^(1\.\d+)(\.\d+)?$")
+_JAVA_VERSION_RE = re.compile(r"^(?:jdk-?)?(\d+)$")
+
+# Node majors offered for per-job selection (active / maintenance LTS lines)
+NODE_MAJORS = (18, 20, 22)
+
+# JDK majors offered for per-job selection (Temurin LTS releases)
+JAVA_MAJORS = (17, 21)
+
+
+class ToolchainError(ValueError):
+    """Raised when a requested toolchain version is not valid for the stack."""
+
+
+@dataclass(frozen=True)
+class ToolchainPlan:
+    """Shell snippets that resolve and activate one toolchain version."""
+
+    stack: str
+    version: str
+    install_dir: str
+    probe_script: str
+    install_script: str
+    activate_prefix: str  # prepended to every job command
+
+
+def _go_plan(version: str) -> ToolchainPlan:
+    match = _GO_VERSION_RE.match(version.strip())
+    if not match:
+        raise ToolchainError(f"Invalid Go toolchain version: {version!r}")
+    goversion = f"go{match.group(1)}"
+    install_dir = f"{TOOLCHAINS_DIR}/{goversion}"
+    q_dir = shlex.quote(install_dir)
+    q_ver = shlex.quote(goversion)
+
+    probe = (
+        f'if [ "$(go env GOVERSION 2>/dev/null)" = {q_ver} ]; then exit {PROBE_BAKED}; fi; '
+        f"if [ -x {q_dir}/bin/go ]; then exit {PROBE_CACHED}; fi; "
+        f"exit {PROBE_MISSING}"
+    )
+    # Extract into a temp dir then rename, so a concurrent or interrupted
+    # download never leaves a half-populated toolchain behind.
+    install = (
+        "set -e; "
+        f"tmp=$(mktemp -d {TOOLCHAINS_DIR}/.{goversion}.XXXXXX); "
+        f"curl -fsSL https://go.dev/dl/{goversion}.linux-$(dpkg --print-architecture).tar.gz "
+        '| tar -C "$tmp" --strip-components=1 -xzf -; '
+        f'mv -T "$tmp" {q_dir} 2>/dev/null || rm -rf "$tmp"; '
+        f"test -x {q_dir}/bin/go"
+    )
+    activate = f'export PATH={q_dir}/bin:"$PATH" GOTOOLCHAIN=local; '
+    return ToolchainPlan(
+        stack="go",
+        version=goversion,
+        install_dir=install_dir,
+        probe_script=probe,
+        install_script=install,
+        activate_prefix=activate,
+    )
+
+
+def _node_plan(version: str) -> ToolchainPlan:
+    match = _NODE_VERSION_RE.match(version.strip())
+    if not match:
+        raise ToolchainError(f"Invalid Node toolchain version: {version!r}")
+    major = int(match.group(1))
+    if major not in NODE_MAJORS:
+        supported = ", ".join(str(m) for m in NODE_MAJORS)
+        raise ToolchainError(f"Node {major} is not supported (choose one of {supported})")
+
+    if match.group(2) is not None:
+        # Exact release: v20.11.1
+        nodeversion = f"v{major}.{match.group(2)}.{match.group(3)}"
+        baked_pattern = nodeversion
+        dist = f"https://nodejs.org/dist/{nodeversion}"
+    else:
+        # Major line: latest v20.x at first download, then pinned by the cache
+        nodeversion = f"v{major}"
+        baked_pattern = f"v{major}.*"
+        dist = f"https://nodejs.org/dist/latest-v{major}.x"
+    install_dir = f"{TOOLCHAINS_DIR}/node-{nodeversion}"
+    q_dir = shlex.quote(install_dir)
+
+    probe = (
+        f'case "$(node --version 2>/dev/null)" in {baked_pattern}) exit {PROBE_BAKED};; esac; '
+        f"if [ -x {q_dir}/bin/node ]; then exit {PROBE_CACHED}; fi; "
+        f"exit {PROBE_MISSING}"
+    )
+    # Same temp-dir-then-rename dance as Go.  Node names amd64 "x64".
+    install = (
+        "set -e; "
+        'arch=$(dpkg --print-architecture); [ "$arch" = amd64 ] && arch=x64; '
+        f"file=$(curl -fsSL {dist}/SHASUMS256.txt "
+        '| grep -o "node-v[0-9.]*-linux-$arch.tar.gz" | head -n 1); '
+        f"tmp=$(mktemp -d {TOOLCHAINS_DIR}/.node-{nodeversion}.XXXXXX); "
+        f'curl -fsSL {dist}/"$file" | tar -C "$tmp" --strip-components=1 -xzf -; '
+        f'mv -T "$tmp" {q_dir} 2>/dev/null || rm -rf "$tmp"; '
+        f"test -x {q_dir}/bin/node"
+    )
+    activate = f'export PATH={q_dir}/bin:"$PATH"; '
+    return ToolchainPlan(
+        stack="node",
+        version=nodeversion,
+        install_dir=install_dir,
+        probe_script=probe,
+        install_script=install,
+        activate_prefix=activate,
+    )
+
+
+def _rust_plan(version: str) -> ToolchainPlan:
+    match = _RUST_VERSION_RE.match(version.strip())
+    if not match:
+        raise ToolchainError(
+            f"Invalid Rust toolchain version: {version!r} (use a release like 1.75.0 or 1.75)"
+        )
+    rustversion = match.group(0)
+    # "1.75" is the latest 1.75.x at first download, then pinned by the cache
+    baked_pattern = f'"rustc {rustversion} "*' if match.group(2) else f'"rustc {rustversion}."*'
+    install_dir = f"{TOOLCHAINS_DIR}/rust-{rustversion}"
+    q_dir = shlex.quote(install_dir)
+
+    probe = (
+        f'case "$(rustc --version 2>/dev/null)" in {baked_pattern}) exit {PROBE_BAKED};; '
+        "esac; "
+        f"for rustc in {q_dir}/toolchains/*/bin/rustc; do "
+        f'[ -x "$rustc" ] && exit {PROBE_CACHED}; done; '
+        f"exit {PROBE_MISSING}"
+    )
+    # A private rustup home with only this release as its default; same
+    # temp-dir-then-rename dance as Go.
+    install = (
+        "set -e; "
+        f"tmp=$(mktemp -d {TOOLCHAINS_DIR}/.rust-{rustversion}.XXXXXX); "
+        f'RUSTUP_HOME="$tmp" rustup toolchain install {rustversion} '
+        "--profile minimal --no-self-update; "
+        f'RUSTUP_HOME="$tmp" rustup default {rustversion}; '
+        f'mv -T "$tmp" {q_dir} 2>/dev/null || rm -rf "$tmp"; '
+        f"test -d {q_dir}/toolchains"
+    )
+    # Only switch homes when the release was downloaded; a baked match
+    # keeps the image's own
+    activate = f"if [ -d {q_dir} ]; then export RUSTUP_HOME={q_dir}; fi; "
+    return ToolchainPlan(
+        stack="rust",
+        version=rustversion,
+        install_dir=install_dir,
+        probe_script=probe,
+        install_script=install,
+        activate_prefix=activate,
+    )
+
+
+def _java_plan(version: str) -> ToolchainPlan:
+    match = _JAVA_VERSION_RE.match(version.strip())
+    if not match:
+        raise ToolchainError(
+            f"Invalid Java toolchain version: {version!r} (use a JDK major like 17)"
+        )
+    major = int(match.group(1))
+    if major not in JAVA_MAJORS:
+        supported = ", ".join(str(m) for m in JAVA_MAJORS)
+        raise ToolchainError(f"JDK {major} is not supported (choose one of {supported})")
+    install_dir = f"{TOOLCHAINS_DIR}/jdk-{major}"
+    q_dir = shlex.quote(install_dir)
+
+    # The baked JDK's release file reads JAVA_VERSION="21.0.4" (or "21")
+    probe = (
+        f"if grep -q '^JAVA_VERSION=\"{major}[.\"]' \"$JAVA_HOME/release\" 2>/dev/null; "
+        f"then exit {PROBE_BAKED}; fi; "
+        f"if [ -x {q_dir}/bin/java ]; then exit {PROBE_CACHED}; fi; "
+        f"exit {PROBE_MISSING}"
+    )
+    # Latest Temurin GA of the major at first download, then pinned by the
+    # cache; same temp-dir-then-rename dance as Go.
+    install = (
+        "set -e; "
+        'arch=$(dpkg --print-architecture); case "$arch" in '
+        "amd64) arch=x64;; arm64) arch=aarch64;; esac; "
+        f"tmp=$(mktemp -d {TOOLCHAINS_DIR}/.jdk-{major}.XXXXXX); "
+        f"curl -fsSL https://api.adoptium.net/v3/binary/latest/{major}/ga/linux/$arch"
+        '/jdk/hotspot/normal/eclipse | tar -C "$tmp" --strip-components=1 -xzf -; '
+        f'mv -T "$tmp" {q_dir} 2>/dev/null || rm -rf "$tmp"; '
+        f"test -x {q_dir}/bin/java"
+    )
+    # Only once downloaded: pointing JAVA_HOME at a missing dir breaks mvn
+    activate = f'if [ -d {q_dir} ]; then export JAVA_HOME={q_dir} PATH={q_dir}/bin:"$PATH"; fi; '
+    return ToolchainPlan(
+        stack="java",
+        version=str(major),
+        install_dir=install_dir,
+        probe_script=probe,
+        install_script=install,
+        activate_prefix=activate,
+    )
+
+
+_PLANNERS = {
+    "go": _go_plan,
+    "node": _node_plan,
+    "rust": _rust_plan,
+    "java": _java_plan,
+}
+
+
+def supports_toolchain(stack: str) -> bool:
+    """Return True if per-job toolchain selection is available for a stack."""
+    return stack in _PLANNERS
+
+
+def plan_toolchain(stack: str, version: str) -> ToolchainPlan:
+    """Build the resolution plan for ``version`` on ``stack``.
+
+    Raises:
+        ToolchainError: If the stack has no toolchain support or the
+            version string is malformed.
+    """
+    planner = _PLANNERS.get(stack)
+    if planner is None:
+        raise ToolchainError(f"Stack {stack!r} does not support toolchain selection")
+    return planner(version)
@@ -0,0 +1,64 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+#    You may use, modify, and distribute this file under AGPL-3.0.
+#    See LICENSE for the full text.
+#
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#    For proprietary use, SaaS deployment, or enterprise licensing.
+#    See LICENSE-ENTERPRISE.md or contact info@phoenixlink.co.za
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Per-job host workspaces.
+
+Every job container mounts a host directory at ``/workspace``.  Each one
+is created empty under the job's directory with a name no other job can
+have (``<jobs_dir>/<job_id>/workspace-<random>``) -- job IDs alone are not
+enough, since a caller may choose one and a resubmission repeats it --
+and is never reused.  Once the job's container is gone the directory is
+removed and its absence checked.
+
+Removal can fail when the job left files the agent's user may not
+delete, typically those written by a ``runAs`` root job.  The executor
+then empties the directory as root inside a throwaway container
+(:data:`PURGE_SCRIPT`) and removes it again; a workspace that survives
+that too is logged as an error, never handed to another job.
+
+A host checkout bound as the whole workspace (``source.hostPath`` without
+``path``) belongs to the operator and is neither created nor removed.
+"""
+
+from __future__ import annotations
+
+import logging
+import shutil
+import tempfile
+from pathlib import Path
+
+logger = logging.getLogger("orion.security.jobs.workspace")
+
+WORKSPACE_PREFIX = "workspace-"
+
+# Run as root in a container mounting the leftover workspace at /workspace
+PURGE_SCRIPT = "find /workspace -mindepth 1 -delete"
+
+
+def create_workspace(job_dir: Path) -> Path:
+    """Create a new, empty, uniquely named workspace under ``job_dir``.
+
+    The directory is only accessible to the agent's user (and root, and
+    the container user the engine maps onto it).
+    """
+    job_dir.mkdir(parents=True, exist_ok=True)
+    return Path(tempfile.mkdtemp(prefix=WORKSPACE_PREFIX, dir=job_dir))
+
+
+def remove_workspace(path: Path) -> bool:
+    """Remove ``path`` and everything in it; True once it is verifiably gone."""
+    shutil.rmtree(path, ignore_errors=True)
+    return not path.exists()
@@ -52,17 +52,33 @@ REGISTRY_DOMAINS: dict[str, list[str]] = {
     "node": [
         "registry.npmjs.org",
         "registry.yarnpkg.com",
+        # Per-job toolchain downloads
+        "nodejs.org",
     ],
     "go": [
         "proxy.golang.org",
         "sum.golang.org",
         "storage.googleapis.com",
+        # Per-job toolchain downloads (go.dev/dl redirects to dl.google.com)
+        "go.dev",
+        "dl.google.com",
     ],
     "rust": [
         "crates.io",
         "static.crates.io",
         "index.crates.io",
     ],
+    "java": [
+        "repo.maven.apache.org",
+        "repo1.maven.org",
+        "plugins.gradle.org",
+        "plugins-artifacts.gradle.org",
+        # Gradle wrapper distributions (services.gradle.org redirects)
+        "services.gradle.org",
+        "downloads.gradle.org",
+        # Per-job JDK downloads (redirect to GitHub release assets)
+        "api.adoptium.net",
+    ],
     "base": [],
 }
 
@@ -98,7 +114,7 @@ def get_registry_domains(stack: str) -> list[str]:
     Includes stack-specific registries plus common registries.
 
     Args:
-        stack: Stack name (e.g. "python", "node", "go", "rust", "base").
+        stack: Stack name (e.g. "python", "node", "go", "rust", "java", "base").
 
     Returns:
         Sorted, deduplicated list of allowed domains.
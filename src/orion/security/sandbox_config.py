@@ -30,6 +30,7 @@ from __future__ import annotations
 
 import json
 import logging
+import re
 from dataclasses import dataclass
 from pathlib import Path
 from typing import Any
@@ -147,6 +148,48 @@ def validate_memory(value: str) -> bool:
         return False
 
 
+_MEMORY_RE = re.compile(r"^\s*(\d+(?:\.\d+)?)\s*([kmgt]i?)?b?\s*$", re.IGNORECASE)
+_MEMORY_UNITS = {
+    "": 1,
+    "k": 1000,
+    "m": 1000**2,
+    "g": 1000**3,
+    "t": 1000**4,
+    "ki": 1024,
+    "mi": 1024**2,
+    "gi": 1024**3,
+    "ti": 1024**4,
+}
+
+
+def parse_memory_bytes(value: str | int) -> int:
+    """Parse a memory size into bytes.
+
+    Accepts plain byte counts (``536870912``), Kubernetes-style binary
+    suffixes (``512Mi``, ``2Gi``) and decimal suffixes (``512M``).  Docker's
+    lowercase suffixes (``512m``, ``2g``) are binary, as in Docker itself.
+
+    Raises:
+        ValueError: If the value is not a positive size.
+    """
+    if isinstance(value, bool):
+        raise ValueError(f"Invalid memory size: {value!r}")
+    if isinstance(value, int):
+        number, unit = float(value), ""
+    else:
+        match = _MEMORY_RE.match(str(value))
+        if not match:
+            raise ValueError(f"Invalid memory size: {value!r}")
+        number, unit = float(match.group(1)), match.group(2) or ""
+        # Docker convention: "512m" / "2g" mean MiB / GiB
+        if unit in ("k", "m", "g", "t"):
+            unit += "i"
+    size = int(number * _MEMORY_UNITS[unit.lower()])
+    if size <= 0:
+        raise ValueError(f"Memory size must be positive: {value!r}")
+    return size
+
+
 def validate_cpus(value: str) -> bool:
     """Check that a cpus value is valid (positive number as string)."""
     try:
@@ -27,6 +27,8 @@ and stays alive via ``sleep infinity`` until the session ends.
 Security invariants:
   - Execute phase: --network none (no internet access)
   - Install phase: temporary connection to orion-egress network (proxy-filtered)
+  - An explicit ``network`` replaces both phases: the container lives on
+    that network only (``none`` = no network at all, installs included)
   - AEGIS config: read-only bind mount, container cannot modify
   - Workspace: read-write bind mount within /workspace only
 """
@@ -36,13 +38,20 @@ from __future__ import annotations
 import asyncio
 import logging
 import os
-import shutil
-import subprocess
+import shlex
 import time
+from collections.abc import Callable
 from dataclasses import dataclass
 from pathlib import Path
 from typing import Any
 
+from orion.security.container_runtime import (
+    ContainerRuntime,
+    ContainerSecurity,
+    ContainerSpec,
+    DockerRuntime,
+)
+
 logger = logging.getLogger("orion.security.session_container")
 
 # ---------------------------------------------------------------------------
@@ -65,6 +74,32 @@ EGRESS_NETWORK = "orion-egress"
 _ORION_HOME = Path(os.environ.get("ORION_HOME", Path.home() / ".orion"))
 _AEGIS_CONFIG_DIR = _ORION_HOME / "aegis"
 
+# Run as root by reset(): kill everything but PID 1 (``sleep infinity``) and
+# this shell, wait until only zombies are left, then empty the scratch dirs
+_RESET_SCRIPT = """
+kill -KILL -1 2>/dev/null
+for attempt in 1 2 3 4 5; do
+  alive=""
+  for p in /proc/[0-9]*; do
+    pid=${p#/proc/}
+    [ "$pid" = 1 ] || [ "$pid" = $$ ] && continue
+    state=""
+    while read -r key value rest; do
+      [ "$key" = State: ] && state=$value && break
+    done 2>/dev/null < "$p/status"
+    [ -n "$state" ] && [ "$state" != Z ] && alive="$alive $pid"
+  done
+  [ -z "$alive" ] && break
+  kill -KILL $alive 2>/dev/null
+  sleep 1
+done
+if [ -n "$alive" ]; then echo "processes survived:$alive" >&2; exit 1; fi
+for dir in /workspace /tmp /var/tmp; do
+  [ -d "$dir" ] || continue
+  find "$dir" -mindepth 1 -delete || exit 1
+done
+"""
+
 
 # ---------------------------------------------------------------------------
 # ExecResult dataclass
@@ -81,6 +116,14 @@ class ExecResult:
     phase: str = "execute"  # 'install', 'execute', 'test'
 
 
+@dataclass
+class ResourceUsage:
+    """The container's cumulative resource consumption; None where unknown."""
+
+    cpu_seconds: float | None = None
+    peak_memory_bytes: int | None = None
+
+
 # ---------------------------------------------------------------------------
 # AuditEntry dataclass
 # ---------------------------------------------------------------------------
@@ -104,7 +147,10 @@ class AuditEntry:
 # SessionContainer
 # ---------------------------------------------------------------------------
 class SessionContainer:
-    """Persistent Docker container for a single ARA work session.
+    """Persistent container for a single ARA work session.
+
+    All engine calls go through ``runtime`` (Docker unless given a
+    :class:`~orion.security.container_runtime.PodmanRuntime`).
 
     Usage::
 
@@ -125,6 +171,15 @@ class SessionContainer:
         workspace_path: Path | str | None = None,
         aegis_config_dir: Path | None = None,
         activity_logger: Any | None = None,
+        extra_volumes: list[str] | None = None,
+        resources: dict[str, str | int] | None = None,
+        image: str | None = None,
+        secret_env: dict[str, str] | None = None,
+        runtime: ContainerRuntime | None = None,
+        user: str | None = None,
+        labels: dict[str, str] | None = None,
+        network: str = "",
+        security: ContainerSecurity | None = None,
     ) -> None:
         self.session_id = session_id
         self.stack = stack
@@ -133,9 +188,30 @@ class SessionContainer:
         self.workspace_path = Path(workspace_path) if workspace_path else Path.cwd()
         self.aegis_config_dir = aegis_config_dir or _AEGIS_CONFIG_DIR
         self.activity_logger = activity_logger
+        # Additional ``-v`` specs (``host:container[:mode]``) for job caches
+        self.extra_volumes = list(extra_volumes or [])
+        # Per-job overrides for profile keys (``memory``, ``cpus``, ``pids``)
+        self.resource_overrides = dict(resources or {})
+        # Explicit image tag (e.g. a per-arch variant); defaults to the stack's
+        self._image = image
+        # Injected at create time via the CLI's environment, never argv
+        self._secret_env = dict(secret_env or {})
+        self.runtime = runtime or DockerRuntime()
+        # 'uid:gid' every command runs as by default; None = the image's user
+        self.user = user
+        # Engine labels, e.g. the job that owns the container
+        self.labels = dict(labels or {})
+        # Engine network for the container's whole life; '' = phased networking
+        self.network = network
+        # Capabilities, no-new-privileges, seccomp and ulimits; engine defaults when None
+        self.security = security or ContainerSecurity()
 
         # State
         self._running = False
+        # True while attached to the egress network (install phase)
+        self._egress_connected = False
+        # Why the last :meth:`start` failed (engine stderr), '' otherwise
+        self.start_error = ""
         self._started_at: float = 0.0
         self._command_count: int = 0
         self._audit_log: list[AuditEntry] = []
@@ -151,12 +227,14 @@ class SessionContainer:
     @property
     def image_name(self) -> str:
         """Docker image name for this stack."""
-        return f"orion-stack-{self.stack}:latest"
+        return self._image or f"orion-stack-{self.stack}:latest"
 
     @property
     def resource_profile(self) -> dict[str, Any]:
-        """Current resource profile dict."""
-        return dict(PROFILES.get(self.profile, PROFILES["standard"]))
+        """Current resource profile dict (profile merged with overrides)."""
+        profile = dict(PROFILES.get(self.profile, PROFILES["standard"]))
+        profile.update(self.resource_overrides)
+        return profile
 
     @property
     def audit_log(self) -> list[AuditEntry]:
@@ -179,14 +257,17 @@ class SessionContainer:
         - Applies resource profile limits
         - NO network by default (added only during install phase)
 
-        Returns True if the container starts successfully.
+        Returns True if the container starts successfully; otherwise the
+        reason is left in :attr:`start_error`.
         """
         if self._running:
             logger.warning("Container %s already running", self.container_name)
             return True
 
+        self.start_error = ""
         if not self._is_docker_available():
-            logger.error("Docker is not available")
+            self.start_error = f"Container runtime {self.runtime.name} is not available"
+            logger.error("%s", self.start_error)
             return False
 
         # Ensure workspace exists
@@ -195,38 +276,32 @@ class SessionContainer:
         # Ensure AEGIS config dir exists (create empty if needed)
         self.aegis_config_dir.mkdir(parents=True, exist_ok=True)
 
-        # Build docker run command
         prof = self.resource_profile
-        cmd = [
-            "docker",
-            "run",
-            "-d",
-            "--name",
-            self.container_name,
-            "--memory",
-            str(prof["memory"]),
-            "--cpus",
-            str(prof["cpus"]),
-            "--pids-limit",
-            str(prof["pids"]),
-            "--network",
-            "none",
-            "-v",
-            f"{self.workspace_path}:/workspace:rw",
-            "-v",
-            f"{self.aegis_config_dir}:/etc/orion/aegis:ro",
-            "-w",
-            "/workspace",
-            self.image_name,
-            "sleep",
-            "infinity",
-        ]
+        spec = ContainerSpec(
+            name=self.container_name,
+            image=self.image_name,
+            command=["sleep", "infinity"],
+            workspace=str(self.workspace_path),
+            volumes=[f"{self.aegis_config_dir}:/etc/orion/aegis:ro", *self.extra_volumes],
+            env_names=list(self._secret_env),
+            memory=str(prof["memory"]),
+            cpus=str(prof["cpus"]),
+            pids=str(prof["pids"]),
+            user=self.user or "",
+            labels=self.labels,
+            network=self.network or "none",
+            security=self.security,
+        )
 
         try:
-            result = await self._run_docker(cmd, timeout=60)
+            result = await self.runtime.create(spec, env=self._secret_env or None)
+            if result.returncode == 0:
+                result = await self.runtime.start(self.container_name)
             if result.returncode != 0:
                 stderr = result.stderr.strip()[:500] if result.stderr else "unknown error"
+                self.start_error = stderr
                 logger.error("Failed to start container: %s", stderr)
+                await self._remove_quietly()
                 return False
 
             self._running = True
@@ -240,7 +315,10 @@ class SessionContainer:
             return True
 
         except Exception as exc:
-            logger.error("Failed to start session container: %s", exc)
+            self.start_error = str(exc) or type(exc).__name__
+            logger.error("Failed to start session container: %s", self.start_error)
+            # A create that timed out may still have left the container behind
+            await self._remove_quietly()
             return False
 
     # ------------------------------------------------------------------
@@ -248,20 +326,45 @@ class SessionContainer:
     # ------------------------------------------------------------------
     async def exec(
         self,
-        command: str,
+        command: str | list[str],
         timeout: int = 120,
         phase: str = "execute",
+        env: dict[str, str] | None = None,
+        on_output: Callable[[str, str], None] | None = None,
+        pidfile: str | None = None,
+        user: str | None = None,
+        workdir: str | None = None,
+        max_output: int | None = None,
+        secret_env: dict[str, str] | None = None,
     ) -> ExecResult:
         """Execute a command inside the container (no network).
 
         Args:
-            command: Shell command to run.
+            command: Shell command to run, or an argv list run as is
+                (never word-split or expanded by a shell).
             timeout: Max seconds before the process is killed.
             phase: Execution phase label for audit.
+            env: Extra environment variables for this command only.
+            on_output: Called with ``(stream, line)`` for each line as it is
+                produced, where stream is ``'stdout'`` or ``'stderr'``.  The
+                full output is still returned in the ExecResult.
+            pidfile: If set, run the command in its own process group and
+                write the group leader's PID here, for :meth:`signal_group`.
+            user: Run as this user instead of the image's default.
+            workdir: Directory to run in instead of /workspace.
+            max_output: Keep at most this many bytes of output, stdout and
+                stderr together, in the ExecResult (None = all of it).
+            secret_env: Like ``env``, but kept out of the runtime CLI's
+                argv (and so out of ``ps``).
 
         Returns:
             ExecResult with stdout, stderr, exit_code, duration.
         """
+        argv = None
+        if isinstance(command, list):
+            argv = list(command)
+            command = shlex.join(argv)  # for the audit and activity logs
+
         if not self._running:
             return ExecResult(
                 stderr="Container not running",
@@ -281,17 +384,29 @@ class SessionContainer:
             )
 
         start = time.time()
-        cmd = [
-            "docker",
-            "exec",
-            self.container_name,
-            "sh",
-            "-c",
-            command,
-        ]
+        if argv is not None:
+            if pidfile:
+                # The shell only records its PID; argv reaches exec as "$@"
+                argv = ["setsid", "-w", "sh", "-c", f'echo $$ > {pidfile}; exec "$@"', "sh", *argv]
+        else:
+            script = command
+            if pidfile:
+                inner = f"echo $$ > {pidfile}; exec sh -c {shlex.quote(command)}"
+                script = f"exec setsid -w sh -c {shlex.quote(inner)}"
+            argv = ["sh", "-c", script]
 
         try:
-            result = await self._run_docker(cmd, timeout=timeout)
+            result = await self.runtime.exec(
+                self.container_name,
+                argv,
+                timeout=timeout,
+                env=env,
+                workdir=workdir,
+                on_output=on_output,
+                user=user,
+                max_output=max_output,
+                secret_env=secret_env,
+            )
             duration = time.time() - start
 
             exec_result = ExecResult(
@@ -354,15 +469,23 @@ class SessionContainer:
         self,
         command: str,
         timeout: int = 300,
+        env: dict[str, str] | None = None,
+        on_output: Callable[[str, str], None] | None = None,
+        user: str | None = None,
     ) -> ExecResult:
         """Execute an install command with temporary network access.
 
         Connects the container to the egress proxy network, runs the
         install command, then disconnects — restoring network isolation.
+        A container on an explicit :attr:`network` stays on it instead, so
+        with ``none`` the install runs offline.
 
         Args:
             command: Install command (e.g. ``pip install -r requirements.txt``).
             timeout: Max seconds for the install.
+            env: Extra environment variables for this command only.
+            on_output: Per-line output callback, as for :meth:`exec`.
+            user: Run as this user instead of the image's default.
 
         Returns:
             ExecResult with stdout, stderr, exit_code, duration.
@@ -376,9 +499,16 @@ class SessionContainer:
             )
 
         # Connect to egress network
-        connected = await self._connect_network()
+        connected = not self.network and await self._connect_network()
         try:
-            result = await self.exec(command, timeout=timeout, phase="install")
+            result = await self.exec(
+                command,
+                timeout=timeout,
+                phase="install",
+                env=env,
+                on_output=on_output,
+                user=user,
+            )
         finally:
             # Always disconnect — even on timeout/error
             if connected:
@@ -417,22 +547,13 @@ class SessionContainer:
 
         # Ensure parent directory exists, then write via stdin pipe
         parent_dir = str(Path(path).parent)
-        mkdir_cmd = ["docker", "exec", self.container_name, "mkdir", "-p", parent_dir]
-        await self._run_docker(mkdir_cmd, timeout=10)
-
-        # Write content via docker exec with stdin
-        write_cmd = [
-            "docker",
-            "exec",
-            "-i",
-            self.container_name,
-            "sh",
-            "-c",
-            f"cat > {path}",
-        ]
+        await self.runtime.exec(self.container_name, ["mkdir", "-p", parent_dir], timeout=10)
+
+        # Write content via exec with stdin
         try:
-            result = await self._run_docker(
-                write_cmd,
+            result = await self.runtime.exec(
+                self.container_name,
+                ["sh", "-c", f"cat > {path}"],
                 timeout=30,
                 input_data=content,
             )
@@ -466,9 +587,8 @@ class SessionContainer:
                 phase="execute",
             )
 
-        cmd = ["docker", "exec", self.container_name, "cat", path]
         try:
-            result = await self._run_docker(cmd, timeout=30)
+            result = await self.runtime.exec(self.container_name, ["cat", path], timeout=30)
             if result.returncode == 0:
                 if self.activity_logger and activity_entry:
                     self.activity_logger.update(activity_entry, status="success")
@@ -489,23 +609,47 @@ class SessionContainer:
         Returns:
             List of file paths relative to the given path.
         """
-        cmd = [
-            "docker",
-            "exec",
-            self.container_name,
-            "find",
-            path,
-            "-type",
-            "f",
-        ]
+        cmd = ["find", path, "-type", "f"]
         try:
-            result = await self._run_docker(cmd, timeout=30)
+            result = await self.runtime.exec(self.container_name, cmd, timeout=30)
             if result.returncode == 0 and result.stdout:
                 return [line.strip() for line in result.stdout.strip().splitlines() if line.strip()]
         except Exception as exc:
             logger.debug("Failed to list files in %s: %s", path, exc)
         return []
 
+    async def list_file_sizes(self, path: str = "/workspace") -> str:
+        """List regular files under ``path`` as ``<size> ./<relative path>`` lines.
+
+        Symlinks are not listed, so nothing outside ``path`` is reachable
+        through them.  Returns an empty string on failure.
+        """
+        cmd = ["find", ".", "-type", "f", "-exec", "stat", "-c", "%s %n", "{}", "+"]
+        try:
+            result = await self.runtime.exec(self.container_name, cmd, timeout=60, workdir=path)
+            if result.returncode == 0:
+                return result.stdout or ""
+        except Exception as exc:
+            logger.debug("Failed to list file sizes in %s: %s", path, exc)
+        return ""
+
+    async def copy_from(self, path: str, dest: Path | str) -> bool:
+        """Copy a file out of the container to ``dest`` on the host.
+
+        Path must be within /workspace/ (AEGIS workspace confinement).
+        Returns True if the copy succeeded.
+        """
+        if not self._is_workspace_path(path):
+            logger.warning("Rejected copy outside workspace: %s", path)
+            return False
+
+        try:
+            result = await self.runtime.copy_from(self.container_name, path, dest)
+        except Exception as exc:
+            logger.debug("Failed to copy %s out of the container: %s", path, exc)
+            return False
+        return result.returncode == 0
+
     # ------------------------------------------------------------------
     # Stop
     # ------------------------------------------------------------------
@@ -521,12 +665,12 @@ class SessionContainer:
 
         try:
             # Stop the container
-            stop_cmd = ["docker", "stop", "-t", "5", self.container_name]
-            await self._run_docker(stop_cmd, timeout=15)
+            await self.runtime.stop(self.container_name, grace=5)
 
             # Remove the container
-            rm_cmd = ["docker", "rm", "-f", self.container_name]
-            await self._run_docker(rm_cmd, timeout=15)
+            removed = await self.runtime.remove(self.container_name)
+            if removed.returncode != 0 and not _already_gone(removed):
+                raise RuntimeError((removed.stderr or "").strip()[:300] or "remove failed")
 
             self._running = False
             logger.info(
@@ -551,24 +695,189 @@ class SessionContainer:
         except Exception as exc:
             logger.error("Failed to stop container %s: %s", self.container_name, exc)
             # Force remove
-            try:
-                force_cmd = ["docker", "rm", "-f", self.container_name]
-                await self._run_docker(force_cmd, timeout=10)
+            if await self._remove_quietly():
                 self._running = False
-            except Exception:
-                pass
             return False
 
+    # ------------------------------------------------------------------
+    # Signals
+    # ------------------------------------------------------------------
+    async def signal_group(self, pidfile: str, sig: str = "TERM") -> bool:
+        """Send ``sig`` to the process group whose leader wrote ``pidfile``.
+
+        Used to stop a command started under ``setsid`` together with
+        every child it spawned.  Returns True if the signal was delivered.
+        """
+        script = f'kill -s {sig} -- -"$(cat {pidfile})"'
+        try:
+            result = await self.runtime.exec(self.container_name, ["sh", "-c", script], timeout=10)
+        except Exception as exc:
+            logger.debug("Failed to send SIG%s: %s", sig, exc)
+            return False
+        return result.returncode == 0
+
+    # ------------------------------------------------------------------
+    # Resource accounting
+    # ------------------------------------------------------------------
+    async def oom_kill_count(self) -> int | None:
+        """Return the container cgroup's OOM-kill counter, or None if unknown.
+
+        Commands run via ``docker exec`` are killed by the cgroup OOM killer
+        while the container itself keeps running, so ``State.OOMKilled``
+        never flips.  The cgroup's own counter does: compare it before and
+        after a command to tell an OOM kill from any other SIGKILL.
+        """
+        script = (
+            # cgroup v2, then cgroup v1
+            "cat /sys/fs/cgroup/memory.events 2>/dev/null"
+            " || cat /sys/fs/cgroup/memory/memory.oom_control 2>/dev/null"
+        )
+        try:
+            result = await self.runtime.exec(self.container_name, ["sh", "-c", script], timeout=10)
+        except Exception as exc:
+            logger.debug("Failed to read OOM counter: %s", exc)
+            return None
+        for line in (result.stdout or "").splitlines():
+            parts = line.split()
+            if len(parts) == 2 and parts[0] == "oom_kill":
+                try:
+                    return int(parts[1])
+                except ValueError:
+                    return None
+        return None
+
+    async def disk_usage(self, path: str = "/workspace") -> int | None:
+        """Return the bytes used under ``path`` (mounts below it included), or None.
+
+        Measured with ``du`` inside the container, so it counts what the
+        job sees however its workspace is mounted.  It runs as root: a
+        directory the job made unreadable to itself still counts.
+        """
+        try:
+            result = await self.runtime.exec(
+                self.container_name, ["du", "-s", "-k", path], timeout=60, user="0"
+            )
+        except Exception as exc:
+            logger.debug("Failed to measure %s: %s", path, exc)
+            return None
+        # du may still print a total after failing to read some entries
+        fields = (result.stdout or "").split()
+        if not fields or not fields[0].isdigit():
+            return None
+        return int(fields[0]) * 1024
+
+    async def resource_usage(self) -> ResourceUsage:
+        """Return the CPU time and peak memory the container has used so far.
+
+        Read from the container cgroup's own counters, the ones the
+        engine's stats API samples (``docker stats`` reports only the
+        current CPU rate and memory, not totals or peaks).  A counter the
+        cgroup does not expose -- no ``memory.peak`` before Linux 5.19, or
+        no delegated controllers under rootless engines -- is None.
+        """
+        script = (
+            # cgroup v2, then cgroup v1
+            "grep -s '^usage_usec ' /sys/fs/cgroup/cpu.stat;"
+            " for f in memory.peak cpuacct/cpuacct.usage memory/memory.max_usage_in_bytes; do"
+            ' [ -r /sys/fs/cgroup/$f ] && printf "%s %s\\n" "${f##*/}" "$(cat /sys/fs/cgroup/$f)";'
+            " done; true"
+        )
+        try:
+            result = await self.runtime.exec(self.container_name, ["sh", "-c", script], timeout=10)
+        except Exception as exc:
+            logger.debug("Failed to read resource usage: %s", exc)
+            return ResourceUsage()
+        counters = {}
+        for line in (result.stdout or "").splitlines():
+            parts = line.split()
+            if len(parts) == 2 and parts[1].isdigit():
+                counters[parts[0]] = int(parts[1])
+        usage = ResourceUsage()
+        if "usage_usec" in counters:
+            usage.cpu_seconds = counters["usage_usec"] / 1e6
+        elif "cpuacct.usage" in counters:
+            usage.cpu_seconds = counters["cpuacct.usage"] / 1e9  # nanoseconds
+        usage.peak_memory_bytes = counters.get(
+            "memory.peak", counters.get("memory.max_usage_in_bytes")
+        )
+        return usage
+
+    # ------------------------------------------------------------------
+    # Reuse (warm pool)
+    # ------------------------------------------------------------------
+    async def layer_changes(self) -> set[str] | None:
+        """Return the container's filesystem changes as ``'<A|C|D> <path>'``
+        entries, or None if the runtime could not list them."""
+        try:
+            result = await self.runtime.diff(self.container_name)
+        except Exception as exc:
+            logger.debug("Failed to list changes of %s: %s", self.container_name, exc)
+            return None
+        if result.returncode != 0:
+            return None
+        return {line.strip() for line in (result.stdout or "").splitlines() if line.strip()}
+
+    async def reset(self, baseline: set[str]) -> str:
+        """Return the container to the state it started in, for the next job.
+
+        Kills every process but the container's init, empties /workspace,
+        /tmp and /var/tmp, then compares the filesystem changes with
+        ``baseline`` (:meth:`layer_changes` right after start).  Anything
+        the job changed elsewhere cannot be undone reliably, so the reset
+        fails instead.  Environment needs no reset: the container's own
+        carries no job data and job variables are passed per command.
+
+        Returns '' once the container is safe to reuse, else the reason it
+        is not.
+        """
+        if not self._running:
+            return "container is not running"
+        if self._egress_connected:
+            return "container is still attached to the egress network"
+
+        try:
+            result = await self.runtime.exec(
+                self.container_name, ["sh", "-c", _RESET_SCRIPT], timeout=120, user="0"
+            )
+        except Exception as exc:
+            return f"reset failed: {exc or type(exc).__name__}"
+        if result.returncode != 0:
+            return f"reset failed: {(result.stderr or '').strip()[:200] or result.returncode}"
+
+        changes = await self.layer_changes()
+        if changes is None:
+            return "could not list the container's filesystem changes"
+        allowed = {c for c in changes if c in baseline or c.startswith("C ")}
+        dirty = sorted(changes - allowed)
+        if dirty:
+            return f"job changed the container outside /workspace ({', '.join(dirty[:3])})"
+
+        # A 'C' entry is only harmless on a directory (an entry below it changed and was
+        # wiped, or a mount point); a changed file would carry over to the next job
+        paths = sorted(c[2:] for c in allowed if not c.startswith("D "))
+        if paths:
+            script = 'for p in "$@"; do [ -d "$p" ] && [ ! -L "$p" ] || echo "$p"; done'
+            try:
+                result = await self.runtime.exec(
+                    self.container_name, ["sh", "-c", script, "sh", *paths], timeout=30, user="0"
+                )
+            except Exception as exc:
+                return f"reset failed: {exc or type(exc).__name__}"
+            changed = (result.stdout or "").split()
+            if result.returncode != 0 or changed:
+                return f"job changed files outside /workspace ({', '.join(changed[:3])})"
+        return ""
+
     # ------------------------------------------------------------------
     # Network management (for install phase)
     # ------------------------------------------------------------------
     async def _connect_network(self) -> bool:
         """Connect the container to the egress proxy network."""
-        cmd = ["docker", "network", "connect", EGRESS_NETWORK, self.container_name]
         try:
-            result = await self._run_docker(cmd, timeout=15)
+            result = await self.runtime.connect_network(self.container_name, EGRESS_NETWORK)
             if result.returncode == 0:
                 logger.debug("Connected %s to egress network", self.container_name)
+                self._egress_connected = True
                 return True
             logger.warning(
                 "Failed to connect to egress network: %s",
@@ -581,11 +890,11 @@ class SessionContainer:
 
     async def _disconnect_network(self) -> bool:
         """Disconnect the container from the egress proxy network."""
-        cmd = ["docker", "network", "disconnect", EGRESS_NETWORK, self.container_name]
         try:
-            result = await self._run_docker(cmd, timeout=15)
+            result = await self.runtime.disconnect_network(self.container_name, EGRESS_NETWORK)
             if result.returncode == 0:
                 logger.debug("Disconnected %s from egress network", self.container_name)
+                self._egress_connected = False
                 return True
             return False
         except Exception as exc:
@@ -605,61 +914,36 @@ class SessionContainer:
     async def _kill_exec_process(self, command: str) -> None:
         """Best-effort kill of a timed-out process inside the container."""
         # Kill all sh processes that might be running our command
-        kill_cmd = [
-            "docker",
-            "exec",
-            self.container_name,
-            "sh",
-            "-c",
-            "kill -9 $(pgrep -f 'sh -c' | head -5) 2>/dev/null || true",
-        ]
+        script = "kill -9 $(pgrep -f 'sh -c' | head -5) 2>/dev/null || true"
         try:
-            await self._run_docker(kill_cmd, timeout=5)
+            await self.runtime.exec(self.container_name, ["sh", "-c", script], timeout=5)
         except Exception:
             pass
 
-    @staticmethod
-    def _is_docker_available() -> bool:
-        """Check if Docker daemon is running."""
-        if not shutil.which("docker"):
+    async def remove(self) -> bool:
+        """Force-remove the container, whatever state it is in.
+
+        For when :meth:`stop` failed.  True once the container is gone,
+        including when it never existed.
+        """
+        if not await self._remove_quietly():
             return False
+        self._running = False
+        return True
+
+    async def _remove_quietly(self) -> bool:
+        """Force-remove the container, ignoring errors.  True if removed."""
         try:
-            result = subprocess.run(
-                ["docker", "info"],
-                capture_output=True,
-                timeout=10,
-            )
-            return result.returncode == 0
-        except (subprocess.TimeoutExpired, FileNotFoundError, OSError):
+            result = await self.runtime.remove(self.container_name)
+        except Exception:
             return False
+        return result.returncode == 0 or _already_gone(result)
 
-    @staticmethod
-    async def _run_docker(
-        cmd: list[str],
-        timeout: int = 60,
-        input_data: str | None = None,
-    ) -> subprocess.CompletedProcess:
-        """Run a Docker CLI command asynchronously.
-
-        Uses asyncio subprocess for non-blocking execution.
-        """
-        loop = asyncio.get_event_loop()
+    def _is_docker_available(self) -> bool:
+        """Check if the container engine is reachable."""
+        return self.runtime.is_available()
 
-        def _run() -> subprocess.CompletedProcess:
-            return subprocess.run(
-                cmd,
-                capture_output=True,
-                text=True,
-                timeout=timeout,
-                input=input_data,
-            )
 
-        try:
-            return await asyncio.wait_for(
-                loop.run_in_executor(None, _run),
-                timeout=timeout + 5,  # Slightly longer than subprocess timeout
-            )
-        except asyncio.TimeoutError:
-            raise
-        except subprocess.TimeoutExpired:
-            raise asyncio.TimeoutError(f"Docker command timed out ({timeout}s): {' '.join(cmd)}")
+def _already_gone(result: Any) -> bool:
+    """True if a failed ``rm`` only says there was no such container."""
+    return "no such container" in (result.stderr or "").lower()
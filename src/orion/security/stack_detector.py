@@ -19,18 +19,50 @@ Supported stacks:
   - node    (package.json, yarn.lock, *.js, *.ts)
   - go      (go.mod, go.sum, *.go)
   - rust    (Cargo.toml, Cargo.lock, *.rs)
+  - java    (pom.xml, build.gradle[.kts], settings.gradle[.kts], *.java, *.kt)
   - base    (fallback — generic Ubuntu with shell tools)
 
+The set of runnable stacks is not hardcoded: it is discovered from the
+``docker/stacks/Dockerfile.*`` files by parsing their ``orion.stack``
+LABEL, so adding a new stack only requires adding a labelled Dockerfile.
+
 See Phase 4A.4 specification.
 """
 
 from __future__ import annotations
 
 import logging
+import re
+from dataclasses import dataclass
 from pathlib import Path
 
 logger = logging.getLogger("orion.security.stack_detector")
 
+# ---------------------------------------------------------------------------
+# Stack image catalog
+# ---------------------------------------------------------------------------
+STACKS_DIR = Path(__file__).resolve().parents[3] / "docker" / "stacks"
+
+# Matches ``LABEL orion.stack="go"`` (quoted or bare, possibly among other
+# key=value pairs on the same LABEL instruction).
+_STACK_LABEL_RE = re.compile(
+    r"""^\s*LABEL\s+(?:.*\s)?orion\.stack\s*=\s*["']?([A-Za-z0-9_.-]*)["']?""",
+    re.IGNORECASE | re.MULTILINE,
+)
+
+
+class StackResolutionError(ValueError):
+    """Raised when a requested stack has no labelled image definition."""
+
+
+@dataclass(frozen=True)
+class StackImage:
+    """A stack image discovered from a labelled Dockerfile."""
+
+    stack: str
+    dockerfile: Path
+    image: str
+
 # ---------------------------------------------------------------------------
 # Stack definitions: marker files → stack name
 # ---------------------------------------------------------------------------
@@ -62,15 +94,107 @@ _STACK_SIGNATURES: list[tuple[str, list[str], list[str], int]] = [
         [".rs"],
         10,
     ),
+    (
+        "java",
+        ["pom.xml", "build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"],
+        [".java", ".kt"],
+        10,
+    ),
 ]
 
-# Valid stack names (must have a matching Dockerfile in docker/stacks/)
-VALID_STACKS = frozenset({"base", "python", "node", "go", "rust"})
-
 # Docker image name pattern
 IMAGE_PREFIX = "orion-stack-"
 
 
+def parse_stack_label(dockerfile: Path | str) -> str | None:
+    """Return the ``orion.stack`` LABEL value declared in a Dockerfile.
+
+    Args:
+        dockerfile: Path to the Dockerfile.
+
+    Returns:
+        The label value, or None if the file is unreadable or the label
+        is missing or empty.
+    """
+    try:
+        text = Path(dockerfile).read_text(encoding="utf-8")
+    except OSError:
+        return None
+    match = _STACK_LABEL_RE.search(text)
+    if not match or not match.group(1):
+        return None
+    return match.group(1)
+
+
+def discover_stacks(stacks_dir: Path | str | None = None) -> dict[str, StackImage]:
+    """Discover available stacks from the labelled Dockerfiles.
+
+    Every ``Dockerfile.*`` in the stacks directory that declares an
+    ``orion.stack`` LABEL registers one stack.  Files without the label
+    are skipped with a warning.
+
+    Args:
+        stacks_dir: Override the ``docker/stacks/`` directory.
+
+    Returns:
+        Mapping of stack name to StackImage.
+    """
+    directory = Path(stacks_dir) if stacks_dir else STACKS_DIR
+    if not directory.is_dir():
+        logger.debug("Stacks directory not found: %s", directory)
+        return {}
+
+    stacks: dict[str, StackImage] = {}
+    for dockerfile in sorted(directory.glob("Dockerfile.*")):
+        stack = parse_stack_label(dockerfile)
+        if stack is None:
+            logger.warning("Skipping %s: no orion.stack LABEL", dockerfile.name)
+            continue
+        if stack in stacks:
+            logger.warning(
+                "Duplicate orion.stack=%r in %s (already defined by %s)",
+                stack,
+                dockerfile.name,
+                stacks[stack].dockerfile.name,
+            )
+            continue
+        stacks[stack] = StackImage(
+            stack=stack,
+            dockerfile=dockerfile,
+            image=f"{IMAGE_PREFIX}{stack}:latest",
+        )
+    return stacks
+
+
+def resolve_stack(stack: str, stacks_dir: Path | str | None = None) -> StackImage:
+    """Resolve a stack name to its labelled image definition.
+
+    Unlike :func:`image_name`, this never falls back to ``base``: a job
+    that asks for a stack nobody defined must fail loudly.
+
+    Args:
+        stack: Requested stack name (e.g. ``"python"``).
+        stacks_dir: Override the ``docker/stacks/`` directory.
+
+    Returns:
+        The matching StackImage.
+
+    Raises:
+        StackResolutionError: If no Dockerfile declares ``orion.stack=<stack>``.
+    """
+    stacks = discover_stacks(stacks_dir)
+    if stack not in stacks:
+        available = ", ".join(sorted(stacks)) or "none"
+        raise StackResolutionError(
+            f"No stack image labelled orion.stack={stack!r} (available: {available})"
+        )
+    return stacks[stack]
+
+
+# Valid stack names (discovered from the labelled Dockerfiles in docker/stacks/)
+VALID_STACKS = frozenset(discover_stacks()) or frozenset({"base"})
+
+
 def detect_stack(workspace_path: Path | str) -> str:
     """Detect the project stack from files in the workspace directory.
 
@@ -148,6 +272,8 @@ def detect_stack_from_goal(goal: str) -> str:
         ],
         "go": ["golang", "go ", " go,", "gin", "echo framework"],
         "rust": ["rust", "cargo", "tokio", "actix"],
+        # After node, whose "javascript" would otherwise read as java
+        "java": ["java", "jvm", "spring boot", "maven", "gradle", "kotlin"],
     }
 
     for stack, keywords in _goal_keywords.items():
@@ -0,0 +1,404 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the Docker / Podman container runtimes (no engine required)."""
+
+from __future__ import annotations
+
+import asyncio
+import base64
+import dataclasses
+import json
+import os
+import subprocess
+
+import pytest
+
+from orion.security.container_runtime import (
+    STREAM_CHUNK,
+    ContainerSecurity,
+    ContainerSpec,
+    DockerRuntime,
+    PodmanRuntime,
+    RegistryLogin,
+    is_auth_failure,
+    make_runtime,
+    registry_of,
+)
+
+
+def _recording(runtime, returncode: int = 0, stdout: str = ""):
+    """Replace the runtime's CLI runner; returns the list of (argv, env) calls."""
+    calls: list[tuple[list[str], dict | None]] = []
+
+    async def fake_run(cmd, timeout=60, input_data=None, env=None):
+        calls.append((cmd, env))
+        return subprocess.CompletedProcess(cmd, returncode, stdout, "")
+
+    runtime._run = fake_run
+    return calls
+
+
+SPEC = ContainerSpec(
+    name="orion-session-t",
+    image="orion-stack-go:latest",
+    command=["sleep", "infinity"],
+    workspace="/home/me/.orion/jobs/t/workspace",
+    env_names=["NPM_TOKEN"],
+    memory="2g",
+    cpus="2",
+    pids="256",
+)
+
+
+class TestMakeRuntime:
+    def test_drivers(self):
+        assert isinstance(make_runtime("docker"), DockerRuntime)
+        assert isinstance(make_runtime("podman"), PodmanRuntime)
+
+    def test_unknown_driver(self):
+        with pytest.raises(ValueError, match="containerd"):
+            make_runtime("containerd")
+
+
+class TestCreate:
+    @pytest.mark.asyncio
+    async def test_docker(self):
+        runtime = DockerRuntime()
+        calls = _recording(runtime)
+        await runtime.create(SPEC, env={"NPM_TOKEN": "hunter2"})
+        cmd, env = calls[0]
+        assert cmd[:2] == ["docker", "create"]
+        assert "--network" in cmd and cmd[cmd.index("--network") + 1] == "none"
+        assert f"{SPEC.workspace}:/workspace:rw" in cmd
+        assert cmd[-3:] == ["orion-stack-go:latest", "sleep", "infinity"]
+        assert not any(arg.startswith("--userns") for arg in cmd)
+        assert "--pull" not in cmd
+        assert "--user" not in cmd
+        assert "hunter2" not in " ".join(cmd)
+        assert env["NPM_TOKEN"] == "hunter2"
+
+    @pytest.mark.asyncio
+    async def test_rootless_podman_maps_invoking_user_to_orion(self):
+        runtime = PodmanRuntime(rootless=True)
+        calls = _recording(runtime)
+        await runtime.create(SPEC)
+        cmd, env = calls[0]
+        assert cmd[:2] == ["podman", "create"]
+        assert "--userns=keep-id:uid=1000,gid=1000" in cmd
+        assert env is None
+
+    @pytest.mark.asyncio
+    async def test_rootful_podman_maps_uids_directly(self):
+        runtime = PodmanRuntime(rootless=False)
+        calls = _recording(runtime)
+        await runtime.create(SPEC)
+        assert not any(arg.startswith("--userns") for arg in calls[0][0])
+
+    @pytest.mark.asyncio
+    async def test_run_as_user(self):
+        spec = dataclasses.replace(SPEC, user="1500:27")
+        docker = DockerRuntime()
+        calls = _recording(docker)
+        await docker.create(spec)
+        cmd = calls[0][0]
+        assert cmd[cmd.index("--user") + 1] == "1500:27"
+
+        podman = PodmanRuntime(rootless=True)
+        calls = _recording(podman)
+        await podman.create(spec)
+        assert "--userns=keep-id:uid=1500,gid=27" in calls[0][0]
+
+    @pytest.mark.asyncio
+    async def test_labels(self):
+        runtime = DockerRuntime()
+        calls = _recording(runtime)
+        await runtime.create(dataclasses.replace(SPEC, labels={"orion.job": "j1"}))
+        cmd = calls[0][0]
+        assert cmd[cmd.index("--label") + 1] == "orion.job=j1"
+        await runtime.create(SPEC)
+        assert "--label" not in calls[1][0]
+
+    @pytest.mark.asyncio
+    async def test_security(self):
+        security = ContainerSecurity(
+            no_new_privileges=True,
+            cap_drop=["ALL"],
+            cap_add=["CHOWN"],
+            ulimits={"nofile": "65536", "nproc": "2048:4096"},
+            seccomp_profile="/etc/orion/seccomp.json",
+        )
+        for runtime in (DockerRuntime(), PodmanRuntime(rootless=True)):
+            calls = _recording(runtime)
+            await runtime.create(dataclasses.replace(SPEC, security=security))
+            cmd = " ".join(calls[0][0])
+            assert "--cap-drop ALL --cap-add CHOWN" in cmd
+            assert "--security-opt no-new-privileges" in cmd
+            assert "--security-opt seccomp=/etc/orion/seccomp.json" in cmd
+            assert "--ulimit nofile=65536 --ulimit nproc=2048:4096" in cmd
+
+    @pytest.mark.asyncio
+    async def test_no_security_flags_by_default(self):
+        runtime = DockerRuntime()
+        calls = _recording(runtime)
+        await runtime.create(SPEC)
+        cmd = calls[0][0]
+        assert not {"--cap-drop", "--cap-add", "--security-opt", "--ulimit"} & set(cmd)
+
+
+class TestSockets:
+    def test_docker_default(self, monkeypatch):
+        monkeypatch.delenv("DOCKER_HOST", raising=False)
+        assert DockerRuntime().socket_path == "/var/run/docker.sock"
+
+    def test_docker_host_env(self, monkeypatch):
+        monkeypatch.setenv("DOCKER_HOST", "unix:///run/user/1000/docker.sock")
+        assert DockerRuntime().socket_path == "/run/user/1000/docker.sock"
+
+    def test_podman_defaults(self, monkeypatch):
+        monkeypatch.setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+        assert PodmanRuntime(rootless=True).socket_path == "/run/user/1000/podman/podman.sock"
+        assert PodmanRuntime(rootless=False).socket_path == "/run/podman/podman.sock"
+
+    def test_configured_socket_selects_it(self):
+        assert DockerRuntime("/tmp/d.sock").command("info") == [
+            "docker",
+            "--host",
+            "unix:///tmp/d.sock",
+            "info",
+        ]
+        assert PodmanRuntime("/tmp/p.sock", rootless=True).command("info") == [
+            "podman",
+            "--remote",
+            "--url",
+            "unix:///tmp/p.sock",
+            "info",
+        ]
+
+    def test_no_socket_uses_cli_default(self):
+        assert DockerRuntime().command("info") == ["docker", "info"]
+        assert PodmanRuntime(rootless=True).command("info") == ["podman", "info"]
+
+
+class TestOperations:
+    @pytest.mark.asyncio
+    async def test_exec_argv(self):
+        runtime = PodmanRuntime(rootless=True)
+        calls = _recording(runtime)
+        await runtime.exec("c", ["cat"], env={"A": "1"}, input_data="x", workdir="/workspace")
+        assert calls[0][0] == ["podman", "exec", "-i", "-w", "/workspace", "-e", "A=1", "c", "cat"]
+
+    @pytest.mark.asyncio
+    async def test_exec_as_user(self):
+        runtime = DockerRuntime()
+        calls = _recording(runtime)
+        await runtime.exec("c", ["git", "status"], user="orion")
+        assert calls[0][0] == ["docker", "exec", "-u", "orion", "c", "git", "status"]
+
+    @pytest.mark.asyncio
+    async def test_exec_secret_env_by_name(self):
+        """Secret values reach exec through the CLI's env, never its argv."""
+        runtime = DockerRuntime()
+        calls = _recording(runtime)
+        await runtime.exec("c", ["make"], env={"A": "1"}, secret_env={"TOKEN": "hunter2"})
+        cmd, env = calls[0]
+        assert cmd == ["docker", "exec", "-e", "A=1", "-e", "TOKEN", "c", "make"]
+        assert env["TOKEN"] == "hunter2"
+        await runtime.exec("c", ["make"])
+        assert calls[1][1] is None
+
+    @pytest.mark.asyncio
+    async def test_lifecycle_argv(self):
+        runtime = DockerRuntime()
+        calls = _recording(runtime)
+        await runtime.start("c")
+        await runtime.stop("c", grace=5)
+        await runtime.remove("c")
+        await runtime.copy_from("c", "/workspace/dist/app", "/tmp/app")
+        await runtime.connect_network("c", "orion-egress")
+        await runtime.diff("c")
+        await runtime.list_containers("orion.job")
+        assert [cmd for cmd, _ in calls] == [
+            ["docker", "start", "c"],
+            ["docker", "stop", "-t", "5", "c"],
+            ["docker", "rm", "-f", "c"],
+            ["docker", "cp", "c:/workspace/dist/app", "/tmp/app"],
+            ["docker", "network", "connect", "orion-egress", "c"],
+            ["docker", "diff", "c"],
+            ["docker", "ps", "-a", "--filter", "label=orion.job", "--format", "{{.Names}}"],
+        ]
+
+    @pytest.mark.asyncio
+    async def test_image_arch(self):
+        runtime = PodmanRuntime(rootless=True)
+        _recording(runtime, stdout="arm64\n")
+        assert await runtime.image_arch("orion-stack-go:latest") == "arm64"
+        _recording(runtime, returncode=125)
+        assert await runtime.image_arch("missing:latest") is None
+
+    @pytest.mark.asyncio
+    async def test_pull_failure(self):
+        runtime = DockerRuntime()
+        _recording(runtime, returncode=1)
+        assert (await runtime.pull("orion-stack-go:latest")).returncode == 1
+
+
+class TestImages:
+    def test_registry_of(self):
+        assert registry_of("orion-stack-go:latest") == "docker.io"
+        assert registry_of("acme/orion-stack-go") == "docker.io"
+        assert registry_of("ghcr.io/acme/orion-stack-go:1.2") == "ghcr.io"
+        assert registry_of("localhost:5000/stack") == "localhost:5000"
+
+    @pytest.mark.asyncio
+    async def test_digest_prefers_own_repository(self):
+        runtime = DockerRuntime()
+        digests = ["other/stack@sha256:bbb", "ghcr.io/acme/stack@sha256:aaa"]
+        _recording(runtime, stdout=f"sha256:id {json.dumps(digests)}\n")
+        assert await runtime.image_digest("ghcr.io/acme/stack:latest") == "sha256:aaa"
+
+    @pytest.mark.asyncio
+    async def test_digest_of_local_build_is_image_id(self):
+        runtime = PodmanRuntime(rootless=True)
+        _recording(runtime, stdout="sha256:id []\n")
+        assert await runtime.image_digest("orion-stack-go:latest") == "sha256:id"
+        _recording(runtime, returncode=125)
+        assert await runtime.image_digest("missing:latest") is None
+
+    @staticmethod
+    def _capture_auth(runtime, auth_path):
+        """Record the pull argv and the auth file's content during the call."""
+        seen = {}
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            path = auth_path(cmd, env)
+            with open(path) as fh:
+                seen["auth"] = json.load(fh)
+            seen["cmd"], seen["path"] = cmd, path
+            return subprocess.CompletedProcess(cmd, 0, "", "")
+
+        runtime._run = fake_run
+        return seen
+
+    @pytest.mark.asyncio
+    async def test_docker_login_uses_throwaway_config(self):
+        runtime = DockerRuntime()
+        seen = self._capture_auth(
+            runtime, lambda cmd, env: os.path.join(env["DOCKER_CONFIG"], "config.json")
+        )
+        login = RegistryLogin("ci-bot", "hunter2")
+        assert (await runtime.pull("ghcr.io/acme/stack:latest", login=login)).returncode == 0
+        token = base64.b64encode(b"ci-bot:hunter2").decode()
+        assert seen["auth"] == {"auths": {"ghcr.io": {"auth": token}}}
+        assert "hunter2" not in " ".join(seen["cmd"])
+        assert not os.path.exists(seen["path"])
+
+    @pytest.mark.asyncio
+    async def test_docker_hub_key(self):
+        runtime = DockerRuntime()
+        seen = self._capture_auth(
+            runtime, lambda cmd, env: os.path.join(env["DOCKER_CONFIG"], "config.json")
+        )
+        await runtime.pull("acme/stack", login=RegistryLogin("u", "p"))
+        assert list(seen["auth"]["auths"]) == ["https://index.docker.io/v1/"]
+
+    @pytest.mark.asyncio
+    async def test_podman_login_uses_authfile(self):
+        runtime = PodmanRuntime(rootless=True)
+        seen = self._capture_auth(runtime, lambda cmd, env: cmd[cmd.index("--authfile") + 1])
+        await runtime.pull("ghcr.io/acme/stack:latest", login=RegistryLogin("u", "p"))
+        assert list(seen["auth"]["auths"]) == ["ghcr.io"]
+        assert seen["cmd"][-1] == "ghcr.io/acme/stack:latest"
+
+    @pytest.mark.asyncio
+    async def test_pull_without_login_keeps_host_config(self):
+        runtime = DockerRuntime()
+        calls = _recording(runtime)
+        await runtime.pull("orion-stack-go:latest")
+        assert calls == [(["docker", "pull", "orion-stack-go:latest"], None)]
+
+    def test_login_repr_hides_password(self):
+        assert "hunter2" not in repr(RegistryLogin("ci-bot", "hunter2"))
+
+    @pytest.mark.parametrize(
+        "output, expected",
+        [
+            ("Error response from daemon: unauthorized: incorrect username or password", True),
+            ("Error: initializing source: reading manifest: authentication required", True),
+            ("denied: no basic auth credentials", True),
+            ("Error response from daemon: manifest unknown", False),
+            ("received unexpected HTTP status: 503 Service Unavailable", False),
+        ],
+    )
+    def test_is_auth_failure(self, output, expected):
+        assert is_auth_failure(output) is expected
+
+
+class TestStream:
+    @pytest.mark.asyncio
+    async def test_reports_each_line(self):
+        """Streamed output is delivered per line, tagged, and still captured."""
+        seen: list[tuple[str, str]] = []
+        result = await DockerRuntime._stream(
+            ["sh", "-c", "echo one; echo two >&2; echo three; exit 3"],
+            timeout=10,
+            on_output=lambda stream, line: seen.append((stream, line)),
+        )
+        assert result.returncode == 3
+        assert [line for stream, line in seen if stream == "stdout"] == ["one", "three"]
+        assert [line for stream, line in seen if stream == "stderr"] == ["two"]
+        assert result.stdout == "one\nthree\n"
+
+    @pytest.mark.asyncio
+    async def test_max_output_bounds_capture(self):
+        """Past max_output only reporting continues; nothing more is kept."""
+        seen: list[str] = []
+        result = await DockerRuntime._stream(
+            ["sh", "-c", "echo aaaa; echo bbbb >&2; sleep 0.05; echo cccc; echo d"],
+            timeout=10,
+            on_output=lambda stream, line: seen.append(line),
+            max_output=12,
+        )
+        assert seen == ["aaaa", "bbbb", "cccc", "d"]
+        assert (result.stdout, result.stderr) == ("aaaa\n", "bbbb\n")
+
+    @pytest.mark.asyncio
+    async def test_line_over_stream_limit(self):
+        """A line past asyncio's 64 KiB readline limit is reported in pieces, not fatal."""
+        seen: list[str] = []
+        result = await DockerRuntime._stream(
+            ["sh", "-c", "head -c 200000 /dev/zero | tr '\\0' x; echo; echo done; exit 2"],
+            timeout=10,
+            on_output=lambda stream, line: seen.append(line),
+        )
+        assert result.returncode == 2
+        assert result.stdout == "x" * 200000 + "\ndone\n"
+        assert "".join(seen[:-1]) == "x" * 200000 and seen[-1] == "done"
+        assert max(len(line) for line in seen) <= STREAM_CHUNK
+
+    @pytest.mark.asyncio
+    async def test_env(self):
+        """A streamed command runs with the given environment."""
+        result = await DockerRuntime._stream(
+            ["sh", "-c", 'echo "$TOKEN"'],
+            timeout=10,
+            on_output=None,
+            env={"PATH": "/usr/bin:/bin", "TOKEN": "hunter2"},
+        )
+        assert result.stdout == "hunter2\n"
+
+    @pytest.mark.asyncio
+    async def test_timeout_kills(self):
+        """A streamed command past its timeout is killed."""
+        with pytest.raises(asyncio.TimeoutError):
+            await DockerRuntime._stream(
+                ["sh", "-c", "sleep 5"], timeout=0.2, on_output=lambda stream, line: None
+            )
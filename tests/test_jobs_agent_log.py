@@ -0,0 +1,113 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the job agent's own (operational) logging."""
+
+from __future__ import annotations
+
+import asyncio
+import io
+import json
+import logging
+
+import pytest
+
+from orion.security.jobs.agent_log import AGENT_LOGGER, configure_logging, job_context
+from orion.security.jobs.config import LogConfig
+
+log = logging.getLogger("orion.security.jobs.test")
+
+
+@pytest.fixture
+def stream():
+    buf = io.StringIO()
+    yield buf
+    agent = logging.getLogger(AGENT_LOGGER)
+    for handler in list(agent.handlers):
+        if getattr(handler, "_orion_agent_log", False):
+            agent.removeHandler(handler)
+    agent.setLevel(logging.NOTSET)
+    agent.propagate = True
+
+
+def _records(buf: io.StringIO) -> list[dict]:
+    return [json.loads(line) for line in buf.getvalue().splitlines()]
+
+
+class TestJsonFormat:
+    def test_fields(self, stream):
+        configure_logging(LogConfig(format="json"), stream)
+        with job_context("abc123", "go"):
+            log.info("Job %s accepted", "abc123")
+        (entry,) = _records(stream)
+        assert entry["level"] == "INFO"
+        assert entry["message"] == "Job abc123 accepted"
+        assert entry["job_id"] == "abc123"
+        assert entry["stack"] == "go"
+        assert entry["logger"] == "orion.security.jobs.test"
+        assert entry["timestamp"].endswith("Z")
+
+    def test_no_job_outside_context(self, stream):
+        configure_logging(LogConfig(format="json"), stream)
+        log.info("idle")
+        (entry,) = _records(stream)
+        assert entry["job_id"] is None
+        assert entry["stack"] is None
+
+    def test_exception(self, stream):
+        configure_logging(LogConfig(format="json"), stream)
+        try:
+            raise RuntimeError("boom")
+        except RuntimeError:
+            log.exception("failed")
+        (entry,) = _records(stream)
+        assert entry["level"] == "ERROR"
+        assert "RuntimeError: boom" in entry["exception"]
+
+    async def test_context_follows_tasks(self, stream):
+        configure_logging(LogConfig(format="json"), stream)
+
+        async def job():
+            await asyncio.sleep(0)
+            log.info("inside")
+
+        with job_context("j1", "go"):
+            first = asyncio.create_task(job())
+        with job_context("j2", "node"):
+            second = asyncio.create_task(job())
+        log.info("outside")
+        await asyncio.gather(first, second)
+
+        by_job = {entry["job_id"]: entry["stack"] for entry in _records(stream)}
+        assert by_job == {None: None, "j1": "go", "j2": "node"}
+
+
+class TestConfigure:
+    def test_text_is_default(self, stream):
+        configure_logging(LogConfig(), stream)
+        with job_context("abc123", "go"):
+            log.info("hello")
+        line = stream.getvalue().strip()
+        assert line.endswith("[job=abc123 stack=go] hello")
+        assert not line.startswith("{")
+
+    def test_level(self, stream):
+        configure_logging(LogConfig(level="WARNING"), stream)
+        log.info("hidden")
+        log.warning("shown")
+        assert "hidden" not in stream.getvalue()
+        assert "shown" in stream.getvalue()
+
+    def test_reconfigure_replaces_handler(self, stream):
+        configure_logging(LogConfig(), io.StringIO())
+        configure_logging(LogConfig(format="json"), stream)
+        log.info("once")
+        assert len(_records(stream)) == 1
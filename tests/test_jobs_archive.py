@@ -0,0 +1,204 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for archive job sources: uploads and safe unpacking."""
+
+from __future__ import annotations
+
+import io
+import os
+import stat
+import tarfile
+import time
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.archive import (
+    ArchiveError,
+    ArchiveQuotaError,
+    UploadsFullError,
+    extract_archive,
+    prune_uploads,
+    save_upload,
+    upload_path,
+    uploads_size,
+)
+
+
+_TYPES = (tarfile.DIRTYPE, tarfile.SYMTYPE, tarfile.LNKTYPE, tarfile.CHRTYPE, tarfile.FIFOTYPE)
+
+
+def _tar(tmp_path: Path, *entries, gz: bool = False) -> Path:
+    """Write a tar of ``(name, data)`` and ``(name, tarfile type[, linkname])`` entries."""
+    path = tmp_path / ("src.tar.gz" if gz else "src.tar")
+    with tarfile.open(path, "w:gz" if gz else "w") as tar:
+        for name, content, *link in entries:
+            info = tarfile.TarInfo(name)
+            if content not in _TYPES:
+                info.size = len(content)
+                info.mode = 0o644
+                tar.addfile(info, io.BytesIO(content))
+            else:
+                info.type = content
+                info.mode = 0o755
+                info.linkname = link[0] if link else ""
+                tar.addfile(info)
+    return path
+
+
+async def _chunks(*parts: bytes):
+    for part in parts:
+        yield part
+
+
+class TestExtract:
+    def test_files_and_dirs(self, tmp_path: Path):
+        archive = _tar(
+            tmp_path,
+            ("./", tarfile.DIRTYPE),
+            ("src", tarfile.DIRTYPE),
+            ("src/main.go", b"package main\n"),
+            ("go.mod", b"module acme\n"),
+            gz=True,
+        )
+        dest = tmp_path / "ws"
+        assert extract_archive(archive, dest) == len(b"package main\n") + len(b"module acme\n")
+        assert (dest / "src" / "main.go").read_bytes() == b"package main\n"
+        assert (dest / "go.mod").read_bytes() == b"module acme\n"
+
+    def test_plain_tar(self, tmp_path: Path):
+        dest = tmp_path / "ws"
+        extract_archive(_tar(tmp_path, ("a/b/c.txt", b"x")), dest)
+        assert (dest / "a" / "b" / "c.txt").read_bytes() == b"x"
+
+    def test_setuid_bits_dropped(self, tmp_path: Path):
+        path = tmp_path / "src.tar"
+        with tarfile.open(path, "w") as tar:
+            info = tarfile.TarInfo("tool")
+            info.size, info.mode = 1, 0o4755
+            tar.addfile(info, io.BytesIO(b"x"))
+        dest = tmp_path / "ws"
+        extract_archive(path, dest)
+        assert stat.S_IMODE(os.stat(dest / "tool").st_mode) == 0o755
+
+    def test_relative_symlink_inside(self, tmp_path: Path):
+        archive = _tar(
+            tmp_path,
+            ("lib/real.txt", b"x"),
+            ("lib/alias.txt", tarfile.SYMTYPE, "real.txt"),
+            ("copy.txt", tarfile.LNKTYPE, "lib/real.txt"),
+        )
+        dest = tmp_path / "ws"
+        extract_archive(archive, dest)
+        assert os.readlink(dest / "lib" / "alias.txt") == "real.txt"
+        assert (dest / "copy.txt").read_bytes() == b"x"
+
+    @pytest.mark.parametrize(
+        "entry, message",
+        [
+            (("/etc/passwd", b"x"), "absolute path"),
+            (("../escape.txt", b"x"), "'..' component"),
+            (("src/../../escape.txt", b"x"), "'..' component"),
+            (("link", tarfile.SYMTYPE, "/etc"), "absolute target"),
+            (("link", tarfile.SYMTYPE, "../outside"), "outside the workspace"),
+            (("a/link", tarfile.SYMTYPE, "../../outside"), "outside the workspace"),
+            (("hard", tarfile.LNKTYPE, "../outside"), "outside the workspace"),
+            (("dev", tarfile.CHRTYPE), "special file"),
+            (("pipe", tarfile.FIFOTYPE), "special file"),
+        ],
+    )
+    def test_unsafe_entries_rejected(self, tmp_path: Path, entry, message):
+        dest = tmp_path / "ws"
+        with pytest.raises(ArchiveError, match=message):
+            extract_archive(_tar(tmp_path, entry), dest)
+        assert not (tmp_path / "escape.txt").exists()
+
+    def test_write_through_symlink_rejected(self, tmp_path: Path):
+        outside = tmp_path / "outside"
+        outside.mkdir()
+        archive = _tar(
+            tmp_path,
+            ("a", tarfile.DIRTYPE),
+            ("a/up", tarfile.SYMTYPE, ".."),
+            ("b", tarfile.SYMTYPE, "a/up/.."),  # reads as 'a', resolves above the root
+        )
+        with pytest.raises(ArchiveError, match="outside the workspace"):
+            extract_archive(archive, tmp_path / "ws")
+        assert not (tmp_path / "ws" / "b").exists()
+
+    def test_file_replacing_a_link_rejected(self, tmp_path: Path):
+        archive = _tar(
+            tmp_path, ("real", b"x"), ("alias", tarfile.SYMTYPE, "real"), ("alias", b"y")
+        )
+        with pytest.raises(ArchiveError, match="replaces a link"):
+            extract_archive(archive, tmp_path / "ws")
+        assert (tmp_path / "ws" / "real").read_bytes() == b"x"
+
+    def test_quota_enforced_before_writing(self, tmp_path: Path):
+        archive = _tar(tmp_path, ("small", b"x" * 10), ("big", b"y" * 100))
+        dest = tmp_path / "ws"
+        with pytest.raises(ArchiveQuotaError, match="workspace.maxSizeGB"):
+            extract_archive(archive, dest, max_bytes=50)
+        assert (dest / "small").exists()
+        assert not (dest / "big").exists()
+
+    def test_within_quota(self, tmp_path: Path):
+        archive = _tar(tmp_path, ("a", b"x" * 10), ("b", b"y" * 10))
+        assert extract_archive(archive, tmp_path / "ws", max_bytes=20) == 20
+
+    def test_not_an_archive(self, tmp_path: Path):
+        path = tmp_path / "junk.tar"
+        path.write_bytes(b"definitely not a tarball" * 40)
+        with pytest.raises(ArchiveError, match="not a readable tar"):
+            extract_archive(path, tmp_path / "ws")
+
+    def test_truncated_gzip(self, tmp_path: Path):
+        archive = _tar(tmp_path, ("a", os.urandom(4096)), gz=True)
+        archive.write_bytes(archive.read_bytes()[:200])
+        with pytest.raises(ArchiveError):
+            extract_archive(archive, tmp_path / "ws")
+
+
+class TestUploads:
+    @pytest.mark.asyncio
+    async def test_saved_under_its_id(self, tmp_path: Path):
+        upload_id = await save_upload(tmp_path, _chunks(b"abc", b"def"), max_bytes=100)
+        assert len(upload_id) == 32
+        assert upload_path(tmp_path, upload_id).read_bytes() == b"abcdef"
+
+    @pytest.mark.asyncio
+    async def test_too_large_rejected(self, tmp_path: Path):
+        with pytest.raises(ArchiveError, match="source.maxArchiveSize"):
+            await save_upload(tmp_path, _chunks(b"x" * 60, b"x" * 60), max_bytes=100)
+        assert list(tmp_path.iterdir()) == []
+
+    @pytest.mark.asyncio
+    async def test_total_capped(self, tmp_path: Path):
+        await save_upload(tmp_path, _chunks(b"x" * 60), max_bytes=100, max_total=100)
+        with pytest.raises(UploadsFullError, match="source.maxUploadsSize"):
+            await save_upload(tmp_path, _chunks(b"x" * 30, b"x" * 30), max_bytes=100, max_total=100)
+        assert uploads_size(tmp_path) == 60  # the refused part is gone
+        await save_upload(tmp_path, _chunks(b"x" * 40), max_bytes=100, max_total=100)
+
+    @pytest.mark.asyncio
+    async def test_empty_rejected(self, tmp_path: Path):
+        with pytest.raises(ArchiveError, match="empty"):
+            await save_upload(tmp_path, _chunks(), max_bytes=100)
+        assert list(tmp_path.iterdir()) == []
+
+    def test_unclaimed_uploads_pruned(self, tmp_path: Path):
+        old, new = tmp_path / ("a" * 32 + ".tar"), tmp_path / ("b" * 32 + ".tar")
+        old.write_bytes(b"x")
+        new.write_bytes(b"x")
+        os.utime(old, (time.time() - 7200, time.time() - 7200))
+        prune_uploads(tmp_path, ttl=3600)
+        assert not old.exists() and new.exists()
@@ -0,0 +1,138 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for artifact pattern matching and selection."""
+
+from __future__ import annotations
+
+import io
+import os
+import tarfile
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.artifacts import (
+    ARCHIVE_CHUNK,
+    Artifact,
+    archive_artifacts,
+    compile_pattern,
+    parse_listing,
+    select_artifacts,
+    validate_patterns,
+)
+
+
+class TestCompilePattern:
+    @pytest.mark.parametrize(
+        "pattern, path, matches",
+        [
+            ("dist/**", "dist/app", True),
+            ("dist/**", "dist/linux/amd64/app", True),
+            ("dist/**", "dist", False),
+            ("dist/**", "distro/app", False),
+            ("*.junit.xml", "unit.junit.xml", True),
+            ("*.junit.xml", "reports/unit.junit.xml", False),
+            ("**/*.junit.xml", "reports/unit.junit.xml", True),
+            ("**/*.junit.xml", "unit.junit.xml", True),
+            ("bin/app?", "bin/app1", True),
+            ("bin/app?", "bin/app12", False),
+            ("coverage.out", "coverage.out", True),
+            ("coverage.out", "coverage_out", False),
+        ],
+    )
+    def test_matching(self, pattern: str, path: str, matches: bool):
+        assert bool(compile_pattern(pattern).match(path)) is matches
+
+
+class TestValidatePatterns:
+    def test_valid(self):
+        assert validate_patterns(["dist/**", "*.xml"]) == []
+
+    def test_problems(self):
+        problems = validate_patterns(["", "/abs", "a/../b"])
+        assert len(problems) == 3
+
+
+class TestParseListing:
+    def test_strips_dot_prefix(self):
+        listing = "12 ./dist/app\n0 ./empty file.txt\nbogus\n"
+        assert parse_listing(listing) == [("dist/app", 12), ("empty file.txt", 0)]
+
+
+class TestSelectArtifacts:
+    FILES = [("dist/a", 10), ("dist/b", 20), ("report.junit.xml", 5), ("main.go", 1)]
+
+    def test_union_in_path_order(self):
+        selected, warnings = select_artifacts(self.FILES, ["*.junit.xml", "dist/**"], 1000)
+        assert [a.path for a in selected] == ["dist/a", "dist/b", "report.junit.xml"]
+        assert warnings == []
+
+    def test_overlapping_patterns_copied_once(self):
+        selected, _ = select_artifacts(self.FILES, ["dist/**", "dist/a"], 1000)
+        assert [a.path for a in selected] == ["dist/a", "dist/b"]
+
+    def test_unmatched_pattern_warns(self):
+        selected, warnings = select_artifacts(self.FILES, ["build/**"], 1000)
+        assert selected == []
+        assert warnings == ["Artifact pattern 'build/**' matched no files"]
+
+    def test_size_cap(self):
+        selected, warnings = select_artifacts(self.FILES, ["**"], 16)
+        assert [a.path for a in selected] == ["dist/a", "main.go", "report.junit.xml"]
+        assert sum(a.size for a in selected) <= 16
+        assert "skipped 1 file(s): dist/b" in warnings[0]
+
+
+class TestArchiveArtifacts:
+    @staticmethod
+    def _open(chunks) -> tarfile.TarFile:
+        return tarfile.open(fileobj=io.BytesIO(b"".join(chunks)), mode="r:gz")
+
+    def test_paths_modes_and_content(self, tmp_path: Path):
+        (tmp_path / "dist").mkdir()
+        binary = os.urandom(3 * ARCHIVE_CHUNK + 17)
+        (tmp_path / "dist" / "app").write_bytes(binary)
+        (tmp_path / "dist" / "app").chmod(0o755)
+        (tmp_path / "report.xml").write_text("<ok/>")
+        (tmp_path / "report.xml").chmod(0o640)
+        artifacts = [Artifact("dist/app", len(binary)), Artifact("report.xml", 5)]
+
+        archive = self._open(archive_artifacts(tmp_path, artifacts))
+        assert archive.getnames() == ["dist/app", "report.xml"]
+        assert archive.getmember("dist/app").mode == 0o755
+        assert archive.getmember("report.xml").mode == 0o640
+        assert archive.extractfile("dist/app").read() == binary
+        assert archive.extractfile("report.xml").read() == b"<ok/>"
+
+    def test_streams_in_chunks(self, tmp_path: Path):
+        (tmp_path / "big").write_bytes(os.urandom(8 * ARCHIVE_CHUNK))
+        chunks = list(archive_artifacts(tmp_path, [Artifact("big", 8 * ARCHIVE_CHUNK)]))
+        assert len(chunks) > 4
+        assert max(len(chunk) for chunk in chunks) < 2 * ARCHIVE_CHUNK
+
+    def test_skips_missing_and_escaping_files(self, tmp_path: Path):
+        root = tmp_path / "artifacts"
+        root.mkdir()
+        (tmp_path / "secret").write_text("host file")
+        (root / "link").symlink_to(tmp_path / "secret")
+        (root / "kept").write_text("x")
+        artifacts = [Artifact("gone", 1), Artifact("link", 9), Artifact("kept", 1)]
+        assert self._open(archive_artifacts(root, artifacts)).getnames() == ["kept"]
+
+    def test_file_changed_since_copy_keeps_the_archive_valid(self, tmp_path: Path):
+        (tmp_path / "log").write_text("abc")
+        chunks = archive_artifacts(tmp_path, [Artifact("log", 3)])
+        first = next(chunks, b"")  # header written with size 3...
+        (tmp_path / "log").write_text("a")  # ...then the file shrinks
+        archive = self._open([first, *chunks])
+        assert archive.extractfile("log").read() == b"a\0\0"
+
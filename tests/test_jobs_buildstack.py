@@ -0,0 +1,154 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for building and verifying stack images."""
+
+from __future__ import annotations
+
+import json
+import subprocess
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.buildstack import StackBuildError, build_stack, main, verify_image
+from orion.security.stack_detector import STACKS_DIR
+
+GOOD_LABELS = {"orion.stack": "go", "orion.toolchain": "go"}
+GOOD_PROBE = "user=orion\norion_uid=1000\nworkdir=/workspace\ntoolchain=/usr/local/go/bin/go\n"
+
+
+class FakeEngine:
+    """Scripted container CLI; records every argv."""
+
+    def __init__(self, labels=GOOD_LABELS, probe=GOOD_PROBE, build_code=0, run_code=0):
+        self.labels = labels
+        self.probe = probe
+        self.build_code = build_code
+        self.run_code = run_code
+        self.calls: list[list[str]] = []
+
+    def __call__(self, argv: list[str], timeout: int) -> subprocess.CompletedProcess:
+        self.calls.append(argv)
+        if argv[1] == "build":
+            return subprocess.CompletedProcess(argv, self.build_code, "", "step 3/9: exit 1")
+        if argv[1] == "image":
+            return subprocess.CompletedProcess(argv, 0, json.dumps(self.labels), "")
+        return subprocess.CompletedProcess(argv, self.run_code, self.probe, "boom")
+
+
+def _stacks(tmp_path: Path) -> Path:
+    (tmp_path / "Dockerfile.go").write_text('FROM ubuntu:22.04\nLABEL orion.stack="go"\n')
+    return tmp_path
+
+
+class TestBuildStack:
+    def test_builds_then_verifies(self, tmp_path: Path):
+        engine = FakeEngine()
+        report = build_stack("go", _stacks(tmp_path), run=engine)
+        assert report.ok and report.image == "orion-stack-go:latest"
+        build, inspect, run = engine.calls
+        assert build == [
+            "docker", "build", "-f", str(tmp_path / "Dockerfile.go"),
+            "-t", "orion-stack-go:latest", str(tmp_path),
+        ]  # fmt: skip
+        assert inspect[:3] == ["docker", "image", "inspect"]
+        assert run[:5] == ["docker", "run", "--rm", "--network", "none"]
+        assert run[-1] == "go"  # the toolchain binary looked up
+
+    def test_podman_and_no_build(self, tmp_path: Path):
+        engine = FakeEngine()
+        build_stack("go", _stacks(tmp_path), binary="podman", run=engine, build=False)
+        assert [argv[:2] for argv in engine.calls] == [["podman", "image"], ["podman", "run"]]
+
+    def test_missing_dockerfile(self, tmp_path: Path):
+        with pytest.raises(StackBuildError, match="No Dockerfile.rust"):
+            build_stack("rust", tmp_path, run=FakeEngine())
+
+    def test_build_failure(self, tmp_path: Path):
+        with pytest.raises(StackBuildError, match="step 3/9"):
+            build_stack("go", _stacks(tmp_path), run=FakeEngine(build_code=1))
+
+    def test_image_that_cannot_start(self):
+        with pytest.raises(StackBuildError, match="Cannot run orion-stack-go:latest: boom"):
+            verify_image("go", "orion-stack-go:latest", run=FakeEngine(run_code=127))
+
+
+class TestInvariants:
+    def _problems(self, **kwargs) -> list[str]:
+        return verify_image("go", "orion-stack-go:latest", run=FakeEngine(**kwargs)).problems
+
+    def test_missing_stack_label(self):
+        problems = self._problems(labels={"orion.toolchain": "go"})
+        assert problems == [
+            "label: LABEL orion.stack is missing or empty, so the stack cannot be resolved"
+        ]
+        assert self._problems(labels=None)[0].startswith("label: LABEL orion.stack is missing")
+
+    def test_mismatched_stack_label(self):
+        problems = self._problems(labels={"orion.stack": "golang", "orion.toolchain": "go"})
+        assert problems == ["label: LABEL orion.stack='golang' does not match Dockerfile.go"]
+
+    def test_no_orion_user(self):
+        problems = self._problems(probe="user=root\norion_uid=\nworkdir=/workspace\ntoolchain=/x\n")
+        assert problems == [
+            "user: no 'orion' user (add one with UID 1000)",
+            "user: the image runs as 'root'; add 'USER orion'",
+        ]
+
+    @pytest.mark.parametrize(
+        ("uid", "message"),
+        [
+            ("0", "user: 'orion' is root (UID 0); it must be UID 1000"),
+            ("1001", "user: 'orion' has UID 1001, not 1000 (ORION_UID in container_runtime.py)"),
+        ],
+    )
+    def test_orion_uid(self, uid: str, message: str):
+        probe = GOOD_PROBE.replace("orion_uid=1000", f"orion_uid={uid}")
+        assert self._problems(probe=probe) == [message]
+
+    def test_workdir(self):
+        probe = GOOD_PROBE.replace("workdir=/workspace", "workdir=/")
+        assert self._problems(probe=probe) == ["workdir: WORKDIR is '/', not /workspace"]
+
+    def test_toolchain_not_on_path(self):
+        probe = GOOD_PROBE.replace("toolchain=/usr/local/go/bin/go", "toolchain=")
+        assert self._problems(probe=probe) == [
+            "toolchain: 'go' (LABEL orion.toolchain) is not on PATH for 'orion'"
+        ]
+
+    def test_stack_without_toolchain_skips_the_check(self):
+        engine = FakeEngine(labels={"orion.stack": "base"}, probe=GOOD_PROBE.split("toolchain")[0])
+        assert verify_image("base", "orion-stack-base:latest", run=engine).ok
+        assert engine.calls[-1][-1] == ""
+
+    def test_every_violation_is_reported(self):
+        problems = self._problems(labels={}, probe="user=root\n")
+        assert [p.split(":")[0] for p in problems] == ["label", "user", "user", "workdir"]
+
+
+class TestMain:
+    @pytest.mark.parametrize(("probe", "status"), [(GOOD_PROBE, 0), ("user=orion\n", 1)])
+    def test_exit_status(self, monkeypatch, probe: str, status: int):
+        import orion.security.jobs.buildstack as buildstack
+
+        def fake_build(name, stacks_dir, binary, build):
+            assert (binary, build) == ("podman", False)
+            return verify_image(name, f"orion-stack-{name}:latest", run=FakeEngine(probe=probe))
+
+        monkeypatch.setattr(buildstack, "build_stack", fake_build)
+        assert main(["--no-build", "--driver", "podman", "go"]) == status
+
+
+def test_shipped_stacks_declare_their_toolchain():
+    for stack in ("go", "node", "python", "rust", "java"):
+        text = (STACKS_DIR / f"Dockerfile.{stack}").read_text()
+        assert 'LABEL orion.toolchain="' in text, stack
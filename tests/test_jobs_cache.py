@@ -0,0 +1,172 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the persistent build cache."""
+
+from __future__ import annotations
+
+import os
+import stat
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.cache import CACHE_MOUNTS, BuildCache, cache_name
+from orion.security.jobs.config import CacheConfig
+
+
+@pytest.fixture
+def cache(tmp_path: Path) -> BuildCache:
+    return BuildCache(CacheConfig(enabled=True, max_size_gb=1.0, path=str(tmp_path / "cache")))
+
+
+def _capped(root: Path, max_bytes: int) -> BuildCache:
+    return BuildCache(CacheConfig(enabled=True, max_size_gb=max_bytes / 1024**3, path=str(root)))
+
+
+def _fill(path: Path, size: int) -> None:
+    path.mkdir(parents=True, exist_ok=True)
+    (path / "blob").write_bytes(b"\0" * size)
+
+
+class TestAcquire:
+    def test_go_mounts(self, cache: BuildCache):
+        volumes = cache.acquire("go")
+        assert f"{cache.root}/go/mod:/home/orion/go/pkg/mod:rw" in volumes
+        assert f"{cache.root}/go/go-build:/home/orion/.cache/go-build:rw" in volumes
+        # Host dirs exist before docker run so they aren't created root-owned
+        for name in CACHE_MOUNTS["go"]:
+            assert (cache.root / "go" / name).is_dir()
+
+    def test_node_npm_cache(self, cache: BuildCache):
+        assert cache.acquire("node") == [f"{cache.root}/node/npm:/home/orion/.npm:rw"]
+
+    def test_rust_cargo_home_and_target_dir(self, cache: BuildCache):
+        assert cache.acquire("rust") == [
+            f"{cache.root}/rust/cargo:/home/orion/.cargo:rw",
+            f"{cache.root}/rust/target:/home/orion/.cache/cargo-target:rw",
+        ]
+
+    def test_rust_mounts_match_image(self):
+        """The image points cargo at the mount points, cached or not."""
+        from orion.security.stack_detector import STACKS_DIR
+
+        text = (STACKS_DIR / "Dockerfile.rust").read_text(encoding="utf-8")
+        assert f"ENV CARGO_HOME={CACHE_MOUNTS['rust']['cargo']}\n" in text
+        assert f"ENV CARGO_TARGET_DIR={CACHE_MOUNTS['rust']['target']}\n" in text
+        # rustup's proxies must not live under the mounted CARGO_HOME
+        assert "CARGO_HOME=/home/orion/.rustup sh" in text
+
+    def test_java_maven_and_gradle_homes(self, cache: BuildCache):
+        assert cache.acquire("java") == [
+            f"{cache.root}/java/m2:/home/orion/.m2:rw",
+            f"{cache.root}/java/gradle:/home/orion/.gradle:rw",
+        ]
+
+    def test_java_mounts_match_image(self):
+        from orion.security.stack_detector import STACKS_DIR
+
+        text = (STACKS_DIR / "Dockerfile.java").read_text(encoding="utf-8")
+        assert f"ENV GRADLE_USER_HOME={CACHE_MOUNTS['java']['gradle']}\n" in text
+        # Maven's default local repository is ~/.m2/repository
+        assert "-Dmaven.repo.local" not in text and "settings.xml" not in text
+
+    def test_disabled(self, tmp_path: Path):
+        off = BuildCache(CacheConfig(enabled=False, path=str(tmp_path)))
+        assert off.acquire("go") == []
+        assert off.acquire("rust") == []
+        assert off.is_leased("go") is False
+
+    def test_uncached_stack(self, cache: BuildCache):
+        assert cache.acquire("base") == []
+
+    def test_leases_are_counted(self, cache: BuildCache):
+        cache.acquire("go")
+        cache.acquire("go")
+        cache.release("go")
+        assert cache.is_leased("go") is True
+        cache.release("go")
+        assert cache.is_leased("go") is False
+
+    def test_separate_cache_per_run_as_uid(self, cache: BuildCache):
+        assert cache.acquire("node", uid=1500) == [
+            f"{cache.root}/node-uid1500/npm:/home/orion/.npm:rw"
+        ]
+        assert cache.is_leased("node-uid1500") is True
+        assert cache.is_leased("node") is False
+        cache.release("node", uid=1500)
+        assert cache.is_leased("node-uid1500") is False
+        # orion's own UID shares the default cache
+        assert cache_name("node", 1000) == "node"
+
+
+class TestEvict:
+    def test_under_limit_noop(self, cache: BuildCache):
+        _fill(cache.root / "go" / "mod", 1024)
+        assert cache.evict() == []
+
+    def test_lru_evicted_first(self, tmp_path: Path):
+        small = _capped(tmp_path, 1500)
+        for name, mtime in (("go", 100), ("node", 200)):
+            _fill(tmp_path / name / "x", 1000)
+            marker = tmp_path / name / ".last_used"
+            marker.touch()
+            os.utime(marker, (mtime, mtime))
+        assert small.evict() == ["go"]
+        assert not (tmp_path / "go").exists()
+        assert (tmp_path / "node").exists()
+
+    def test_leased_cache_not_evicted(self, tmp_path: Path):
+        small = _capped(tmp_path, 10)
+        small.acquire("go")
+        _fill(tmp_path / "go" / "mod", 1000)
+        assert small.evict() == []
+        assert (tmp_path / "go" / "mod" / "blob").exists()
+
+    def test_lease_taken_during_eviction_gets_a_fresh_cache(self, tmp_path: Path, monkeypatch):
+        import orion.security.jobs.cache as cache_module
+
+        small = _capped(tmp_path, 10)
+        _fill(tmp_path / "go" / "mod", 1000)
+        deleted = []
+        rmtree = cache_module._force_rmtree
+
+        def force_rmtree(path: Path) -> None:
+            # A job leases go while the old cache, renamed aside, is being deleted
+            small.acquire("go")
+            deleted.append(path)
+            rmtree(path)
+
+        monkeypatch.setattr(cache_module, "_force_rmtree", force_rmtree)
+        assert small.evict() == ["go"]
+        assert deleted[0].name.startswith(".evicting-go-") and not deleted[0].exists()
+        assert (tmp_path / "go" / "mod").is_dir()
+        assert not (tmp_path / "go" / "mod" / "blob").exists()
+
+    def test_interrupted_eviction_cleaned_up(self, tmp_path: Path):
+        small = _capped(tmp_path, 10**6)
+        _fill(tmp_path / ".evicting-go-1234abcd" / "mod", 1000)
+        assert small.evict() == []
+        assert list(tmp_path.iterdir()) == []
+
+    def test_read_only_module_cache_removed(self, tmp_path: Path):
+        small = _capped(tmp_path, 10)
+        pkg = tmp_path / "go" / "mod" / "example.com" / "m@v1.0.0"
+        _fill(pkg, 1000)
+        # Go marks module cache directories read-only
+        os.chmod(pkg, stat.S_IRUSR | stat.S_IXUSR)
+        assert small.evict() == ["go"]
+        assert not (tmp_path / "go").exists()
+
+    def test_usage_bytes(self, cache: BuildCache):
+        _fill(cache.root / "go" / "mod", 2048)
+        assert cache.usage_bytes("go") == 2048
+        assert cache.usage_bytes() == 2048
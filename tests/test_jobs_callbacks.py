@@ -0,0 +1,117 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for job completion webhooks."""
+
+from __future__ import annotations
+
+import hashlib
+import hmac
+import json
+
+import pytest
+
+from orion.security.jobs.artifacts import Artifact
+from orion.security.jobs.callbacks import (
+    SIGNATURE_HEADER,
+    callback_payload,
+    deliver,
+    sign,
+)
+from orion.security.jobs.config import CallbacksConfig
+from orion.security.jobs.manifest import display_url
+from orion.security.jobs.executor import JobResult
+
+
+class _Endpoint:
+    """Replays statuses (or raises exceptions) and records each request."""
+
+    def __init__(self, *responses):
+        self.responses = list(responses)
+        self.requests: list[tuple[str, bytes, dict]] = []
+
+    async def __call__(self, url, body, headers, timeout):
+        self.requests.append((url, body, headers))
+        response = self.responses.pop(0)
+        if isinstance(response, Exception):
+            raise response
+        return response
+
+
+class _Sleeps(list):
+    async def __call__(self, delay):
+        self.append(delay)
+
+
+def test_payload():
+    result = JobResult(job_id="j1", stack="go", status="succeeded", exit_code=0)
+    result.duration_seconds = 4.5
+    result.artifacts = [Artifact("dist/app", 10)]
+    assert callback_payload(result) == {
+        "job_id": "j1",
+        "status": "succeeded",
+        "error_code": "",
+        "exit_code": 0,
+        "duration_seconds": 4.5,
+        "artifacts": [{"path": "dist/app", "size": 10}],
+    }
+
+
+def test_signature_is_hmac_of_body():
+    expected = hmac.new(b"key", b'{"a": 1}', hashlib.sha256).hexdigest()
+    assert sign(b'{"a": 1}', "key") == f"sha256={expected}"
+
+
+def test_display_hides_credentials_and_query():
+    url = "https://bot:pw@ci.acme.dev:8443/hooks/orion?token=abc"
+    assert display_url(url) == "https://ci.acme.dev:8443/hooks/orion"
+
+
+class TestDeliver:
+    @pytest.mark.asyncio
+    async def test_signed_post(self):
+        endpoint = _Endpoint(204)
+        assert await deliver("https://h/x", {"job_id": "j1"}, CallbacksConfig(), "key", endpoint)
+        (url, body, headers), = endpoint.requests
+        assert json.loads(body) == {"job_id": "j1"}
+        assert headers["Content-Type"] == "application/json"
+        assert headers[SIGNATURE_HEADER] == sign(body, "key")
+
+    @pytest.mark.asyncio
+    async def test_unsigned_without_secret(self):
+        endpoint = _Endpoint(200)
+        await deliver("https://h/x", {}, CallbacksConfig(), "", endpoint)
+        assert SIGNATURE_HEADER not in endpoint.requests[0][2]
+
+    @pytest.mark.asyncio
+    async def test_transient_failures_retried_with_backoff(self):
+        endpoint = _Endpoint(ConnectionError("refused"), 503, 429, 200)
+        sleeps = _Sleeps()
+        assert await deliver("https://h/x", {}, CallbacksConfig(), post=endpoint, sleep=sleeps)
+        assert sleeps == [1.0, 2.0, 4.0]
+        # Every attempt carries the same body
+        assert len({body for _, body, _ in endpoint.requests}) == 1
+
+    @pytest.mark.asyncio
+    async def test_gives_up_after_max_attempts(self):
+        config = CallbacksConfig()
+        config.retry.max_attempts = 2
+        endpoint = _Endpoint(TimeoutError(), TimeoutError())
+        sleeps = _Sleeps()
+        assert not await deliver("https://h/x", {}, config, post=endpoint, sleep=sleeps)
+        assert len(endpoint.requests) == 2 and sleeps == [1.0]
+
+    @pytest.mark.asyncio
+    async def test_client_error_is_final(self):
+        endpoint = _Endpoint(404)
+        sleeps = _Sleeps()
+        assert not await deliver("https://h/x", {}, CallbacksConfig(), post=endpoint, sleep=sleeps)
+        assert sleeps == []
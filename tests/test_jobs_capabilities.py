@@ -0,0 +1,56 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for matching manifest ``requires`` selectors against agent labels."""
+
+from __future__ import annotations
+
+import pytest
+
+from orion.security.jobs.capabilities import label_value, parse_selector, unmatched
+
+LABELS = {"gpu": "true", "mem": "high", "zone": "eu-1"}
+
+
+class TestParseSelector:
+    def test_equality_and_presence(self):
+        assert parse_selector("gpu=true") == ("gpu", "true")
+        assert parse_selector(" mem = high ") == ("mem", "high")
+        assert parse_selector("cuda") == ("cuda", None)
+        assert parse_selector("acme.dev/tier=gold") == ("acme.dev/tier", "gold")
+
+    @pytest.mark.parametrize("text", ["", "=x", "gpu=", "gpu=a=b", "-gpu", "a b"])
+    def test_invalid(self, text):
+        with pytest.raises(ValueError):
+            parse_selector(text)
+
+
+class TestUnmatched:
+    def test_all_satisfied(self):
+        assert unmatched(["gpu=true", "mem", "zone=eu-1"], LABELS) == []
+        assert unmatched([], {}) == []
+
+    def test_reports_each_missing(self):
+        assert unmatched(["gpu=false", "arch", "mem", "zone=EU-1"], LABELS) == [
+            "gpu=false",
+            "arch",
+            "zone=EU-1",  # values match exactly
+        ]
+
+    def test_unlabelled_agent(self):
+        assert unmatched(["gpu"], {}) == ["gpu"]
+
+
+def test_label_value():
+    assert label_value(True) == "true"
+    assert label_value(False) == "false"
+    assert label_value(64) == "64"
+    assert label_value(" high ") == "high"
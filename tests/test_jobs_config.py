@@ -0,0 +1,769 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the job agent configuration file."""
+
+from __future__ import annotations
+
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.config import (
+    RESTART_REQUIRED,
+    ConfigError,
+    JobsConfig,
+    diff_config,
+    keep_restart_settings,
+    load_config,
+    parse_config,
+    parse_duration,
+    read_config,
+)
+
+
+class TestLoadConfig:
+    def test_missing_file_gives_defaults(self, tmp_path: Path):
+        assert load_config(tmp_path / "nope.yaml") == JobsConfig()
+
+    def test_invalid_file_gives_defaults(self, tmp_path: Path):
+        path = tmp_path / "jobs.yaml"
+        path.write_text("cache: [1, 2\n")
+        assert load_config(path) == JobsConfig()
+
+    def test_cache_section(self, tmp_path: Path):
+        path = tmp_path / "jobs.yaml"
+        path.write_text(f"cache:\n  enabled: true\n  maxSizeGB: 2.5\n  path: {tmp_path}\n")
+        cfg = load_config(path)
+        assert cfg.cache.enabled is True
+        assert cfg.cache.max_size_gb == 2.5
+        assert cfg.cache.path == str(tmp_path)
+
+
+class TestParseConfig:
+    def test_cache_disabled_by_default(self):
+        assert parse_config({}).cache.enabled is False
+
+    def test_section_must_be_mapping(self):
+        with pytest.raises(ConfigError, match="cache"):
+            parse_config({"cache": "yes"})
+
+    def test_max_size_must_be_positive(self):
+        with pytest.raises(ConfigError, match="maxSizeGB"):
+            parse_config({"cache": {"maxSizeGB": 0}})
+
+
+class TestResourcesConfig:
+    def test_defaults_and_caps(self):
+        cfg = parse_config(
+            {
+                "resources": {
+                    "defaultCpu": 1,
+                    "defaultMemory": "1Gi",
+                    "maxCpu": 4,
+                    "maxMemory": "8Gi",
+                }
+            }
+        )
+        assert cfg.resources.default_cpu == 1
+        assert cfg.resources.default_memory == 1024**3
+        assert cfg.resources.max_cpu == 4
+        assert cfg.resources.max_memory == 8 * 1024**3
+
+    def test_unset_by_default(self):
+        assert parse_config({}).resources.max_memory is None
+
+    def test_invalid_memory(self):
+        with pytest.raises(ConfigError, match="resources.maxMemory"):
+            parse_config({"resources": {"maxMemory": "huge"}})
+
+
+class TestTimeoutConfig:
+    def test_durations(self):
+        cfg = parse_config({"timeout": {"default": "30m", "gracePeriod": "5s"}})
+        assert cfg.timeout.default == 1800
+        assert cfg.timeout.grace_period == 5
+
+    def test_defaults(self):
+        cfg = parse_config({})
+        assert cfg.timeout.default == 3600
+        assert cfg.timeout.grace_period == 10
+
+    def test_invalid(self):
+        with pytest.raises(ConfigError, match="timeout.default"):
+            parse_config({"timeout": {"default": "forever"}})
+
+
+class TestParseDuration:
+    def test_units(self):
+        assert parse_duration("500ms") == 0.5
+        assert parse_duration("90s") == 90
+        assert parse_duration("10m") == 600
+        assert parse_duration("1h") == 3600
+
+    def test_bare_numbers_are_seconds(self):
+        assert parse_duration(45) == 45
+        assert parse_duration("45") == 45
+
+    def test_rejects_non_positive_and_garbage(self):
+        for bad in (0, "-5s", "1d", "", True):
+            with pytest.raises(ValueError):
+                parse_duration(bad)
+
+
+class TestSecretsConfig:
+    def test_env_source(self):
+        cfg = parse_config({"secrets": {"source": "env", "envPrefix": "CI_"}})
+        assert cfg.secrets.source == "env"
+        assert cfg.secrets.env_prefix == "CI_"
+
+    def test_unknown_source(self):
+        with pytest.raises(ConfigError, match="secrets.source"):
+            parse_config({"secrets": {"source": "vault"}})
+
+
+class TestSchedulerConfig:
+    def test_limits(self):
+        cfg = parse_config({"scheduler": {"maxConcurrent": 8, "maxQueued": 0}})
+        assert cfg.scheduler.max_concurrent == 8
+        assert cfg.scheduler.max_queued == 0
+
+    def test_zero_concurrency_rejected(self):
+        with pytest.raises(ConfigError, match="scheduler.maxConcurrent"):
+            parse_config({"scheduler": {"maxConcurrent": 0}})
+
+
+class TestShutdownGracePeriod:
+    def test_default(self):
+        assert parse_config({}).scheduler.shutdown_grace_period == 30.0
+
+    def test_values(self):
+        for value, seconds in (("5m", 300.0), (0, 0.0), (90, 90.0)):
+            config = parse_config({"scheduler": {"shutdownGracePeriod": value}})
+            assert config.scheduler.shutdown_grace_period == seconds
+
+    def test_invalid(self):
+        with pytest.raises(ConfigError, match="scheduler.shutdownGracePeriod"):
+            parse_config({"scheduler": {"shutdownGracePeriod": "soon"}})
+
+
+class TestMetricsConfig:
+    def test_enabled_by_default(self):
+        assert parse_config({}).metrics.enabled is True
+
+    def test_disable(self):
+        assert parse_config({"metrics": {"enabled": False}}).metrics.enabled is False
+
+
+class TestArtifactsConfig:
+    def test_default_cap(self):
+        assert parse_config({}).artifacts.max_total_size == 1024**3
+
+    def test_max_total_size(self):
+        cfg = parse_config({"artifacts": {"maxTotalSize": "200Mi"}})
+        assert cfg.artifacts.max_total_size == 200 * 1024**2
+
+    def test_invalid(self):
+        with pytest.raises(ConfigError, match="artifacts.maxTotalSize"):
+            parse_config({"artifacts": {"maxTotalSize": "lots"}})
+
+
+class TestRuntimeConfig:
+    def test_defaults_to_docker(self):
+        assert parse_config({}).runtime.driver == "docker"
+
+    def test_podman_with_socket(self):
+        cfg = parse_config({"runtime": {"driver": "podman", "socket": "/run/podman/podman.sock"}})
+        assert cfg.runtime.driver == "podman"
+        assert cfg.runtime.socket == "/run/podman/podman.sock"
+
+    def test_unknown_driver(self):
+        with pytest.raises(ConfigError, match="runtime.driver"):
+            parse_config({"runtime": {"driver": "lxc"}})
+
+
+class TestSourceConfig:
+    def test_defaults(self):
+        cfg = parse_config({})
+        assert cfg.source.allowed_host_paths == []
+        assert cfg.source.clone_timeout == 600
+
+    def test_fields(self):
+        cfg = parse_config(
+            {"source": {"allowedHostPaths": ["/srv/checkouts"], "cloneTimeout": "2m"}}
+        )
+        assert cfg.source.allowed_host_paths == ["/srv/checkouts"]
+        assert cfg.source.clone_timeout == 120
+
+    def test_paths_must_be_list(self):
+        with pytest.raises(ConfigError, match="source.allowedHostPaths"):
+            parse_config({"source": {"allowedHostPaths": "/srv"}})
+
+    def test_max_archive_size(self):
+        assert parse_config({}).source.max_archive_size == 1024**3
+        cfg = parse_config({"source": {"maxArchiveSize": "200Mi"}})
+        assert cfg.source.max_archive_size == 200 * 1024**2
+        with pytest.raises(ConfigError, match="source.maxArchiveSize"):
+            parse_config({"source": {"maxArchiveSize": "lots"}})
+
+    def test_max_uploads_size(self):
+        assert parse_config({}).source.max_uploads_size == 10 * 1024**3
+        cfg = parse_config({"source": {"maxUploadsSize": "2Gi"}})
+        assert cfg.source.max_uploads_size == 2 * 1024**3
+        with pytest.raises(ConfigError, match="source.maxUploadsSize"):
+            parse_config({"source": {"maxUploadsSize": "lots"}})
+
+    def test_repo_defaults(self):
+        assert parse_config({}).source.repo_defaults is True
+        assert parse_config({"source": {"repoDefaults": False}}).source.repo_defaults is False
+        with pytest.raises(ConfigError, match="source.repoDefaults"):
+            parse_config({"source": {"repoDefaults": "no"}})
+
+
+class TestLogConfig:
+    def test_defaults_to_text(self):
+        cfg = parse_config({})
+        assert cfg.log.format == "text"
+        assert cfg.log.level == "INFO"
+
+    def test_json_and_level(self):
+        cfg = parse_config({"log": {"format": "json", "level": "debug"}})
+        assert cfg.log.format == "json"
+        assert cfg.log.level == "DEBUG"
+
+    def test_unknown_format(self):
+        with pytest.raises(ConfigError, match="log.format"):
+            parse_config({"log": {"format": "xml"}})
+
+    def test_unknown_level(self):
+        with pytest.raises(ConfigError, match="log.level"):
+            parse_config({"log": {"level": "chatty"}})
+
+
+class TestImagesConfig:
+    def test_defaults(self):
+        cfg = parse_config({})
+        assert cfg.images.policy_for("go") == "IfNotPresent"
+        assert cfg.images.registries == {}
+
+    def test_stack_override(self):
+        cfg = parse_config(
+            {"images": {"pullPolicy": "Never", "stacks": {"go": {"pullPolicy": "Always"}}}}
+        )
+        assert cfg.images.policy_for("go") == "Always"
+        assert cfg.images.policy_for("node") == "Never"
+
+    def test_allowed_prefixes(self):
+        cfg = parse_config({"images": {"allowedPrefixes": ["ghcr.io/acme", "docker.io/library/"]}})
+        assert cfg.images.allows("ghcr.io/acme/builder:1")
+        assert cfg.images.allows("ghcr.io/acme@sha256:abc")
+        assert not cfg.images.allows("ghcr.io/acme-evil/builder:1")
+        assert cfg.images.allows("ubuntu:22.04")  # docker.io/library/ubuntu
+        assert not cfg.images.allows("someone/ubuntu")
+        assert not parse_config({}).images.allows("ghcr.io/acme/builder:1")
+        with pytest.raises(ConfigError, match="images.allowedPrefixes"):
+            parse_config({"images": {"allowedPrefixes": "ghcr.io"}})
+
+    def test_registries(self):
+        cfg = parse_config(
+            {
+                "images": {
+                    "registries": {
+                        "ghcr.io": {"username": "ci-bot", "passwordSecret": "ghcr-token"}
+                    }
+                }
+            }
+        )
+        login = cfg.images.registries["ghcr.io"]
+        assert (login.username, login.password_secret) == ("ci-bot", "ghcr-token")
+
+    def test_unknown_policy(self):
+        with pytest.raises(ConfigError, match="images.stacks.go.pullPolicy"):
+            parse_config({"images": {"stacks": {"go": {"pullPolicy": "Sometimes"}}}})
+
+    def test_registry_needs_secret(self):
+        with pytest.raises(ConfigError, match="'images.registries.ghcr.io' needs exactly one"):
+            parse_config({"images": {"registries": {"ghcr.io": {"username": "ci-bot"}}}})
+
+    def test_registry_password_file_and_env(self):
+        registries = {
+            "registry.acme.dev": {"username": "deploy", "passwordFile": "/run/secrets/acme"},
+            "quay.io": {"username": "robot", "passwordEnv": "QUAY_PASSWORD"},
+        }
+        cfg = parse_config({"images": {"registries": registries}})
+        assert cfg.images.registries["registry.acme.dev"].origin == ("file", "/run/secrets/acme")
+        assert cfg.images.registries["quay.io"].origin == ("env", "QUAY_PASSWORD")
+        assert not cfg.images.registries["quay.io"].token
+
+    def test_registry_token_without_username(self):
+        registries = {"ghcr.io": {"tokenSecret": "ghcr-pat"}}
+        login = parse_config({"images": {"registries": registries}}).images.registries["ghcr.io"]
+        assert (login.username, login.origin) == ("token", ("secret", "ghcr-pat"))
+        assert login.token
+
+    def test_registry_password_needs_username(self):
+        with pytest.raises(ConfigError, match="images.registries.ghcr.io.username"):
+            parse_config({"images": {"registries": {"ghcr.io": {"passwordEnv": "GHCR"}}}})
+
+    def test_registry_one_password_source(self):
+        login = {"username": "ci-bot", "passwordSecret": "a", "tokenEnv": "B"}
+        with pytest.raises(ConfigError, match="needs exactly one"):
+            parse_config({"images": {"registries": {"ghcr.io": login}}})
+
+    def test_stacks_must_be_mapping(self):
+        with pytest.raises(ConfigError, match="images.stacks"):
+            parse_config({"images": {"stacks": ["go"]}})
+
+    def test_warm_stacks(self):
+        cfg = parse_config(
+            {
+                "images": {
+                    "warmStacks": ["go", {"stack": "node", "pool": True, "required": True}],
+                    "warmTimeout": "2m",
+                },
+                "pool": {"stacks": {"node": 1}},
+            }
+        )
+        assert [(w.stack, w.pool, w.required) for w in cfg.images.warm_stacks] == [
+            ("go", False, False),
+            ("node", True, True),
+        ]
+        assert cfg.images.warm_timeout == 120
+        assert parse_config({}).images.warm_stacks == []
+
+    @pytest.mark.parametrize(
+        "warm, match",
+        [
+            ("go", "'images.warmStacks' must be a list"),
+            ([3], r"'images.warmStacks\[0\]' must be a stack name or a mapping"),
+            ([{"pool": True}], r"'images.warmStacks\[0\].stack'"),
+            ([{"stack": "go", "required": "yes"}], r"'images.warmStacks\[0\].required'"),
+            (["go", {"stack": "go"}], r"'images.warmStacks\[1\]' repeats stack 'go'"),
+            ([{"stack": "go", "pool": True}], r"needs a pool for go \(pool.stacks.go\)"),
+        ],
+    )
+    def test_warm_stacks_invalid(self, warm, match):
+        with pytest.raises(ConfigError, match=match):
+            parse_config({"images": {"warmStacks": warm}})
+
+
+class TestHealthConfig:
+    def test_defaults(self):
+        cfg = parse_config({})
+        assert cfg.health.probe_ttl == 10
+        assert cfg.health.min_free_disk == 1024**3
+        assert cfg.health.probe_image == ""
+
+    def test_fields(self):
+        cfg = parse_config(
+            {"health": {"probeTtl": "30s", "minFreeDisk": "5Gi", "probeImage": "busybox"}}
+        )
+        assert cfg.health.probe_ttl == 30
+        assert cfg.health.min_free_disk == 5 * 1024**3
+        assert cfg.health.probe_image == "busybox"
+
+    def test_invalid_disk(self):
+        with pytest.raises(ConfigError, match="health.minFreeDisk"):
+            parse_config({"health": {"minFreeDisk": "lots"}})
+
+
+class TestRunAsConfig:
+    def test_root_refused_by_default(self):
+        assert parse_config({}).run_as.allow_root is False
+
+    def test_allow_root(self):
+        assert parse_config({"runAs": {"allowRoot": True}}).run_as.allow_root is True
+
+    def test_allow_root_must_be_boolean(self):
+        with pytest.raises(ConfigError, match="runAs.allowRoot"):
+            parse_config({"runAs": {"allowRoot": "yes"}})
+
+
+class TestRetryConfig:
+    def test_defaults(self):
+        retry = parse_config({}).retry
+        assert (retry.max_attempts, retry.initial_backoff, retry.max_backoff) == (3, 1.0, 30.0)
+
+    def test_values(self):
+        retry = parse_config(
+            {"retry": {"maxAttempts": 5, "initialBackoff": "500ms", "maxBackoff": "1m"}}
+        ).retry
+        assert (retry.max_attempts, retry.initial_backoff, retry.max_backoff) == (5, 0.5, 60.0)
+
+    def test_max_attempts_must_be_at_least_one(self):
+        with pytest.raises(ConfigError, match="retry.maxAttempts"):
+            parse_config({"retry": {"maxAttempts": 0}})
+
+
+class TestWorkspaceConfig:
+    def test_unlimited_by_default(self):
+        workspace = parse_config({}).workspace
+        assert workspace.max_size_gb == 0
+        assert workspace.check_interval == 10.0
+
+    def test_values(self):
+        workspace = parse_config({"workspace": {"maxSizeGB": 50, "checkInterval": "30s"}}).workspace
+        assert workspace.max_bytes == 50 * 1024**3
+        assert workspace.check_interval == 30.0
+
+    def test_max_size_must_be_positive(self):
+        with pytest.raises(ConfigError, match="workspace.maxSizeGB"):
+            parse_config({"workspace": {"maxSizeGB": 0}})
+
+
+class TestOutputConfig:
+    def test_unlimited_by_default(self):
+        output = parse_config({}).output
+        assert (output.max_log_bytes, output.kill_on_limit) == (0, False)
+
+    def test_fields(self):
+        output = parse_config({"output": {"maxLogBytes": "100Mi", "killOnLimit": True}}).output
+        assert (output.max_log_bytes, output.kill_on_limit) == (100 * 1024**2, True)
+
+    def test_invalid(self):
+        with pytest.raises(ConfigError, match="output.maxLogBytes"):
+            parse_config({"output": {"maxLogBytes": 0}})
+        with pytest.raises(ConfigError, match="output.killOnLimit"):
+            parse_config({"output": {"killOnLimit": "yes"}})
+
+
+class TestPoolConfig:
+    def test_disabled_by_default(self):
+        pool = parse_config({}).pool
+        assert pool.size_for("go") == 0
+        assert pool.max_idle == 300.0
+
+    def test_values(self):
+        pool = parse_config({"pool": {"maxIdle": "2m", "stacks": {"go": 2, "node": 0}}}).pool
+        assert (pool.size_for("go"), pool.size_for("node"), pool.size_for("rust")) == (2, 0, 0)
+        assert pool.max_idle == 120.0
+
+    def test_size_must_be_a_count(self):
+        with pytest.raises(ConfigError, match="pool.stacks.go"):
+            parse_config({"pool": {"stacks": {"go": -1}}})
+
+
+class TestCallbacksConfig:
+    def test_defaults(self):
+        callbacks = parse_config({}).callbacks
+        assert callbacks.secret == "" and callbacks.timeout == 10.0
+        assert (callbacks.retry.max_attempts, callbacks.retry.max_backoff) == (5, 60.0)
+
+    def test_values(self):
+        callbacks = parse_config(
+            {"callbacks": {"secret": "hook-key", "timeout": "5s", "maxAttempts": 2}}
+        ).callbacks
+        assert (callbacks.secret, callbacks.timeout, callbacks.retry.max_attempts) == (
+            "hook-key",
+            5.0,
+            2,
+        )
+        # Independent of the pull / start retry settings
+        assert parse_config({"callbacks": {"maxAttempts": 2}}).retry.max_attempts == 3
+
+    def test_max_attempts(self):
+        with pytest.raises(ConfigError, match="callbacks.maxAttempts"):
+            parse_config({"callbacks": {"maxAttempts": 0}})
+
+
+class TestMountsConfig:
+    def test_none_allowed_by_default(self):
+        assert parse_config({}).mounts.allowed_host_paths == []
+
+    def test_paths(self):
+        cfg = parse_config({"mounts": {"allowedHostPaths": ["/opt/toolchains", "~/datasets"]}})
+        assert cfg.mounts.allowed_host_paths == [
+            "/opt/toolchains",
+            str(Path("~/datasets").expanduser()),
+        ]
+        # Separate from the source checkouts allow-list
+        assert cfg.source.allowed_host_paths == []
+
+    def test_paths_must_be_list(self):
+        with pytest.raises(ConfigError, match="mounts.allowedHostPaths"):
+            parse_config({"mounts": {"allowedHostPaths": "/opt"}})
+
+
+class TestEnvFilesConfig:
+    def test_none_allowed_by_default(self):
+        assert parse_config({}).env_files.allowed_host_paths == []
+
+    def test_paths(self):
+        cfg = parse_config({"envFiles": {"allowedHostPaths": ["/srv/env"]}})
+        assert cfg.env_files.allowed_host_paths == ["/srv/env"]
+
+    def test_paths_must_be_list(self):
+        with pytest.raises(ConfigError, match="envFiles.allowedHostPaths"):
+            parse_config({"envFiles": {"allowedHostPaths": {"a": 1}}})
+
+
+class TestHistoryConfig:
+    def test_defaults(self):
+        cfg = parse_config({}).history
+        assert (cfg.max_jobs, cfg.max_age) == (1000, 86400.0)
+
+    def test_values(self):
+        cfg = parse_config({"history": {"maxJobs": 50, "maxAge": "2h"}}).history
+        assert (cfg.max_jobs, cfg.max_age) == (50, 7200.0)
+
+    def test_max_jobs_at_least_one(self):
+        with pytest.raises(ConfigError, match="history.maxJobs"):
+            parse_config({"history": {"maxJobs": 0}})
+
+
+class TestCleanupConfig:
+    def test_reaps_by_default(self):
+        assert parse_config({}).cleanup.reap_on_startup
+
+    def test_disabled(self):
+        assert not parse_config({"cleanup": {"reapOnStartup": False}}).cleanup.reap_on_startup
+
+    def test_must_be_bool(self):
+        with pytest.raises(ConfigError, match="cleanup.reapOnStartup"):
+            parse_config({"cleanup": {"reapOnStartup": "no"}})
+
+
+class TestNetworksConfig:
+    def test_none_allowed_by_default(self):
+        assert parse_config({}).networks.allowed == []
+
+    def test_allowed(self):
+        config = parse_config({"networks": {"allowed": ["ci-services"]}})
+        assert config.networks.allowed == ["ci-services"]
+
+    def test_must_be_names(self):
+        with pytest.raises(ConfigError, match="networks.allowed"):
+            parse_config({"networks": {"allowed": "ci-services"}})
+
+
+class TestAgentConfig:
+    def test_no_labels_by_default(self):
+        assert parse_config({}).agent.labels == {}
+
+    def test_labels(self):
+        config = parse_config({"agent": {"labels": {"gpu": True, "mem": "high", "cores": 64}}})
+        assert config.agent.labels == {"gpu": "true", "mem": "high", "cores": "64"}
+
+    @pytest.mark.parametrize(
+        "labels, match",
+        [
+            (["gpu=true"], "agent.labels' must map"),
+            ({"bad key": "x"}, "invalid label name"),
+            ({"gpu": "a b"}, "agent.labels.gpu"),
+            ({"gpu": ["x"]}, "agent.labels.gpu"),
+        ],
+    )
+    def test_invalid(self, labels, match):
+        with pytest.raises(ConfigError, match=match):
+            parse_config({"agent": {"labels": labels}})
+
+
+class TestSigningConfig:
+    def test_defaults(self):
+        cfg = parse_config({})
+        assert not cfg.signing.enabled and cfg.signing.keys == {}
+
+    def test_keys(self):
+        key = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+        cfg = parse_config(
+            {
+                "signing": {
+                    "enabled": True,
+                    "keys": {
+                        "ci-prod": {"publicKey": key},
+                        "release": {"publicKeyFile": "/etc/orion/release.pub"},
+                    },
+                }
+            }
+        )
+        assert cfg.signing.enabled
+        assert cfg.signing.keys["ci-prod"].public_key == key
+        assert cfg.signing.keys["release"].public_key_file == "/etc/orion/release.pub"
+
+    @pytest.mark.parametrize(
+        "signing, match",
+        [
+            ({"enabled": "yes"}, "'signing.enabled' must be true or false"),
+            ({"enabled": True}, "'signing.keys' needs at least one key"),
+            ({"keys": {"ci": {}}}, "'signing.keys.ci' needs exactly one of publicKey"),
+            ({"keys": {"ci": {"publicKey": "abc", "publicKeyFile": "/k"}}}, "exactly one"),
+            ({"keys": {"ci": {"publicKey": "c2hvcnQ="}}}, "not an Ed25519 key: .*32 bytes"),
+            ({"keys": {"ci": {"publicKeyFile": ""}}}, "'signing.keys.ci.publicKeyFile'"),
+            ({"keys": ["ci"]}, "'signing.keys' must be a mapping"),
+        ],
+    )
+    def test_invalid(self, signing, match):
+        with pytest.raises(ConfigError, match=match):
+            parse_config({"signing": signing})
+
+
+class TestHardeningConfig:
+    def test_defaults(self):
+        security = parse_config({}).hardening.container_security()
+        assert security.no_new_privileges
+        assert security.cap_drop == ["ALL"]
+        assert security.cap_add == ["CHOWN", "DAC_OVERRIDE", "FOWNER", "KILL"]
+        assert security.ulimits == {"nofile": "65536", "nproc": "4096"}
+        assert security.seccomp_profile == ""
+
+    def test_parsed(self, tmp_path: Path):
+        profile = tmp_path / "seccomp.json"
+        profile.write_text("{}")
+        cfg = parse_config(
+            {
+                "hardening": {
+                    "addCapabilities": ["net_raw", "CAP_SYS_PTRACE", "CHOWN"],
+                    "ulimits": {"nofile": "1024:8192", "nproc": None, "core": 0},
+                    "seccompProfile": str(profile),
+                }
+            }
+        )
+        security = cfg.hardening.container_security()
+        assert security.cap_add == [
+            "CHOWN",
+            "DAC_OVERRIDE",
+            "FOWNER",
+            "KILL",
+            "NET_RAW",
+            "SYS_PTRACE",
+        ]
+        assert security.ulimits == {"nofile": "1024:8192", "core": "0"}
+        assert security.seccomp_profile == str(profile.resolve())
+
+    def test_trusted_escape_hatch(self):
+        cfg = parse_config({"hardening": {"noNewPrivileges": False, "dropCapabilities": False}})
+        security = cfg.hardening.container_security()
+        assert not security.no_new_privileges
+        assert security.cap_drop == [] and security.cap_add == []
+        assert security.ulimits  # still applied
+
+    @pytest.mark.parametrize(
+        "hardening, match",
+        [
+            ({"noNewPrivileges": "yes"}, "'hardening.noNewPrivileges' must be true or false"),
+            ({"dropCapabilities": 0}, "'hardening.dropCapabilities' must be true or false"),
+            ({"addCapabilities": "NET_RAW"}, "must be a list of names"),
+            ({"addCapabilities": ["net raw"]}, "'net raw' is not a capability"),
+            ({"addCapabilities": ["all"]}, "cannot be ALL"),
+            ({"ulimits": {"files": 10}}, "'hardening.ulimits.files' is not a ulimit"),
+            ({"ulimits": {"nofile": -1}}, "'hardening.ulimits.nofile' must be a number"),
+            ({"ulimits": {"nofile": "8192:1024"}}, "soft <= hard"),
+            ({"ulimits": {"nproc": 1.5}}, "must be a number"),
+            ({"ulimits": {"nproc": True}}, "must be a number"),
+            ({"ulimits": ["nofile"]}, "'hardening.ulimits' must be a mapping"),
+            ({"seccompProfile": "/nonexistent/seccomp.json"}, "is not a readable file"),
+        ],
+    )
+    def test_invalid(self, hardening, match):
+        with pytest.raises(ConfigError, match=match):
+            parse_config({"hardening": hardening})
+
+
+class TestStoreConfig:
+    def test_memory_only_by_default(self):
+        assert parse_config({}).store.backend == "none"
+
+    def test_sqlite(self):
+        config = parse_config({"store": {"backend": "sqlite", "path": "~/state/jobs.db"}})
+        assert config.store.backend == "sqlite"
+        assert config.store.path == str(Path("~/state/jobs.db").expanduser())
+
+    def test_unknown_backend(self):
+        with pytest.raises(ConfigError, match="store.backend"):
+            parse_config({"store": {"backend": "bolt"}})
+
+
+class TestSubmissionsConfig:
+    def test_unlimited_by_default(self):
+        assert parse_config({}).submissions.rate_per_second == 0
+
+    def test_parsed(self):
+        config = parse_config(
+            {"submissions": {"ratePerSecond": 0.5, "burst": 5, "perClient": False}}
+        )
+        assert config.submissions.rate_per_second == 0.5
+        assert config.submissions.burst == 5
+        assert not config.submissions.per_client
+
+    def test_burst_at_least_one(self):
+        with pytest.raises(ConfigError, match="submissions.burst"):
+            parse_config({"submissions": {"burst": 0}})
+
+
+class TestReadConfig:
+    def test_valid_file(self, tmp_path: Path):
+        path = tmp_path / "jobs.yaml"
+        path.write_text("scheduler:\n  maxConcurrent: 8\n")
+        assert read_config(path).scheduler.max_concurrent == 8
+
+    def test_missing_file_raises(self, tmp_path: Path):
+        with pytest.raises(ConfigError, match="does not exist"):
+            read_config(tmp_path / "nope.yaml")
+
+    def test_bad_yaml_raises(self, tmp_path: Path):
+        path = tmp_path / "jobs.yaml"
+        path.write_text("cache: [1, 2\n")
+        with pytest.raises(ConfigError, match="Cannot read jobs config"):
+            read_config(path)
+
+    def test_invalid_field_raises(self, tmp_path: Path):
+        path = tmp_path / "jobs.yaml"
+        path.write_text("scheduler:\n  maxConcurrent: 0\n")
+        with pytest.raises(ConfigError, match="scheduler.maxConcurrent"):
+            read_config(path)
+
+
+class TestDiffConfig:
+    def test_no_changes(self):
+        assert diff_config(JobsConfig(), JobsConfig()) == []
+
+    def test_changes_keyed_as_in_yaml(self):
+        new = parse_config(
+            {
+                "scheduler": {"maxConcurrent": 8},
+                "cache": {"maxSizeGB": 2},
+                "images": {"stacks": {"go": {"pullPolicy": "Always"}}},
+                "runAs": {"allowRoot": True},
+                "runtime": {"driver": "podman"},
+            }
+        )
+        changes = {c.key: c for c in diff_config(JobsConfig(), new)}
+        assert set(changes) == {
+            "cache.maxSizeGB",
+            "scheduler.maxConcurrent",
+            "runtime.driver",
+            "images.stacks",
+            "runAs.allowRoot",
+        }
+        concurrency = changes["scheduler.maxConcurrent"]
+        assert (concurrency.old, concurrency.new, concurrency.live) == (4, 8, True)
+        assert not changes["runtime.driver"].live
+
+    def test_restart_keys_name_real_settings(self):
+        changed = JobsConfig()
+        for section in vars(changed).values():
+            for name in vars(section):
+                setattr(section, name, object())
+        keys = {c.key for c in diff_config(JobsConfig(), changed)}
+        assert RESTART_REQUIRED <= keys
+
+    def test_keep_restart_settings(self):
+        new = parse_config(
+            {
+                "runtime": {"driver": "podman"},
+                "store": {"backend": "sqlite"},
+                "log": {"level": "DEBUG"},
+            }
+        )
+        keep_restart_settings(JobsConfig(), new)
+        assert new.runtime.driver == "docker"
+        assert new.store.backend == "none"
+        assert new.log.level == "DEBUG"
@@ -0,0 +1,100 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the KEY=VALUE env file parser."""
+
+from __future__ import annotations
+
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.envfile import (
+    MAX_ENV_FILE_SIZE,
+    EnvFileError,
+    parse_env_file,
+    read_env_file,
+)
+
+
+class TestParse:
+    def test_plain_lines_comments_and_blanks(self):
+        text = "# settings\n\nA=1\n  B = two  \nexport C=3\nexport\tD=4\n"
+        assert parse_env_file(text) == {"A": "1", "B": "two", "C": "3", "D": "4"}
+
+    def test_inline_comment_only_after_whitespace(self):
+        assert parse_env_file("A=x # note\nB=x#y\n") == {"A": "x", "B": "x#y"}
+
+    def test_empty_and_repeated(self):
+        assert parse_env_file("A=\nB=1\nB=2\n") == {"A": "", "B": "2"}
+
+    def test_value_keeps_equals(self):
+        assert parse_env_file("URL=postgres://u@h/db?sslmode=require\n") == {
+            "URL": "postgres://u@h/db?sslmode=require"
+        }
+
+    def test_double_quotes_unescape(self):
+        assert parse_env_file('A="two words # not a comment"\nB="a\\nb \\"q\\" \\\\"\n') == {
+            "A": "two words # not a comment",
+            "B": 'a\nb "q" \\',
+        }
+
+    def test_single_quotes_are_literal(self):
+        assert parse_env_file("A='$HOME \\n' # comment\n") == {"A": "$HOME \\n"}
+
+    def test_no_expansion(self):
+        assert parse_env_file("A=$B\n") == {"A": "$B"}
+
+    @pytest.mark.parametrize(
+        "line, message",
+        [
+            ("just-a-word", "expected KEY=VALUE"),
+            ("1ABC=x", "not a valid"),
+            ("MY VAR=x", "not a valid"),
+            ("=x", "not a valid"),
+            ('A="open', "unterminated"),
+            ("A='x' y", "after the closing"),
+        ],
+    )
+    def test_malformed_lines_name_the_line(self, line, message):
+        with pytest.raises(EnvFileError, match=f"line 3: .*{message}") as excinfo:
+            parse_env_file(f"# header\nOK=1\n{line}\n")
+        assert excinfo.value.line == 3
+
+
+class TestRead:
+    def test_reads_file(self, tmp_path: Path):
+        path = tmp_path / "api.env"
+        path.write_text("A=1\n")
+        assert read_env_file(path) == {"A": "1"}
+
+    def test_error_names_file_and_line(self, tmp_path: Path):
+        path = tmp_path / "api.env"
+        path.write_text("A=1\nbroken\n")
+        with pytest.raises(EnvFileError, match=f"{path}: line 2") as excinfo:
+            read_env_file(path)
+        assert excinfo.value.line == 2
+
+    def test_missing(self, tmp_path: Path):
+        with pytest.raises(EnvFileError, match="does not exist"):
+            read_env_file(tmp_path / "nope.env")
+
+    def test_too_large(self, tmp_path: Path):
+        path = tmp_path / "big.env"
+        path.write_text("A=" + "x" * MAX_ENV_FILE_SIZE)
+        with pytest.raises(EnvFileError, match="larger than"):
+            read_env_file(path)
+
+    def test_not_text(self, tmp_path: Path):
+        path = tmp_path / "bin.env"
+        path.write_bytes(b"A=\xff\xfe\n")
+        with pytest.raises(EnvFileError, match="UTF-8"):
+            read_env_file(path)
@@ -0,0 +1,3783 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for JobExecutor -- running manifests in stack containers.
+
+Uses a fake SessionContainer so no Docker daemon is required.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import hashlib
+import io
+import json
+import logging
+import shutil
+import subprocess
+import tarfile
+import threading
+import time
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.config import (
+    ArtifactsConfig,
+    CacheConfig,
+    CallbacksConfig,
+    CleanupConfig,
+    ImagesConfig,
+    AgentConfig,
+    EnvFilesConfig,
+    HardeningConfig,
+    HistoryConfig,
+    JobsConfig,
+    MountsConfig,
+    MetricsConfig,
+    NetworksConfig,
+    OutputConfig,
+    PoolConfig,
+    RegistryCredentials,
+    ResourcesConfig,
+    RetryConfig,
+    RunAsConfig,
+    RuntimeConfig,
+    SchedulerConfig,
+    SourceConfig,
+    StoreConfig,
+    TimeoutConfig,
+    WarmStack,
+    WorkspaceConfig,
+)
+from orion.security.container_runtime import PodmanRuntime
+from orion.security.jobs.agent_log import JobContextFilter
+from orion.security.jobs.archive import ArchiveError, UploadsFullError, upload_path
+from orion.security.jobs.callbacks import SIGNATURE_HEADER, sign
+from orion.security.jobs.executor import (
+    JobErrorCode,
+    JobExecutor,
+    JobResult,
+    JobStatus,
+    NoMatchingCapabilityError,
+    QueueFullError,
+    ShuttingDownError,
+)
+from orion.security.jobs.logs import LogChannel
+from orion.security.jobs.manifest import (
+    JobEnvFile,
+    JobManifest,
+    JobMount,
+    JobResources,
+    JobSource,
+    JobStep,
+)
+from orion.security.jobs.secrets import DictSecretSource
+from orion.security.jobs.source import CLONE_ENV
+from orion.security.jobs.store import JobStoreError, MemoryJobStore
+from orion.security.jobs.toolchains import PROBE_BAKED, PROBE_CACHED, PROBE_MISSING, TOOLCHAINS_DIR
+from orion.security.session_container import ExecResult, ResourceUsage
+
+# ---------------------------------------------------------------------------
+# Fake container
+# ---------------------------------------------------------------------------
+
+
+class FakeContainer:
+    """Records calls and replays scripted exit codes."""
+
+    instances: list[FakeContainer] = []
+
+    def __init__(self, **kwargs):
+        self.kwargs = kwargs
+        self.container_name = f"orion-session-{kwargs.get('session_id')}"
+        self.execs: list[tuple[str, str]] = []
+        self.start_ok = True
+        self.start_error = ""
+        self.exit_codes: dict[str, int] = {}
+        self.stopped = False
+        self.oom_counts: list[int | None] = []
+        self.disk_usages: list[int | None] = []  # successive /workspace sizes
+        self.usages: list[ResourceUsage] = []  # successive resource_usage() readings
+        self.output: list[tuple[str, str]] = []  # replayed to on_output
+        self.hold: asyncio.Event | None = None  # blocks the execute phase
+        self.signals: list[str] = []
+        self.honours: set[str] = {"TERM", "KILL"}  # signals that release ``hold``
+        self.pidfile: str | None = None
+        self.files: dict[str, bytes] = {}  # /workspace-relative path -> content
+        self.calls: list[str] = []
+        self.installs: list[dict] = []  # env / user of each exec_install
+        self.users: dict[str, str | None] = {}  # phase -> user of its last exec
+        self.envs: dict[str, dict | None] = {}  # phase -> env of its last exec
+        self.secret_envs: dict[str, dict | None] = {}  # phase -> secret_env of its last exec
+        self.workdirs: dict[str, str | None] = {}  # phase -> workdir of its last exec
+        self.install_stderr = ""
+        self.reset_error = ""  # returned by reset(); '' = clean
+        FakeContainer.instances.append(self)
+
+    async def start(self) -> bool:
+        return self.start_ok
+
+    async def stop(self) -> bool:
+        self.calls.append("stop")
+        self.stopped = True
+        return True
+
+    async def exec(
+        self,
+        command,
+        timeout=120,
+        phase="execute",
+        env=None,
+        on_output=None,
+        pidfile=None,
+        user=None,
+        workdir=None,
+        max_output=None,
+        secret_env=None,
+    ):
+        self.execs.append((phase, command))
+        self.users[phase] = user
+        self.envs[phase] = env
+        self.secret_envs[phase] = secret_env
+        self.workdirs[phase] = workdir
+        if phase == "execute":
+            self.pidfile = pidfile
+        stdout = ""
+        if phase == "execute":
+            kept = 0
+            for stream, line in self.output:
+                if on_output:
+                    on_output(stream, line)
+                kept += len(line) + 1
+                if stream == "stdout" and (max_output is None or kept <= max_output):
+                    stdout += line + "\n"
+            if self.hold is not None:
+                await self.hold.wait()
+        stderr = self.install_stderr if phase == "install" else ""
+        return ExecResult(
+            exit_code=self.exit_codes.get(phase, 0),
+            stdout=stdout,
+            stderr=stderr,
+            command=command,
+            phase=phase,
+        )
+
+    async def exec_install(self, command, timeout=300, env=None, on_output=None, user=None):
+        self.installs.append({"env": env, "user": user})
+        return await self.exec(
+            command, timeout=timeout, phase="install", env=env, on_output=on_output
+        )
+
+    async def signal_group(self, pidfile, sig="TERM"):
+        self.signals.append(sig)
+        if sig in self.honours and self.hold is not None:
+            self.exit_codes["execute"] = 143 if sig == "TERM" else 137
+            self.hold.set()
+        return True
+
+    async def oom_kill_count(self):
+        return self.oom_counts.pop(0) if self.oom_counts else 0
+
+    async def disk_usage(self, path="/workspace"):
+        self.calls.append("disk_usage")
+        return self.disk_usages.pop(0) if self.disk_usages else 0
+
+    async def resource_usage(self):
+        return self.usages.pop(0) if self.usages else ResourceUsage()
+
+    async def layer_changes(self):
+        return {"A /etc/orion"}
+
+    async def reset(self, baseline):
+        self.calls.append("reset")
+        assert baseline == {"A /etc/orion"}
+        return self.reset_error
+
+    async def list_file_sizes(self, path="/workspace"):
+        return "".join(f"{len(data)} ./{name}\n" for name, data in self.files.items())
+
+    async def copy_from(self, path, dest):
+        self.calls.append(f"copy {path}")
+        Path(dest).write_bytes(self.files[path.removeprefix("/workspace/")])
+        return True
+
+
+async def _no_local_images(image: str, runtime=None) -> str | None:
+    return None
+
+
+async def _pulled(image: str, runtime=None, login=None) -> subprocess.CompletedProcess:
+    return subprocess.CompletedProcess(["docker", "pull", image], 0, "", "")
+
+
+async def _digest(image: str, runtime=None) -> str | None:
+    return "sha256:0123abcd"
+
+
+@pytest.fixture(autouse=True)
+def _no_docker_inspect(monkeypatch):
+    """Keep image selection and pulls off the real Docker daemon."""
+    monkeypatch.setattr("orion.security.jobs.executor.image_arch", _no_local_images)
+    monkeypatch.setattr("orion.security.jobs.executor.pull_image", _pulled)
+    monkeypatch.setattr("orion.security.jobs.executor.image_digest", _digest)
+
+
+@pytest.fixture(autouse=True)
+def _reset_instances():
+    FakeContainer.instances = []
+
+
+@pytest.fixture
+def executor(tmp_path: Path) -> JobExecutor:
+    return JobExecutor(
+        jobs_dir=tmp_path / "jobs",
+        toolchain_cache_dir=tmp_path / "toolchains",
+        container_factory=FakeContainer,
+    )
+
+
+def _executor(tmp_path: Path, factory=FakeContainer, **sections) -> JobExecutor:
+    """An executor on ``factory`` whose config sets ``sections`` (JobsConfig fields)."""
+    return JobExecutor(
+        jobs_dir=tmp_path / "jobs", container_factory=factory, config=JobsConfig(**sections)
+    )
+
+
+def _container() -> FakeContainer:
+    return FakeContainer.instances[-1]
+
+
+def _scripted(probe: int = 0, install: int = 0, execute: int = 0):
+    """FakeContainer subclass with fixed exit codes per phase."""
+
+    class Scripted(FakeContainer):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.exit_codes = {"toolchain": probe, "install": install, "execute": execute}
+
+    return Scripted
+
+
+# ---------------------------------------------------------------------------
+# Basic lifecycle
+# ---------------------------------------------------------------------------
+
+
+class TestRun:
+    @pytest.mark.asyncio
+    async def test_success(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="python", command="pytest"), job_id="j1")
+        assert result.status == JobStatus.SUCCEEDED.value
+        assert result.image == "orion-stack-python:latest"
+        assert result.error_code == ""
+        assert _container().execs == [("execute", "pytest")]
+        assert _container().stopped is True
+
+    @pytest.mark.asyncio
+    async def test_command_failure(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(execute=2))
+        result = await ex.run(JobManifest(stack="go", command="go build"))
+        assert result.status == JobStatus.FAILED.value
+        assert result.error_code == JobErrorCode.COMMAND_FAILED.value
+        assert result.exit_code == 2
+
+    @pytest.mark.asyncio
+    async def test_unknown_stack(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="fortran", command="make"))
+        assert result.error_code == JobErrorCode.STACK_RESOLUTION_FAILED.value
+        assert FakeContainer.instances == []
+
+
+class TestCommandEnvWorkdir:
+    @pytest.mark.asyncio
+    async def test_argv_command_passed_through(self, executor: JobExecutor):
+        argv = ["go", "test", "-run", "Test Foo"]
+        result = await executor.run(JobManifest(stack="go", command=argv, workdir="/workspace/api"))
+        assert result.succeeded
+        assert _container().execs == [("execute", argv)]
+        assert _container().workdirs["execute"] == "/workspace/api"
+
+    @pytest.mark.asyncio
+    async def test_argv_command_behind_toolchain(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(PROBE_CACHED))
+        await ex.run(JobManifest(stack="go", command=["go", "version"], toolchain="1.21.13"))
+        _, cmd = _container().execs[-1]
+        assert cmd[:2] == ["sh", "-c"]
+        assert "/home/orion/toolchains/go1.21.13/bin" in cmd[2]
+        assert cmd[2].endswith('exec "$@"')
+        assert cmd[3:] == ["sh", "go", "version"]
+
+    @pytest.mark.asyncio
+    async def test_secrets_win_over_env(self, tmp_path: Path):
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            secret_source=DictSecretSource({"npm": "hunter2"}),
+        )
+        result = await ex.run(
+            JobManifest(
+                stack="node",
+                command="npm publish",
+                env={"NPM_TOKEN": "placeholder", "CI": "1"},
+                secrets={"NPM_TOKEN": "npm"},
+            )
+        )
+        assert _container().envs["execute"] == {"CI": "1"}
+        assert _container().kwargs["secret_env"] == {"NPM_TOKEN": "hunter2"}
+        assert result.env_overridden == ["NPM_TOKEN"]
+        assert result.to_dict()["env_overridden"] == ["NPM_TOKEN"]
+
+
+class TestEnvFile:
+    @staticmethod
+    def _executor(tmp_path: Path, factory=FakeContainer) -> JobExecutor:
+        (tmp_path / "env").mkdir()
+        config = JobsConfig(env_files=EnvFilesConfig(allowed_host_paths=[str(tmp_path / "env")]))
+        return JobExecutor(
+            jobs_dir=tmp_path / "jobs",
+            container_factory=factory,
+            config=config,
+            secret_source=DictSecretSource({"npm": "hunter2"}),
+        )
+
+    @pytest.mark.asyncio
+    async def test_precedence_file_env_secrets(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        (tmp_path / "env" / "api.env").write_text("A=file\nB=file\nNPM_TOKEN=file\n")
+        result = await ex.run(
+            JobManifest(
+                stack="node",
+                command="npm publish",
+                env={"B": "env"},
+                env_file=JobEnvFile(path=str(tmp_path / "env" / "api.env")),
+                secrets={"NPM_TOKEN": "npm"},
+            )
+        )
+        assert result.succeeded
+        assert _container().envs["execute"] == {"A": "file", "B": "env"}
+        assert result.env_overridden == ["NPM_TOKEN"]
+
+    @pytest.mark.asyncio
+    async def test_inline_content(self, executor: JobExecutor):
+        env_file = JobEnvFile(content="export CI=1\n")
+        await executor.run(JobManifest(stack="go", command="make", env_file=env_file))
+        assert _container().envs["execute"] == {"CI": "1"}
+
+    @pytest.mark.asyncio
+    async def test_sensitive_values_redacted(self, tmp_path: Path):
+        ex = self._executor(tmp_path, _leaky())
+        env_file = JobEnvFile(content="TOKEN=hunter2\n", sensitive=["TOKEN"])
+        handle = ex.submit(JobManifest(stack="node", command="env", env_file=env_file))
+        await handle.task
+        assert [ln.line for ln in handle.logs.lines] == ["token is ***", "auth *** ok"]
+        assert "hunter2" not in str(handle.result.to_dict())
+
+    @pytest.mark.asyncio
+    async def test_sensitive_values_passed_by_name(self, executor: JobExecutor):
+        env_file = JobEnvFile(content="TOKEN=hunter2\nCI=1\n", sensitive=["TOKEN"])
+        await executor.run(JobManifest(stack="go", command="make", env_file=env_file))
+        assert _container().envs["execute"] == {"CI": "1"}
+        assert _container().secret_envs["execute"] == {"TOKEN": "hunter2"}
+
+    @pytest.mark.asyncio
+    async def test_other_values_not_redacted(self, tmp_path: Path):
+        ex = self._executor(tmp_path, _leaky())
+        env_file = JobEnvFile(content="TOKEN=hunter2\n")
+        result = await ex.run(JobManifest(stack="node", command="env", env_file=env_file))
+        assert "hunter2" in result.stdout
+
+    @pytest.mark.asyncio
+    async def test_host_file_not_allowed(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        (tmp_path / "elsewhere.env").write_text("A=1\n")
+        env_file = JobEnvFile(path=str(tmp_path / "elsewhere.env"))
+        result = await ex.run(JobManifest(stack="go", command="make", env_file=env_file))
+        assert result.error_code == JobErrorCode.ENV_FILE_INVALID.value
+        assert "envFiles.allowedHostPaths" in result.error
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_malformed_host_file(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        (tmp_path / "env" / "api.env").write_text("A=1\nnot a line\n")
+        manifest = {"stack": "go", "command": "make", "envFile": str(tmp_path / "env" / "api.env")}
+        result = await ex.run(JobManifest.from_dict(manifest))
+        assert result.error_code == JobErrorCode.ENV_FILE_INVALID.value
+        assert "line 2" in result.error
+
+        report = await ex.validate(manifest)
+        assert [p.path for p in report.problems] == ["envFile.path"]
+
+
+# ---------------------------------------------------------------------------
+# Toolchain resolution
+# ---------------------------------------------------------------------------
+
+
+class TestToolchain:
+    @pytest.mark.asyncio
+    async def test_baked_version_skips_download(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(PROBE_BAKED))
+        result = await ex.run(JobManifest(stack="go", command="go version", toolchain="1.22.5"))
+        assert result.succeeded
+        assert [p for p, _ in _container().execs] == ["toolchain", "execute"]
+
+    @pytest.mark.asyncio
+    async def test_cached_version_skips_download(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(PROBE_CACHED))
+        result = await ex.run(JobManifest(stack="go", command="go version", toolchain="1.21.13"))
+        assert result.succeeded
+        assert result.toolchain == "go1.21.13"
+        phase, cmd = _container().execs[-1]
+        assert "/home/orion/toolchains/go1.21.13/bin" in cmd
+        assert cmd.endswith("go version")
+
+    @pytest.mark.asyncio
+    async def test_missing_version_downloads(self, tmp_path: Path):
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            toolchain_cache_dir=tmp_path / "tc",
+            container_factory=_scripted(PROBE_MISSING, install=0),
+        )
+        result = await ex.run(JobManifest(stack="go", command="go test", toolchain="1.23.1"))
+        assert result.succeeded
+        assert [p for p, _ in _container().execs] == ["toolchain", "install", "execute"]
+        assert any("go1.23.1.linux-" in c for _, c in _container().execs)
+        # Host cache is mounted at $GOPATH/../toolchains
+        assert f"{tmp_path / 'tc' / 'go'}:/home/orion/toolchains:rw" in (
+            _container().kwargs["extra_volumes"]
+        )
+
+    @pytest.mark.asyncio
+    async def test_download_failure_has_distinct_code(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(PROBE_MISSING, install=22))
+        result = await ex.run(JobManifest(stack="go", command="go test", toolchain="1.99.0"))
+        assert result.status == JobStatus.FAILED.value
+        assert result.error_code == JobErrorCode.TOOLCHAIN_RESOLUTION_FAILED.value
+        assert result.error_code != JobErrorCode.COMMAND_FAILED.value
+        assert "execute" not in [p for p, _ in _container().execs]
+        assert _container().stopped is True
+
+    @pytest.mark.asyncio
+    async def test_unsupported_stack_fails_before_container(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="base", command="ls", toolchain="1.0"))
+        assert result.error_code == JobErrorCode.TOOLCHAIN_RESOLUTION_FAILED.value
+        assert FakeContainer.instances == []
+
+
+# ---------------------------------------------------------------------------
+# Build cache
+# ---------------------------------------------------------------------------
+
+
+class TestBuildCache:
+    @pytest.mark.asyncio
+    async def test_cache_mounted_and_released(self, tmp_path: Path):
+        config = JobsConfig(cache=CacheConfig(enabled=True, path=str(tmp_path / "cache")))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        await ex.run(JobManifest(stack="go", command="go build ./..."))
+        volumes = _container().kwargs["extra_volumes"]
+        assert any(v.endswith(":/home/orion/go/pkg/mod:rw") for v in volumes)
+        assert ex.cache.is_leased("go") is False
+
+    @pytest.mark.asyncio
+    async def test_lease_released_when_start_fails(self, tmp_path: Path):
+        class NoStart(FakeContainer):
+            async def start(self) -> bool:
+                return False
+
+        config = JobsConfig(cache=CacheConfig(enabled=True, path=str(tmp_path / "cache")))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=NoStart, config=config)
+        result = await ex.run(JobManifest(stack="go", command="go build"))
+        assert result.error_code == JobErrorCode.CONTAINER_START_FAILED.value
+        assert ex.cache.is_leased("go") is False
+
+    @pytest.mark.asyncio
+    async def test_eviction_runs_off_the_event_loop(self, tmp_path: Path, monkeypatch):
+        config = JobsConfig(cache=CacheConfig(enabled=True, path=str(tmp_path / "cache")))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        threads = []
+        monkeypatch.setattr(ex.cache, "evict", lambda: threads.append(threading.current_thread()))
+        await ex.run(JobManifest(stack="go", command="go build ./..."))
+        await ex._cache_eviction
+        assert threads and threads[0] is not threading.main_thread()
+
+    @pytest.mark.asyncio
+    async def test_evictions_requested_during_a_pass_coalesce(self, tmp_path: Path):
+        config = JobsConfig(cache=CacheConfig(enabled=True, path=str(tmp_path / "cache")))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        passes, gate = [], threading.Event()
+
+        def evict():
+            passes.append(1)
+            gate.wait(5)
+            return []
+
+        ex.cache.evict = evict
+        ex._evict_cache()
+        first = ex._cache_eviction
+        for _ in range(5):
+            ex._evict_cache()
+        assert ex._cache_eviction is first
+        gate.set()
+        await first
+        assert len(passes) == 2  # the running pass and one more for the five requests
+
+    @pytest.mark.asyncio
+    async def test_disabled_by_default(self, executor: JobExecutor):
+        await executor.run(JobManifest(stack="go", command="go build"))
+        assert _container().kwargs["extra_volumes"] == []
+
+
+# ---------------------------------------------------------------------------
+# Resource limits / OOM
+# ---------------------------------------------------------------------------
+
+
+class TestResources:
+    @pytest.mark.asyncio
+    async def test_manifest_limits_passed_to_container(self, executor: JobExecutor):
+        manifest = JobManifest(
+            stack="go", command="go test", resources=JobResources(cpu=1.5, memory=512 * 1024**2)
+        )
+        await executor.run(manifest)
+        assert _container().kwargs["resources"] == {"cpus": "1.5", "memory": "536870912b"}
+
+    @pytest.mark.asyncio
+    async def test_config_defaults_apply_when_omitted(self, tmp_path: Path):
+        config = JobsConfig(resources=ResourcesConfig(default_cpu=1, default_memory=1024**3))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        await ex.run(JobManifest(stack="go", command="go test"))
+        assert _container().kwargs["resources"] == {"cpus": "1", "memory": "1073741824b"}
+
+    @pytest.mark.asyncio
+    async def test_no_limits_keeps_profile(self, executor: JobExecutor):
+        await executor.run(JobManifest(stack="go", command="go test"))
+        assert _container().kwargs["resources"] == {}
+
+    @pytest.mark.asyncio
+    async def test_over_cap_rejected_before_launch(self, tmp_path: Path):
+        config = JobsConfig(resources=ResourcesConfig(max_cpu=2, max_memory=1024**3))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        result = await ex.run(
+            JobManifest(stack="go", command="go test", resources=JobResources(cpu=8))
+        )
+        assert result.error_code == JobErrorCode.RESOURCE_LIMIT_EXCEEDED.value
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_oom_kill_reported_distinctly(self, tmp_path: Path):
+        class Oom(_scripted(execute=137)):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.oom_counts = [0, 1]
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=Oom)
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.exit_code == 137
+        assert result.error_code == JobErrorCode.OOM_KILLED.value
+        assert result.to_dict()["exit"] == {
+            "exit_code": 137,
+            "signal": "SIGKILL",
+            "signal_number": 9,
+            "oom_killed": True,
+            "timed_out": False,
+        }
+
+    @pytest.mark.asyncio
+    async def test_plain_sigkill_is_not_oom(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(execute=137))
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.error_code == JobErrorCode.COMMAND_FAILED.value
+        assert result.error == "Command was killed by SIGKILL (exit 137)"
+        assert (result.exit.signal, result.exit.oom_killed, result.exit.timed_out) == (
+            "SIGKILL",
+            False,
+            False,
+        )
+
+    @pytest.mark.asyncio
+    async def test_plain_exit_code_has_no_signal(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(execute=2))
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.error == "Command exited with code 2"
+        assert result.to_dict()["exit"]["signal"] is None
+
+    @pytest.mark.asyncio
+    async def test_exit_state_unset_when_command_never_ran(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        result = await ex.run(JobManifest(stack="nope", command="go test"))
+        assert result.to_dict()["exit"]["exit_code"] == -1
+
+    @pytest.mark.asyncio
+    async def test_unknown_oom_counter_is_not_oom(self, tmp_path: Path):
+        class NoCgroup(_scripted(execute=137)):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.oom_counts = [None, None]
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=NoCgroup)
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.error_code == JobErrorCode.COMMAND_FAILED.value
+
+
+# ---------------------------------------------------------------------------
+# Background jobs / live logs
+# ---------------------------------------------------------------------------
+
+
+def _chatty(hold: asyncio.Event | None = None):
+    """FakeContainer subclass that emits output and optionally blocks."""
+
+    class Chatty(FakeContainer):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.output = [("stdout", "compiling"), ("stderr", "warning: x"), ("stdout", "ok")]
+            self.hold = hold
+
+    return Chatty
+
+
+class TestSubmit:
+    @pytest.mark.asyncio
+    async def test_lines_published_in_order(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_chatty())
+        handle = ex.submit(JobManifest(stack="go", command="go build"))
+        assert handle.result.status == "queued"
+        await handle.task
+        lines = [(ln.stream, ln.line) for ln in handle.logs.lines]
+        assert lines == [("stdout", "compiling"), ("stderr", "warning: x"), ("stdout", "ok")]
+        stamps = [ln.timestamp for ln in handle.logs.lines]
+        assert stamps == sorted(stamps)
+        assert handle.result.succeeded
+        assert handle.logs.closed
+
+    @pytest.mark.asyncio
+    async def test_run_streams_to_given_channel(self, tmp_path: Path):
+        logs = LogChannel()
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_chatty())
+        result = await ex.run(JobManifest(stack="go", command="go build"), logs=logs)
+        assert [ln.line for ln in logs.lines] == ["compiling", "warning: x", "ok"]
+        assert result.stdout == "compiling\nok\n"
+
+    @pytest.mark.asyncio
+    async def test_follower_disconnect_does_not_stop_job(self, tmp_path: Path):
+        hold = asyncio.Event()
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_chatty(hold))
+        handle = ex.submit(JobManifest(stack="go", command="go build"))
+
+        follower = handle.logs.follow()
+        first = await follower.__anext__()
+        assert first.line == "compiling"
+        await follower.aclose()
+
+        hold.set()
+        await handle.task
+        assert handle.result.succeeded
+
+    @pytest.mark.asyncio
+    async def test_cancel_tears_down(self, tmp_path: Path):
+        hold = asyncio.Event()
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_chatty(hold))
+        handle = ex.submit(JobManifest(stack="go", command="go build"))
+        await asyncio.sleep(0.01)
+
+        assert ex.cancel(handle.job_id) is True
+        await asyncio.gather(handle.task, return_exceptions=True)
+        assert handle.result.status == "cancelled"
+        assert handle.result.error_code == JobErrorCode.CANCELLED.value
+        assert _container().stopped
+        assert handle.logs.closed
+        assert ex.cancel(handle.job_id) is False
+
+    @pytest.mark.asyncio
+    async def test_lifecycle_logs_tagged_with_job(self, executor: JobExecutor):
+        records: list[logging.LogRecord] = []
+        handler = logging.Handler(logging.INFO)
+        handler.emit = records.append
+        handler.addFilter(JobContextFilter())
+        jobs_logger = logging.getLogger("orion.security.jobs")
+        jobs_logger.addHandler(handler)
+        jobs_logger.setLevel(logging.INFO)
+        try:
+            handle = executor.submit(JobManifest(stack="go", command="ok"))
+            await handle.task
+        finally:
+            jobs_logger.removeHandler(handler)
+            jobs_logger.setLevel(logging.NOTSET)
+
+        messages = [r.getMessage() for r in records]
+        assert f"Job {handle.job_id} accepted (1 queued)" in messages
+        assert any(m.startswith("Pulling image orion-stack-go") for m in messages)
+        assert {(r.job_id, r.stack) for r in records} == {(handle.job_id, "go")}
+
+    @pytest.mark.asyncio
+    async def test_get_unknown(self, executor: JobExecutor):
+        assert executor.get("nope") is None
+        assert executor.cancel("nope") is False
+
+
+# ---------------------------------------------------------------------------
+# Platform image selection
+# ---------------------------------------------------------------------------
+
+
+class TestPlatformImage:
+    @pytest.mark.asyncio
+    async def test_arch_variant_preferred(self, tmp_path: Path):
+        async def inspect(image):
+            return "arm64" if image.endswith("-arm64") else "amd64"
+
+        ex = JobExecutor(
+            jobs_dir=tmp_path, container_factory=FakeContainer, image_inspector=inspect
+        )
+        ex.arch = "arm64"
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.image == "orion-stack-go:latest-arm64"
+        assert _container().kwargs["image"] == "orion-stack-go:latest-arm64"
+
+    @pytest.mark.asyncio
+    async def test_mismatched_arch_fails_before_container(self, tmp_path: Path):
+        async def inspect(image):
+            return None if image.endswith("-arm64") else "amd64"
+
+        ex = JobExecutor(
+            jobs_dir=tmp_path, container_factory=FakeContainer, image_inspector=inspect
+        )
+        ex.arch = "arm64"
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.error_code == JobErrorCode.STACK_RESOLUTION_FAILED.value
+        assert "built for amd64" in result.error
+        assert FakeContainer.instances == []
+
+
+class TestPullPolicy:
+    @staticmethod
+    def _executor(
+        tmp_path: Path,
+        policy: str,
+        local: bool,
+        pulls: list,
+        pull_ok: bool = True,
+        pull_error: str = "manifest unknown",
+        **kwargs,
+    ):
+        async def inspect(image):
+            return "amd64" if local and not image.endswith(("-amd64", "-arm64")) else None
+
+        async def puller(image, login):
+            pulls.append((image, login))
+            return subprocess.CompletedProcess([], 0 if pull_ok else 1, "", pull_error)
+
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            config=JobsConfig(images=ImagesConfig(pull_policy=policy, **kwargs)),
+            image_inspector=inspect,
+            image_puller=puller,
+        )
+        ex.arch = "amd64"
+        return ex
+
+    @pytest.mark.asyncio
+    async def test_if_not_present_uses_local_copy(self, tmp_path: Path):
+        pulls = []
+        ex = self._executor(tmp_path, "IfNotPresent", local=True, pulls=pulls)
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.succeeded
+        assert pulls == []
+        assert result.image_digest == "sha256:0123abcd"
+        assert result.pull_duration_seconds == 0
+
+    @pytest.mark.asyncio
+    async def test_always_pulls_local_image(self, tmp_path: Path, monkeypatch):
+        digests = iter(["sha256:old", "sha256:new"])
+
+        async def digest(image, runtime=None):
+            return next(digests)
+
+        monkeypatch.setattr("orion.security.jobs.executor.image_digest", digest)
+        pulls = []
+        ex = self._executor(tmp_path, "Always", local=True, pulls=pulls)
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.succeeded
+        assert pulls == [("orion-stack-go:latest", None)]
+        assert result.image_digest == "sha256:new"
+        assert result.to_dict()["pull_duration_seconds"] >= 0
+
+    @pytest.mark.asyncio
+    async def test_always_fails_when_pull_fails(self, tmp_path: Path):
+        ex = self._executor(tmp_path, "Always", local=True, pulls=[], pull_ok=False)
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_PULL_FAILED.value
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_never_fails_fast_when_absent(self, tmp_path: Path):
+        pulls = []
+        ex = self._executor(tmp_path, "Never", local=False, pulls=pulls)
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_NOT_PRESENT.value
+        assert "pullPolicy is Never" in result.error
+        assert pulls == []
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_never_flagged_by_validate(self, tmp_path: Path):
+        ex = self._executor(tmp_path, "Never", local=False, pulls=[])
+        report = await ex.validate("stack: go\ncommand: ok\n")
+        assert not report.ok
+        assert [p.path for p in report.problems] == ["stack"]
+
+    @pytest.mark.asyncio
+    async def test_stack_override(self, tmp_path: Path):
+        pulls = []
+        ex = self._executor(
+            tmp_path, "Never", local=True, pulls=pulls, stack_pull_policies={"go": "Always"}
+        )
+        await ex.run(JobManifest(stack="go", command="ok"))
+        assert len(pulls) == 1
+
+    @pytest.mark.asyncio
+    async def test_registry_login_from_secret_source(self, tmp_path: Path):
+        pulls = []
+        ex = self._executor(
+            tmp_path,
+            "Always",
+            local=False,
+            pulls=pulls,
+            registries={"docker.io": RegistryCredentials("ci-bot", "hub-token")},
+        )
+        ex.secret_source = DictSecretSource({"hub-token": "hunter2"})
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.succeeded
+        ((_, login),) = pulls
+        assert (login.username, login.password) == ("ci-bot", "hunter2")
+
+    @pytest.mark.asyncio
+    async def test_missing_registry_secret(self, tmp_path: Path):
+        pulls = []
+        ex = self._executor(
+            tmp_path,
+            "IfNotPresent",
+            local=False,
+            pulls=pulls,
+            registries={"docker.io": RegistryCredentials("ci-bot", "hub-token")},
+        )
+        ex.secret_source = DictSecretSource({})
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_PULL_FAILED.value
+        assert "hub-token" in result.error
+        assert pulls == []
+
+    @pytest.mark.asyncio
+    async def test_registry_login_from_file(self, tmp_path: Path):
+        (tmp_path / "hub-password").write_text("hunter2\n")
+        pulls = []
+        credentials = RegistryCredentials("ci-bot", password_file=str(tmp_path / "hub-password"))
+        ex = self._executor(
+            tmp_path, "Always", local=False, pulls=pulls, registries={"docker.io": credentials}
+        )
+        assert (await ex.run(JobManifest(stack="go", command="ok"))).succeeded
+        ((_, login),) = pulls
+        assert (login.username, login.password) == ("ci-bot", "hunter2")
+        assert "hunter2" not in repr(login)
+
+    @pytest.mark.asyncio
+    async def test_registry_token_from_env(self, tmp_path: Path, monkeypatch):
+        monkeypatch.setenv("ORION_TEST_HUB_TOKEN", "tok-123")
+        pulls = []
+        credentials = RegistryCredentials("token", password_env="ORION_TEST_HUB_TOKEN", token=True)
+        ex = self._executor(
+            tmp_path, "Always", local=False, pulls=pulls, registries={"docker.io": credentials}
+        )
+        assert (await ex.run(JobManifest(stack="go", command="ok"))).succeeded
+        assert pulls[0][1].password == "tok-123"
+
+    @pytest.mark.asyncio
+    @pytest.mark.parametrize("origin", ["password_file", "password_env"])
+    async def test_unreadable_registry_password(self, tmp_path: Path, origin):
+        name = str(tmp_path / "missing") if origin == "password_file" else "ORION_TEST_UNSET"
+        pulls = []
+        ex = self._executor(
+            tmp_path,
+            "Always",
+            local=False,
+            pulls=pulls,
+            registries={"docker.io": RegistryCredentials("ci-bot", **{origin: name})},
+        )
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_PULL_FAILED.value
+        assert name in result.error and "docker.io" in result.error
+        assert pulls == []
+
+    @pytest.mark.asyncio
+    @pytest.mark.parametrize("policy", ["Always", "IfNotPresent"])
+    async def test_registry_auth_failure(self, tmp_path: Path, policy):
+        pulls = []
+        ex = self._executor(
+            tmp_path,
+            policy,
+            local=False,
+            pulls=pulls,
+            pull_ok=False,
+            pull_error="Error response from daemon: unauthorized: incorrect username or password",
+            registries={"docker.io": RegistryCredentials("ci-bot", "hub-token")},
+        )
+        ex.secret_source = DictSecretSource({"hub-token": "hunter2"})
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_AUTH_FAILED.value
+        assert "authentication failed for docker.io" in result.error
+        assert "ci-bot" in result.error and "hunter2" not in result.error
+        assert len(pulls) == 1  # never retried
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_registry_auth_required_without_login(self, tmp_path: Path):
+        ex = self._executor(
+            tmp_path,
+            "Always",
+            local=False,
+            pulls=[],
+            pull_ok=False,
+            pull_error="Error: initializing source: authentication required",
+        )
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_AUTH_FAILED.value
+        assert "no login is configured" in result.error
+
+
+# ---------------------------------------------------------------------------
+# Timeouts
+# ---------------------------------------------------------------------------
+
+
+def _hanging(honours: set[str]):
+    """FakeContainer subclass whose command blocks until signalled."""
+
+    class Hanging(FakeContainer):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.hold = asyncio.Event()
+            self.honours = honours
+
+    return Hanging
+
+
+class TestRetry:
+    FAST = RetryConfig(max_attempts=3, initial_backoff=0.001, max_backoff=0.001)
+
+    @classmethod
+    def _executor(cls, tmp_path: Path, pull_errors=(), container_factory=FakeContainer, **kwargs):
+        pulls = []
+        errors = list(pull_errors)
+
+        async def puller(image, login):
+            pulls.append(image)
+            if errors:
+                return subprocess.CompletedProcess([], 1, "", errors.pop(0))
+            return subprocess.CompletedProcess([], 0, "", "")
+
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=container_factory,
+            config=JobsConfig(
+                images=ImagesConfig(pull_policy="Always"), retry=kwargs.get("retry", cls.FAST)
+            ),
+            image_puller=puller,
+        )
+        return ex, pulls
+
+    @pytest.mark.asyncio
+    async def test_transient_pull_failure_is_retried(self, tmp_path: Path):
+        ex, pulls = self._executor(tmp_path, pull_errors=["503 Service Unavailable"])
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.succeeded
+        assert len(pulls) == 2
+        assert result.pull_retries == 1
+        assert result.to_dict()["pull_retries"] == 1
+
+    @pytest.mark.asyncio
+    async def test_permanent_pull_failure_is_not_retried(self, tmp_path: Path):
+        ex, pulls = self._executor(tmp_path, pull_errors=["manifest unknown"] * 3)
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_PULL_FAILED.value
+        assert "manifest unknown" in result.error
+        assert len(pulls) == 1
+        assert result.pull_retries == 0
+
+    @pytest.mark.asyncio
+    async def test_pull_gives_up_after_max_attempts(self, tmp_path: Path):
+        ex, pulls = self._executor(tmp_path, pull_errors=["502 Bad Gateway"] * 5)
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_PULL_FAILED.value
+        assert len(pulls) == 3
+        assert result.pull_retries == 2
+
+    @pytest.mark.asyncio
+    async def test_single_attempt_disables_retries(self, tmp_path: Path):
+        ex, pulls = self._executor(
+            tmp_path, pull_errors=["503 Service Unavailable"], retry=RetryConfig(max_attempts=1)
+        )
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.IMAGE_PULL_FAILED.value
+        assert len(pulls) == 1
+
+    @pytest.mark.asyncio
+    async def test_transient_start_failure_is_retried(self, tmp_path: Path):
+        class FlakyStart(FakeContainer):
+            starts = 0
+
+            async def start(self) -> bool:
+                FlakyStart.starts += 1
+                if FlakyStart.starts == 1:
+                    self.start_error = "dial unix /var/run/docker.sock: connection refused"
+                    return False
+                self.start_error = ""
+                return True
+
+        ex, _ = self._executor(tmp_path, container_factory=FlakyStart)
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.succeeded
+        assert FlakyStart.starts == 2
+        assert result.start_retries == 1
+
+    @pytest.mark.asyncio
+    async def test_permanent_start_failure_reports_engine_error(self, tmp_path: Path):
+        class BadStart(FakeContainer):
+            starts = 0
+
+            async def start(self) -> bool:
+                BadStart.starts += 1
+                self.start_error = "invalid reference format"
+                return False
+
+        ex, _ = self._executor(tmp_path, container_factory=BadStart)
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.CONTAINER_START_FAILED.value
+        assert "invalid reference format" in result.error
+        assert BadStart.starts == 1
+        assert result.start_retries == 0
+
+
+class TestTimeout:
+    TIMEOUT = TimeoutConfig(default=0.05, grace_period=0.05)
+
+    @pytest.mark.asyncio
+    async def test_sigterm_is_enough(self, tmp_path: Path):
+        ex = _executor(tmp_path, _hanging({"TERM", "KILL"}), timeout=self.TIMEOUT)
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.timed_out is True
+        assert result.killed_by == "SIGTERM"
+        assert result.error_code == JobErrorCode.TIMED_OUT.value
+        assert _container().signals == ["TERM"]
+        assert _container().pidfile
+        assert result.duration_seconds >= 0.05
+
+    @pytest.mark.asyncio
+    async def test_escalates_to_sigkill(self, tmp_path: Path):
+        ex = _executor(tmp_path, _hanging({"KILL"}), timeout=self.TIMEOUT)
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.killed_by == "SIGKILL"
+        assert _container().signals == ["TERM", "KILL"]
+        assert result.exit_code == 137
+        assert (result.exit.signal, result.exit.timed_out, result.exit.oom_killed) == (
+            "SIGKILL",
+            True,
+            False,
+        )
+
+    @pytest.mark.asyncio
+    async def test_early_finish_not_timed_out(self, tmp_path: Path):
+        ex = _executor(tmp_path, timeout=self.TIMEOUT)
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.succeeded
+        assert result.timed_out is False
+        assert _container().signals == []
+
+    @pytest.mark.asyncio
+    async def test_manifest_timeout_overrides_default(self, tmp_path: Path):
+        ex = _executor(tmp_path, _hanging({"TERM"}), timeout=TestCancel.TIMEOUT)
+        result = await ex.run(JobManifest(stack="go", command="go test", timeout=0.05))
+        assert result.timed_out is True
+        assert "0.05s timeout" in result.error
+
+    @pytest.mark.asyncio
+    async def test_grace_wait_does_not_block_other_jobs(self, tmp_path: Path):
+        slow = _executor(
+            tmp_path / "a",
+            _hanging({"KILL"}),
+            timeout=TimeoutConfig(default=0.05, grace_period=0.5),
+        )
+        fast = _executor(tmp_path / "b", timeout=TestCancel.TIMEOUT)
+
+        loop = asyncio.get_event_loop()
+        slow_task = asyncio.ensure_future(slow.run(JobManifest(stack="go", command="hang")))
+        await asyncio.sleep(0.1)  # slow job is now in its grace period
+        started = loop.time()
+        fast_result = await fast.run(JobManifest(stack="go", command="go test"))
+        assert fast_result.succeeded
+        assert loop.time() - started < 0.4
+        assert (await slow_task).killed_by == "SIGKILL"
+
+    @pytest.mark.asyncio
+    async def test_no_tasks_left_behind(self, tmp_path: Path):
+        ex = _executor(tmp_path, timeout=self.TIMEOUT)
+        before = len(asyncio.all_tasks())
+        await ex.run(JobManifest(stack="go", command="go test"))
+        assert len(asyncio.all_tasks()) == before
+
+
+_GiB = 1024**3
+
+
+def _filling(usages: list[int | None]):
+    """Hanging FakeContainer whose workspace reports ``usages`` in turn."""
+
+    class Filling(_hanging({"TERM", "KILL"})):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.disk_usages = list(usages)
+
+    return Filling
+
+
+class TestWorkspaceQuota:
+    TIMEOUT = TimeoutConfig(default=5, grace_period=0.05)
+    QUOTA = WorkspaceConfig(max_size_gb=1.0, check_interval=0.01)
+
+    @pytest.mark.asyncio
+    async def test_exceeding_quota_stops_the_command(self, tmp_path: Path):
+        ex = _executor(
+            tmp_path,
+            _filling([_GiB // 2, None, 2 * _GiB]),
+            timeout=self.TIMEOUT,
+            workspace=self.QUOTA,
+        )
+        result = await ex.run(JobManifest(stack="go", command="make"))
+        assert result.error_code == JobErrorCode.WORKSPACE_QUOTA_EXCEEDED.value
+        assert result.status == "failed"
+        assert "2.00 GiB" in result.error and "workspace.maxSizeGB" in result.error
+        assert result.killed_by == "SIGTERM"
+        assert result.timed_out is False
+        assert _container().signals == ["TERM"]
+        assert _container().calls.count("disk_usage") == 3
+
+    @pytest.mark.asyncio
+    async def test_under_quota_keeps_running(self, tmp_path: Path):
+        class Quick(FakeContainer):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.disk_usages = [_GiB // 2] * 100
+
+        ex = _executor(tmp_path, Quick, timeout=self.TIMEOUT, workspace=self.QUOTA)
+        result = await ex.run(JobManifest(stack="go", command="make"))
+        assert result.succeeded
+
+    @pytest.mark.asyncio
+    async def test_unlimited_by_default(self, tmp_path: Path):
+        ex = _executor(tmp_path)
+        assert ex.config.workspace.max_size_gb == 0
+        await ex.run(JobManifest(stack="go", command="make"))
+        assert "disk_usage" not in _container().calls
+
+
+def _printing(lines: list[tuple[str, str]], hanging: bool = False):
+    """FakeContainer printing ``lines``, optionally blocking until signalled."""
+    base = _hanging({"TERM", "KILL"}) if hanging else FakeContainer
+
+    class Printing(base):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.output = list(lines)
+
+    return Printing
+
+
+class TestOutputLimit:
+    LINES = [("stdout", "line-1..."), ("stderr", "line-2..."), ("stdout", "line-3...")]
+    TIMEOUT = TimeoutConfig(default=5, grace_period=0.05)
+
+    @pytest.mark.asyncio
+    async def test_truncated_across_both_streams(self, tmp_path: Path):
+        # Each line is 10 bytes with its newline: the second one fits, the third does not
+        ex = _executor(
+            tmp_path,
+            _printing(self.LINES),
+            timeout=self.TIMEOUT,
+            output=OutputConfig(max_log_bytes=25),
+        )
+        handle = ex.submit(JobManifest(stack="go", command="make"))
+        await handle.task
+        result = handle.result
+
+        assert result.succeeded  # the job itself carries on
+        assert result.logs_truncated
+        lines = [(ln.stream, ln.line) for ln in handle.logs.lines]
+        assert lines[:2] == self.LINES[:2]
+        assert lines[2][0] == "stderr" and lines[2][1].startswith("[orion] Output truncated")
+        assert "25 bytes (output.maxLogBytes); later output is dropped" in lines[2][1]
+        assert len(lines) == 3
+        assert result.stdout == "line-1...\n"
+        assert result.stderr.endswith("later output is dropped\n")
+        assert result.to_dict()["logs_truncated"] is True
+
+    @pytest.mark.asyncio
+    async def test_within_limit(self, tmp_path: Path):
+        ex = _executor(
+            tmp_path,
+            _printing(self.LINES),
+            timeout=self.TIMEOUT,
+            output=OutputConfig(max_log_bytes=30),
+        )
+        result = await ex.run(JobManifest(stack="go", command="make"))
+        assert result.succeeded
+        assert not result.logs_truncated
+        assert result.stdout == "line-1...\nline-3...\n"
+
+    @pytest.mark.asyncio
+    async def test_kill_on_limit(self, tmp_path: Path):
+        ex = _executor(
+            tmp_path,
+            _printing(self.LINES, hanging=True),
+            timeout=self.TIMEOUT,
+            output=OutputConfig(max_log_bytes=15, kill_on_limit=True),
+        )
+        result = await ex.run(JobManifest(stack="go", command="yes"))
+        assert result.error_code == JobErrorCode.LOG_LIMIT_EXCEEDED.value
+        assert result.status == "failed"
+        assert "output.killOnLimit" in result.error
+        assert result.logs_truncated
+        assert result.killed_by == "SIGTERM"
+        assert _container().signals == ["TERM"]
+
+    @pytest.mark.asyncio
+    async def test_budget_shared_by_steps(self, tmp_path: Path):
+        ex = _executor(
+            tmp_path,
+            _printing(self.LINES),
+            timeout=self.TIMEOUT,
+            output=OutputConfig(max_log_bytes=25),
+        )
+        steps = [JobStep(name="build", command="make"), JobStep(name="test", command="make test")]
+        handle = ex.submit(JobManifest(stack="go", steps=steps))
+        await handle.task
+
+        assert handle.result.succeeded
+        markers = [ln for ln in handle.logs.lines if ln.line.startswith("[orion]")]
+        assert [ln.step for ln in markers] == ["build"]  # marked once
+        assert [ln.step for ln in handle.logs.lines].count("test") == 0
+
+    @pytest.mark.asyncio
+    async def test_unlimited_by_default(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_printing(self.LINES))
+        assert ex.config.output.max_log_bytes == 0
+        result = await ex.run(JobManifest(stack="go", command="make"))
+        assert not result.logs_truncated
+
+
+def _go_event(action: str, test: str = "", output: str | None = None) -> tuple[str, str]:
+    event = {"Action": action, "Package": "github.com/acme/api", "Test": test}
+    if output is not None:
+        event["Output"] = output
+    return "stdout", json.dumps(event)
+
+
+class TestGoTestReport:
+    EVENTS = [
+        ("stdout", "go: downloading github.com/lib/pq v1.10.9"),
+        _go_event("run", "TestOK"),
+        _go_event("output", "TestOK", "--- PASS: TestOK (0.00s)\n"),
+        _go_event("pass", "TestOK"),
+        _go_event("run", "TestToken"),
+        _go_event("output", "TestToken", "    token_test.go:9: bad token hunter2\n"),
+        _go_event("output", "TestToken", "--- FAIL: TestToken (0.00s)\n"),
+        _go_event("fail", "TestToken"),
+        _go_event("output", "", "FAIL\n"),
+        _go_event("fail"),
+    ]
+
+    @pytest.mark.asyncio
+    async def test_report_and_plain_log(self, tmp_path: Path):
+        class Failing(_printing(self.EVENTS)):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.exit_codes["execute"] = 1
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=Failing)
+        ex.secret_source = DictSecretSource({"api-token": "hunter2"})
+        manifest = JobManifest(
+            stack="go",
+            command=["go", "test", "./..."],
+            report="gotest",
+            secrets={"API_TOKEN": "api-token"},
+        )
+        handle = ex.submit(manifest)
+        await handle.task
+        result = handle.result
+
+        assert _container().execs[-1] == ("execute", ["go", "test", "-json", "./..."])
+        assert result.error_code == JobErrorCode.COMMAND_FAILED.value
+        report = result.to_dict()["test_report"]
+        assert (report["passed"], report["failed"], report["aborted"]) == (1, 1, False)
+        assert report["tests"][1]["name"] == "TestToken"
+        assert "bad token ***" in report["tests"][1]["output"]
+        assert "hunter2" not in json.dumps(report)
+        # The log and stdout read as go test's plain output
+        plain = [
+            "go: downloading github.com/lib/pq v1.10.9",
+            "--- PASS: TestOK (0.00s)",
+            "    token_test.go:9: bad token ***",
+            "--- FAIL: TestToken (0.00s)",
+            "FAIL",
+        ]
+        assert [ln.line for ln in handle.logs.lines] == plain
+        assert result.stdout == "".join(line + "\n" for line in plain)
+        # Kept with the job's record
+        assert JobResult.from_dict(result.to_dict()).test_report == result.test_report
+
+    @pytest.mark.asyncio
+    async def test_shell_command_wrapped(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_printing(self.EVENTS[:4]))
+        result = await ex.run(JobManifest(stack="go", command="go test ./...", report="gotest"))
+        command = _container().execs[-1][1]
+        assert "command go test -json" in command and command.endswith("go test ./...")
+        assert result.succeeded and result.test_report.count("pass") == 1
+
+    @pytest.mark.asyncio
+    async def test_timeout_keeps_finished_tests(self, tmp_path: Path):
+        config = JobsConfig(timeout=TimeoutConfig(default=0.05, grace_period=0.05))
+        factory = _printing([*self.EVENTS[:4], _go_event("run", "TestHang")], hanging=True)
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory, config=config)
+        result = await ex.run(JobManifest(stack="go", command="go test ./...", report="gotest"))
+        assert result.timed_out
+        report = result.test_report.to_dict()
+        assert report["aborted"] and report["aborted_packages"] == ["github.com/acme/api"]
+        assert [(t["name"], t["status"]) for t in report["tests"]] == [
+            ("TestOK", "pass"),
+            ("TestHang", "incomplete"),
+        ]
+
+    @pytest.mark.asyncio
+    async def test_off_by_default(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_printing(self.EVENTS[:4]))
+        result = await ex.run(JobManifest(stack="go", command="go test -json ./..."))
+        assert result.test_report is None and result.to_dict()["test_report"] is None
+        assert result.stdout.count('"Action"') == 3  # the events, untouched
+
+
+class TestCancel:
+    TIMEOUT = TimeoutConfig(default=3600, grace_period=0.05)
+
+    @pytest.mark.asyncio
+    async def test_running_command_gets_sigterm(self, tmp_path: Path):
+        ex = _executor(tmp_path, _hanging({"TERM", "KILL"}), timeout=self.TIMEOUT)
+        handle = ex.submit(JobManifest(stack="go", command="go test"))
+        await asyncio.sleep(0.01)
+
+        assert ex.cancel(handle.job_id) is True
+        await handle.task
+        assert handle.result.status == "cancelled"
+        assert handle.result.error_code == JobErrorCode.CANCELLED.value
+        assert handle.result.killed_by == "SIGTERM"
+        assert handle.result.timed_out is False
+        assert handle.result.exit_code == 143
+        assert _container().signals == ["TERM"]
+        assert _container().stopped
+
+    @pytest.mark.asyncio
+    async def test_escalates_to_sigkill_after_grace(self, tmp_path: Path):
+        ex = _executor(tmp_path, _hanging({"KILL"}), timeout=self.TIMEOUT)
+        handle = ex.submit(JobManifest(stack="go", command="go test"))
+        await asyncio.sleep(0.01)
+
+        ex.cancel(handle.job_id)
+        await handle.task
+        assert handle.result.status == "cancelled"
+        assert handle.result.killed_by == "SIGKILL"
+        assert _container().signals == ["TERM", "KILL"]
+
+    @pytest.mark.asyncio
+    async def test_repeat_cancel_is_a_no_op(self, tmp_path: Path):
+        ex = _executor(tmp_path, _hanging({"KILL"}), timeout=self.TIMEOUT)
+        handle = ex.submit(JobManifest(stack="go", command="go test"))
+        await asyncio.sleep(0.01)
+
+        assert ex.cancel(handle.job_id) is True
+        assert ex.cancel(handle.job_id) is False
+        await handle.task
+        assert ex.cancel(handle.job_id) is False
+        assert handle.result.status == "cancelled"
+        assert _container().calls.count("stop") == 1
+
+    @pytest.mark.asyncio
+    async def test_finished_job_keeps_its_outcome(self, executor: JobExecutor):
+        handle = executor.submit(JobManifest(stack="go", command="ok"))
+        await handle.task
+        assert executor.cancel(handle.job_id) is False
+        assert handle.result.succeeded
+
+    @pytest.mark.asyncio
+    async def test_starting_container_is_torn_down_after_start(self, tmp_path: Path):
+        started = asyncio.Event()
+        release = asyncio.Event()
+
+        class SlowStart(FakeContainer):
+            async def start(self) -> bool:
+                started.set()
+                await release.wait()
+                return True
+
+        ex = _executor(tmp_path, SlowStart, timeout=self.TIMEOUT)
+        handle = ex.submit(JobManifest(stack="go", command="go test"))
+        await started.wait()
+
+        assert ex.cancel(handle.job_id) is True
+        release.set()
+        await handle.task
+        assert handle.result.status == "cancelled"
+        assert _container().stopped
+        assert [phase for phase, _ in _container().execs if phase == "execute"] == []
+
+    @pytest.mark.asyncio
+    async def test_cancel_while_finishing_is_ignored(self, tmp_path: Path):
+        stopping = asyncio.Event()
+        release = asyncio.Event()
+
+        class SlowStop(FakeContainer):
+            async def stop(self) -> bool:
+                stopping.set()
+                await release.wait()
+                return await super().stop()
+
+        ex = _executor(tmp_path, SlowStop, timeout=self.TIMEOUT)
+        handle = ex.submit(JobManifest(stack="go", command="ok"))
+        await stopping.wait()
+
+        assert ex.cancel(handle.job_id) is False
+        release.set()
+        await handle.task
+        assert handle.result.succeeded
+        assert _container().stopped
+
+    @pytest.mark.asyncio
+    async def test_followers_see_end_of_stream(self, tmp_path: Path):
+        ex = _executor(tmp_path, _hanging({"TERM"}), timeout=self.TIMEOUT)
+        handle = ex.submit(JobManifest(stack="go", command="go test"))
+        await asyncio.sleep(0.01)
+
+        async def follow():
+            return [line async for line in handle.logs.follow()]
+
+        follower = asyncio.create_task(follow())
+        await asyncio.sleep(0.01)
+        ex.cancel(handle.job_id)
+        await asyncio.wait_for(follower, timeout=1)
+        await handle.task
+        assert handle.logs.closed
+
+
+# ---------------------------------------------------------------------------
+# Secrets
+# ---------------------------------------------------------------------------
+
+
+def _leaky():
+    """FakeContainer subclass that echoes the secret it was given."""
+
+    class Leaky(FakeContainer):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.output = [("stdout", "token is hunter2"), ("stderr", "auth hunter2 ok")]
+
+    return Leaky
+
+
+class TestSecrets:
+    @pytest.mark.asyncio
+    async def test_injected_and_redacted(self, tmp_path: Path):
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=_leaky(),
+            secret_source=DictSecretSource({"npm": "hunter2"}),
+        )
+        handle = ex.submit(
+            JobManifest(stack="node", command="npm publish", secrets={"NPM_TOKEN": "npm"})
+        )
+        await handle.task
+
+        assert _container().kwargs["secret_env"] == {"NPM_TOKEN": "hunter2"}
+        assert [ln.line for ln in handle.logs.lines] == ["token is ***", "auth *** ok"]
+        assert "hunter2" not in handle.result.stdout
+        assert "hunter2" not in str(handle.result.to_dict())
+
+    @pytest.mark.asyncio
+    async def test_missing_secret_fails_before_container(self, tmp_path: Path):
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            secret_source=DictSecretSource({}),
+        )
+        result = await ex.run(
+            JobManifest(stack="node", command="npm publish", secrets={"NPM_TOKEN": "npm"})
+        )
+        assert result.error_code == JobErrorCode.SECRET_RESOLUTION_FAILED.value
+        assert "npm" in result.error
+        assert FakeContainer.instances == []
+
+
+# ---------------------------------------------------------------------------
+# Validation (dry run)
+# ---------------------------------------------------------------------------
+
+
+class TestValidate:
+    @pytest.mark.asyncio
+    async def test_valid_manifest_reports_image(self, executor: JobExecutor):
+        report = await executor.validate("stack: go\ncommand: go test ./...\n")
+        assert report.ok is True
+        assert report.image == "orion-stack-go:latest"
+        assert report.problems == []
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_parse_problems_listed(self, executor: JobExecutor):
+        report = await executor.validate({"command": 1})
+        assert report.ok is False
+        assert [p.path for p in report.problems] == ["stack", "command"]
+
+    @pytest.mark.asyncio
+    async def test_semantic_problems_all_reported(self, tmp_path: Path):
+        config = JobsConfig(resources=ResourcesConfig(max_cpu=2, max_memory=1024**3))
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            config=config,
+            secret_source=DictSecretSource({"present": "x"}),
+        )
+        report = await ex.validate(
+            {
+                "stack": "go",
+                "toolchain": "not-a-version",
+                "resources": {"cpu": 8, "memory": "4Gi"},
+                "secrets": {"A": "present", "B": "absent"},
+            }
+        )
+        assert report.ok is False
+        assert report.image == "orion-stack-go:latest"
+        assert {p.path for p in report.problems} == {
+            "toolchain",
+            "resources.cpu",
+            "resources.memory",
+            "secrets.B",
+        }
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_unknown_stack(self, executor: JobExecutor):
+        report = await executor.validate({"stack": "cobol"})
+        assert report.to_dict()["problems"][0]["path"] == "stack"
+        assert report.image == ""
+
+
+# ---------------------------------------------------------------------------
+# Scheduler
+# ---------------------------------------------------------------------------
+
+
+def _held():
+    """A FakeContainer factory whose commands all block on one shared event."""
+    release = asyncio.Event()
+
+    class Held(FakeContainer):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.hold = release
+
+    return Held, release
+
+
+class TestScheduler:
+    @pytest.mark.asyncio
+    async def test_excess_jobs_wait_fifo(self, tmp_path: Path):
+        factory, release = _held()
+        scheduler = SchedulerConfig(max_concurrent=2, max_queued=10)
+        ex = _executor(tmp_path, factory, scheduler=scheduler)
+        handles = [ex.submit(JobManifest(stack="go", command=f"job{i}")) for i in range(4)]
+        await asyncio.sleep(0.01)
+
+        assert ex.stats()["running"] == 2
+        assert ex.stats()["queued"] == 2
+        assert [h.result.status for h in handles] == ["running", "running", "queued", "queued"]
+
+        release.set()
+        await asyncio.gather(*(h.task for h in handles))
+        started = [c.execs[-1][1] for c in FakeContainer.instances]
+        assert started == ["job0", "job1", "job2", "job3"]
+        assert ex.stats() == {"running": 0, "queued": 0, "max_concurrent": 2, "max_queued": 10}
+
+    @pytest.mark.asyncio
+    async def test_next_job_starts_when_slot_frees(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        first = ex.submit(JobManifest(stack="go", command="first"))
+        second = ex.submit(JobManifest(stack="go", command="second"))
+        await asyncio.sleep(0.01)
+        assert len(FakeContainer.instances) == 1
+
+        release.set()
+        await asyncio.gather(first.task, second.task)
+        assert second.result.succeeded
+        assert len(FakeContainer.instances) == 2
+
+    @pytest.mark.asyncio
+    async def test_cancel_queued_job_never_launches(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        running = ex.submit(JobManifest(stack="go", command="running"))
+        queued = ex.submit(JobManifest(stack="go", command="queued"))
+        await asyncio.sleep(0.01)
+
+        assert ex.cancel(queued.job_id) is True
+        await queued.task
+        assert queued.result.status == "cancelled"
+        assert queued.logs.closed
+        assert ex.stats()["queued"] == 0
+
+        release.set()
+        await running.task
+        assert [c.execs[-1][1] for c in FakeContainer.instances] == ["running"]
+
+    @pytest.mark.asyncio
+    async def test_queue_full_rejected(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(
+            tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1, max_queued=1)
+        )
+        first = ex.submit(JobManifest(stack="go", command="a"))
+        await asyncio.sleep(0.01)
+        second = ex.submit(JobManifest(stack="go", command="b"))
+
+        with pytest.raises(QueueFullError, match="queue full"):
+            ex.submit(JobManifest(stack="go", command="c"))
+
+        release.set()
+        await asyncio.gather(first.task, second.task)
+
+
+class TestReloadConfig:
+    @pytest.mark.asyncio
+    async def test_raised_concurrency_starts_waiting_jobs(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        handles = [ex.submit(JobManifest(stack="go", command=f"job{i}")) for i in range(3)]
+        await asyncio.sleep(0.01)
+        assert ex.stats()["running"] == 1
+
+        ex.reload_config(JobsConfig(scheduler=SchedulerConfig(max_concurrent=3)))
+        await asyncio.sleep(0.01)
+        assert ex.stats()["running"] == 3
+
+        release.set()
+        await asyncio.gather(*(h.task for h in handles))
+        assert [c.execs[-1][1] for c in FakeContainer.instances] == ["job0", "job1", "job2"]
+
+    @pytest.mark.asyncio
+    async def test_lowered_concurrency_lets_running_jobs_finish(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=2))
+        handles = [ex.submit(JobManifest(stack="go", command=f"job{i}")) for i in range(3)]
+        await asyncio.sleep(0.01)
+
+        ex.reload_config(JobsConfig(scheduler=SchedulerConfig(max_concurrent=1)))
+        await asyncio.sleep(0.01)
+        assert ex.stats()["running"] == 2  # nothing is interrupted
+        assert ex.stats()["max_concurrent"] == 1
+
+        release.set()
+        await asyncio.gather(*(h.task for h in handles))
+        assert all(h.result.succeeded for h in handles)
+
+    @pytest.mark.asyncio
+    async def test_lowered_concurrency_applies_once_jobs_finish(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=2))
+        running = [ex.submit(JobManifest(stack="go", command=f"run{i}")) for i in range(2)]
+        await asyncio.sleep(0.01)
+        ex.reload_config(JobsConfig(scheduler=SchedulerConfig(max_concurrent=1)))
+        release.set()
+        await asyncio.gather(*(h.task for h in running))
+
+        release.clear()
+        queued = [ex.submit(JobManifest(stack="go", command=f"next{i}")) for i in range(2)]
+        await asyncio.sleep(0.01)
+        assert ex.stats()["running"] == 1
+        assert ex.stats()["queued"] == 1
+        release.set()
+        await asyncio.gather(*(h.task for h in queued))
+
+    @pytest.mark.asyncio
+    async def test_new_settings_apply_to_next_job(self, tmp_path: Path):
+        pulls = []
+
+        async def local(image):
+            return None if image.endswith(("-amd64", "-arm64")) else "amd64"
+
+        async def puller(image, login):
+            pulls.append(image)
+            return await _pulled(image)
+
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            image_inspector=local,
+            image_puller=puller,
+        )
+        ex.arch = "amd64"
+        await ex.run(JobManifest(stack="go", command="ok"))
+        assert pulls == []
+
+        changes = ex.reload_config(JobsConfig(images=ImagesConfig(pull_policy="Always")))
+        assert [(c.key, c.live) for c in changes] == [("images.pullPolicy", True)]
+        await ex.run(JobManifest(stack="go", command="ok"))
+        assert len(pulls) == 1
+
+    def test_restart_settings_kept(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        runtime = ex.runtime
+        new = JobsConfig(
+            runtime=RuntimeConfig(driver="podman", socket="/run/podman.sock"),
+            timeout=TimeoutConfig(default=60),
+        )
+        changes = ex.reload_config(new)
+
+        assert {c.key: c.live for c in changes} == {
+            "timeout.default": True,
+            "runtime.driver": False,
+            "runtime.socket": False,
+        }
+        assert ex.config is new
+        assert ex.config.runtime == RuntimeConfig()
+        assert ex.config.timeout.default == 60
+        assert ex.runtime is runtime
+
+
+# ---------------------------------------------------------------------------
+# Metrics
+# ---------------------------------------------------------------------------
+
+
+class TestMetrics:
+    @pytest.mark.asyncio
+    async def test_success_and_failure_counted_once(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        await ex.run(JobManifest(stack="go", command="ok"))
+        bad = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(execute=1))
+        bad.metrics = ex.metrics
+        await bad.run(JobManifest(stack="go", command="fail"))
+
+        text = ex.metrics.to_prometheus()
+        assert 'orion_jobs_started_total{stack="go"} 2' in text
+        assert 'orion_jobs_finished_total{stack="go",exit_reason="succeeded"} 1' in text
+        assert 'orion_jobs_finished_total{stack="go",exit_reason="command_failed"} 1' in text
+        assert 'orion_job_duration_seconds_count{stack="go"} 2' in text
+
+    @pytest.mark.asyncio
+    async def test_pull_timed_when_image_missing(self, executor: JobExecutor):
+        await executor.run(JobManifest(stack="go", command="ok"))
+        assert 'orion_image_pull_duration_seconds_count{stack="go"} 1' in (
+            executor.metrics.to_prometheus()
+        )
+
+    @pytest.mark.asyncio
+    async def test_no_pull_when_image_local(self, tmp_path: Path):
+        async def local(image):
+            return None if image.endswith(("-amd64", "-arm64")) else "amd64"
+
+        pulls = []
+
+        async def puller(image, login):
+            pulls.append(image)
+            return await _pulled(image)
+
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            image_inspector=local,
+            image_puller=puller,
+        )
+        ex.arch = "amd64"
+        await ex.run(JobManifest(stack="go", command="ok"))
+        assert pulls == []
+
+    @pytest.mark.asyncio
+    async def test_internal_error_still_recorded(self, tmp_path: Path):
+        class Broken(FakeContainer):
+            async def start(self) -> bool:
+                raise RuntimeError("docker exploded")
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=Broken)
+        with pytest.raises(RuntimeError):
+            await ex.run(JobManifest(stack="go", command="ok"))
+        text = ex.metrics.to_prometheus()
+        assert 'exit_reason="internal_error"} 1' in text
+
+    @pytest.mark.asyncio
+    async def test_cancelled_while_queued(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        running = ex.submit(JobManifest(stack="go", command="a"))
+        queued = ex.submit(JobManifest(stack="go", command="b"))
+        await asyncio.sleep(0.01)
+        ex.cancel(queued.job_id)
+        await queued.task
+
+        text = ex.metrics.to_prometheus(**{k: ex.stats()[k] for k in ("running", "queued")})
+        assert 'orion_jobs_started_total{stack="go"} 1' in text
+        assert 'exit_reason="cancelled"} 1' in text
+        assert "orion_jobs_running 1" in text
+        release.set()
+        await running.task
+
+    def test_disabled_by_config(self, tmp_path: Path):
+        config = JobsConfig(metrics=MetricsConfig(enabled=False))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        assert ex.metrics is None
+
+
+# ---------------------------------------------------------------------------
+# Artifacts
+# ---------------------------------------------------------------------------
+
+
+def _with_files(files: dict[str, bytes], execute: int = 0):
+    class WithFiles(_scripted(execute=execute)):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.files = dict(files)
+
+    return WithFiles
+
+
+_BUILD_OUTPUT = {
+    "dist/app": b"\x7fELF" + b"0" * 96,
+    "dist/lib/util.so": b"1" * 50,
+    "unit.junit.xml": b"<testsuite/>",
+    "main.go": b"package main",
+}
+
+
+class TestArtifacts:
+    @pytest.mark.asyncio
+    async def test_copied_and_reported(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_with_files(_BUILD_OUTPUT))
+        manifest = JobManifest(stack="go", command="make", artifacts=["dist/**", "*.junit.xml"])
+        result = await ex.run(manifest, job_id="a1")
+
+        assert result.succeeded
+        assert [(a.path, a.size) for a in result.artifacts] == [
+            ("dist/app", 100),
+            ("dist/lib/util.so", 50),
+            ("unit.junit.xml", 12),
+        ]
+        out = tmp_path / "a1" / "artifacts"
+        assert result.artifacts_dir == str(out)
+        assert (out / "dist" / "lib" / "util.so").read_bytes() == b"1" * 50
+        assert not (out / "main.go").exists()
+        assert result.to_dict()["artifacts"][0] == {"path": "dist/app", "size": 100}
+
+    @pytest.mark.asyncio
+    async def test_collected_before_teardown(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_with_files(_BUILD_OUTPUT))
+        await ex.run(JobManifest(stack="go", command="make", artifacts=["unit.junit.xml"]))
+        assert _container().calls == ["copy /workspace/unit.junit.xml", "stop"]
+
+    @pytest.mark.asyncio
+    async def test_collected_when_command_fails(self, tmp_path: Path):
+        factory = _with_files(_BUILD_OUTPUT, execute=1)
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory)
+        result = await ex.run(JobManifest(stack="go", command="go test", artifacts=["*.xml"]))
+        assert result.error_code == JobErrorCode.COMMAND_FAILED.value
+        assert [a.path for a in result.artifacts] == ["unit.junit.xml"]
+
+    @pytest.mark.asyncio
+    async def test_unmatched_pattern_warns_only(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_with_files(_BUILD_OUTPUT))
+        result = await ex.run(JobManifest(stack="go", command="make", artifacts=["build/**"]))
+        assert result.succeeded
+        assert result.artifacts == []
+        assert result.artifact_warnings == ["Artifact pattern 'build/**' matched no files"]
+
+    @pytest.mark.asyncio
+    async def test_size_cap(self, tmp_path: Path):
+        config = JobsConfig(artifacts=ArtifactsConfig(max_total_size=120))
+        ex = JobExecutor(
+            jobs_dir=tmp_path, container_factory=_with_files(_BUILD_OUTPUT), config=config
+        )
+        result = await ex.run(JobManifest(stack="go", command="make", artifacts=["dist/**"]))
+        assert result.succeeded
+        assert [a.path for a in result.artifacts] == ["dist/app"]
+        assert "120 byte limit" in result.artifact_warnings[0]
+        assert not (tmp_path / result.job_id / "artifacts" / "dist" / "lib").exists()
+
+    @pytest.mark.asyncio
+    async def test_no_patterns_no_listing(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="make"))
+        assert result.artifacts_dir == ""
+        assert _container().calls == ["stop"]
+
+
+# ---------------------------------------------------------------------------
+# Container runtime
+# ---------------------------------------------------------------------------
+
+
+class TestRuntime:
+    @pytest.mark.asyncio
+    async def test_configured_driver_reaches_container(self, tmp_path: Path):
+        config = JobsConfig(runtime=RuntimeConfig(driver="podman"))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        assert isinstance(ex.runtime, PodmanRuntime)
+        await ex.run(JobManifest(stack="go", command="make"))
+        assert _container().kwargs["runtime"] is ex.runtime
+
+    @pytest.mark.asyncio
+    async def test_image_checks_use_runtime(self, tmp_path: Path, monkeypatch):
+        seen = []
+
+        async def inspect(image, runtime=None):
+            seen.append(runtime)
+            return None
+
+        monkeypatch.setattr("orion.security.jobs.executor.image_arch", inspect)
+        runtime = PodmanRuntime(rootless=True)
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, runtime=runtime)
+        await ex.run(JobManifest(stack="go", command="make"))
+        assert seen and all(r is runtime for r in seen)
+
+
+# ---------------------------------------------------------------------------
+# Source checkout
+# ---------------------------------------------------------------------------
+
+
+def _clone_fails(stderr: str):
+    class CloneFails(_scripted(install=128)):
+        def __init__(self, **kwargs):
+            super().__init__(**kwargs)
+            self.install_stderr = stderr
+
+    return CloneFails
+
+
+class TestSource:
+    GIT = JobSource(git="https://github.com/acme/api.git", ref="main", depth=1)
+
+    @pytest.mark.asyncio
+    async def test_clone_before_toolchain_and_command(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(PROBE_BAKED))
+        manifest = JobManifest(stack="go", command="go test", toolchain="1.22.5", source=self.GIT)
+        result = await ex.run(manifest)
+
+        assert result.succeeded
+        phases = [phase for phase, _ in _container().execs]
+        assert phases == ["install", "toolchain", "execute"]
+        assert "git fetch -q --depth 1 origin main" in _container().execs[0][1]
+        assert _container().installs == [{"env": CLONE_ENV, "user": "orion"}]
+
+    @pytest.mark.asyncio
+    async def test_auth_failure(self, tmp_path: Path):
+        factory = _clone_fails("fatal: Authentication failed for 'https://github.com/acme/api/'")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory)
+        result = await ex.run(JobManifest(stack="go", command="go test", source=self.GIT))
+        assert result.error_code == JobErrorCode.SOURCE_AUTH_FAILED.value
+        assert "credentials" in result.error
+        assert [phase for phase, _ in _container().execs] == ["install"]
+        assert _container().stopped
+
+    @pytest.mark.asyncio
+    async def test_network_failure(self, tmp_path: Path):
+        factory = _clone_fails("fatal: unable to access '...': Could not resolve host: github.com")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory)
+        result = await ex.run(JobManifest(stack="go", command="go test", source=self.GIT))
+        assert result.error_code == JobErrorCode.SOURCE_NETWORK_FAILED.value
+
+    @pytest.mark.asyncio
+    async def test_missing_ref(self, tmp_path: Path):
+        factory = _clone_fails("fatal: couldn't find remote ref nope")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory)
+        result = await ex.run(JobManifest(stack="go", command="go test", source=self.GIT))
+        assert result.error_code == JobErrorCode.SOURCE_CHECKOUT_FAILED.value
+
+    @pytest.mark.asyncio
+    async def test_bind_workspace_root(self, tmp_path: Path):
+        checkout = tmp_path / "checkouts" / "api"
+        checkout.mkdir(parents=True)
+        config = JobsConfig(source=SourceConfig(allowed_host_paths=[str(tmp_path / "checkouts")]))
+        ex = JobExecutor(jobs_dir=tmp_path / "jobs", container_factory=FakeContainer, config=config)
+        result = await ex.run(
+            JobManifest(stack="go", command="go test", source=JobSource(host_path=str(checkout)))
+        )
+        assert result.succeeded
+        assert _container().kwargs["workspace_path"] == checkout
+        assert [phase for phase, _ in _container().execs] == ["execute"]
+        assert checkout.is_dir()  # the operator's, never removed
+
+    @pytest.mark.asyncio
+    async def test_bind_subdirectory(self, tmp_path: Path):
+        checkout = tmp_path / "checkouts" / "api"
+        checkout.mkdir(parents=True)
+        config = JobsConfig(source=SourceConfig(allowed_host_paths=[str(tmp_path / "checkouts")]))
+        ex = JobExecutor(jobs_dir=tmp_path / "jobs", container_factory=FakeContainer, config=config)
+        source = JobSource(host_path=str(checkout), path="src/api")
+        await ex.run(JobManifest(stack="go", command="go test", source=source))
+        assert f"{checkout}:/workspace/src/api:rw" in _container().kwargs["extra_volumes"]
+
+    @pytest.mark.asyncio
+    async def test_bind_not_allowed(self, executor: JobExecutor, tmp_path: Path):
+        source = JobSource(host_path=str(tmp_path))
+        result = await executor.run(JobManifest(stack="go", command="ls", source=source))
+        assert result.error_code == JobErrorCode.SOURCE_CHECKOUT_FAILED.value
+        assert "allowedHostPaths" in result.error
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_validate_reports_disallowed_bind(self, executor: JobExecutor, tmp_path: Path):
+        report = await executor.validate(
+            {"stack": "go", "command": "ls", "source": {"hostPath": str(tmp_path)}}
+        )
+        assert not report.ok
+        assert [p.path for p in report.problems] == ["source.hostPath"]
+
+
+def _archive(*files: tuple[str, bytes]) -> bytes:
+    buffer = io.BytesIO()
+    with tarfile.open(fileobj=buffer, mode="w:gz") as tar:
+        for name, data in files:
+            info = tarfile.TarInfo(name)
+            info.size = len(data)
+            tar.addfile(info, io.BytesIO(data))
+    return buffer.getvalue()
+
+
+async def _chunks(*parts: bytes):
+    for part in parts:
+        yield part
+
+
+class _ListsWorkspace(FakeContainer):
+    """Records the workspace files it sees when the command runs."""
+
+    async def exec(self, command, timeout=120, phase="execute", **kwargs):
+        if phase == "execute":
+            root = Path(self.kwargs["workspace_path"])
+            for path in root.rglob("*"):
+                if path.is_file():
+                    self.files[str(path.relative_to(root))] = path.read_bytes()
+        return await super().exec(command, timeout, phase, **kwargs)
+
+
+class TestArchiveSource:
+    @pytest.mark.asyncio
+    async def test_unpacked_before_the_command(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_ListsWorkspace)
+        upload = await ex.save_upload(_chunks(_archive(("main.go", b"package main\n"))))
+        source = JobSource(archive=True, upload=upload, path="api")
+        result = await ex.run(JobManifest(stack="go", command="go test", source=source))
+
+        assert result.succeeded
+        assert _container().files == {"api/main.go": b"package main\n"}
+        assert _container().execs[0] == ("prepare", "chown -R 1000:1000 /workspace/api")
+        assert _container().users["prepare"] == "0:0"
+        assert not (ex.uploads_dir / f"{upload}.tar").exists()  # consumed by the job
+
+    @pytest.mark.asyncio
+    async def test_owned_by_run_as(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        upload = await ex.save_upload(_chunks(_archive(("a", b"x"))))
+        source = JobSource(archive=True, upload=upload)
+        await ex.run(JobManifest(stack="go", command="ls", run_as="2000:3000", source=source))
+        assert ("prepare", "chown -R 2000:3000 /workspace") in _container().execs
+
+    @pytest.mark.asyncio
+    async def test_unsafe_archive_fails_before_starting(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        upload = await ex.save_upload(_chunks(_archive(("../escape", b"x"))))
+        source = JobSource(archive=True, upload=upload)
+        result = await ex.run(JobManifest(stack="go", command="ls", source=source))
+
+        assert result.error_code == JobErrorCode.SOURCE_ARCHIVE_INVALID.value
+        assert "'..' component" in result.error
+        assert FakeContainer.instances == []
+        assert not (tmp_path / "escape").exists()
+
+    @pytest.mark.asyncio
+    async def test_quota_exceeded_while_unpacking(self, tmp_path: Path):
+        config = JobsConfig(workspace=WorkspaceConfig(max_size_gb=1 / 1024**3))  # 1 byte
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        upload = await ex.save_upload(_chunks(_archive(("big", b"xx"))))
+        source = JobSource(archive=True, upload=upload)
+        result = await ex.run(JobManifest(stack="go", command="ls", source=source))
+        assert result.error_code == JobErrorCode.WORKSPACE_QUOTA_EXCEEDED.value
+        assert "workspace.maxSizeGB" in result.error
+
+    @pytest.mark.asyncio
+    async def test_pinned_digest(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        data = _archive(("main.go", b"package main\n"))
+        upload = await ex.save_upload(_chunks(data))
+        source = JobSource(archive=True, upload=upload, sha256=hashlib.sha256(data).hexdigest())
+        result = await ex.run(JobManifest(stack="go", command="go test", source=source))
+        assert result.succeeded
+
+    @pytest.mark.asyncio
+    async def test_swapped_archive_refused(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        signed = hashlib.sha256(_archive(("main.go", b"package main\n"))).hexdigest()
+        upload = await ex.save_upload(_chunks(_archive(("main.go", b"package evil\n"))))
+        source = JobSource(archive=True, upload=upload, sha256=signed)
+        result = await ex.run(JobManifest(stack="go", command="go test", source=source))
+
+        assert result.error_code == JobErrorCode.SOURCE_ARCHIVE_INVALID.value
+        assert f"not the manifest's source.sha256 {signed}" in result.error
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_missing_upload(self, executor: JobExecutor):
+        source = JobSource(archive=True, upload="0" * 32)
+        result = await executor.run(JobManifest(stack="go", command="ls", source=source))
+        assert result.error_code == JobErrorCode.SOURCE_ARCHIVE_INVALID.value
+        assert "does not exist" in result.error
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_no_archive_sent(self, executor: JobExecutor):
+        source = JobSource(archive=True)
+        result = await executor.run(JobManifest(stack="go", command="ls", source=source))
+        assert result.error_code == JobErrorCode.SOURCE_ARCHIVE_INVALID.value
+        assert "send one with the submission" in result.error
+
+    @pytest.mark.asyncio
+    async def test_upload_too_large(self, tmp_path: Path):
+        config = JobsConfig(source=SourceConfig(max_archive_size=4))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        with pytest.raises(ArchiveError, match="source.maxArchiveSize"):
+            await ex.save_upload(_chunks(b"12345"))
+
+    @pytest.mark.asyncio
+    async def test_uploads_capped_together(self, tmp_path: Path):
+        config = JobsConfig(source=SourceConfig(max_uploads_size=8))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        await ex.save_upload(_chunks(b"12345"))
+        with pytest.raises(UploadsFullError, match="source.maxUploadsSize"):
+            await ex.save_upload(_chunks(b"12345"))
+
+    @pytest.mark.asyncio
+    async def test_upload_dropped_when_cancelled_while_queued(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        running = ex.submit(JobManifest(stack="go", command="a"))
+        upload = await ex.save_upload(_chunks(_archive(("a", b"x"))))
+        source = JobSource(archive=True, upload=upload)
+        queued = ex.submit(JobManifest(stack="go", command="b", source=source))
+        await asyncio.sleep(0.01)
+        ex.cancel(queued.job_id)
+        await queued.task
+
+        assert queued.result.status == "cancelled"
+        assert not upload_path(ex.uploads_dir, upload).exists()
+        release.set()
+        await running.task
+
+    @pytest.mark.asyncio
+    async def test_validate_reports_missing_upload(self, executor: JobExecutor):
+        report = await executor.validate(
+            {"stack": "go", "command": "ls", "source": {"type": "archive", "upload": "0" * 32}}
+        )
+        assert [p.path for p in report.problems] == ["source.upload"]
+
+
+# ---------------------------------------------------------------------------
+# Extra mounts
+# ---------------------------------------------------------------------------
+
+
+class TestMounts:
+    @staticmethod
+    def _executor(tmp_path: Path, **kwargs) -> JobExecutor:
+        (tmp_path / "shared" / "sdk").mkdir(parents=True)
+        config = JobsConfig(
+            mounts=MountsConfig(allowed_host_paths=[str(tmp_path / "shared")]), **kwargs
+        )
+        return JobExecutor(
+            jobs_dir=tmp_path / "jobs", container_factory=FakeContainer, config=config
+        )
+
+    @pytest.mark.asyncio
+    async def test_mounted_with_mode(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        (tmp_path / "shared" / "data").mkdir()
+        mounts = [
+            JobMount(str(tmp_path / "shared" / "sdk"), "/opt/sdk", read_only=True),
+            JobMount(str(tmp_path / "shared" / "data"), "/data"),
+        ]
+        result = await ex.run(JobManifest(stack="go", command="make", mounts=mounts))
+        assert result.succeeded
+        volumes = _container().kwargs["extra_volumes"]
+        assert f"{tmp_path}/shared/sdk:/opt/sdk:ro" in volumes
+        assert f"{tmp_path}/shared/data:/data:rw" in volumes
+
+    @pytest.mark.asyncio
+    async def test_source_not_allowed(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        mounts = [JobMount("/etc", "/host-etc", read_only=True)]
+        result = await ex.run(JobManifest(stack="go", command="cat /host-etc/hosts", mounts=mounts))
+        assert result.error_code == JobErrorCode.MOUNT_REJECTED.value
+        assert "mounts.allowedHostPaths" in result.error
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_symlink_out_of_allowed_dir(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        (tmp_path / "shared" / "escape").symlink_to("/etc")
+        mounts = [JobMount(str(tmp_path / "shared" / "escape"), "/data")]
+        result = await ex.run(JobManifest(stack="go", command="ls", mounts=mounts))
+        assert result.error_code == JobErrorCode.MOUNT_REJECTED.value
+
+    @pytest.mark.asyncio
+    async def test_missing_source(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        mounts = [JobMount(str(tmp_path / "shared" / "gone"), "/data")]
+        result = await ex.run(JobManifest(stack="go", command="ls", mounts=mounts))
+        assert result.error_code == JobErrorCode.MOUNT_REJECTED.value
+        assert "does not exist" in result.error
+
+    @pytest.mark.asyncio
+    async def test_target_over_agent_mount(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        mounts = [JobMount(str(tmp_path / "shared" / "sdk"), TOOLCHAINS_DIR)]
+        result = await ex.run(JobManifest(stack="go", command="ls", mounts=mounts))
+        assert result.error_code == JobErrorCode.MOUNT_REJECTED.value
+        assert TOOLCHAINS_DIR in result.error
+
+    @pytest.mark.asyncio
+    async def test_validate_reports_each_mount(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        report = await ex.validate(
+            {
+                "stack": "go",
+                "command": "ls",
+                "mounts": [
+                    {"source": str(tmp_path / "shared" / "sdk"), "target": "/opt/sdk"},
+                    {"source": "/etc", "target": "/host-etc"},
+                ],
+            }
+        )
+        assert [p.path for p in report.problems] == ["mounts[1].source"]
+
+    def test_never_pooled(self, tmp_path: Path):
+        ex = self._executor(tmp_path, pool=PoolConfig(sizes={"go": 1}))
+        mounts = [JobMount(str(tmp_path / "shared" / "sdk"), "/opt/sdk", read_only=True)]
+        manifest = JobManifest(stack="go", command="make", mounts=mounts)
+        spec = {"secret_env": {}, "user": ""}
+        assert ex._pool_key(manifest, JobResult("j", "go"), spec) == ""
+        assert ex._pool_key(JobManifest(stack="go", command="make"), JobResult("j", "go"), spec)
+
+
+# ---------------------------------------------------------------------------
+# Workspaces
+# ---------------------------------------------------------------------------
+
+
+class _WritesWorkspace(FakeContainer):
+    """Writes its command to /workspace/out/result.txt, then reads it back."""
+
+    both_wrote: asyncio.Event
+    writers = 0
+
+    async def exec(self, command, timeout=120, phase="execute", **kwargs):
+        if phase == "execute":
+            out = Path(self.kwargs["workspace_path"]) / "out" / "result.txt"
+            seen = out.read_text() if out.exists() else ""
+            out.parent.mkdir(exist_ok=True)
+            out.write_text(command)
+            _WritesWorkspace.writers += 1
+            if _WritesWorkspace.writers == 2:
+                _WritesWorkspace.both_wrote.set()
+            await asyncio.wait_for(_WritesWorkspace.both_wrote.wait(), 5)
+            self.files["seen"] = seen.encode()
+            self.files["read back"] = out.read_bytes()
+        return await super().exec(command, timeout, phase, **kwargs)
+
+
+class TestWorkspace:
+    @pytest.mark.asyncio
+    async def test_fresh_per_job_and_removed(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="make"), job_id="job1")
+        assert result.succeeded
+        workspace = _container().kwargs["workspace_path"]
+        assert workspace.parent == executor.jobs_dir / "job1"
+        assert workspace.name.startswith("workspace-")
+        assert not workspace.exists()
+
+    @pytest.mark.asyncio
+    async def test_concurrent_jobs_never_share_files(self, tmp_path: Path):
+        _WritesWorkspace.both_wrote = asyncio.Event()
+        _WritesWorkspace.writers = 0
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_WritesWorkspace)
+        results = await asyncio.gather(
+            ex.run(JobManifest(stack="go", command="job a")),
+            ex.run(JobManifest(stack="go", command="job b")),
+        )
+        assert all(result.succeeded for result in results)
+        a, b = FakeContainer.instances
+        assert a.kwargs["workspace_path"] != b.kwargs["workspace_path"]
+        assert (a.files["seen"], a.files["read back"]) == (b"", b"job a")
+        assert (b.files["seen"], b.files["read back"]) == (b"", b"job b")
+
+    @pytest.mark.asyncio
+    async def test_reused_job_id_starts_empty(self, tmp_path: Path):
+        leftover = tmp_path / "ci-42" / "workspace"  # from before workspaces were per run
+        (leftover / "out").mkdir(parents=True)
+        (leftover / "out" / "result.txt").write_text("stale")
+        _WritesWorkspace.both_wrote = asyncio.Event()
+        _WritesWorkspace.both_wrote.set()
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_WritesWorkspace)
+        for _ in range(2):
+            assert (await ex.run(JobManifest(stack="go", command="x"), job_id="ci-42")).succeeded
+            assert _container().files["seen"] == b""
+
+    @pytest.mark.asyncio
+    async def test_root_cleanup_when_the_agent_cannot_remove_it(self, executor, monkeypatch):
+        attempts = []
+
+        def remove(path):
+            attempts.append(path)
+            if len(attempts) == 1:
+                return False  # e.g. root-owned files from a runAs root job
+            shutil.rmtree(path)
+            return True
+
+        monkeypatch.setattr("orion.security.jobs.executor.remove_workspace", remove)
+        result = await executor.run(JobManifest(stack="go", command="make"), job_id="job1")
+        assert result.succeeded
+        job, cleanup = FakeContainer.instances
+        assert attempts == [job.kwargs["workspace_path"]] * 2
+        assert cleanup.kwargs["session_id"].startswith("cleanup-job1-")
+        assert cleanup.kwargs["workspace_path"] == job.kwargs["workspace_path"]
+        assert cleanup.execs == [("cleanup", "find /workspace -mindepth 1 -delete")]
+        assert cleanup.users["cleanup"] == "0"
+        assert cleanup.stopped and len(executor.containers) == 0
+
+    @pytest.mark.asyncio
+    async def test_root_cleanup_failing_too_is_not_fatal(self, executor, monkeypatch):
+        monkeypatch.setattr("orion.security.jobs.executor.remove_workspace", lambda path: False)
+        result = await executor.run(JobManifest(stack="go", command="make"))
+        assert result.succeeded
+        assert FakeContainer.instances[-1].stopped
+
+
+# ---------------------------------------------------------------------------
+# runAs
+# ---------------------------------------------------------------------------
+
+
+class TestRunAs:
+    @pytest.mark.asyncio
+    async def test_user_passed_and_mounts_handed_off(self, tmp_path: Path):
+        config = JobsConfig(cache=CacheConfig(enabled=True, path=str(tmp_path / "cache")))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        result = await ex.run(JobManifest(stack="go", command="go build", run_as="1500:1500"))
+
+        assert result.succeeded
+        assert result.run_as == "1500:1500"
+        assert "handed to 1500:1500" in result.run_as_note
+        assert _container().kwargs["user"] == "1500:1500"
+        phase, command = _container().execs[0]
+        assert phase == "prepare"
+        assert command.startswith("chown 1500:1500 /workspace /home/orion/go/pkg/mod")
+        assert _container().users["prepare"] == "0:0"
+
+    @pytest.mark.asyncio
+    async def test_caches_kept_per_uid(self, tmp_path: Path):
+        config = JobsConfig(cache=CacheConfig(enabled=True, path=str(tmp_path / "cache")))
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            toolchain_cache_dir=tmp_path / "toolchains",
+            container_factory=_scripted(PROBE_BAKED),
+            config=config,
+        )
+        await ex.run(
+            JobManifest(stack="go", command="go build", toolchain="1.22.5", run_as="1500")
+        )
+        volumes = _container().kwargs["extra_volumes"]
+        assert f"{tmp_path / 'toolchains' / 'go-uid1500'}:{TOOLCHAINS_DIR}:rw" in volumes
+        assert any(v.startswith(str(tmp_path / "cache" / "go-uid1500")) for v in volumes)
+        assert ex.cache.is_leased("go-uid1500") is False
+
+    @pytest.mark.asyncio
+    async def test_default_user_unchanged(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="ok"))
+        assert _container().kwargs["user"] is None
+        assert result.run_as == ""
+        assert [phase for phase, _ in _container().execs] == ["execute"]
+
+    @pytest.mark.asyncio
+    async def test_root_refused_by_default(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="ok", run_as="0"))
+        assert result.error_code == JobErrorCode.RUN_AS_REFUSED.value
+        assert "runAs.allowRoot" in result.error
+        assert FakeContainer.instances == []
+
+        report = await executor.validate({"stack": "go", "command": "ok", "runAs": "0:0"})
+        assert [p.path for p in report.problems] == ["runAs"]
+
+    @pytest.mark.asyncio
+    async def test_root_when_allowed(self, tmp_path: Path):
+        config = JobsConfig(run_as=RunAsConfig(allow_root=True))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        result = await ex.run(JobManifest(stack="go", command="ok", run_as="0:0"))
+        assert result.succeeded
+        assert _container().kwargs["user"] == "0:0"
+
+    @pytest.mark.asyncio
+    async def test_handoff_failure(self, tmp_path: Path):
+        class ChownFails(FakeContainer):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.exit_codes["prepare"] = 1
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=ChownFails)
+        result = await ex.run(JobManifest(stack="go", command="ok", run_as="1500"))
+        assert result.error_code == JobErrorCode.CONTAINER_START_FAILED.value
+        assert "runAs 1500:1500" in result.error
+        assert _container().stopped
+
+    @pytest.mark.asyncio
+    async def test_clone_runs_as_user(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        source = JobSource(git="https://github.com/acme/api.git")
+        await ex.run(JobManifest(stack="go", command="ok", source=source, run_as="1500:27"))
+        assert _container().installs == [{"env": CLONE_ENV, "user": "1500:27"}]
+
+    @pytest.mark.asyncio
+    async def test_bind_workspace_keeps_host_ownership(self, tmp_path: Path):
+        checkout = tmp_path / "checkout"
+        checkout.mkdir()
+        config = JobsConfig(source=SourceConfig(allowed_host_paths=[str(tmp_path)]))
+        ex = JobExecutor(jobs_dir=tmp_path / "jobs", container_factory=FakeContainer, config=config)
+        manifest = JobManifest(
+            stack="go", command="ok", source=JobSource(host_path=str(checkout)), run_as="1500"
+        )
+        result = await ex.run(manifest)
+        assert result.succeeded
+        assert "ownership unchanged" in result.run_as_note
+        assert "owned by 1500:1500 on the host" in result.run_as_note
+        # Nothing of the host checkout is chowned
+        assert [phase for phase, _ in _container().execs] == ["execute"]
+
+
+# ---------------------------------------------------------------------------
+# Shutdown
+# ---------------------------------------------------------------------------
+
+
+class TestShutdown:
+    @pytest.mark.asyncio
+    async def test_running_jobs_finish_within_grace(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        running = ex.submit(JobManifest(stack="go", command="running"))
+        queued = ex.submit(JobManifest(stack="go", command="queued"))
+        await asyncio.sleep(0.01)
+
+        drain = ex.begin_shutdown(grace=5)
+        assert ex.begin_shutdown() is drain and ex.draining
+        with pytest.raises(ShuttingDownError):
+            ex.submit(JobManifest(stack="go", command="late"))
+        await asyncio.sleep(0.01)
+        assert queued.done
+        assert queued.result.status == JobStatus.CANCELLED.value
+        assert queued.result.error_code == JobErrorCode.AGENT_SHUTDOWN.value
+        assert "resubmit" in queued.result.error
+        assert not drain.done()
+
+        release.set()
+        await ex.shutdown()
+        assert running.result.succeeded
+        assert len(FakeContainer.instances) == 1
+
+    @pytest.mark.asyncio
+    async def test_jobs_past_grace_are_cancelled(self, tmp_path: Path):
+        factory, _ = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        handle = ex.submit(JobManifest(stack="go", command="go test"))
+        await asyncio.sleep(0.01)
+
+        await ex.shutdown(grace=0.05)
+        assert handle.result.status == JobStatus.CANCELLED.value
+        assert handle.result.error_code == JobErrorCode.AGENT_SHUTDOWN.value
+        assert "shutdownGracePeriod" in handle.result.error
+        assert _container().signals == ["TERM"] and _container().stopped
+
+    @pytest.mark.asyncio
+    async def test_cancel_in_progress_keeps_its_outcome(self, tmp_path: Path):
+        factory, _ = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        handle = ex.submit(JobManifest(stack="go", command="go test"))
+        await asyncio.sleep(0.01)
+        assert ex.cancel(handle.job_id)
+
+        await ex.shutdown(grace=0)
+        assert handle.result.error_code == JobErrorCode.CANCELLED.value
+
+
+# ---------------------------------------------------------------------------
+# Warm pool
+# ---------------------------------------------------------------------------
+
+
+async def _settle(ex: JobExecutor) -> None:
+    """Wait for the pool's background starts and resets."""
+    while ex.pool._tasks:
+        await asyncio.gather(*list(ex.pool._tasks))
+
+
+class TestWarmPool:
+    @pytest.mark.asyncio
+    async def test_next_job_reuses_warm_container(self, tmp_path: Path):
+        ex = _executor(tmp_path, pool=PoolConfig(sizes={"go": 1}))
+        first = await ex.run(JobManifest(stack="go", command="go test"))
+        await _settle(ex)
+        cold, warm = FakeContainer.instances
+        assert first.pool_reused is False and cold.stopped
+        assert warm.kwargs["session_id"].startswith("pool-")
+        assert warm.kwargs["workspace_path"].parent.parent == tmp_path / "jobs" / "pool"
+        assert not warm.stopped and ex.pool.idle("go") == 1
+
+        second = await ex.run(JobManifest(stack="go", command="go vet"))
+        assert second.succeeded and second.pool_reused
+        assert second.to_dict()["pool_reused"] is True
+        assert warm.execs == [("execute", "go vet")]
+        await _settle(ex)
+        # Reset and kept; the pool already owned its one container, so none was added
+        assert warm.calls[-1] == "reset" and not warm.stopped
+        assert len(FakeContainer.instances) == 2
+        assert ex.pool.idle("go") == 1
+
+    @pytest.mark.asyncio
+    async def test_container_that_fails_reset_is_discarded(self, tmp_path: Path):
+        ex = _executor(tmp_path, pool=PoolConfig(sizes={"go": 1}))
+        await ex.run(JobManifest(stack="go", command="go test"))
+        await _settle(ex)
+        warm = _container()
+        warm.reset_error = "job changed the container outside /workspace (A /home/orion/.x)"
+        assert (await ex.run(JobManifest(stack="go", command="go vet"))).pool_reused
+        await _settle(ex)
+        assert warm.stopped and ex.pool.idle() == 0
+
+        third = await ex.run(JobManifest(stack="go", command="go vet"))
+        assert third.pool_reused is False
+
+    @pytest.mark.asyncio
+    async def test_only_identical_containers_are_shared(self, tmp_path: Path):
+        ex = _executor(tmp_path, pool=PoolConfig(sizes={"go": 1}))
+        ex.secret_source = DictSecretSource({"token": "hunter2"})
+        await ex.run(JobManifest(stack="go", command="go test"))
+        await _settle(ex)
+
+        for manifest in (
+            JobManifest(stack="go", command="ok", secrets={"TOKEN": "token"}),
+            JobManifest(stack="go", command="ok", run_as="1500"),
+            JobManifest(stack="go", command="ok", resources=JobResources(cpu=1)),
+            JobManifest(stack="node", command="ok"),
+        ):
+            assert (await ex.run(manifest)).pool_reused is False, manifest
+        # Secrets and runAs never pool; other limits replace the stale warm container
+        await _settle(ex)
+        assert ex.pool.idle("go") == 1
+        assert FakeContainer.instances[1].stopped
+        assert ex.pool.idle("node") == 0
+
+    @pytest.mark.asyncio
+    async def test_idle_containers_expire(self, tmp_path: Path):
+        ex = _executor(tmp_path, pool=PoolConfig(sizes={"go": 1}, max_idle=0.05))
+        await ex.run(JobManifest(stack="go", command="go test"))
+        await _settle(ex)
+        warm = _container()
+        await asyncio.sleep(0.1)
+        await _settle(ex)
+        assert warm.stopped and ex.pool.idle() == 0
+
+    @pytest.mark.asyncio
+    async def test_shutdown_drains_pool(self, tmp_path: Path):
+        ex = _executor(tmp_path, pool=PoolConfig(sizes={"go": 1}))
+        await ex.run(JobManifest(stack="go", command="go test"))
+        await ex.shutdown()
+        warm = _container()
+        assert warm.kwargs["session_id"].startswith("pool-") and warm.stopped
+        assert not warm.kwargs["workspace_path"].parent.exists()
+
+        # Jobs still run afterwards, just without the pool
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.succeeded and not result.pool_reused
+        assert len(FakeContainer.instances) == 3
+
+
+def _warming(tmp_path: Path, *warm: WarmStack, pulls: list, timeout: float = 60.0, **kwargs):
+    """An executor warming ``warm``; pulls of python's image fail, rust's never end."""
+    local: set[str] = set()
+
+    async def inspect(image):
+        return "amd64" if image in local else None
+
+    async def puller(image, login):
+        pulls.append(image)
+        if image.startswith("orion-stack-rust"):
+            await asyncio.Event().wait()
+        if image.startswith("orion-stack-python"):
+            return subprocess.CompletedProcess([], 1, "", "manifest unknown")
+        local.add(image)
+        return subprocess.CompletedProcess([], 0, "", "")
+
+    images = ImagesConfig(warm_stacks=list(warm), warm_timeout=timeout)
+    ex = JobExecutor(
+        jobs_dir=tmp_path / "jobs",
+        container_factory=FakeContainer,
+        config=JobsConfig(images=images, **kwargs),
+        image_inspector=inspect,
+        image_puller=puller,
+    )
+    ex.arch = "amd64"
+    return ex
+
+
+class TestWarmUp:
+    @pytest.mark.asyncio
+    async def test_pulls_stack_images(self, tmp_path: Path):
+        pulls = []
+        ex = _warming(tmp_path, WarmStack("go"), WarmStack("node"), pulls=pulls)
+        task = ex.begin_warm_up()
+        assert ex.warming and ex.begin_warm_up() is task
+        await task
+        assert not ex.warming and ex.warm_failures == {}
+        assert sorted(pulls) == ["orion-stack-go:latest", "orion-stack-node:latest"]
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_nothing_to_warm(self, tmp_path: Path):
+        ex = _warming(tmp_path, pulls=[])
+        assert ex.begin_warm_up() is None and not ex.warming
+
+    @pytest.mark.asyncio
+    async def test_fills_warm_pool(self, tmp_path: Path):
+        pulls = []
+        pool = PoolConfig(sizes={"go": 1})
+        ex = _warming(tmp_path, WarmStack("go", pool=True), pulls=pulls, pool=pool)
+        await ex.warm_up()
+        assert ex.warm_failures == {} and ex.pool.idle("go") == 1
+        cold, warm = FakeContainer.instances
+        assert cold.stopped and not warm.stopped
+        # The next job takes the warm container
+        result = await ex.run(JobManifest(stack="go", command="go test"))
+        assert result.pool_reused
+        await ex.pool.drain()
+
+    @pytest.mark.asyncio
+    async def test_only_required_failures_recorded(self, tmp_path: Path):
+        ex = _warming(
+            tmp_path,
+            WarmStack("python"),
+            WarmStack("go", required=True),
+            pulls=[],
+        )
+        await ex.warm_up()
+        assert ex.warm_failures == {}
+
+        ex = _warming(tmp_path, WarmStack("python", required=True), pulls=[])
+        await ex.warm_up()
+        assert ex.warm_failures == {"python": "Image orion-stack-python:latest could not be pulled"}
+
+    @pytest.mark.asyncio
+    async def test_unknown_stack(self, tmp_path: Path):
+        ex = _warming(tmp_path, WarmStack("cobol", required=True), pulls=[])
+        await ex.warm_up()
+        assert "No stack image labelled orion.stack='cobol'" in ex.warm_failures["cobol"]
+
+    @pytest.mark.asyncio
+    async def test_timeout_abandons_unfinished_stacks(self, tmp_path: Path):
+        pulls = []
+        ex = _warming(
+            tmp_path,
+            WarmStack("rust", required=True),
+            WarmStack("go", required=True),
+            pulls=pulls,
+            timeout=0.05,
+        )
+        await asyncio.wait_for(ex.warm_up(), 5)
+        assert list(ex.warm_failures) == ["rust"]
+        assert "images.warmTimeout" in ex.warm_failures["rust"]
+        assert "orion-stack-go:latest" in pulls
+
+
+# ---------------------------------------------------------------------------
+# Resource usage
+# ---------------------------------------------------------------------------
+
+
+class TestResourceUsage:
+    @pytest.mark.asyncio
+    async def test_read_before_teardown(self, tmp_path: Path):
+        class Measured(FakeContainer):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.usages = [ResourceUsage(cpu_seconds=12.5, peak_memory_bytes=300 * 1024**2)]
+
+            async def stop(self) -> bool:
+                assert self.usages == [], "read after teardown"
+                return await super().stop()
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=Measured)
+        result = await ex.run(JobManifest(stack="go", command="make"))
+        assert result.cpu_seconds == 12.5
+        assert result.peak_memory_bytes == 300 * 1024**2
+        data = result.to_dict()
+        assert (data["cpu_seconds"], data["peak_memory_bytes"]) == (12.5, 300 * 1024**2)
+        assert JobResult.from_dict(data).cpu_seconds == 12.5
+
+    @pytest.mark.asyncio
+    async def test_unknown_is_null_not_zero(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="make"))
+        assert result.succeeded
+        assert result.to_dict()["cpu_seconds"] is None
+        assert result.to_dict()["peak_memory_bytes"] is None
+
+    @pytest.mark.asyncio
+    async def test_failed_command_still_measured(self, tmp_path: Path):
+        class Failing(FakeContainer):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.exit_codes = {"execute": 2}
+                self.usages = [ResourceUsage(cpu_seconds=1.0, peak_memory_bytes=None)]
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=Failing)
+        result = await ex.run(JobManifest(stack="go", command="make"))
+        assert result.error_code == "command_failed"
+        assert (result.cpu_seconds, result.peak_memory_bytes) == (1.0, None)
+
+    @pytest.mark.asyncio
+    async def test_not_measured_when_container_never_started(self, tmp_path: Path):
+        class Unstartable(FakeContainer):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.start_ok = False
+                self.usages = [ResourceUsage(cpu_seconds=1.0, peak_memory_bytes=1)]
+
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=Unstartable,
+            config=JobsConfig(retry=RetryConfig(max_attempts=1)),
+        )
+        result = await ex.run(JobManifest(stack="go", command="make"))
+        assert result.error_code == "container_start_failed"
+        assert (result.cpu_seconds, result.peak_memory_bytes) == (None, None)
+
+    @pytest.mark.asyncio
+    async def test_warm_container_counts_only_this_job(self, tmp_path: Path):
+        ex = _executor(tmp_path, pool=PoolConfig(sizes={"go": 1}))
+        await ex.run(JobManifest(stack="go", command="go test"))
+        await _settle(ex)
+        warm = _container()
+        warm.usages = [
+            ResourceUsage(cpu_seconds=40.0, peak_memory_bytes=512),
+            ResourceUsage(cpu_seconds=42.5, peak_memory_bytes=512),
+        ]
+        result = await ex.run(JobManifest(stack="go", command="go vet"))
+        assert result.pool_reused
+        assert result.cpu_seconds == 2.5
+        # Its peak did not exceed an earlier job's, so this job's is unknown
+        assert result.peak_memory_bytes is None
+
+    @pytest.mark.asyncio
+    async def test_warm_container_new_peak_is_this_jobs(self, tmp_path: Path):
+        ex = _executor(tmp_path, pool=PoolConfig(sizes={"go": 1}))
+        await ex.run(JobManifest(stack="go", command="go test"))
+        await _settle(ex)
+        _container().usages = [
+            ResourceUsage(cpu_seconds=None, peak_memory_bytes=512),
+            ResourceUsage(cpu_seconds=3.0, peak_memory_bytes=2048),
+        ]
+        result = await ex.run(JobManifest(stack="go", command="go vet"))
+        assert (result.cpu_seconds, result.peak_memory_bytes) == (None, 2048)
+
+
+# ---------------------------------------------------------------------------
+# Completion callbacks
+# ---------------------------------------------------------------------------
+
+
+class _Hook:
+    def __init__(self, status: int = 200):
+        self.status = status
+        self.posts: list[tuple[str, dict, dict]] = []
+
+    async def __call__(self, url, body, headers, timeout):
+        self.posts.append((url, json.loads(body), headers))
+        if self.status is None:
+            raise ConnectionError("connection refused")
+        return self.status
+
+
+def _hooked(tmp_path: Path, hook: _Hook, secret: str = "hook-key", **kwargs) -> JobExecutor:
+    config = JobsConfig(callbacks=CallbacksConfig(secret=secret), **kwargs)
+    config.callbacks.retry.initial_backoff = 0.001
+    return JobExecutor(
+        jobs_dir=tmp_path,
+        container_factory=_scripted(execute=3),
+        config=config,
+        secret_source=DictSecretSource({"hook-key": "s3cret"}),
+        callback_poster=hook,
+    )
+
+
+async def _delivered(ex: JobExecutor) -> None:
+    while ex._callbacks:
+        await asyncio.gather(*list(ex._callbacks))
+
+
+class TestCallbacks:
+    URL = "https://ci.acme.dev/hooks/orion"
+
+    @pytest.mark.asyncio
+    async def test_posted_when_job_ends(self, tmp_path: Path):
+        hook = _Hook()
+        ex = _hooked(tmp_path, hook)
+        result = await ex.run(JobManifest(stack="go", command="go test", callback_url=self.URL))
+        await _delivered(ex)
+        (url, payload, headers), = hook.posts
+        assert url == self.URL
+        assert payload["job_id"] == result.job_id
+        assert (payload["status"], payload["error_code"], payload["exit_code"]) == (
+            "failed",
+            "command_failed",
+            3,
+        )
+        body = json.dumps(payload, sort_keys=True).encode()
+        assert headers[SIGNATURE_HEADER] == sign(body, "s3cret")
+
+    @pytest.mark.asyncio
+    async def test_failed_delivery_keeps_result(self, tmp_path: Path):
+        hook = _Hook(status=None)
+        ex = _hooked(tmp_path, hook)
+        result = await ex.run(JobManifest(stack="go", command="go test", callback_url=self.URL))
+        before = result.to_dict()
+        await _delivered(ex)
+        assert len(hook.posts) == 5
+        assert result.to_dict() == before
+
+    @pytest.mark.asyncio
+    async def test_missing_secret_sends_nothing(self, tmp_path: Path):
+        hook = _Hook()
+        ex = _hooked(tmp_path, hook, secret="not-set")
+        result = await ex.run(JobManifest(stack="go", command="go test", callback_url=self.URL))
+        await _delivered(ex)
+        assert hook.posts == [] and result.exit_code == 3
+
+    @pytest.mark.asyncio
+    async def test_no_url_no_callback(self, tmp_path: Path):
+        hook = _Hook()
+        ex = _hooked(tmp_path, hook)
+        await ex.run(JobManifest(stack="go", command="go test"))
+        assert ex._callbacks == set() and hook.posts == []
+
+    @pytest.mark.asyncio
+    async def test_jobs_dropped_at_shutdown_are_reported(self, tmp_path: Path):
+        hook = _Hook()
+        ex = _hooked(tmp_path, hook, scheduler=SchedulerConfig(max_concurrent=1))
+        ex._container_factory, _ = _held()
+        ex.submit(JobManifest(stack="go", command="running", callback_url=self.URL))
+        queued = ex.submit(JobManifest(stack="go", command="queued", callback_url=self.URL))
+        await asyncio.sleep(0.01)
+
+        await ex.shutdown(grace=0)
+        statuses = {payload["job_id"]: payload["error_code"] for _, payload, _ in hook.posts}
+        assert len(statuses) == 2
+        assert statuses[queued.job_id] == "agent_shutdown"
+
+
+# ---------------------------------------------------------------------------
+# Job list and history
+# ---------------------------------------------------------------------------
+
+
+class TestJobList:
+    @pytest.mark.asyncio
+    async def test_states_newest_first(self, tmp_path: Path):
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        done = ex.submit(JobManifest(stack="python", command="true"))
+        ex._container_factory = factory
+        await asyncio.sleep(0)
+        release.set()
+        await done.task
+        release.clear()
+        running = ex.submit(JobManifest(stack="go", command="a"))
+        queued = ex.submit(JobManifest(stack="go", command="b"))
+        await asyncio.sleep(0.01)
+
+        jobs, cursor = ex.list_jobs()
+        assert [h.job_id for h in jobs] == [queued.job_id, running.job_id, done.job_id]
+        assert cursor is None
+        assert [h.job_id for h in ex.list_jobs(state="running")[0]] == [running.job_id]
+        assert [h.job_id for h in ex.list_jobs(state="finished")[0]] == [done.job_id]
+        assert [h.job_id for h in ex.list_jobs(stack="python")[0]] == [done.job_id]
+        release.set()
+        await asyncio.gather(running.task, queued.task)
+
+    @pytest.mark.asyncio
+    async def test_timestamps(self, executor: JobExecutor):
+        before = time.time()
+        handle = executor.submit(JobManifest(stack="go", command="go test"))
+        await handle.task
+        row = handle.summary()
+        assert before <= row["submitted_at"] <= row["started_at"] <= row["finished_at"]
+        assert row["state"] == "finished" and row["status"] == "succeeded"
+
+        assert executor.list_jobs(since=before)[0] == [handle]
+        assert executor.list_jobs(until=before - 1)[0] == []
+
+    @pytest.mark.asyncio
+    async def test_paginated(self, executor: JobExecutor):
+        handles = [executor.submit(JobManifest(stack="go", command=f"j{i}")) for i in range(5)]
+        await asyncio.gather(*(h.task for h in handles))
+        newest_first = [h.job_id for h in reversed(handles)]
+
+        seen, cursor = [], None
+        while True:
+            page, cursor = executor.list_jobs(limit=2, before=cursor)
+            seen.append([h.job_id for h in page])
+            if cursor is None:
+                break
+        assert seen == [newest_first[:2], newest_first[2:4], newest_first[4:]]
+
+    def test_unknown_state(self, executor: JobExecutor):
+        with pytest.raises(ValueError, match="queued, running, finished"):
+            executor.list_jobs(state="done")
+
+    @pytest.mark.asyncio
+    async def test_history_capped_by_count(self, tmp_path: Path):
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            config=JobsConfig(history=HistoryConfig(max_jobs=2)),
+        )
+        handles = [ex.submit(JobManifest(stack="go", command=f"j{i}")) for i in range(3)]
+        await asyncio.gather(*(h.task for h in handles))
+        assert ex.get(handles[0].job_id) is None
+        assert [h.job_id for h in ex.list_jobs()[0]] == [handles[2].job_id, handles[1].job_id]
+        assert handles[0].job_id not in ex._cancellations
+
+    @pytest.mark.asyncio
+    async def test_evicted_job_dir_removed(self, tmp_path: Path):
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            config=JobsConfig(history=HistoryConfig(max_jobs=1)),
+        )
+        first = ex.submit(JobManifest(stack="go", command="go build"))
+        await first.task
+        artifacts = tmp_path / first.job_id / "artifacts"
+        artifacts.mkdir(parents=True)
+        (artifacts / "api").write_bytes(b"\x7fELF")
+        await ex.submit(JobManifest(stack="go", command="go vet")).task
+        assert ex.get(first.job_id) is None
+        for _ in range(100):
+            if not (tmp_path / first.job_id).exists():
+                break
+            await asyncio.sleep(0.01)
+        assert not (tmp_path / first.job_id).exists()
+
+    @pytest.mark.asyncio
+    async def test_history_capped_by_age(self, tmp_path: Path):
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            config=JobsConfig(history=HistoryConfig(max_age=60)),
+        )
+        old = ex.submit(JobManifest(stack="go", command="old"))
+        await old.task
+        old.finished_at -= 61
+        assert ex.get(old.job_id) is None
+        assert ex.list_jobs()[0] == []
+
+    @pytest.mark.asyncio
+    async def test_running_jobs_never_evicted(self, tmp_path: Path):
+        factory, release = _held()
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=factory,
+            config=JobsConfig(history=HistoryConfig(max_jobs=1, max_age=0)),
+        )
+        running = ex.submit(JobManifest(stack="go", command="a"))
+        await asyncio.sleep(0.01)
+        assert ex.get(running.job_id) is running
+        release.set()
+        await running.task
+
+    @pytest.mark.asyncio
+    async def test_describe(self, executor: JobExecutor):
+        manifest = JobManifest(
+            stack="go",
+            command="go test",
+            env={"TOKEN": "plain-value"},
+            source=JobSource(git="https://bot:pw@github.com/acme/api.git"),
+            callback_url="https://ci.acme.dev/hook?token=abc",
+        )
+        handle = executor.submit(manifest)
+        await handle.task
+        details = handle.describe()
+
+        assert details["job_id"] == handle.job_id
+        assert details["exit"] == handle.result.exit.to_dict()
+        assert details["artifacts"] == [] and details["finished_at"] is not None
+        assert details["manifest"]["command"] == "go test"
+        assert details["manifest"]["env"] == ["TOKEN"]
+        assert details["manifest"]["source"]["git"] == "https://github.com/acme/api.git"
+        assert details["manifest"]["callbackUrl"] == "https://ci.acme.dev/hook"
+        assert "plain-value" not in json.dumps(details) and "pw@" not in json.dumps(details)
+
+
+# ---------------------------------------------------------------------------
+# Container cleanup
+# ---------------------------------------------------------------------------
+
+
+class _Unremovable(FakeContainer):
+    """A container whose stop() fails; remove() succeeds once allowed."""
+
+    def __init__(self, **kwargs):
+        super().__init__(**kwargs)
+        self.removable = True
+
+    async def stop(self) -> bool:
+        self.calls.append("stop")
+        return False
+
+    async def remove(self) -> bool:
+        self.calls.append("remove")
+        return self.removable
+
+
+class _ListingRuntime:
+    name = "docker"
+
+    def __init__(self, names: str):
+        self.names = names
+        self.removed: list[str] = []
+
+    async def list_containers(self, label):
+        return subprocess.CompletedProcess([], 0, self.names, "")
+
+    async def remove(self, name):
+        self.removed.append(name)
+        return subprocess.CompletedProcess([], 0, "", "")
+
+
+class TestContainerCleanup:
+    @pytest.mark.asyncio
+    async def test_job_container_labelled_and_released(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="go test"))
+        assert _container().kwargs["labels"] == {"orion.job": result.job_id}
+        assert _container().stopped and len(executor.containers) == 0
+
+    @pytest.mark.asyncio
+    async def test_failed_stop_is_forced(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_Unremovable)
+        assert (await ex.run(JobManifest(stack="go", command="ok"))).succeeded
+        assert _container().calls[-2:] == ["stop", "remove"]
+        assert len(ex.containers) == 0
+
+    @pytest.mark.asyncio
+    async def test_unexpected_error_still_tears_down(self, tmp_path: Path):
+        class Broken(FakeContainer):
+            async def oom_kill_count(self):
+                raise RuntimeError("engine hiccup")
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=Broken)
+        with pytest.raises(RuntimeError, match="engine hiccup"):
+            await ex.run(JobManifest(stack="go", command="ok"))
+        assert _container().stopped and len(ex.containers) == 0
+
+    @pytest.mark.asyncio
+    async def test_failed_start_is_released(self, tmp_path: Path):
+        class NoStart(FakeContainer):
+            async def start(self):
+                return False
+
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=NoStart,
+            config=JobsConfig(retry=RetryConfig(max_attempts=1)),
+        )
+        result = await ex.run(JobManifest(stack="go", command="ok"))
+        assert result.error_code == JobErrorCode.CONTAINER_START_FAILED.value
+        assert _container().calls == ["stop"] and len(ex.containers) == 0
+
+    @pytest.mark.asyncio
+    async def test_shutdown_removes_leftovers(self, tmp_path: Path):
+        class Stuck(_Unremovable):
+            def __init__(self, **kwargs):
+                super().__init__(**kwargs)
+                self.removable = False
+
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=Stuck)
+        await ex.run(JobManifest(stack="go", command="ok"))
+        container = _container()
+        assert container.container_name in ex.containers
+
+        container.removable = True
+        await ex.shutdown()
+        assert container.calls[-1] == "remove" and len(ex.containers) == 0
+
+    @pytest.mark.asyncio
+    async def test_reap_on_startup(self, tmp_path: Path):
+        runtime = _ListingRuntime("orion-session-job-old\n")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, runtime=runtime)
+        assert await ex.reap_orphans() == 1
+        assert runtime.removed == ["orion-session-job-old"]
+
+    @pytest.mark.asyncio
+    async def test_reap_disabled(self, tmp_path: Path):
+        runtime = _ListingRuntime("orion-session-job-old\n")
+        ex = JobExecutor(
+            jobs_dir=tmp_path,
+            container_factory=FakeContainer,
+            runtime=runtime,
+            config=JobsConfig(cleanup=CleanupConfig(reap_on_startup=False)),
+        )
+        assert await ex.reap_orphans() == 0
+        assert runtime.removed == []
+
+
+# ---------------------------------------------------------------------------
+# Steps
+# ---------------------------------------------------------------------------
+
+
+class _Stepped(FakeContainer):
+    """Exit codes and output per command; a 'hang' command blocks until signalled."""
+
+    exits: dict[str, int] = {}
+
+    async def exec(self, command, timeout=120, phase="execute", env=None, **kwargs):
+        if phase == "execute":
+            self.exit_codes["execute"] = self.exits.get(str(command), 0)
+            self.output = [("stdout", f"{command} says hi")]
+            self.hold = asyncio.Event() if command == "hang" else None
+        return await super().exec(command, timeout=timeout, phase=phase, env=env, **kwargs)
+
+
+def _stepped(exits: dict[str, int]):
+    return type("Stepped", (_Stepped,), {"exits": exits})
+
+
+def _steps(*steps: JobStep, **kwargs) -> JobManifest:
+    return JobManifest(stack="go", steps=list(steps), **kwargs)
+
+
+class TestSteps:
+    @pytest.mark.asyncio
+    async def test_run_in_order_in_one_container(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_stepped({}))
+        manifest = _steps(
+            JobStep("deps", "fetch"),
+            JobStep("build", ["make", "all"], {"MODE": "release"}),
+            env={"CI": "1", "MODE": "debug"},
+        )
+        result = await ex.run(manifest)
+        assert result.succeeded and result.exit_code == 0
+        assert len(FakeContainer.instances) == 1
+        execs = [cmd for phase, cmd in _container().execs if phase == "execute"]
+        assert execs == ["fetch", ["make", "all"]]
+        assert _container().envs["execute"] == {"CI": "1", "MODE": "release"}
+        assert [(s.name, s.status, s.exit_code) for s in result.steps] == [
+            ("deps", "succeeded", 0),
+            ("build", "succeeded", 0),
+        ]
+        assert all(s.duration_seconds >= 0 for s in result.steps)
+        assert result.stdout == "fetch says hi\n['make', 'all'] says hi\n"
+
+    @pytest.mark.asyncio
+    async def test_failure_skips_the_rest(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_stepped({"test": 2}))
+        result = await ex.run(
+            _steps(JobStep("build", "build"), JobStep("test", "test"), JobStep("pack", "pack"))
+        )
+        assert result.error_code == JobErrorCode.COMMAND_FAILED.value
+        assert result.exit_code == 2
+        assert result.error == "Step 'test' exited with code 2"
+        assert [(s.name, s.status) for s in result.steps] == [
+            ("build", "succeeded"),
+            ("test", "failed"),
+            ("pack", "skipped"),
+        ]
+        assert result.to_dict()["steps"][2]["exit_code"] == -1
+
+    @pytest.mark.asyncio
+    async def test_continue_on_error(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_stepped({"lint": 1}))
+        result = await ex.run(
+            _steps(JobStep("lint", "lint", continue_on_error=True), JobStep("test", "test"))
+        )
+        assert result.succeeded and result.exit_code == 0
+        assert [(s.name, s.status, s.exit_code) for s in result.steps] == [
+            ("lint", "failed", 1),
+            ("test", "succeeded", 0),
+        ]
+
+    @pytest.mark.asyncio
+    async def test_last_step_continues_on_error(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_stepped({"report": 3}))
+        result = await ex.run(
+            _steps(JobStep("test", "test"), JobStep("report", "report", continue_on_error=True))
+        )
+        assert result.succeeded and result.exit_code == 0
+        assert result.steps[1].exit_code == 3
+
+    @pytest.mark.asyncio
+    async def test_logs_tagged_with_step(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_stepped({}))
+        logs = LogChannel()
+        await ex.run(_steps(JobStep("a", "one"), JobStep("b", "two")), logs=logs)
+        assert [(line.step, line.line) for line in logs.lines] == [
+            ("a", "one says hi"),
+            ("b", "two says hi"),
+        ]
+
+    @pytest.mark.asyncio
+    async def test_plain_command_has_no_steps(self, executor: JobExecutor):
+        logs = LogChannel()
+        result = await executor.run(JobManifest(stack="go", command="go test"), logs=logs)
+        assert result.steps == [] and result.to_dict()["steps"] == []
+
+    @pytest.mark.asyncio
+    async def test_timeout_covers_all_steps(self, tmp_path: Path):
+        ex = _executor(tmp_path, _stepped({}), timeout=TestTimeout.TIMEOUT)
+        result = await ex.run(_steps(JobStep("a", "ok"), JobStep("b", "hang"), JobStep("c", "c")))
+        assert result.error_code == JobErrorCode.TIMED_OUT.value
+        assert result.error.endswith("during step 'b'")
+        assert [s.status for s in result.steps] == ["succeeded", "failed", "skipped"]
+        assert result.steps[1].exit.timed_out
+
+    @pytest.mark.asyncio
+    async def test_cancel_between_steps(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_stepped({}))
+        handle = ex.submit(_steps(JobStep("a", "hang"), JobStep("b", "b")))
+        await asyncio.sleep(0.01)
+        assert ex.cancel(handle.job_id)
+        await handle.task
+        assert handle.result.error_code == JobErrorCode.CANCELLED.value
+        assert [s.status for s in handle.result.steps] == ["failed", "skipped"]
+
+
+# ---------------------------------------------------------------------------
+# Capability labels
+# ---------------------------------------------------------------------------
+
+
+class TestCapabilities:
+    GPU = JobsConfig(agent=AgentConfig(labels={"gpu": "true", "mem": "high"}))
+
+    @pytest.mark.asyncio
+    async def test_satisfied(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=self.GPU)
+        handle = ex.submit(JobManifest(stack="go", command="nvcc", requires=["gpu=true", "mem"]))
+        await handle.task
+        assert handle.result.succeeded
+
+    @pytest.mark.asyncio
+    async def test_submit_refused(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=self.GPU)
+        manifest = JobManifest(stack="go", command="nvcc", requires=["gpu=false", "arch", "mem"])
+        with pytest.raises(NoMatchingCapabilityError, match="No matching capability") as info:
+            ex.submit(manifest)
+        assert "does not satisfy gpu=false, arch (" in str(info.value)
+        assert "gpu=true, mem=high" in str(info.value)
+        assert ex.list_jobs() == ([], None)
+        assert FakeContainer.instances == []
+
+    @pytest.mark.asyncio
+    async def test_unlabelled_agent(self, executor: JobExecutor):
+        manifest = JobManifest(stack="go", command="nvcc", requires=["gpu"])
+        result = await executor.run(manifest)
+        assert result.error_code == JobErrorCode.NO_MATCHING_CAPABILITY.value
+        assert "agent.labels: none" in result.error
+        assert FakeContainer.instances == []
+        report = await executor.validate(manifest)
+        assert [p.path for p in report.problems] == ["requires"]
+
+    @pytest.mark.asyncio
+    async def test_labels_follow_a_reload(self, executor: JobExecutor):
+        manifest = JobManifest(stack="go", command="nvcc", requires=["gpu=true"])
+        with pytest.raises(NoMatchingCapabilityError):
+            executor.submit(manifest)
+        executor.reload_config(self.GPU)
+        await executor.submit(manifest).task
+
+
+# ---------------------------------------------------------------------------
+# Network mode
+# ---------------------------------------------------------------------------
+
+class TestSignedBy:
+    @pytest.mark.asyncio
+    async def test_recorded_on_result(self, executor: JobExecutor):
+        handle = executor.submit(JobManifest(stack="go", command="go test"), signed_by="ci-prod")
+        await handle.task
+        assert handle.result.signed_by == "ci-prod"
+        assert handle.result.to_dict()["signed_by"] == "ci-prod"
+
+    @pytest.mark.asyncio
+    async def test_unsigned(self, executor: JobExecutor):
+        handle = executor.submit(JobManifest(stack="go", command="go test"))
+        await handle.task
+        assert handle.result.to_dict()["signed_by"] == ""
+
+
+class TestHardening:
+    @pytest.mark.asyncio
+    async def test_job_container_hardened_by_default(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="go build ./..."))
+        assert result.succeeded
+        security = _container().kwargs["security"]
+        assert security.no_new_privileges and security.cap_drop == ["ALL"]
+        assert "SYS_ADMIN" not in security.cap_add
+        assert security.ulimits == {"nofile": "65536", "nproc": "4096"}
+
+    @pytest.mark.asyncio
+    async def test_reload_applies_to_next_job(self, executor: JobExecutor):
+        executor.reload_config(
+            JobsConfig(hardening=HardeningConfig(drop_capabilities=False, ulimits={}))
+        )
+        await executor.run(JobManifest(stack="node", command="npm test"))
+        security = _container().kwargs["security"]
+        assert security.cap_drop == [] and security.ulimits == {}
+        assert security.no_new_privileges
+
+    @pytest.mark.asyncio
+    async def test_warm_container_matches_hardening(self, tmp_path: Path):
+        ex = _executor(tmp_path, pool=PoolConfig(sizes={"go": 1}))
+        await ex.run(JobManifest(stack="go", command="go test"))
+        await _settle(ex)
+        assert ex.pool.idle("go") == 1
+        ex.reload_config(
+            JobsConfig(
+                pool=ex.config.pool, hardening=HardeningConfig(ulimits={"nofile": "1024"})
+            )
+        )
+        result = await ex.run(JobManifest(stack="go", command="go vet"))
+        # Started under the old limits, the warm container is not reused
+        assert result.succeeded and not result.pool_reused
+        assert _container().kwargs["security"].ulimits == {"nofile": "1024"}
+
+
+class TestNetwork:
+    @pytest.mark.asyncio
+    async def test_default_is_phased(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="go test"))
+        assert result.succeeded
+        assert _container().kwargs["network"] == ""
+        assert "GOPROXY" not in (_container().envs["execute"] or {})
+
+    @pytest.mark.asyncio
+    async def test_offline_uses_cache_only(self, executor: JobExecutor):
+        manifest = JobManifest(
+            stack="go", command="go test", network="none", env={"GOSUMDB": "sum.golang.org"}
+        )
+        result = await executor.run(manifest)
+        assert result.succeeded
+        assert _container().kwargs["network"] == "none"
+        env = _container().envs["execute"]
+        assert env["GOPROXY"] == "off"
+        assert env["GOSUMDB"] == "sum.golang.org"  # the manifest's env wins
+
+    @pytest.mark.asyncio
+    async def test_offline_toolchain_must_be_cached(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_scripted(PROBE_MISSING, install=0))
+        result = await ex.run(
+            JobManifest(stack="go", command="go test", toolchain="1.23.1", network="none")
+        )
+        assert result.error_code == JobErrorCode.TOOLCHAIN_RESOLUTION_FAILED.value
+        assert "network 'none'" in result.error
+        assert [p for p, _ in _container().execs] == ["toolchain"]
+
+    @pytest.mark.asyncio
+    async def test_named_network_must_be_allowed(self, tmp_path: Path):
+        manifest = JobManifest(stack="go", command="go test", network="ci-services")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer)
+        result = await ex.run(manifest)
+        assert result.error_code == JobErrorCode.NETWORK_REFUSED.value
+        assert "networks.allowed" in result.error
+        assert FakeContainer.instances == []
+        report = await ex.validate(manifest)
+        assert [p.path for p in report.problems] == ["network"]
+
+        config = JobsConfig(networks=NetworksConfig(allowed=["ci-services"]))
+        ex = JobExecutor(jobs_dir=tmp_path, config=config, container_factory=FakeContainer)
+        assert (await ex.run(manifest)).succeeded
+        assert _container().kwargs["network"] == "ci-services"
+
+    @pytest.mark.asyncio
+    async def test_bridge_always_allowed(self, executor: JobExecutor):
+        result = await executor.run(JobManifest(stack="go", command="go test", network="bridge"))
+        assert result.succeeded
+        assert _container().kwargs["network"] == "bridge"
+
+
+# ---------------------------------------------------------------------------
+# Persisted history
+# ---------------------------------------------------------------------------
+
+
+class _BrokenStore(MemoryJobStore):
+    def save(self, record):
+        raise JobStoreError("disk full")
+
+
+class TestJobStore:
+    @pytest.mark.asyncio
+    async def test_every_transition_is_written(self, tmp_path: Path):
+        store = MemoryJobStore()
+        factory, release = _held()
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory, job_store=store)
+        handle = ex.submit(JobManifest(stack="go", command="go test", env={"TOKEN": "x"}))
+        assert store.records[handle.job_id]["result"]["status"] == "queued"
+        await asyncio.sleep(0.01)
+        assert store.records[handle.job_id]["result"]["status"] == "running"
+        release.set()
+        await handle.task
+        record = store.records[handle.job_id]
+        assert record["result"]["status"] == "succeeded"
+        assert record["finished_at"] == handle.finished_at
+        assert record["manifest"]["env"] == ["TOKEN"]  # names only, as in describe
+
+    @pytest.mark.asyncio
+    async def test_history_reloaded(self, tmp_path: Path):
+        store = MemoryJobStore()
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, job_store=store)
+        first = ex.submit(JobManifest(stack="go", command="go test"))
+        second = ex.submit(JobManifest(stack="python", command="exit 1"))
+        await asyncio.gather(first.task, second.task)
+
+        restarted = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, job_store=store)
+        jobs, _ = restarted.list_jobs()
+        assert [h.job_id for h in jobs] == [second.job_id, first.job_id]
+        assert jobs[1].describe() == first.describe()
+        assert restarted.get(first.job_id).done
+        assert not restarted.cancel(first.job_id)
+
+        third = restarted.submit(JobManifest(stack="go", command="go vet"))
+        assert third.seq > second.seq
+        await third.task
+        assert restarted.list_jobs()[0][0] is third
+
+    @pytest.mark.asyncio
+    async def test_shutdown_with_reloaded_history(self, tmp_path: Path):
+        class ClosingStore(MemoryJobStore):
+            closed = False
+
+            def close(self) -> None:
+                self.closed = True
+
+        store = ClosingStore()
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, job_store=store)
+        await ex.submit(JobManifest(stack="go", command="go test")).task
+
+        restarted = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, job_store=store)
+        assert restarted.list_jobs()[0][0].task is None
+        await restarted.shutdown()
+        assert store.closed
+
+    @pytest.mark.asyncio
+    async def test_unfinished_jobs_reloaded_interrupted(self, tmp_path: Path):
+        store = MemoryJobStore()
+        factory, release = _held()
+        ex = _executor(tmp_path, factory, scheduler=SchedulerConfig(max_concurrent=1))
+        ex.store = store
+        running = ex.submit(JobManifest(stack="go", command="a"))
+        queued = ex.submit(JobManifest(stack="go", command="b"))
+        await asyncio.sleep(0.01)
+
+        restarted = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, job_store=store)
+        for handle, state in ((running, "running"), (queued, "queued")):
+            result = restarted.get(handle.job_id).result
+            assert result.status == JobStatus.INTERRUPTED.value
+            assert result.error_code == JobErrorCode.AGENT_RESTARTED.value
+            assert f"while the job was {state}" in result.error
+            assert store.records[handle.job_id]["result"]["status"] == "interrupted"
+        assert restarted.list_jobs(state="finished")[0][-1].job_id == running.job_id
+
+        release.set()
+        await asyncio.gather(running.task, queued.task)
+
+    @pytest.mark.asyncio
+    async def test_evicted_jobs_leave_the_store(self, tmp_path: Path):
+        store = MemoryJobStore()
+        config = JobsConfig(history=HistoryConfig(max_jobs=1))
+        ex = JobExecutor(
+            jobs_dir=tmp_path, container_factory=FakeContainer, config=config, job_store=store
+        )
+        for command in ("a", "b"):
+            await ex.submit(JobManifest(stack="go", command=command)).task
+        assert len(store.records) == 1
+
+        kept = next(iter(store.records.values()))
+        old = {**kept, "job_id": "old", "seq": 0, "finished_at": kept["finished_at"] - 1}
+        store.save({**old, "result": {**kept["result"], "job_id": "old"}})
+        restarted = JobExecutor(
+            jobs_dir=tmp_path, container_factory=FakeContainer, config=config, job_store=store
+        )
+        assert [h.job_id for h in restarted.list_jobs()[0]] == [kept["job_id"]]
+        assert list(store.records) == [kept["job_id"]]
+
+    @pytest.mark.asyncio
+    async def test_failed_writes_never_fail_jobs(self, tmp_path: Path):
+        ex = JobExecutor(
+            jobs_dir=tmp_path, container_factory=FakeContainer, job_store=_BrokenStore()
+        )
+        handle = ex.submit(JobManifest(stack="go", command="go test"))
+        await handle.task
+        assert handle.result.succeeded
+
+    def test_sqlite_from_config(self, tmp_path: Path):
+        config = JobsConfig(store=StoreConfig(backend="sqlite", path=str(tmp_path / "jobs.db")))
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        assert ex.store.path == tmp_path / "jobs.db"
+
+        (tmp_path / "dir.db").mkdir()
+        config.store.path = str(tmp_path / "dir.db")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=FakeContainer, config=config)
+        assert ex.store is None  # unopenable: memory only, the agent still runs
+
+    def test_result_round_trip(self):
+        result = JobResult(job_id="j", stack="go", status=JobStatus.FAILED.value, exit_code=137)
+        assert JobResult.from_dict(result.to_dict()) == result
+
+
+# ---------------------------------------------------------------------------
+# Repository defaults (.orion-agent.yaml)
+# ---------------------------------------------------------------------------
+
+
+def _repo_file(text: str | None, exit_code: int = 0, stderr: str = ""):
+    """FakeContainer factory whose defaults lookup prints ``text`` (None: no file)."""
+
+    class WithDefaults(FakeContainer):
+        async def exec_install(self, command, timeout=300, env=None, on_output=None, user=None):
+            if not self.kwargs["session_id"].startswith("defaults-"):
+                return await super().exec_install(command, timeout, env, on_output, user)
+            self.installs.append({"env": env, "user": user})
+            self.execs.append(("install", command))
+            code = 3 if text is None else exit_code
+            return ExecResult(
+                exit_code=code, stdout=text or "", stderr=stderr, command=command, phase="install"
+            )
+
+    return WithDefaults
+
+
+class TestRepoDefaults:
+    GIT = JobSource(git="https://bot:pw@github.com/acme/api.git", ref="main")
+
+    @pytest.mark.asyncio
+    async def test_repo_declares_the_build(self, tmp_path: Path):
+        factory = _repo_file("stack: go\nenv: {CGO_ENABLED: '0', LOG: info}\ncommand: go test\n")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory)
+        result = await ex.run(JobManifest(stack="", source=self.GIT, env={"LOG": "debug"}))
+
+        assert result.succeeded, result.error
+        assert result.stack == "go" and result.image == "orion-stack-go:latest"
+        assert result.repo_defaults == ["command", "env", "stack"]
+        lookup, job = FakeContainer.instances
+        assert lookup.kwargs["stack"] == "base" and lookup.stopped
+        assert "git show FETCH_HEAD:.orion-agent.yaml" in lookup.execs[0][1]
+        assert job.execs[-1] == ("execute", "go test")
+        assert job.envs["execute"] == {"CGO_ENABLED": "0", "LOG": "debug"}
+
+    @pytest.mark.asyncio
+    async def test_metrics_use_the_repo_stack(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_repo_file("stack: go\n"))
+        await ex.run(JobManifest(stack="", command="go test", source=self.GIT))
+        text = ex.metrics.to_prometheus()
+        assert 'orion_jobs_started_total{stack="go"} 1' in text
+        assert 'orion_jobs_finished_total{stack="go",exit_reason="succeeded"} 1' in text
+        assert 'stack=""' not in text
+
+    @pytest.mark.asyncio
+    async def test_submitted_command_replaces_repo_steps(self, tmp_path: Path):
+        factory = _repo_file("stack: go\nsteps: [{command: make}, {command: make test}]\n")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory)
+        result = await ex.run(JobManifest(stack="", command="go vet", source=self.GIT))
+        assert result.succeeded and result.steps == []
+        assert _container().execs[-1] == ("execute", "go vet")
+
+    @pytest.mark.asyncio
+    async def test_no_file(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=_repo_file(None))
+        result = await ex.run(JobManifest(stack="go", command="go test", source=self.GIT))
+        assert result.succeeded and result.repo_defaults == []
+
+        result = await ex.run(JobManifest(stack="", command="go test", source=self.GIT))
+        assert result.error_code == JobErrorCode.STACK_RESOLUTION_FAILED.value
+        assert "https://github.com/acme/api.git" in result.error  # no credentials
+
+    @pytest.mark.asyncio
+    async def test_malformed_file_fails_with_line(self, tmp_path: Path):
+        factory = _repo_file("stack: go\nenv:\n  A: [1\n")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory)
+        result = await ex.run(JobManifest(stack="go", command="go test", source=self.GIT))
+        assert result.error_code == JobErrorCode.REPO_DEFAULTS_INVALID.value
+        assert result.error.startswith("Invalid .orion-agent.yaml in https://github.com/acme/api")
+        assert "line 4" in result.error
+        assert len(FakeContainer.instances) == 1  # no job container
+
+    @pytest.mark.asyncio
+    async def test_lookup_failure_classified(self, tmp_path: Path):
+        factory = _repo_file("", exit_code=128, stderr="fatal: Authentication failed for 'x'")
+        ex = JobExecutor(jobs_dir=tmp_path, container_factory=factory)
+        result = await ex.run(JobManifest(stack="go", command="go test", source=self.GIT))
+        assert result.error_code == JobErrorCode.SOURCE_AUTH_FAILED.value
+        assert _container().stopped
+
+    @pytest.mark.asyncio
+    async def test_no_base_stack(self, tmp_path: Path):
+        ex = JobExecutor(jobs_dir=tmp_path / "jobs", container_factory=_repo_file("stack: go\n"))
+        ex.stacks_dir = tmp_path / "stacks"
+        ex.stacks_dir.mkdir()
+        result = await ex.run(JobManifest(stack="", command="go test", source=self.GIT))
+        assert result.error_code == JobErrorCode.STACK_RESOLUTION_FAILED.value
+        assert "orion.stack='base'" in result.error
+        assert FakeContainer.instances == []
+        assert not (tmp_path / "jobs" / result.job_id).exists()
+
+    @pytest.mark.asyncio
+    async def test_disabled(self, tmp_path: Path):
+        config = JobsConfig(source=SourceConfig(repo_defaults=False))
+        ex = JobExecutor(jobs_dir=tmp_path, config=config, container_factory=_repo_file("x: 1"))
+        result = await ex.run(JobManifest(stack="go", command="go test", source=self.GIT))
+        assert result.succeeded
+        assert [c.kwargs["session_id"] for c in FakeContainer.instances] == [
+            f"job-{result.job_id}"
+        ]
+
+    @pytest.mark.asyncio
+    async def test_validate_leaves_stack_to_the_repo(self, executor: JobExecutor):
+        report = await executor.validate(
+            "command: make\nsource: {git: https://github.com/acme/api.git}\n"
+        )
+        assert report.ok, report.problems
+
+
+
+# ---------------------------------------------------------------------------
+# Custom images
+# ---------------------------------------------------------------------------
+
+
+class TestCustomImage:
+    IMAGE = "ghcr.io/acme/builder:1.4"
+
+    @staticmethod
+    def _executor(tmp_path: Path, pulls: list | None = None, **images) -> JobExecutor:
+        async def inspect(image):
+            return "amd64" if image.endswith(":1.4") or image.startswith("orion-") else None
+
+        async def puller(image, login):
+            (pulls if pulls is not None else []).append(image)
+            return subprocess.CompletedProcess([], 0, "", "")
+
+        images.setdefault("allowed_prefixes", ["ghcr.io/acme"])
+        return JobExecutor(
+            jobs_dir=tmp_path / "jobs",
+            toolchain_cache_dir=tmp_path / "toolchains",
+            container_factory=FakeContainer,
+            config=JobsConfig(
+                images=ImagesConfig(**images),
+                pool=PoolConfig(sizes={"go": 1}),
+                cache=CacheConfig(enabled=True, path=str(tmp_path / "cache")),
+            ),
+            image_inspector=inspect,
+            image_puller=puller,
+        )
+
+    @pytest.mark.asyncio
+    async def test_runs_the_image_as_given(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        manifest = JobManifest(
+            stack="",
+            image=self.IMAGE,
+            command="make",
+            resources=JobResources(cpu=1),
+            run_as="1500",
+        )
+        result = await ex.run(manifest)
+        assert result.succeeded, result.error
+        assert result.image == self.IMAGE and result.to_dict()["image"] == self.IMAGE
+        kwargs = _container().kwargs
+        assert kwargs["image"] == self.IMAGE
+        assert kwargs["resources"]["cpus"] == "1"
+        assert kwargs["user"] == "1500:1500"
+
+    @pytest.mark.asyncio
+    async def test_image_wins_over_stack_which_keeps_its_caches(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        result = await ex.run(JobManifest(stack="go", image=self.IMAGE, command="go test"))
+        assert result.succeeded
+        assert result.stack == "go" and result.image == self.IMAGE
+        volumes = _container().kwargs["extra_volumes"]
+        assert any(volume.endswith(":/home/orion/go/pkg/mod:rw") for volume in volumes)
+        assert not result.pool_reused and len(FakeContainer.instances) == 1  # never pooled
+
+    @pytest.mark.asyncio
+    async def test_not_allowed(self, tmp_path: Path):
+        ex = self._executor(tmp_path)
+        manifest = JobManifest(stack="go", image="ghcr.io/acme-evil/builder:1.4", command="make")
+        result = await ex.run(manifest)
+        assert result.error_code == JobErrorCode.IMAGE_NOT_ALLOWED.value
+        assert "images.allowedPrefixes" in result.error
+        assert FakeContainer.instances == []
+        report = await ex.validate(manifest)
+        assert [p.path for p in report.problems] == ["image"]
+
+        ex = self._executor(tmp_path, allowed_prefixes=[])
+        result = await ex.run(JobManifest(stack="", image=self.IMAGE, command="make"))
+        assert result.error_code == JobErrorCode.IMAGE_NOT_ALLOWED.value
+
+    @pytest.mark.asyncio
+    async def test_global_pull_policy_applies(self, tmp_path: Path):
+        pulls = []
+        ex = self._executor(
+            tmp_path, pulls, pull_policy="Always", stack_pull_policies={"go": "Never"}
+        )
+        result = await ex.run(JobManifest(stack="go", image=self.IMAGE, command="make"))
+        assert result.succeeded
+        assert pulls == [self.IMAGE]
+
+    @pytest.mark.asyncio
+    async def test_validate_reports_the_image(self, tmp_path: Path):
+        ex = self._executor(tmp_path, pull_policy="Never")
+        report = await ex.validate(f"image: {self.IMAGE}\ncommand: make\n")
+        assert report.ok and report.image == self.IMAGE
+
+        report = await ex.validate("image: ghcr.io/acme/missing:2\ncommand: make\n")
+        assert [p.path for p in report.problems] == ["image"]
+        assert "pullPolicy is Never" in report.problems[0].message
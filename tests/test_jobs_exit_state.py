@@ -0,0 +1,73 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for decoding a job command's exit status."""
+
+from __future__ import annotations
+
+import pytest
+
+from orion.security.jobs.exit_state import ExitState, signal_name
+
+
+class TestDecode:
+    @pytest.mark.parametrize(
+        "status, signal, number",
+        [(137, "SIGKILL", 9), (143, "SIGTERM", 15), (139, "SIGSEGV", 11), (134, "SIGABRT", 6)],
+    )
+    def test_signal_deaths(self, status, signal, number):
+        state = ExitState.decode(status)
+        assert (state.exit_code, state.signal, state.signal_number) == (status, signal, number)
+
+    @pytest.mark.parametrize("status", [0, 1, 2, 126, 127, 128, -1])
+    def test_not_a_signal(self, status):
+        state = ExitState.decode(status)
+        assert (state.signal, state.signal_number) == ("", 0)
+
+    def test_observations_kept_apart_from_the_signal(self):
+        oom = ExitState.decode(137, oom_killed=True)
+        timed_out = ExitState.decode(137, timed_out=True)
+        assert (oom.signal, oom.oom_killed, oom.timed_out) == ("SIGKILL", True, False)
+        assert (timed_out.signal, timed_out.oom_killed, timed_out.timed_out) == (
+            "SIGKILL",
+            False,
+            True,
+        )
+
+    def test_linux_numbering(self):
+        # SIGUSR1 is 30 on macOS / BSD; the job ran on Linux
+        assert signal_name(10) == "SIGUSR1"
+        assert signal_name(34) == "SIGRTMIN"
+        assert signal_name(36) == "SIGRTMIN+2"
+        assert signal_name(99) == "SIG99"
+
+
+class TestDescribe:
+    @pytest.mark.parametrize(
+        "status, text",
+        [
+            (139, "was killed by SIGSEGV (exit 139)"),
+            (1, "exited with code 1"),
+            (127, "could not run: command not found (exit 127)"),
+            (-1, "ended without an exit status"),
+        ],
+    )
+    def test_describe(self, status, text):
+        assert ExitState.decode(status).describe() == text
+
+    def test_to_dict(self):
+        assert ExitState.decode(1).to_dict() == {
+            "exit_code": 1,
+            "signal": None,
+            "signal_number": None,
+            "oom_killed": False,
+            "timed_out": False,
+        }
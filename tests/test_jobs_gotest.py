@@ -0,0 +1,199 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for ``go test -json`` reports."""
+
+from __future__ import annotations
+
+import json
+
+from orion.security.jobs.gotest import (
+    GoTestParser,
+    GoTestReport,
+    event_text,
+    json_command,
+    plain_output,
+)
+
+_PKG = "github.com/acme/api"
+
+
+def _event(action: str, test: str = "", output: str | None = None, elapsed=None, pkg=_PKG):
+    event = {"Time": "2025-03-01T10:00:00Z", "Action": action, "Package": pkg}
+    if test:
+        event["Test"] = test
+    if output is not None:
+        event["Output"] = output
+    if elapsed is not None:
+        event["Elapsed"] = elapsed
+    return json.dumps(event)
+
+
+def _passing(test: str, pkg: str = _PKG) -> list[str]:
+    return [
+        _event("run", test, pkg=pkg),
+        _event("output", test, f"=== RUN   {test}\n", pkg=pkg),
+        _event("output", test, f"--- PASS: {test} (0.01s)\n", pkg=pkg),
+        _event("pass", test, elapsed=0.01, pkg=pkg),
+    ]
+
+
+def _parse(lines: list[str]) -> GoTestReport:
+    parser = GoTestParser()
+    for line in lines:
+        parser.feed(line)
+    return parser.report()
+
+
+class TestParser:
+    def test_pass_fail_skip(self):
+        report = _parse(
+            [
+                _event("start"),
+                *_passing("TestHealth"),
+                _event("run", "TestLogin"),
+                _event("output", "TestLogin", "=== RUN   TestLogin\n"),
+                _event("output", "TestLogin", "    login_test.go:12: got 401, want 200\n"),
+                _event("output", "TestLogin", "--- FAIL: TestLogin (0.25s)\n"),
+                _event("fail", "TestLogin", elapsed=0.25),
+                _event("run", "TestSlow"),
+                _event("output", "TestSlow", "    slow_test.go:8: short mode\n"),
+                _event("skip", "TestSlow", elapsed=0),
+                _event("output", output="FAIL\n"),
+                _event("fail", elapsed=0.31),
+            ]
+        )
+        data = report.to_dict()
+        assert (data["passed"], data["failed"], data["skipped"]) == (1, 1, 1)
+        assert data["aborted"] is False
+        assert data["packages"] == [
+            {"package": _PKG, "status": "fail", "duration_seconds": 0.31, "aborted": False}
+        ]
+        health, login, slow = data["tests"]
+        assert (health["name"], health["status"], health["output"]) == ("TestHealth", "pass", "")
+        assert login == {
+            "package": _PKG,
+            "name": "TestLogin",
+            "status": "fail",
+            "duration_seconds": 0.25,
+            "output": "=== RUN   TestLogin\n    login_test.go:12: got 401, want 200\n"
+            "--- FAIL: TestLogin (0.25s)\n",
+        }
+        assert slow["status"] == "skip" and slow["output"] == ""
+
+    def test_subtests_and_packages(self):
+        other = "github.com/acme/api/store"
+        report = _parse(
+            [
+                _event("run", "TestParse"),
+                *_passing("TestParse/empty"),
+                *_passing("TestParse/unicode"),
+                _event("pass", "TestParse", elapsed=0.02),
+                _event("pass", elapsed=0.1),
+                *_passing("TestOpen", pkg=other),
+                _event("pass", elapsed=0.2, pkg=other),
+            ]
+        )
+        assert [t.name for t in report.tests] == [
+            "TestParse",
+            "TestParse/empty",
+            "TestParse/unicode",
+            "TestOpen",
+        ]
+        assert [(p.package, p.status) for p in report.packages] == [
+            (_PKG, "pass"),
+            (other, "pass"),
+        ]
+
+    def test_panic_mid_suite(self):
+        report = _parse(
+            [
+                *_passing("TestFirst"),
+                _event("run", "TestCrash"),
+                _event("output", "TestCrash", "=== RUN   TestCrash\n"),
+                _event("output", "TestCrash", "--- FAIL: TestCrash (0.00s)\n"),
+                _event("output", "TestCrash", "panic: runtime error: nil map [recovered]\n"),
+                _event("output", "TestCrash", "goroutine 7 [running]:\n"),
+                _event("fail", "TestCrash", elapsed=0),
+                _event("output", output="FAIL\tgithub.com/acme/api\t0.01s\n"),
+                _event("fail", elapsed=0.01),
+            ]
+        )
+        data = report.to_dict()
+        assert data["aborted"] is True and data["aborted_packages"] == [_PKG]
+        assert [(t["name"], t["status"]) for t in data["tests"]] == [
+            ("TestFirst", "pass"),
+            ("TestCrash", "fail"),
+        ]
+        assert "panic: runtime error" in data["tests"][1]["output"]
+
+    def test_binary_exits_with_tests_running(self):
+        report = _parse(
+            [
+                *_passing("TestFirst"),
+                _event("run", "TestExit"),
+                _event("output", "TestExit", "=== RUN   TestExit\n"),
+                _event("fail", elapsed=0.5),
+            ]
+        )
+        assert report.aborted
+        assert [(t.name, t.status) for t in report.tests] == [
+            ("TestFirst", "pass"),
+            ("TestExit", "incomplete"),
+        ]
+        assert report.tests[1].output == "=== RUN   TestExit\n"
+
+    def test_stream_cut_off(self):
+        # e.g. the job's timeout killed go test: no package event ever came
+        report = _parse([*_passing("TestFirst"), _event("run", "TestHang")])
+        data = report.to_dict()
+        assert data["aborted"] is True and data["incomplete"] == 1
+        assert data["packages"][0]["status"] == "incomplete"
+
+    def test_other_lines_ignored(self):
+        report = _parse(["go: downloading github.com/lib/pq v1.10.9", "{not json", "[1, 2]"])
+        assert report.to_dict()["tests"] == [] and report.packages == []
+
+    def test_failure_output_capped(self):
+        lines = [_event("run", "TestLoud")]
+        lines += [_event("output", "TestLoud", "x" * 1023 + "\n")] * 100
+        lines += [_event("fail", "TestLoud", elapsed=1)]
+        assert len(_parse(lines).tests[0].output) == 64 * 1024
+
+    def test_round_trip(self):
+        report = _parse([*_passing("TestFirst"), _event("pass", elapsed=0.1)])
+        assert GoTestReport.from_dict(report.to_dict()) == report
+
+
+class TestOutput:
+    def test_event_text(self):
+        assert event_text(_event("output", "TestA", "--- PASS: TestA (0.00s)\n")) == (
+            "--- PASS: TestA (0.00s)"
+        )
+        assert event_text(_event("pass", "TestA", elapsed=0)) is None
+        assert event_text("go: downloading x v1.0.0") == "go: downloading x v1.0.0"
+
+    def test_plain_output(self):
+        text = "\n".join(["go: downloading x v1.0.0", *_passing("TestA")]) + "\n"
+        assert plain_output(text) == (
+            "go: downloading x v1.0.0\n=== RUN   TestA\n--- PASS: TestA (0.01s)\n"
+        )
+
+
+class TestJsonCommand:
+    def test_argv(self):
+        assert json_command(["go", "test", "./..."]) == ["go", "test", "-json", "./..."]
+        assert json_command(["make", "test"]) == ["make", "test"]
+
+    def test_shell(self):
+        command = json_command("cd api && go test -race ./...")
+        assert command.endswith("; cd api && go test -race ./...")
+        assert 'command go test -json "$@"' in command
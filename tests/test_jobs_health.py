@@ -0,0 +1,184 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the job agent readiness probe."""
+
+from __future__ import annotations
+
+import asyncio
+import shutil
+import subprocess
+from collections import namedtuple
+from pathlib import Path
+
+import pytest
+
+from orion.security.container_runtime import ContainerRuntime
+from orion.security.jobs.config import (
+    HealthConfig,
+    ImagesConfig,
+    JobsConfig,
+    SchedulerConfig,
+    WarmStack,
+)
+from orion.security.jobs.executor import JobExecutor
+from orion.security.jobs.health import (
+    AT_CAPACITY,
+    DISK_FULL,
+    RUNTIME_UNREACHABLE,
+    SHUTTING_DOWN,
+    WARM_UP_FAILED,
+    WARMING_UP,
+    ReadinessProbe,
+)
+
+_Usage = namedtuple("_Usage", "total used free")
+
+
+class FakeRuntime(ContainerRuntime):
+    name = "fake"
+
+    def __init__(self, available: bool = True, create_rc: int = 0) -> None:
+        self.available = available
+        self.create_rc = create_rc
+        self.calls: list[str] = []
+        self.specs = []
+
+    def is_available(self) -> bool:
+        self.calls.append("info")
+        return self.available
+
+    async def create(self, spec, env=None):
+        self.calls.append(f"create {spec.image} pull={spec.pull}")
+        self.specs.append(spec)
+        return subprocess.CompletedProcess([], self.create_rc, "", "no such image")
+
+    async def remove(self, name):
+        self.calls.append(f"rm {name}")
+        return subprocess.CompletedProcess([], 0, "", "")
+
+
+class Clock:
+    def __init__(self) -> None:
+        self.now = 100.0
+
+    def __call__(self) -> float:
+        return self.now
+
+
+@pytest.fixture
+def plenty_of_disk(monkeypatch):
+    monkeypatch.setattr(shutil, "disk_usage", lambda path: _Usage(0, 0, 50 * 1024**3))
+
+
+def _probe(tmp_path: Path, runtime: FakeRuntime, clock=None, **config) -> ReadinessProbe:
+    executor = JobExecutor(
+        jobs_dir=tmp_path / "jobs", config=JobsConfig(**config), runtime=runtime
+    )
+    return ReadinessProbe(executor, clock=clock or Clock())
+
+
+class TestReadiness:
+    @pytest.mark.asyncio
+    async def test_ready(self, tmp_path: Path, plenty_of_disk):
+        runtime = FakeRuntime()
+        readiness = await _probe(tmp_path, runtime).check()
+        assert readiness.ready
+        assert readiness.to_dict()["status"] == "ready"
+        assert runtime.calls[:2] == ["info", "create orion-stack-base:latest pull=never"]
+        assert runtime.calls[2].startswith("rm orion-probe-")
+
+    @pytest.mark.asyncio
+    async def test_runtime_unreachable(self, tmp_path: Path, plenty_of_disk):
+        readiness = await _probe(tmp_path, FakeRuntime(available=False)).check()
+        assert (readiness.ready, readiness.reason) == (False, RUNTIME_UNREACHABLE)
+        assert "not responding" in readiness.detail
+
+    @pytest.mark.asyncio
+    async def test_cannot_create_container(self, tmp_path: Path, plenty_of_disk):
+        readiness = await _probe(tmp_path, FakeRuntime(create_rc=125)).check()
+        assert readiness.reason == RUNTIME_UNREACHABLE
+        assert "no such image" in readiness.detail
+
+    @pytest.mark.asyncio
+    async def test_disk_full(self, tmp_path: Path, monkeypatch):
+        monkeypatch.setattr(shutil, "disk_usage", lambda path: _Usage(0, 0, 1024**2))
+        probe = _probe(tmp_path, FakeRuntime(), health=HealthConfig(min_free_disk=1024**3))
+        readiness = await probe.check()
+        assert readiness.reason == DISK_FULL
+        assert "health.minFreeDisk" in readiness.detail
+
+    @pytest.mark.asyncio
+    async def test_at_capacity(self, tmp_path: Path, plenty_of_disk):
+        probe = _probe(tmp_path, FakeRuntime(), scheduler=SchedulerConfig(max_queued=1))
+        probe.executor._queued.add("j1")
+        readiness = await probe.check()
+        assert readiness.reason == AT_CAPACITY
+        assert readiness.to_dict()["status"] == "not_ready"
+
+    @pytest.mark.asyncio
+    async def test_shutting_down(self, tmp_path: Path, plenty_of_disk):
+        runtime = FakeRuntime()
+        probe = _probe(tmp_path, runtime)
+        await probe.executor.shutdown()
+        readiness = await probe.check()
+        assert (readiness.ready, readiness.reason) == (False, SHUTTING_DOWN)
+        assert runtime.calls == []
+
+    @pytest.mark.asyncio
+    async def test_warming_up(self, tmp_path: Path, plenty_of_disk):
+        images = ImagesConfig(warm_stacks=[WarmStack("go"), WarmStack("node")])
+        probe = _probe(tmp_path, FakeRuntime(), images=images)
+        probe.executor._warm_up = asyncio.get_running_loop().create_future()
+        readiness = await probe.check()
+        assert (readiness.ready, readiness.reason) == (False, WARMING_UP)
+        assert readiness.detail == "Warming up stacks: go, node"
+
+        probe.executor._warm_up.set_result(None)
+        assert (await probe.check()).ready
+
+    @pytest.mark.asyncio
+    async def test_required_stack_not_warmed(self, tmp_path: Path, plenty_of_disk):
+        probe = _probe(tmp_path, FakeRuntime())
+        probe.executor.warm_failures["go"] = "Image orion-stack-go:latest could not be pulled"
+        readiness = await probe.check()
+        assert (readiness.ready, readiness.reason) == (False, WARM_UP_FAILED)
+        assert "go: Image orion-stack-go:latest could not be pulled" in readiness.detail
+
+    @pytest.mark.asyncio
+    async def test_runtime_probe_cached_for_ttl(self, tmp_path: Path, plenty_of_disk):
+        clock = Clock()
+        runtime = FakeRuntime()
+        probe = _probe(tmp_path, runtime, clock, health=HealthConfig(probe_ttl=10))
+        await probe.check()
+        clock.now += 5
+        await probe.check()
+        assert runtime.calls.count("info") == 1
+
+        clock.now += 6
+        runtime.available = False
+        readiness = await probe.check()
+        assert runtime.calls.count("info") == 2
+        assert readiness.reason == RUNTIME_UNREACHABLE
+
+    @pytest.mark.asyncio
+    async def test_probe_image_configurable(self, tmp_path: Path, plenty_of_disk):
+        runtime = FakeRuntime()
+        await _probe(tmp_path, runtime, health=HealthConfig(probe_image="busybox")).check()
+        assert "create busybox pull=never" in runtime.calls
+
+    @pytest.mark.asyncio
+    async def test_probe_container_hardened_like_jobs(self, tmp_path: Path, plenty_of_disk):
+        runtime = FakeRuntime()
+        probe = _probe(tmp_path, runtime)
+        await probe.check()
+        assert runtime.specs[0].security == probe.executor.config.hardening.container_security()
+        assert runtime.specs[0].security.cap_drop == ["ALL"]
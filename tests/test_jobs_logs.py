@@ -0,0 +1,83 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the live job log channel."""
+
+from __future__ import annotations
+
+import asyncio
+
+import pytest
+
+from orion.security.jobs.logs import LogChannel
+
+
+async def _collect(channel: LogChannel, since: int = 0) -> list[str]:
+    return [line.line async for line in channel.follow(since)]
+
+
+class TestLogChannel:
+    def test_lines_are_sequenced_and_tagged(self):
+        channel = LogChannel()
+        channel.publish("stdout", "a")
+        channel.publish("stderr", "b")
+        lines = channel.lines
+        assert [(ln.seq, ln.stream, ln.line) for ln in lines] == [
+            (0, "stdout", "a"),
+            (1, "stderr", "b"),
+        ]
+        assert lines[0].timestamp <= lines[1].timestamp
+
+    def test_step_tag(self):
+        channel = LogChannel()
+        channel.publish("stdout", "compiling", step="build")
+        channel.publish("stdout", "plain")
+        first, second = channel.lines
+        assert (first.step, first.to_dict()["step"]) == ("build", "build")
+        assert (second.step, second.to_dict()["step"]) == ("", None)
+
+    @pytest.mark.asyncio
+    async def test_follow_replays_then_waits(self):
+        channel = LogChannel()
+        channel.publish("stdout", "early")
+        reader = asyncio.create_task(_collect(channel))
+        await asyncio.sleep(0)
+        channel.publish("stdout", "late")
+        channel.close()
+        assert await reader == ["early", "late"]
+
+    @pytest.mark.asyncio
+    async def test_follow_since(self):
+        channel = LogChannel()
+        for text in ("a", "b", "c"):
+            channel.publish("stdout", text)
+        channel.close()
+        assert await _collect(channel, since=2) == ["c"]
+
+    @pytest.mark.asyncio
+    async def test_multiple_followers(self):
+        channel = LogChannel()
+        readers = [asyncio.create_task(_collect(channel)) for _ in range(3)]
+        await asyncio.sleep(0)
+        channel.publish("stdout", "x")
+        channel.close()
+        assert await asyncio.gather(*readers) == [["x"]] * 3
+
+    def test_publish_after_close_ignored(self):
+        channel = LogChannel()
+        channel.close()
+        channel.publish("stdout", "x")
+        assert channel.lines == []
+
+    def test_to_dict(self):
+        channel = LogChannel()
+        channel.publish("stderr", "boom")
+        assert channel.lines[0].to_dict()["stream"] == "stderr"
@@ -0,0 +1,621 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the job manifest schema and stack resolution."""
+
+from __future__ import annotations
+
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.manifest import (
+    JobEnvFile,
+    JobManifest,
+    JobMount,
+    JobResources,
+    JobStep,
+    ManifestError,
+    display_url,
+    load_manifest,
+    parse_manifest,
+    resolve_image,
+)
+from orion.security.stack_detector import StackResolutionError
+
+
+class TestParseManifest:
+    """Manifests parse from YAML and validate required fields."""
+
+    def test_minimal(self):
+        m = parse_manifest("stack: python\ncommand: pytest -q\n")
+        assert m.stack == "python"
+        assert m.command == "pytest -q"
+
+    def test_json_is_accepted(self):
+        m = parse_manifest('{"stack": "go", "command": "go test ./..."}')
+        assert m.stack == "go"
+
+    def test_missing_stack(self):
+        with pytest.raises(ManifestError, match="stack"):
+            parse_manifest("command: make\n")
+
+    def test_stack_may_come_from_a_git_source(self):
+        m = parse_manifest("command: make\nsource: {git: https://github.com/acme/api.git}\n")
+        assert m.stack == ""
+        with pytest.raises(ManifestError, match="stack"):
+            parse_manifest("command: make\nsource: {hostPath: /srv/api}\n")
+
+    def test_given_is_what_was_submitted(self):
+        m = parse_manifest("stack: go\nenv: {A: '1'}\ntimeout: 30m\n")
+        assert set(m.given()) == {"stack", "env", "timeout"}
+        assert m.given()["env"] == {"A": "1"}
+
+    def test_not_a_mapping(self):
+        with pytest.raises(ManifestError):
+            parse_manifest("- stack: python\n")
+
+    def test_invalid_yaml(self):
+        with pytest.raises(ManifestError, match="Invalid manifest YAML"):
+            parse_manifest("stack: [python\n")
+
+    def test_load_from_file(self, tmp_path: Path):
+        path = tmp_path / "job.yaml"
+        path.write_text("stack: node\n")
+        assert load_manifest(path).stack == "node"
+
+    def test_round_trip(self):
+        m = JobManifest(stack="rust", command="cargo test", resources=JobResources(cpu=2))
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+
+class TestTimeout:
+    def test_duration_string(self):
+        assert parse_manifest("stack: go\ntimeout: 10m\n").timeout == 600
+
+    def test_seconds(self):
+        assert parse_manifest("stack: go\ntimeout: 30\n").timeout == 30
+
+    def test_omitted(self):
+        assert parse_manifest("stack: go\n").timeout is None
+
+    def test_invalid(self):
+        with pytest.raises(ManifestError, match="timeout"):
+            parse_manifest("stack: go\ntimeout: soon\n")
+
+
+class TestSecrets:
+    def test_names_only(self):
+        m = parse_manifest("stack: node\nsecrets:\n  NPM_TOKEN: npm-publish-token\n")
+        assert m.secrets == {"NPM_TOKEN": "npm-publish-token"}
+        assert m.to_dict()["secrets"] == {"NPM_TOKEN": "npm-publish-token"}
+
+    def test_must_be_mapping(self):
+        with pytest.raises(ManifestError, match="secrets"):
+            parse_manifest("stack: node\nsecrets: [a, b]\n")
+
+    def test_invalid_env_name(self):
+        with pytest.raises(ManifestError, match="environment variable"):
+            parse_manifest("stack: node\nsecrets:\n  bad-name: npm\n")
+
+
+class TestArtifacts:
+    def test_patterns(self):
+        m = parse_manifest("stack: go\nartifacts:\n  - dist/**\n  - '*.junit.xml'\n")
+        assert m.artifacts == ["dist/**", "*.junit.xml"]
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+    def test_single_pattern(self):
+        assert parse_manifest("stack: go\nartifacts: bin/app\n").artifacts == ["bin/app"]
+
+    def test_absolute_rejected(self):
+        with pytest.raises(ManifestError, match="relative to /workspace"):
+            parse_manifest("stack: go\nartifacts: [/etc/passwd]\n")
+
+    def test_parent_rejected(self):
+        with pytest.raises(ManifestError, match="'..'"):
+            parse_manifest("stack: go\nartifacts: ['../secrets/*']\n")
+
+
+class TestSource:
+    def test_git(self):
+        m = parse_manifest(
+            "stack: go\nsource:\n  git: https://github.com/acme/api.git\n"
+            "  ref: main\n  commit: 3f2a9c1\n  depth: 1\n  path: api/\n"
+        )
+        assert m.source.kind == "git"
+        assert (m.source.ref, m.source.commit, m.source.depth) == ("main", "3f2a9c1", 1)
+        assert m.source.container_path == "/workspace/api"
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+    def test_bind(self):
+        m = parse_manifest("stack: go\nsource:\n  hostPath: /srv/checkouts/api\n")
+        assert m.source.kind == "bind"
+        assert m.source.container_path == "/workspace"
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+    def test_archive(self):
+        m = parse_manifest("stack: go\nsource:\n  type: archive\n  path: api\n")
+        assert m.source.kind == "archive"
+        assert m.source.upload == ""  # supplied with the submission
+        assert m.source.container_path == "/workspace/api"
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+    def test_archive_upload(self):
+        upload = "0123456789abcdef" * 2
+        m = parse_manifest(f"stack: go\nsource:\n  type: archive\n  upload: {upload}\n")
+        assert m.source.upload == upload
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+    def test_archive_sha256(self):
+        digest = "AB" * 32
+        m = parse_manifest(f"stack: go\nsource:\n  type: archive\n  sha256: {digest}\n")
+        assert m.source.sha256 == digest.lower()
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+    @pytest.mark.parametrize(
+        "source, path",
+        [
+            ("{type: archive, git: x}", "source"),
+            ("{type: archive, depth: 1}", "source"),
+            ("{type: archive, upload: ../x}", "source.upload"),
+            ("{type: tarball}", "source.type"),
+            ("{type: bind, git: x}", "source.type"),
+            ("{git: x, upload: 0123456789abcdef0123456789abcdef}", "source.upload"),
+            ("{type: archive, sha256: abc}", "source.sha256"),
+            ("{hostPath: /srv/x, sha256: " + "a" * 64 + "}", "source.sha256"),
+        ],
+    )
+    def test_invalid_archive(self, source, path):
+        with pytest.raises(ManifestError) as info:
+            parse_manifest(f"stack: go\nsource: {source}\n")
+        assert path in [p.path for p in info.value.problems]
+
+    def test_omitted(self):
+        m = parse_manifest("stack: go\n")
+        assert m.source.kind == ""
+        assert m.to_dict()["source"] is None
+
+    def test_exactly_one_kind(self):
+        with pytest.raises(ManifestError, match="exactly one"):
+            parse_manifest("stack: go\nsource:\n  git: x\n  hostPath: /srv/x\n")
+
+    def test_invalid_fields(self):
+        with pytest.raises(ManifestError) as info:
+            parse_manifest(
+                "stack: go\nsource:\n  git: x\n  commit: main\n  depth: 0\n  path: ../up\n"
+            )
+        paths = [p.path for p in info.value.problems]
+        assert paths == ["source.commit", "source.depth", "source.path"]
+
+    def test_relative_host_path(self):
+        with pytest.raises(ManifestError, match="absolute"):
+            parse_manifest("stack: go\nsource:\n  hostPath: checkouts/api\n")
+
+
+class TestRunAs:
+    def test_uid_and_gid(self):
+        m = parse_manifest('stack: go\nrunAs: "1500:27"\n')
+        assert m.run_as == "1500:27"
+        assert m.run_as_ids == (1500, 27)
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+    def test_gid_defaults_to_uid(self):
+        assert parse_manifest('stack: go\nrunAs: "1500"\n').run_as == "1500:1500"
+
+    def test_omitted(self):
+        m = parse_manifest("stack: go\n")
+        assert m.run_as_ids is None
+        assert m.to_dict()["runAs"] is None
+
+    def test_unquoted_rejected(self):
+        # YAML reads 1000:50 as a base-60 integer
+        with pytest.raises(ManifestError, match="quoted"):
+            parse_manifest("stack: go\nrunAs: 1000:50\n")
+
+    def test_names_rejected(self):
+        with pytest.raises(ManifestError, match="runAs"):
+            parse_manifest('stack: go\nrunAs: "builder"\n')
+
+
+class TestCommand:
+    def test_shell_string(self):
+        assert parse_manifest("stack: go\ncommand: go test ./...\n").command == "go test ./..."
+
+    def test_argv_list(self):
+        manifest = parse_manifest('stack: go\ncommand: [go, test, -run, "Test Foo"]\n')
+        assert manifest.command == ["go", "test", "-run", "Test Foo"]
+        assert manifest.to_dict()["command"] == ["go", "test", "-run", "Test Foo"]
+
+    @pytest.mark.parametrize("command", ["[]", "[go, 1]", "{go: test}"])
+    def test_bad_command(self, command):
+        with pytest.raises(ManifestError, match="'command'"):
+            parse_manifest(f"stack: go\ncommand: {command}\n")
+
+
+class TestWorkdir:
+    def test_default(self):
+        assert parse_manifest("stack: go\n").workdir == "/workspace"
+
+    @pytest.mark.parametrize(
+        "workdir, expected",
+        [
+            ("api", "/workspace/api"),
+            ("/workspace/api/", "/workspace/api"),
+            ("/workspace", "/workspace"),
+        ],
+    )
+    def test_under_workspace(self, workdir, expected):
+        assert parse_manifest(f"stack: go\nworkdir: {workdir}\n").workdir == expected
+
+    @pytest.mark.parametrize("workdir", ["/etc", "/workspacex", "api/../..", "../etc"])
+    def test_outside_workspace_rejected(self, workdir):
+        with pytest.raises(ManifestError, match="'workdir'"):
+            parse_manifest(f"stack: go\nworkdir: {workdir}\n")
+
+
+class TestEnv:
+    def test_env_map(self):
+        manifest = parse_manifest('stack: go\nenv:\n  CGO_ENABLED: "0"\n  GOFLAGS: -mod=vendor\n')
+        assert manifest.env == {"CGO_ENABLED": "0", "GOFLAGS": "-mod=vendor"}
+        assert manifest.to_dict()["env"] == manifest.env
+
+    def test_unquoted_number_rejected(self):
+        with pytest.raises(ManifestError, match="quote numbers"):
+            parse_manifest("stack: go\nenv:\n  CGO_ENABLED: 0\n")
+
+    def test_invalid_name(self):
+        with pytest.raises(ManifestError, match="'env.1X'"):
+            parse_manifest('stack: go\nenv:\n  "1X": y\n')
+
+
+class TestEnvFile:
+    def test_path_shorthand(self):
+        manifest = parse_manifest("stack: go\nenvFile: /srv/env/api.env\n")
+        assert manifest.env_file == JobEnvFile(path="/srv/env/api.env")
+        assert parse_manifest("stack: go\n").env_file is None
+
+    def test_inline_content_and_sensitive(self):
+        manifest = parse_manifest(
+            "stack: go\n"
+            "envFile:\n"
+            "  content: |\n"
+            "    # db\n"
+            "    DB_PASSWORD='p w'\n"
+            "  sensitive: [DB_PASSWORD]\n"
+        )
+        assert manifest.env_file.sensitive == ["DB_PASSWORD"]
+        assert manifest.to_dict()["envFile"] == {
+            "path": None,
+            "content": "# db\nDB_PASSWORD='p w'\n",
+            "sensitive": ["DB_PASSWORD"],
+        }
+
+    def test_malformed_content_names_line(self):
+        with pytest.raises(ManifestError, match=r"envFile.content.*line 2: expected KEY=VALUE"):
+            parse_manifest("stack: go\nenvFile:\n  content: |\n    A=1\n    oops\n")
+
+    @pytest.mark.parametrize(
+        "value, field",
+        [
+            ("env/api.env", "envFile.path"),
+            ("{path: /a.env, content: 'A=1'}", "envFile"),
+            ("{sensitive: [A]}", "envFile"),
+            ("[/a.env]", "envFile"),
+            ("{path: /a.env, sensitive: [1A]}", "envFile.sensitive"),
+        ],
+    )
+    def test_invalid(self, value, field):
+        with pytest.raises(ManifestError) as excinfo:
+            parse_manifest(f"stack: go\nenvFile: {value}\n")
+        assert [p.path for p in excinfo.value.problems] == [field]
+
+
+class TestMounts:
+    def test_parsed(self):
+        manifest = parse_manifest(
+            "stack: go\n"
+            "mounts:\n"
+            "  - {source: /opt/toolchains/sdk/, target: /opt/sdk, readOnly: true}\n"
+            "  - {source: /srv/data, target: /data}\n"
+        )
+        assert manifest.mounts == [
+            JobMount("/opt/toolchains/sdk", "/opt/sdk", read_only=True),
+            JobMount("/srv/data", "/data"),
+        ]
+        assert manifest.to_dict()["mounts"][0] == {
+            "source": "/opt/toolchains/sdk",
+            "target": "/opt/sdk",
+            "readOnly": True,
+        }
+        assert parse_manifest("stack: go\n").mounts == []
+
+    @pytest.mark.parametrize(
+        "target", ["/workspace", "/workspace/cache", "/", "/etc/orion/aegis", "/etc/orion"]
+    )
+    def test_reserved_targets(self, target):
+        with pytest.raises(ManifestError, match=r"mounts\[0\]\.target.*must not overlap"):
+            parse_manifest(f"stack: go\nmounts:\n  - {{source: /srv/data, target: {target}}}\n")
+
+    @pytest.mark.parametrize("second", ["/data", "/data/sub", "/"])
+    def test_overlapping_targets(self, second):
+        with pytest.raises(ManifestError, match=r"mounts\[1\]\.target"):
+            parse_manifest(
+                "stack: go\nmounts:\n"
+                "  - {source: /srv/a, target: /data}\n"
+                f"  - {{source: /srv/b, target: {second}}}\n"
+            )
+
+    def test_siblings_do_not_overlap(self):
+        manifest = parse_manifest(
+            "stack: go\nmounts:\n"
+            "  - {source: /srv/a, target: /data}\n"
+            "  - {source: /srv/b, target: /data2}\n"
+        )
+        assert len(manifest.mounts) == 2
+
+    @pytest.mark.parametrize(
+        "entry, field",
+        [
+            ("{target: /data}", "mounts[0].source"),
+            ("{source: srv/data, target: /data}", "mounts[0].source"),
+            ("{source: /srv/../etc, target: /data}", "mounts[0].source"),
+            ("{source: '/srv/a:/etc', target: /data}", "mounts[0].source"),
+            ("{source: /srv/a, target: data}", "mounts[0].target"),
+            ("{source: /srv/a, target: /data, readOnly: 'yes'}", "mounts[0].readOnly"),
+            ("/srv/a:/data", "mounts[0]"),
+        ],
+    )
+    def test_invalid(self, entry, field):
+        with pytest.raises(ManifestError) as excinfo:
+            parse_manifest(f"stack: go\nmounts:\n  - {entry}\n")
+        assert [p.path for p in excinfo.value.problems] == [field]
+
+
+class TestCallbackUrl:
+    def test_parsed(self):
+        manifest = parse_manifest("stack: go\ncallbackUrl: https://ci.acme.dev/hooks/orion\n")
+        assert manifest.callback_url == "https://ci.acme.dev/hooks/orion"
+        assert manifest.to_dict()["callbackUrl"] == manifest.callback_url
+        assert parse_manifest("stack: go\n").to_dict()["callbackUrl"] is None
+
+    @pytest.mark.parametrize("url", ["ftp://h/x", "https://", "ci.acme.dev/hook", "[1]"])
+    def test_invalid(self, url):
+        with pytest.raises(ManifestError, match="callbackUrl"):
+            parse_manifest(f"stack: go\ncallbackUrl: {url}\n")
+
+
+class TestSummary:
+    def test_hides_env_values_and_credentials(self):
+        manifest = parse_manifest(
+            "stack: go\n"
+            "env: {B: two, A: one}\n"
+            "envFile: {content: 'K=v', sensitive: [K]}\n"
+            "source: {git: 'https://bot:pw@github.com/acme/api.git'}\n"
+            "callbackUrl: https://ci.acme.dev/hook?token=abc\n"
+        )
+        summary = manifest.summary()
+        assert summary["env"] == ["A", "B"]
+        assert summary["envFile"] == {"path": None, "inline": True, "sensitive": ["K"]}
+        assert summary["source"]["git"] == "https://github.com/acme/api.git"
+        assert summary["callbackUrl"] == "https://ci.acme.dev/hook"
+
+    def test_display_url(self):
+        assert display_url("https://u:p@h.dev:8443/x?q=1#f") == "https://h.dev:8443/x"
+        assert display_url("/srv/repo") == "/srv/repo"
+
+
+class TestSteps:
+    def test_parsed(self):
+        manifest = parse_manifest(
+            "stack: go\n"
+            "env: {CI: '1'}\n"
+            "steps:\n"
+            "  - command: go mod download\n"
+            "  - name: test\n"
+            "    command: [go, test, ./...]\n"
+            "    env: {GOFLAGS: -count=1}\n"
+            "    continueOnError: true\n"
+        )
+        assert manifest.steps == [
+            JobStep("step-1", "go mod download"),
+            JobStep("test", ["go", "test", "./..."], {"GOFLAGS": "-count=1"}, True),
+        ]
+        assert manifest.plan == manifest.steps
+        assert JobManifest.from_dict(manifest.to_dict()) == manifest
+        assert manifest.summary()["steps"][1]["env"] == ["GOFLAGS"]
+
+    def test_plain_command_is_one_unnamed_step(self):
+        manifest = parse_manifest("stack: go\ncommand: make\n")
+        assert manifest.steps == []
+        assert manifest.plan == [JobStep("", "make")]
+
+    def test_not_with_command(self):
+        with pytest.raises(ManifestError, match="'steps' cannot be combined"):
+            parse_manifest("stack: go\ncommand: make\nsteps: [{command: make}]\n")
+
+    def test_problems_per_step(self):
+        with pytest.raises(ManifestError) as excinfo:
+            parse_manifest(
+                "stack: go\n"
+                "steps:\n"
+                "  - {name: build, command: make}\n"
+                "  - {name: build, command: make test}\n"
+                "  - {name: 'bad name'}\n"
+                "  - {command: make, continueOnError: 'yes', env: {1X: a}}\n"
+                "  - make\n"
+            )
+        assert sorted(p.path for p in excinfo.value.problems) == [
+            "steps[1].name",
+            "steps[2].command",
+            "steps[2].name",
+            "steps[3].continueOnError",
+            "steps[3].env.1X",
+            "steps[4]",
+        ]
+
+    def test_must_be_list(self):
+        with pytest.raises(ManifestError, match="'steps' must be a list"):
+            parse_manifest("stack: go\nsteps: make\n")
+
+
+class TestProblems:
+    def test_all_problems_collected(self):
+        with pytest.raises(ManifestError) as info:
+            parse_manifest("command: 3\ntoolchain: 1.20\nresources:\n  cpu: -1\n  memory: x\n")
+        paths = [p.path for p in info.value.problems]
+        assert paths == ["stack", "command", "toolchain", "resources.cpu", "resources.memory"]
+
+    def test_problem_to_dict(self):
+        with pytest.raises(ManifestError) as info:
+            parse_manifest("stack: go\nsecrets:\n  BAD-NAME: npm\n")
+        problem = info.value.problems[0].to_dict()
+        assert problem["path"] == "secrets.BAD-NAME"
+        assert "environment variable" in problem["message"]
+
+
+class TestResources:
+    """resources.cpu / resources.memory parse into cores and bytes."""
+
+    def test_human_memory(self):
+        m = parse_manifest("stack: go\nresources:\n  cpu: 1.5\n  memory: 512Mi\n")
+        assert m.resources.cpu == 1.5
+        assert m.resources.memory == 512 * 1024**2
+
+    def test_byte_memory(self):
+        m = parse_manifest("stack: go\nresources:\n  memory: 1048576\n")
+        assert m.resources.memory == 1048576
+
+    def test_omitted(self):
+        m = parse_manifest("stack: go\n")
+        assert m.resources == JobResources()
+
+    def test_invalid_memory(self):
+        with pytest.raises(ManifestError, match="resources.memory"):
+            parse_manifest("stack: go\nresources:\n  memory: lots\n")
+
+    def test_non_positive_cpu(self):
+        with pytest.raises(ManifestError, match="resources.cpu"):
+            parse_manifest("stack: go\nresources:\n  cpu: 0\n")
+
+
+class TestResolveImage:
+    """A manifest's stack resolves via the orion.stack LABEL."""
+
+    def test_python_resolves_to_python_image(self):
+        image = resolve_image(parse_manifest("stack: python\n"))
+        assert image.image == "orion-stack-python:latest"
+        assert image.dockerfile.name == "Dockerfile.python"
+
+    def test_unknown_stack_fails_clearly(self):
+        with pytest.raises(StackResolutionError, match="fortran"):
+            resolve_image(parse_manifest("stack: fortran\n"))
+
+
+class TestRequires:
+    def test_selectors(self):
+        m = parse_manifest("stack: go\nrequires: [gpu=true, ' mem = high ', cuda]\n")
+        assert m.requires == ["gpu=true", "mem=high", "cuda"]
+        assert JobManifest.from_dict(m.to_dict()) == m
+
+    def test_default_and_single(self):
+        assert parse_manifest("stack: go\n").requires == []
+        assert parse_manifest("stack: go\nrequires: gpu\n").requires == ["gpu"]
+
+    @pytest.mark.parametrize(
+        "requires, path",
+        [
+            ("{gpu: true}", "requires"),
+            ("[gpu=true, 3]", "requires"),
+            ("['=true']", "requires[0]"),
+            ("[gpu, 'mem=']", "requires[1]"),
+            ("['gpu=a b']", "requires[0]"),
+        ],
+    )
+    def test_invalid(self, requires, path):
+        with pytest.raises(ManifestError) as info:
+            parse_manifest(f"stack: go\nrequires: {requires}\n")
+        assert [p.path for p in info.value.problems] == [path]
+
+
+class TestReport:
+    def test_gotest(self):
+        m = parse_manifest("stack: go\ncommand: go test ./...\nreport: gotest\n")
+        assert m.report == "gotest"
+        assert JobManifest.from_dict(m.to_dict()) == m
+        assert parse_manifest("stack: go\n").to_dict()["report"] is None
+
+    def test_custom_image_without_stack(self):
+        m = parse_manifest("image: golang:1.23\ncommand: go test ./...\nreport: gotest\n")
+        assert m.report == "gotest"
+
+    @pytest.mark.parametrize(
+        "text, message",
+        [
+            ("stack: go\nreport: junit\n", "must be one of: gotest"),
+            ("stack: go\nreport: [gotest]\n", "must be one of: gotest"),
+            ("stack: node\nreport: gotest\n", "needs stack 'go', not 'node'"),
+        ],
+    )
+    def test_invalid(self, text, message):
+        with pytest.raises(ManifestError, match=message) as info:
+            parse_manifest(text)
+        assert [p.path for p in info.value.problems] == ["report"]
+
+
+class TestNetwork:
+    def test_default_is_phased(self):
+        manifest = parse_manifest("stack: go\ncommand: make\n")
+        assert manifest.network == ""
+        assert manifest.to_dict()["network"] is None
+
+    @pytest.mark.parametrize("network", ["none", "bridge", "ci-services"])
+    def test_accepted(self, network: str):
+        manifest = parse_manifest(f"stack: go\ncommand: make\nnetwork: {network}\n")
+        assert manifest.network == network
+        assert JobManifest.from_dict(manifest.to_dict()) == manifest
+
+    @pytest.mark.parametrize("network", ["host", "container:db", "'-x'", "[a]"])
+    def test_rejected(self, network: str):
+        with pytest.raises(ManifestError, match="'network'"):
+            parse_manifest(f"stack: go\ncommand: make\nnetwork: {network}\n")
+
+    def test_no_git_clone_offline(self):
+        with pytest.raises(ManifestError, match="'source.git' cannot be cloned"):
+            parse_manifest(
+                "stack: go\ncommand: make\nnetwork: none\nsource: {git: https://x.dev/a.git}\n"
+            )
+        manifest = parse_manifest(
+            "stack: go\ncommand: make\nnetwork: none\nsource: {hostPath: /srv/api}\n"
+        )
+        assert manifest.source.host_path == "/srv/api"
+
+
+class TestImage:
+    def test_replaces_the_stack_requirement(self):
+        manifest = parse_manifest("image: ghcr.io/acme/builder:1.4\ncommand: make\n")
+        assert (manifest.stack, manifest.image) == ("", "ghcr.io/acme/builder:1.4")
+        assert manifest.to_dict()["image"] == "ghcr.io/acme/builder:1.4"
+        assert JobManifest.from_dict(manifest.to_dict()) == manifest
+
+    def test_unset(self):
+        assert parse_manifest("stack: go\n").to_dict()["image"] is None
+
+    @pytest.mark.parametrize(
+        "image", ["localhost:5000/team/img@sha256:" + "a" * 64, "ubuntu", "quay.io/x/y:v1.2-rc"]
+    )
+    def test_accepted(self, image: str):
+        assert parse_manifest(f"stack: go\nimage: '{image}'\n").image == image
+
+    @pytest.mark.parametrize("image", ["'-v /:/host'", "'a b'", "[a]", "42"])
+    def test_rejected(self, image: str):
+        with pytest.raises(ManifestError, match="'image'"):
+            parse_manifest(f"stack: go\nimage: {image}\n")
@@ -0,0 +1,54 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for job metrics exposition."""
+
+from __future__ import annotations
+
+from orion.security.jobs.metrics import JobMetrics
+
+
+class TestJobMetrics:
+    def test_counters_by_label(self):
+        m = JobMetrics()
+        m.job_started("go")
+        m.job_started("node")
+        m.job_finished("go", "timed_out", 12.0)
+        text = m.to_prometheus()
+        assert "# TYPE orion_jobs_started_total counter" in text
+        assert 'orion_jobs_started_total{stack="node"} 1' in text
+        assert 'orion_jobs_finished_total{stack="go",exit_reason="timed_out"} 1' in text
+
+    def test_histogram_buckets_are_cumulative(self):
+        m = JobMetrics()
+        m.job_finished("go", "succeeded", 3)
+        m.job_finished("go", "succeeded", 45)
+        text = m.to_prometheus()
+        assert 'orion_job_duration_seconds_bucket{stack="go",le="1"} 0' in text
+        assert 'orion_job_duration_seconds_bucket{stack="go",le="5"} 1' in text
+        assert 'orion_job_duration_seconds_bucket{stack="go",le="60"} 2' in text
+        assert 'orion_job_duration_seconds_bucket{stack="go",le="+Inf"} 2' in text
+        assert 'orion_job_duration_seconds_sum{stack="go"} 48' in text
+
+    def test_unstarted_jobs_have_no_duration(self):
+        m = JobMetrics()
+        m.job_finished("go", "cancelled", None)
+        assert "orion_job_duration_seconds_count" not in m.to_prometheus()
+
+    def test_gauges(self):
+        text = JobMetrics().to_prometheus(running=3, queued=7)
+        assert "orion_jobs_running 3" in text
+        assert "orion_jobs_queued 7" in text
+
+    def test_pull_histogram(self):
+        m = JobMetrics()
+        m.image_pulled("rust", 20)
+        assert 'orion_image_pull_duration_seconds_count{stack="rust"} 1' in m.to_prometheus()
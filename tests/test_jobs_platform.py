@@ -0,0 +1,73 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for host architecture detection and image variant selection."""
+
+from __future__ import annotations
+
+import pytest
+
+from orion.security.jobs import platform as jobs_platform
+from orion.security.jobs.platform import host_arch, select_image
+from orion.security.stack_detector import StackResolutionError
+
+IMAGE = "orion-stack-go:latest"
+
+
+def _inspector(images: dict[str, str]):
+    async def inspect(image: str) -> str | None:
+        return images.get(image)
+
+    return inspect
+
+
+class TestHostArch:
+    @pytest.mark.parametrize(
+        "machine,expected",
+        [("x86_64", "amd64"), ("AMD64", "amd64"), ("aarch64", "arm64"), ("arm64", "arm64")],
+    )
+    def test_normalised(self, monkeypatch, machine, expected):
+        monkeypatch.setattr(jobs_platform.platform, "machine", lambda: machine)
+        assert host_arch() == expected
+
+    def test_unknown_passes_through(self, monkeypatch):
+        monkeypatch.setattr(jobs_platform.platform, "machine", lambda: "riscv64")
+        assert host_arch() == "riscv64"
+
+
+class TestSelectImage:
+    @pytest.mark.asyncio
+    async def test_variant_wins(self):
+        inspect = _inspector({IMAGE: "amd64", f"{IMAGE}-arm64": "arm64"})
+        assert await select_image(IMAGE, "arm64", inspect) == f"{IMAGE}-arm64"
+
+    @pytest.mark.asyncio
+    async def test_matching_base_tag(self):
+        inspect = _inspector({IMAGE: "arm64"})
+        assert await select_image(IMAGE, "arm64", inspect) == IMAGE
+
+    @pytest.mark.asyncio
+    async def test_absent_image_left_to_docker(self):
+        assert await select_image(IMAGE, "arm64", _inspector({})) == IMAGE
+
+    @pytest.mark.asyncio
+    async def test_mismatched_only(self):
+        with pytest.raises(StackResolutionError, match="built for amd64 but this host is arm64"):
+            await select_image(IMAGE, "arm64", _inspector({IMAGE: "amd64"}))
+
+
+class TestGoDockerfile:
+    def test_download_uses_target_arch(self):
+        from orion.security.stack_detector import STACKS_DIR
+
+        text = (STACKS_DIR / "Dockerfile.go").read_text(encoding="utf-8")
+        assert "linux-amd64" not in text
+        assert "ARG TARGETARCH" in text
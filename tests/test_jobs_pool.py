@@ -0,0 +1,173 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the warm container pool's bookkeeping."""
+
+from __future__ import annotations
+
+import asyncio
+
+import pytest
+
+from orion.security.jobs.config import PoolConfig
+from orion.security.jobs.pool import WarmContainer, WarmPool
+
+
+class _Container:
+    def __init__(self, name: str, reset_error: str = ""):
+        self.container_name = name
+        self.reset_error = reset_error
+        self.stopped = False
+
+    async def reset(self, baseline):
+        return self.reset_error
+
+    async def stop(self):
+        self.stopped = True
+        return True
+
+
+def _starter(started: list[WarmContainer], key: str = "k", fail: bool = False):
+    async def start():
+        if fail:
+            return None
+        warm = WarmContainer(_Container(f"c{len(started)}"), "go", key, set())
+        started.append(warm)
+        return warm
+
+    return start
+
+
+async def _settle(pool: WarmPool) -> None:
+    while pool._tasks:
+        await asyncio.gather(*list(pool._tasks))
+
+
+class TestWarmPool:
+    @pytest.mark.asyncio
+    async def test_fill_to_size(self):
+        pool = WarmPool(PoolConfig(sizes={"go": 2}))
+        started: list[WarmContainer] = []
+        pool.fill("go", "k", _starter(started))
+        pool.fill("go", "k", _starter(started))  # already starting two
+        await _settle(pool)
+        assert len(started) == 2 and pool.idle("go") == 2
+
+    @pytest.mark.asyncio
+    async def test_settle_waits_for_starts(self):
+        pool = WarmPool(PoolConfig(sizes={"go": 2}))
+        started: list[WarmContainer] = []
+        pool.fill("go", "k", _starter(started))
+        await pool.settle()
+        assert pool.idle("go") == 2 and pool.enabled("go")
+
+    @pytest.mark.asyncio
+    async def test_disabled_stack(self):
+        pool = WarmPool(PoolConfig(sizes={"go": 2}))
+        started: list[WarmContainer] = []
+        pool.fill("node", "k", _starter(started))
+        await _settle(pool)
+        assert started == [] and not pool.enabled("node")
+
+    @pytest.mark.asyncio
+    async def test_busy_containers_count_towards_size(self):
+        pool = WarmPool(PoolConfig(sizes={"go": 1}))
+        started: list[WarmContainer] = []
+        pool.fill("go", "k", _starter(started))
+        await _settle(pool)
+        warm = pool.take("k")
+        assert warm is started[0] and pool.take("k") is None
+        pool.fill("go", "k", _starter(started))
+        await _settle(pool)
+        assert len(started) == 1
+
+        pool.give_back(warm)
+        await _settle(pool)
+        assert pool.take("k") is warm and warm.jobs == 2
+
+    @pytest.mark.asyncio
+    async def test_key_must_match(self):
+        pool = WarmPool(PoolConfig(sizes={"go": 1}))
+        started: list[WarmContainer] = []
+        pool.fill("go", "k", _starter(started))
+        await _settle(pool)
+        assert pool.take("other") is None
+
+        # A fill for another key supersedes the stale container
+        pool.fill("go", "other", _starter(started, key="other"))
+        await _settle(pool)
+        assert started[0].container.stopped
+        assert pool.take("other") is started[1]
+
+    @pytest.mark.asyncio
+    async def test_dirty_container_is_stopped_and_released(self):
+        pool = WarmPool(PoolConfig(sizes={"go": 1}))
+        released = []
+        container = _Container("c", reset_error="job changed files outside /workspace")
+        warm = WarmContainer(container, "go", "k", set(), release=lambda: released.append(1))
+        pool._busy["go"] = 1
+        pool.give_back(warm)
+        await _settle(pool)
+        assert container.stopped and released == [1] and pool.idle() == 0
+
+    @pytest.mark.asyncio
+    async def test_failed_start_is_not_counted(self):
+        pool = WarmPool(PoolConfig(sizes={"go": 1}))
+        started: list[WarmContainer] = []
+        pool.fill("go", "k", _starter(started, fail=True))
+        await _settle(pool)
+        pool.fill("go", "k", _starter(started))
+        await _settle(pool)
+        assert len(started) == 1 and pool.idle("go") == 1
+
+    @pytest.mark.asyncio
+    async def test_expiry(self):
+        now = [0.0]
+        pool = WarmPool(PoolConfig(sizes={"go": 1}, max_idle=60), clock=lambda: now[0])
+        started: list[WarmContainer] = []
+        pool.fill("go", "k", _starter(started))
+        await _settle(pool)
+        now[0] = 61
+        assert pool.take("k") is None
+        await _settle(pool)
+        assert started[0].container.stopped
+        await pool.drain()
+
+    @pytest.mark.asyncio
+    async def test_drain(self):
+        pool = WarmPool(PoolConfig(sizes={"go": 2}))
+        started: list[WarmContainer] = []
+        pool.fill("go", "k", _starter(started))
+        await _settle(pool)
+        busy = pool.take("k")
+        await pool.drain()
+        assert all(w.container.stopped for w in started if w is not busy)
+        assert pool.idle() == 0 and not pool.enabled("go")
+
+        # A job finishing after the drain hands back a container that is stopped
+        pool.give_back(busy)
+        await _settle(pool)
+        assert busy.container.stopped
+
+    @pytest.mark.asyncio
+    async def test_discard_hook(self):
+        discarded = []
+
+        async def discard(container):
+            discarded.append(container.container_name)
+            return True
+
+        pool = WarmPool(PoolConfig(sizes={"go": 1}), discard=discard)
+        started: list[WarmContainer] = []
+        pool.fill("go", "k", _starter(started))
+        await _settle(pool)
+        await pool.drain()
+        assert discarded == ["c0"] and not started[0].container.stopped
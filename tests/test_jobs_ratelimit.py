@@ -0,0 +1,111 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the job submission rate limiter."""
+
+from __future__ import annotations
+
+from orion.security.jobs.config import SubmissionsConfig
+from orion.security.jobs.ratelimit import (
+    SubmissionLimiter,
+    client_key,
+    retry_after,
+)
+
+
+class _Clock:
+    def __init__(self) -> None:
+        self.now = 100.0
+
+    def __call__(self) -> float:
+        return self.now
+
+
+def _limiter(rate=2.0, burst=3, per_client=True) -> tuple[SubmissionLimiter, _Clock]:
+    clock = _Clock()
+    config = SubmissionsConfig(rate_per_second=rate, burst=burst, per_client=per_client)
+    return SubmissionLimiter(config, clock=clock), clock
+
+
+class TestSubmissionLimiter:
+    def test_unlimited_by_default(self):
+        limiter = SubmissionLimiter(SubmissionsConfig())
+        assert not limiter.enabled
+        assert all(limiter.acquire("ip:1.2.3.4") == 0 for _ in range(1000))
+
+    def test_burst_then_refill(self):
+        limiter, clock = _limiter(rate=2.0, burst=3)
+        assert [limiter.acquire("a") for _ in range(3)] == [0, 0, 0]
+        assert limiter.acquire("a") == 0.5
+        clock.now += 0.25
+        assert limiter.acquire("a") == 0.25
+        clock.now += 0.25
+        assert limiter.acquire("a") == 0
+
+    def test_refill_capped_at_burst(self):
+        limiter, clock = _limiter(rate=1.0, burst=2)
+        clock.now += 3600
+        assert [limiter.acquire("a") == 0 for _ in range(3)] == [True, True, False]
+
+    def test_per_client(self):
+        limiter, _ = _limiter(burst=1)
+        assert limiter.acquire("a") == 0
+        assert limiter.acquire("a") > 0
+        assert limiter.acquire("b") == 0
+
+    def test_shared_bucket(self):
+        limiter, _ = _limiter(burst=1, per_client=False)
+        assert limiter.acquire("a") == 0
+        assert limiter.acquire("b") > 0
+
+    def test_least_recently_used_dropped(self, monkeypatch):
+        import orion.security.jobs.ratelimit as ratelimit
+
+        monkeypatch.setattr(ratelimit, "_MAX_BUCKETS", 2)
+        limiter, clock = _limiter(rate=1.0, burst=1)
+        limiter.acquire("a")
+        clock.now += 10
+        limiter.acquire("b")
+        limiter.acquire("c")  # 'a' is the least recently used: dropped
+        assert list(limiter._buckets) == ["b", "c"]
+        assert limiter.acquire("b") > 0  # still limited
+        limiter.acquire("d")  # 'b' was just used, so 'c' goes
+        assert list(limiter._buckets) == ["b", "d"]
+
+    def test_rotating_keys_stay_under_the_cap(self, monkeypatch):
+        import orion.security.jobs.ratelimit as ratelimit
+
+        monkeypatch.setattr(ratelimit, "_MAX_BUCKETS", 100)
+        limiter, _ = _limiter(rate=0.001, burst=1)
+        # No bucket ever refills, so none is idle-and-full
+        for n in range(1000):
+            limiter.acquire(f"ip:10.0.{n // 256}.{n % 256}")
+        assert len(limiter._buckets) == 100
+        assert next(iter(limiter._buckets)) == "ip:10.0.3.132"  # the 900th
+
+
+class TestClientKey:
+    def test_authenticated_key_wins_and_is_hashed(self):
+        key = client_key("Bearer s3cret", "10.0.0.1")
+        assert key.startswith("key:") and "s3cret" not in key
+        assert key == client_key("Bearer s3cret", "10.0.0.2")
+        assert key != client_key("Bearer other", "10.0.0.1")
+
+    def test_source_ip(self):
+        assert client_key("", "10.0.0.1") == "ip:10.0.0.1"
+        assert client_key() == "ip:unknown"
+
+
+class TestRetryAfter:
+    def test_whole_seconds(self):
+        assert retry_after(0.01) == "1"
+        assert retry_after(1.2) == "2"
+        assert retry_after(3.0) == "3"
@@ -0,0 +1,137 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for tracking job containers and reaping leftover ones."""
+
+from __future__ import annotations
+
+import subprocess
+
+import pytest
+
+from orion.security.jobs.reaper import (
+    JOB_LABEL,
+    ContainerTracker,
+    job_labels,
+    pool_labels,
+    reap_orphans,
+)
+
+
+class _Container:
+    def __init__(self, name: str, stop_ok: bool = True, remove_ok: bool = True):
+        self.container_name = name
+        self.stop_ok = stop_ok
+        self.remove_ok = remove_ok
+        self.calls: list[str] = []
+
+    async def stop(self):
+        self.calls.append("stop")
+        if self.stop_ok is None:
+            raise RuntimeError("engine went away")
+        return self.stop_ok
+
+    async def remove(self):
+        self.calls.append("remove")
+        return self.remove_ok
+
+
+class _Runtime:
+    def __init__(self, names: str = "", list_code: int = 0, refuse: tuple[str, ...] = ()):
+        self.names = names
+        self.list_code = list_code
+        self.refuse = refuse
+        self.removed: list[str] = []
+        self.labels: list[str] = []
+
+    async def list_containers(self, label):
+        self.labels.append(label)
+        return subprocess.CompletedProcess([], self.list_code, self.names, "permission denied")
+
+    async def remove(self, name):
+        if name in self.refuse:
+            return subprocess.CompletedProcess([], 1, "", "device or resource busy")
+        self.removed.append(name)
+        return subprocess.CompletedProcess([], 0, "", "")
+
+
+class TestLabels:
+    def test_job_and_pool(self):
+        assert job_labels("j1") == {JOB_LABEL: "j1"}
+        assert pool_labels("go") == {JOB_LABEL: "", "orion.pool": "go"}
+
+
+class TestContainerTracker:
+    @pytest.mark.asyncio
+    async def test_stopped_container_is_untracked(self):
+        tracker = ContainerTracker()
+        container = _Container("c1")
+        tracker.track(container)
+        assert "c1" in tracker and len(tracker) == 1
+        assert await tracker.discard(container)
+        assert container.calls == ["stop"] and len(tracker) == 0
+
+    @pytest.mark.asyncio
+    @pytest.mark.parametrize("stop_ok", [False, None])
+    async def test_failed_stop_is_forced(self, stop_ok):
+        tracker = ContainerTracker()
+        container = _Container("c1", stop_ok=stop_ok)
+        tracker.track(container)
+        assert await tracker.discard(container)
+        assert container.calls == ["stop", "remove"] and "c1" not in tracker
+
+    @pytest.mark.asyncio
+    async def test_unremovable_stays_tracked(self):
+        tracker = ContainerTracker()
+        stuck = _Container("stuck", stop_ok=False, remove_ok=False)
+        tracker.track(stuck)
+        tracker.track(_Container("ok"))
+        assert not await tracker.discard(stuck)
+        assert "stuck" in tracker
+
+        assert await tracker.discard_all() == 1
+        assert len(tracker) == 1
+
+
+class TestReapOrphans:
+    @pytest.mark.asyncio
+    async def test_removes_labelled_containers(self):
+        runtime = _Runtime("orion-session-job-a\norion-session-pool-b\n")
+        assert await reap_orphans(runtime) == 2
+        assert runtime.labels == [JOB_LABEL]
+        assert runtime.removed == ["orion-session-job-a", "orion-session-pool-b"]
+
+    @pytest.mark.asyncio
+    async def test_keeps_tracked_containers(self):
+        tracker = ContainerTracker()
+        tracker.track(_Container("orion-session-job-live"))
+        runtime = _Runtime("orion-session-job-live\norion-session-job-old\n")
+        assert await reap_orphans(runtime, keep=tracker) == 1
+        assert runtime.removed == ["orion-session-job-old"]
+
+    @pytest.mark.asyncio
+    async def test_counts_only_removed(self):
+        runtime = _Runtime("a\nb\n", refuse=("a",))
+        assert await reap_orphans(runtime) == 1
+
+    @pytest.mark.asyncio
+    async def test_listing_fails(self):
+        runtime = _Runtime("a\n", list_code=1)
+        assert await reap_orphans(runtime) == 0
+        assert runtime.removed == []
+
+    @pytest.mark.asyncio
+    async def test_engine_unreachable(self):
+        class Gone(_Runtime):
+            async def list_containers(self, label):
+                raise FileNotFoundError("docker")
+
+        assert await reap_orphans(Gone()) == 0
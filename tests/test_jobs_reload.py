@@ -0,0 +1,141 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for reloading the jobs config of a running agent."""
+
+from __future__ import annotations
+
+import logging
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.config import JobsConfig, SubmissionsConfig
+from orion.security.jobs.executor import JobExecutor
+from orion.security.jobs.ratelimit import SubmissionLimiter
+from orion.security.jobs.reload import reload_config
+
+
+class _Recorder(logging.Handler):
+    def __init__(self):
+        super().__init__()
+        self.records: list[logging.LogRecord] = []
+
+    def emit(self, record: logging.LogRecord) -> None:
+        self.records.append(record)
+
+    def messages(self, level: int) -> list[str]:
+        return [r.getMessage() for r in self.records if r.levelno == level]
+
+
+@pytest.fixture
+def recorder():
+    handler = _Recorder()
+    logger = logging.getLogger("orion.security.jobs.reload")
+    level = logger.level
+    logger.addHandler(handler)
+    logger.setLevel(logging.INFO)
+    yield handler
+    logger.removeHandler(handler)
+    logger.setLevel(level)
+
+
+@pytest.fixture
+def configured(monkeypatch):
+    calls = []
+    monkeypatch.setattr("orion.security.jobs.reload.configure_logging", calls.append)
+    return calls
+
+
+def _executor(tmp_path: Path) -> JobExecutor:
+    return JobExecutor(jobs_dir=tmp_path / "jobs", container_factory=object)
+
+
+def _write(tmp_path: Path, text: str) -> Path:
+    path = tmp_path / "jobs_config.yaml"
+    path.write_text(text)
+    return path
+
+
+class TestReload:
+    def test_live_settings_applied(self, tmp_path: Path, recorder, configured):
+        executor = _executor(tmp_path)
+        limiter = SubmissionLimiter(executor.config.submissions)
+        path = _write(
+            tmp_path,
+            "scheduler:\n  maxConcurrent: 8\n"
+            "submissions:\n  ratePerSecond: 2\n  burst: 3\n"
+            "log:\n  level: DEBUG\n"
+            "images:\n  pullPolicy: Always\n"
+            "resources:\n  defaultCpu: 2\n",
+        )
+
+        changes = reload_config(executor, path, limiter)
+
+        assert {c.key for c in changes} == {
+            "scheduler.maxConcurrent",
+            "submissions.ratePerSecond",
+            "submissions.burst",
+            "log.level",
+            "images.pullPolicy",
+            "resources.defaultCpu",
+        }
+        assert executor.stats()["max_concurrent"] == 8
+        assert executor.config.images.pull_policy == "Always"
+        assert executor.config.resources.default_cpu == 2
+        assert limiter.config == SubmissionsConfig(rate_per_second=2, burst=3)
+        assert [log.level for log in configured] == ["DEBUG"]
+        [summary] = recorder.messages(logging.INFO)
+        assert summary.startswith("Jobs config reloaded: ")
+        assert "scheduler.maxConcurrent 4 -> 8" in summary
+        assert "log.level 'INFO' -> 'DEBUG'" in summary
+
+    def test_restart_settings_logged_and_kept(self, tmp_path: Path, recorder, configured):
+        executor = _executor(tmp_path)
+        path = _write(tmp_path, "runtime:\n  driver: podman\nhistory:\n  maxJobs: 5\n")
+
+        changes = reload_config(executor, path)
+
+        assert {c.key: c.live for c in changes} == {
+            "runtime.driver": False,
+            "history.maxJobs": True,
+        }
+        assert executor.config.runtime.driver == "docker"
+        assert executor.config.history.max_jobs == 5
+        [warning] = recorder.messages(logging.WARNING)
+        assert "runtime.driver" in warning and "requires restart" in warning
+        assert "history.maxJobs 1000 -> 5" in recorder.messages(logging.INFO)[0]
+        assert configured == []  # log settings unchanged
+
+    def test_invalid_file_rejected_wholesale(self, tmp_path: Path, recorder, configured):
+        executor = _executor(tmp_path)
+        before = executor.config
+        # The first section is valid, but the whole file is refused
+        path = _write(tmp_path, "scheduler:\n  maxConcurrent: 8\nretry:\n  maxAttempts: 0\n")
+
+        assert reload_config(executor, path) is None
+
+        assert executor.config is before
+        assert executor.config == JobsConfig()
+        assert executor.stats()["max_concurrent"] == 4
+        [error] = recorder.messages(logging.ERROR)
+        assert "rejected, keeping the current config" in error
+        assert "retry.maxAttempts" in error
+
+    def test_missing_file_rejected(self, tmp_path: Path, recorder, configured):
+        executor = _executor(tmp_path)
+        assert reload_config(executor, tmp_path / "gone.yaml") is None
+        assert executor.config == JobsConfig()
+
+    def test_unchanged_file(self, tmp_path: Path, recorder, configured):
+        executor = _executor(tmp_path)
+        assert reload_config(executor, _write(tmp_path, "{}\n")) == []
+        assert recorder.messages(logging.INFO) == ["Jobs config reloaded: no live settings changed"]
@@ -0,0 +1,119 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for merging a repository's .orion-agent.yaml under a manifest."""
+
+from __future__ import annotations
+
+import pytest
+
+from orion.security.jobs.manifest import JobSource, parse_manifest
+from orion.security.jobs.repo_defaults import (
+    DEFAULTS_ABSENT,
+    RepoDefaultsError,
+    lookup_script,
+    merge_repo_defaults,
+)
+
+GIT = "source: {git: https://github.com/acme/api.git}\n"
+
+
+def _merge(submitted: str, repo: str):
+    return merge_repo_defaults(parse_manifest(submitted + GIT), repo)
+
+
+class TestMerge:
+    def test_repo_fills_what_the_manifest_leaves_out(self):
+        m, taken = _merge("command: make\n", "stack: go\ntoolchain: '1.22.5'\nworkdir: svc\n")
+        assert (m.stack, m.toolchain, m.command) == ("go", "1.22.5", "make")
+        assert m.workdir == "/workspace/svc"
+        assert taken == ["stack", "toolchain", "workdir"]
+
+    def test_manifest_scalars_win(self):
+        m, taken = _merge("stack: python\ntimeout: 5m\n", "stack: go\ntimeout: 1h\n")
+        assert m.stack == "python" and m.timeout == 300
+        assert taken == []
+
+    def test_maps_merge_key_by_key(self):
+        m, taken = _merge(
+            "stack: go\nenv: {LOG: debug}\nresources: {memory: 1024}\n",
+            "env: {LOG: info, CGO_ENABLED: '0'}\nresources: {cpu: 2, memory: 4096}\n",
+        )
+        assert m.env == {"LOG": "debug", "CGO_ENABLED": "0"}
+        assert m.resources.cpu == 2 and m.resources.memory == 1024
+        assert taken == ["env", "resources"]
+
+    def test_lists_are_replaced_not_concatenated(self):
+        m, _ = _merge("stack: go\nartifacts: [out/a]\n", "artifacts: [dist/*, out/b]\n")
+        assert m.artifacts == ["out/a"]
+
+    def test_command_replaces_repo_steps(self):
+        repo = "steps: [{command: make}, {command: make test}]\n"
+        m, taken = _merge("stack: go\ncommand: go vet\n", repo)
+        assert m.command == "go vet" and m.steps == []
+        assert taken == []
+
+        m, taken = _merge("stack: go\n", repo)
+        assert [s.command for s in m.steps] == ["make", "make test"]
+        assert taken == ["steps"]
+
+    def test_steps_replace_repo_command(self):
+        m, _ = _merge("stack: go\nsteps: [{command: a}]\n", "command: b\n")
+        assert m.command == "" and [s.command for s in m.steps] == ["a"]
+
+    def test_empty_file(self):
+        m, taken = _merge("stack: go\n", "# nothing yet\n")
+        assert m.stack == "go" and taken == []
+
+    def test_source_and_network_are_kept(self):
+        m, _ = _merge("stack: go\nnetwork: bridge\n", "command: make\n")
+        assert m.source.git == "https://github.com/acme/api.git"
+        assert m.network == "bridge"
+
+
+class TestErrors:
+    def test_invalid_yaml_has_a_line(self):
+        with pytest.raises(RepoDefaultsError, match=r"\.orion-agent\.yaml line 3: invalid YAML"):
+            _merge("stack: go\n", "stack: go\nenv:\n  A: b: c\n")
+
+    def test_not_a_mapping(self):
+        with pytest.raises(RepoDefaultsError, match="must be a mapping"):
+            _merge("stack: go\n", "- make\n")
+
+    @pytest.mark.parametrize(
+        "key", ["secrets", "mounts", "envFiles", "runAs", "network", "source", "callbackUrl"]
+    )
+    def test_submitter_only_keys_are_refused(self, key: str):
+        with pytest.raises(RepoDefaultsError, match=f"line 2: '{key}' cannot be set"):
+            _merge("stack: go\n", f"command: make\n{key}: x\n")
+
+    def test_bad_value_points_at_the_file(self):
+        with pytest.raises(RepoDefaultsError, match=r"\.orion-agent\.yaml line 2: .*timeout"):
+            _merge("stack: go\n", "command: make\ntimeout: soon\n")
+
+
+class TestLookupScript:
+    def test_ref(self):
+        script = lookup_script(JobSource(git="https://github.com/acme/api.git", ref="v1.2"))
+        assert "--filter=blob:none origin v1.2" in script
+        assert f"FETCH_HEAD:.orion-agent.yaml 2>/dev/null || exit {DEFAULTS_ABSENT}" in script
+        assert script.endswith("git show FETCH_HEAD:.orion-agent.yaml")
+
+    def test_commit(self):
+        sha = "0123456789abcdef0123456789abcdef01234567"
+        script = lookup_script(JobSource(git="https://github.com/acme/api.git", commit=sha))
+        assert f"origin {sha} 2>/dev/null || git fetch -q origin" in script
+        assert script.endswith(f"git show {sha}:.orion-agent.yaml")
+
+    def test_url_is_quoted(self):
+        script = lookup_script(JobSource(git="https://github.com/acme/a b.git"))
+        assert "git remote add origin 'https://github.com/acme/a b.git'" in script
+        assert "origin HEAD" in script
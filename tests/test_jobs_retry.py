@@ -0,0 +1,123 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for retrying transient pull / container start failures."""
+
+from __future__ import annotations
+
+import pytest
+
+from orion.security.jobs.config import RetryConfig
+from orion.security.jobs.retry import backoff_delay, is_transient, retry_transient
+
+
+class TestIsTransient:
+    @pytest.mark.parametrize(
+        "output",
+        [
+            "Error response from daemon: received unexpected HTTP status: 503 Service Unavailable",
+            "error pulling image: 502 Bad Gateway",
+            "toomanyrequests: You have reached your pull rate limit",
+            "read tcp 10.0.0.2:443: read: connection reset by peer",
+            "net/http: TLS handshake timeout",
+        ],
+    )
+    def test_transient(self, output):
+        assert is_transient(output)
+
+    @pytest.mark.parametrize(
+        "output",
+        [
+            "manifest unknown: manifest unknown",
+            "Error response from daemon: pull access denied for acme/stack",
+            "invalid reference format",
+            "Error: No such image: orion-stack-go:latest",
+            "something nobody has seen before",
+            "",
+        ],
+    )
+    def test_permanent(self, output):
+        assert not is_transient(output)
+
+    def test_permanent_wins_over_transient(self):
+        assert not is_transient("503 Service Unavailable: manifest unknown")
+
+
+class TestBackoffDelay:
+    def test_doubles_up_to_the_cap(self):
+        config = RetryConfig(initial_backoff=1.0, max_backoff=5.0)
+        assert [backoff_delay(n, config) for n in range(5)] == [1.0, 2.0, 4.0, 5.0, 5.0]
+
+
+class TestRetryTransient:
+    @staticmethod
+    def _attempts(*errors):
+        remaining = list(errors)
+        calls = []
+
+        async def attempt():
+            calls.append(1)
+            return remaining.pop(0) if remaining else None
+
+        return attempt, calls
+
+    @staticmethod
+    def _sleeper(delays):
+        async def sleep(seconds):
+            delays.append(seconds)
+
+        return sleep
+
+    @pytest.mark.asyncio
+    async def test_success_first_time(self):
+        attempt, calls = self._attempts()
+        result, retries = await retry_transient(attempt, lambda r: r, "Pull", RetryConfig())
+        assert (result, retries, len(calls)) == (None, 0, 1)
+
+    @pytest.mark.asyncio
+    async def test_retries_transient_errors_with_backoff(self):
+        attempt, calls = self._attempts("503 Service Unavailable", "connection reset by peer")
+        delays = []
+        result, retries = await retry_transient(
+            attempt, lambda r: r, "Pull", RetryConfig(initial_backoff=2.0), self._sleeper(delays)
+        )
+        assert result is None
+        assert retries == 2
+        assert len(calls) == 3
+        assert delays == [2.0, 4.0]
+
+    @pytest.mark.asyncio
+    async def test_permanent_error_is_not_retried(self):
+        attempt, calls = self._attempts("manifest unknown")
+        delays = []
+        result, retries = await retry_transient(
+            attempt, lambda r: r, "Pull", RetryConfig(), self._sleeper(delays)
+        )
+        assert result == "manifest unknown"
+        assert (retries, len(calls), delays) == (0, 1, [])
+
+    @pytest.mark.asyncio
+    async def test_gives_up_after_max_attempts(self):
+        attempt, calls = self._attempts(*["503 Service Unavailable"] * 5)
+        delays = []
+        result, retries = await retry_transient(
+            attempt, lambda r: r, "Pull", RetryConfig(max_attempts=3), self._sleeper(delays)
+        )
+        assert result == "503 Service Unavailable"
+        assert (retries, len(calls), len(delays)) == (2, 3, 2)
+
+    @pytest.mark.asyncio
+    async def test_single_attempt_disables_retries(self):
+        attempt, calls = self._attempts("503 Service Unavailable")
+        _, retries = await retry_transient(
+            attempt, lambda r: r, "Pull", RetryConfig(max_attempts=1), self._sleeper([])
+        )
+        assert (retries, len(calls)) == (0, 1)
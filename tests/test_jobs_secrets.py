@@ -0,0 +1,83 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for job secret resolution and redaction."""
+
+from __future__ import annotations
+
+import pytest
+
+from orion.security.jobs.config import SecretsConfig
+from orion.security.jobs.secrets import (
+    DictSecretSource,
+    EnvSecretSource,
+    Redactor,
+    SecretError,
+    StoreSecretSource,
+    make_secret_source,
+    resolve_secrets,
+    validate_secret_refs,
+)
+
+
+class _FakeStore:
+    def __init__(self, values):
+        self.values = values
+
+    def get_key(self, provider):
+        return self.values.get(provider)
+
+
+class TestSources:
+    def test_store_uses_prefixed_provider(self):
+        source = StoreSecretSource(_FakeStore({"job-secret:npm": "tok"}))
+        assert source.get("npm") == "tok"
+        assert source.get("other") is None
+
+    def test_env_source(self):
+        source = EnvSecretSource("ORION_SECRET_", {"ORION_SECRET_NPM_TOKEN": "tok"})
+        assert source.get("npm-token") == "tok"
+        assert source.has("missing") is False
+
+    def test_factory(self):
+        assert isinstance(make_secret_source(SecretsConfig(source="env")), EnvSecretSource)
+        assert isinstance(make_secret_source(SecretsConfig()), StoreSecretSource)
+
+
+class TestResolve:
+    def test_maps_env_names_to_values(self):
+        source = DictSecretSource({"npm": "tok", "ghcr": "pw"})
+        env = resolve_secrets({"NPM_TOKEN": "npm", "REG_PW": "ghcr"}, source)
+        assert env == {"NPM_TOKEN": "tok", "REG_PW": "pw"}
+
+    def test_lists_all_missing(self):
+        with pytest.raises(SecretError, match="a, b"):
+            resolve_secrets({"A": "a", "B": "b"}, DictSecretSource({}))
+
+    def test_validate_refs(self):
+        assert validate_secret_refs({"NPM_TOKEN": "npm-token"}) == []
+        problems = validate_secret_refs({"1BAD": "ok", "OK": "has space"})
+        assert len(problems) == 2
+
+
+class TestRedactor:
+    def test_substring_replaced(self):
+        r = Redactor(["s3cr3t"])
+        assert r.redact("token=s3cr3t; again s3cr3t") == "token=***; again ***"
+
+    def test_longest_first(self):
+        r = Redactor(["abc", "abcdef"])
+        assert r.redact("xabcdefx") == "x***x"
+
+    def test_empty_values_ignored(self):
+        r = Redactor([""])
+        assert not r
+        assert r.redact("text") == "text"
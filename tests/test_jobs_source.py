@@ -0,0 +1,136 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for job source checkout (clone scripts, error classes, bind checks)."""
+
+from __future__ import annotations
+
+import os
+import subprocess
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.manifest import JobSource
+from orion.security.jobs.source import (
+    AUTH,
+    CHECKOUT,
+    NETWORK,
+    SourceError,
+    classify_clone_error,
+    clone_error,
+    clone_script,
+    resolve_host_path,
+)
+
+
+class TestCloneScript:
+    def test_ref_with_depth(self):
+        script = clone_script(
+            JobSource(git="https://example.com/r.git", ref="v1.2", depth=5, path="src")
+        )
+        assert "mkdir -p /workspace/src" in script
+        assert "git fetch -q --depth 5 origin v1.2" in script
+        assert script.endswith("git checkout -q --detach FETCH_HEAD")
+
+    def test_default_branch(self):
+        assert "git fetch -q origin HEAD" in clone_script(JobSource(git="https://e.com/r.git"))
+
+    def test_commit_falls_back_to_ref(self):
+        script = clone_script(JobSource(git="https://e.com/r.git", ref="main", commit="abc1234"))
+        assert "git fetch -q origin abc1234 2>/dev/null || git fetch -q origin main" in script
+        assert script.endswith("git checkout -q --detach abc1234")
+
+    def test_url_is_quoted(self):
+        script = clone_script(JobSource(git="https://e.com/r.git; rm -rf /"))
+        assert "'https://e.com/r.git; rm -rf /'" in script
+
+    def test_clones_a_local_repository(self, tmp_path: Path):
+        """The script is a real checkout (run on the host against a local repo)."""
+        origin = tmp_path / "origin"
+        origin.mkdir()
+
+        def git(*args, cwd=origin):
+            return subprocess.run(["git", *args], cwd=cwd, check=True, capture_output=True)
+
+        try:
+            git("init", "-q", "-b", "main")
+        except (OSError, subprocess.CalledProcessError):
+            pytest.skip("git not available")
+        (origin / "a.txt").write_text("one")
+        git("add", "a.txt")
+        git("-c", "user.name=t", "-c", "user.email=t@e", "commit", "-qm", "one")
+        first = git("rev-parse", "HEAD").stdout.decode().strip()
+        (origin / "a.txt").write_text("two")
+        git("-c", "user.name=t", "-c", "user.email=t@e", "commit", "-qam", "two")
+
+        source = JobSource(git=str(origin), commit=first, path="checkout")
+        script = clone_script(source).replace("/workspace", str(tmp_path / "ws"))
+        subprocess.run(["sh", "-c", script], check=True, capture_output=True)
+        assert (tmp_path / "ws" / "checkout" / "a.txt").read_text() == "one"
+
+
+class TestClassify:
+    @pytest.mark.parametrize(
+        "output, kind",
+        [
+            ("fatal: Authentication failed for 'https://github.com/a/b.git/'", AUTH),
+            ("fatal: could not read Username for 'https://github.com'", AUTH),
+            ("git@github.com: Permission denied (publickey).", AUTH),
+            ("remote: Repository not found.", AUTH),
+            ("fatal: unable to access 'x': The requested URL returned error: 403", AUTH),
+            ("fatal: unable to access 'x': Could not resolve host: github.com", NETWORK),
+            ("fatal: unable to access 'x': Failed to connect to github.com port 443", NETWORK),
+            ("Command timed out after 600s", NETWORK),
+            ("fatal: couldn't find remote ref nope", CHECKOUT),
+            ("fatal: reference is not a tree: abc1234", CHECKOUT),
+        ],
+    )
+    def test_kinds(self, output: str, kind: str):
+        assert classify_clone_error(output) == kind
+
+    def test_error_messages(self):
+        source = JobSource(git="https://e.com/r.git", ref="main")
+        assert "denied" in str(clone_error(source, "fatal: Authentication failed"))
+        assert "Could not reach" in str(clone_error(source, "Could not resolve host: e.com"))
+        error = clone_error(source, "fatal: couldn't find remote ref main")
+        assert error.kind == CHECKOUT
+        assert "Could not check out main" in str(error)
+
+
+class TestResolveHostPath:
+    def test_allowed(self, tmp_path: Path):
+        repo = tmp_path / "checkouts" / "api"
+        repo.mkdir(parents=True)
+        source = JobSource(host_path=str(repo))
+        assert resolve_host_path(source, [str(tmp_path / "checkouts")]) == os.path.realpath(repo)
+
+    def test_none_allowed_by_default(self, tmp_path: Path):
+        with pytest.raises(SourceError, match="allowed"):
+            resolve_host_path(JobSource(host_path=str(tmp_path)), [])
+
+    def test_sibling_prefix_not_allowed(self, tmp_path: Path):
+        (tmp_path / "checkouts-evil").mkdir()
+        source = JobSource(host_path=str(tmp_path / "checkouts-evil"))
+        with pytest.raises(SourceError):
+            resolve_host_path(source, [str(tmp_path / "checkouts")])
+
+    def test_symlink_escape(self, tmp_path: Path):
+        allowed = tmp_path / "checkouts"
+        allowed.mkdir()
+        (allowed / "etc").symlink_to("/etc")
+        with pytest.raises(SourceError, match="allowed"):
+            resolve_host_path(JobSource(host_path=str(allowed / "etc")), [str(allowed)])
+
+    def test_missing_directory(self, tmp_path: Path):
+        source = JobSource(host_path=str(tmp_path / "gone"))
+        with pytest.raises(SourceError, match="not a directory"):
+            resolve_host_path(source, [str(tmp_path)])
@@ -0,0 +1,104 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for the job history stores."""
+
+from __future__ import annotations
+
+from pathlib import Path
+
+import pytest
+
+from orion.security.jobs.config import StoreConfig
+from orion.security.jobs.store import (
+    JobStoreError,
+    MemoryJobStore,
+    SQLiteJobStore,
+    make_job_store,
+)
+
+
+def _record(job_id: str, status: str = "succeeded") -> dict:
+    return {"job_id": job_id, "seq": 1, "result": {"status": status, "stdout": "ok\n"}}
+
+
+def _open(backend: str, tmp_path: Path):
+    if backend == "memory":
+        return MemoryJobStore()
+    return SQLiteJobStore(tmp_path / "state" / "jobs.db")
+
+
+_BACKENDS = pytest.mark.parametrize("backend", ["memory", "sqlite"])
+
+
+class TestStores:
+    @_BACKENDS
+    def test_save_replaces_by_id(self, backend: str, tmp_path: Path):
+        store = _open(backend, tmp_path)
+        store.save(_record("a", "running"))
+        store.save(_record("b"))
+        store.save(_record("a", "failed"))
+        records = sorted(store.load(), key=lambda r: r["job_id"])
+        assert records == [_record("a", "failed"), _record("b")]
+
+    @_BACKENDS
+    def test_delete(self, backend: str, tmp_path: Path):
+        store = _open(backend, tmp_path)
+        store.save(_record("a"))
+        store.save(_record("b"))
+        store.delete(["a", "unknown"])
+        assert [r["job_id"] for r in store.load()] == ["b"]
+
+    @_BACKENDS
+    def test_load_is_a_copy(self, backend: str, tmp_path: Path):
+        store = _open(backend, tmp_path)
+        record = _record("a")
+        store.save(record)
+        record["result"]["status"] = "changed"
+        store.load()[0]["result"]["status"] = "changed"
+        assert store.load()[0]["result"]["status"] == "succeeded"
+
+
+class TestSQLiteJobStore:
+    def test_survives_reopening(self, tmp_path: Path):
+        path = tmp_path / "jobs.db"
+        store = SQLiteJobStore(path)
+        store.save(_record("a"))
+        store.close()
+        assert SQLiteJobStore(path).load() == [_record("a")]
+
+    def test_unreadable_record_skipped(self, tmp_path: Path):
+        store = SQLiteJobStore(tmp_path / "jobs.db")
+        store.save(_record("a"))
+        with store._db:
+            store._db.execute("INSERT INTO jobs VALUES ('b', 'not json')")
+        assert [r["job_id"] for r in store.load()] == ["a"]
+
+    def test_cannot_open(self, tmp_path: Path):
+        (tmp_path / "jobs.db").mkdir()
+        with pytest.raises(JobStoreError, match="Cannot open job store"):
+            SQLiteJobStore(tmp_path / "jobs.db")
+
+    def test_write_after_close(self, tmp_path: Path):
+        store = SQLiteJobStore(tmp_path / "jobs.db")
+        store.close()
+        with pytest.raises(JobStoreError, match="Cannot write job store"):
+            store.save(_record("a"))
+
+
+class TestMakeJobStore:
+    def test_memory_only_by_default(self):
+        assert make_job_store(StoreConfig()) is None
+
+    def test_sqlite(self, tmp_path: Path):
+        store = make_job_store(StoreConfig(backend="sqlite", path=str(tmp_path / "jobs.db")))
+        assert isinstance(store, SQLiteJobStore)
+        assert store.path == tmp_path / "jobs.db"
@@ -0,0 +1,149 @@
+# Orion Agent
+# Copyright (C) 2025 Phoenix Link (Pty) Ltd. All Rights Reserved.
+#
+# This file is part of Orion Agent.
+#
+# Orion Agent is dual-licensed:
+#
+# 1. Open Source: GNU Affero General Public License v3.0 (AGPL-3.0)
+# 2. Commercial: Available from Phoenix Link (Pty) Ltd
+#
+# Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
+"""Tests for per-job toolchain planning."""
+
+from __future__ import annotations
+
+import pytest
+
+from orion.security.jobs.manifest import ManifestError, parse_manifest
+from orion.security.jobs.toolchains import (
+    TOOLCHAINS_DIR,
+    ToolchainError,
+    plan_toolchain,
+    supports_toolchain,
+)
+
+
+class TestGoPlan:
+    def test_version_normalised(self):
+        assert plan_toolchain("go", "1.21.13").version == "go1.21.13"
+        assert plan_toolchain("go", "go1.23.0").version == "go1.23.0"
+
+    def test_install_dir_under_gopath_parent(self):
+        plan = plan_toolchain("go", "1.21.13")
+        # GOPATH=/home/orion/go, so $GOPATH/../toolchains/<version>
+        assert plan.install_dir == f"{TOOLCHAINS_DIR}/go1.21.13"
+        assert TOOLCHAINS_DIR == "/home/orion/toolchains"
+
+    def test_download_uses_container_arch(self):
+        plan = plan_toolchain("go", "1.21.13")
+        assert "dpkg --print-architecture" in plan.install_script
+
+    def test_activation_disables_auto_toolchain(self):
+        assert "GOTOOLCHAIN=local" in plan_toolchain("go", "1.21.13").activate_prefix
+
+    def test_invalid_version(self):
+        with pytest.raises(ToolchainError):
+            plan_toolchain("go", "1.21; rm -rf /")
+
+    def test_unsupported_stack(self):
+        assert supports_toolchain("go") is True
+        assert supports_toolchain("base") is False
+        with pytest.raises(ToolchainError):
+            plan_toolchain("base", "1.0")
+
+
+class TestNodePlan:
+    @pytest.mark.parametrize("requested", ["18", "20", "22", "v22"])
+    def test_major_lines(self, requested):
+        plan = plan_toolchain("node", requested)
+        major = requested.lstrip("v")
+        assert plan.version == f"v{major}"
+        assert plan.install_dir == f"{TOOLCHAINS_DIR}/node-v{major}"
+        assert f"latest-v{major}.x" in plan.install_script
+        assert f"v{major}.*)" in plan.probe_script
+
+    def test_exact_release(self):
+        plan = plan_toolchain("node", "20.11.1")
+        assert plan.version == "v20.11.1"
+        assert "https://nodejs.org/dist/v20.11.1" in plan.install_script
+
+    def test_arch_mapped_to_node_naming(self):
+        assert "arch=x64" in plan_toolchain("node", "18").install_script
+
+    def test_activation_puts_node_first_on_path(self):
+        plan = plan_toolchain("node", "18")
+        assert plan.activate_prefix.startswith(f"export PATH={TOOLCHAINS_DIR}/node-v18/bin:")
+
+    def test_unsupported_major(self):
+        with pytest.raises(ToolchainError, match="not supported"):
+            plan_toolchain("node", "16")
+
+    def test_invalid_version(self):
+        with pytest.raises(ToolchainError):
+            plan_toolchain("node", "lts/*")
+
+
+class TestRustPlan:
+    @pytest.mark.parametrize("requested", ["1.75.0", "1.75"])
+    def test_release_or_minor_line(self, requested):
+        plan = plan_toolchain("rust", requested)
+        assert plan.version == requested
+        assert plan.install_dir == f"{TOOLCHAINS_DIR}/rust-{requested}"
+        assert f"rustup toolchain install {requested} " in plan.install_script
+        assert f"rustup default {requested}" in plan.install_script
+
+    def test_baked_match(self):
+        assert '"rustc 1.75.0 "*)' in plan_toolchain("rust", "1.75.0").probe_script
+        assert '"rustc 1.75."*)' in plan_toolchain("rust", "1.75").probe_script
+
+    def test_activation_switches_rustup_home_only_when_downloaded(self):
+        plan = plan_toolchain("rust", "1.75.0")
+        assert plan.activate_prefix == (
+            f"if [ -d {TOOLCHAINS_DIR}/rust-1.75.0 ]; then "
+            f"export RUSTUP_HOME={TOOLCHAINS_DIR}/rust-1.75.0; fi; "
+        )
+
+    @pytest.mark.parametrize("requested", ["stable", "nightly", "1", "2.0.0", "1.75; rm -rf /"])
+    def test_invalid_version(self, requested):
+        with pytest.raises(ToolchainError, match="Invalid Rust toolchain"):
+            plan_toolchain("rust", requested)
+
+
+class TestJavaPlan:
+    @pytest.mark.parametrize("requested", ["17", "jdk-17", "jdk17"])
+    def test_major(self, requested):
+        plan = plan_toolchain("java", requested)
+        assert plan.version == "17"
+        assert plan.install_dir == f"{TOOLCHAINS_DIR}/jdk-17"
+        assert "https://api.adoptium.net/v3/binary/latest/17/ga/linux/$arch" in plan.install_script
+        assert "arm64) arch=aarch64" in plan.install_script
+
+    def test_baked_match_reads_release_file(self):
+        probe = plan_toolchain("java", "21").probe_script
+        assert """grep -q '^JAVA_VERSION="21[."]' "$JAVA_HOME/release\"""" in probe
+
+    def test_activation_sets_java_home_only_when_downloaded(self):
+        plan = plan_toolchain("java", "17")
+        assert plan.activate_prefix == (
+            f"if [ -d {TOOLCHAINS_DIR}/jdk-17 ]; then export JAVA_HOME={TOOLCHAINS_DIR}/jdk-17 "
+            f'PATH={TOOLCHAINS_DIR}/jdk-17/bin:"$PATH"; fi; '
+        )
+
+    def test_unsupported_major(self):
+        with pytest.raises(ToolchainError, match="JDK 11 is not supported"):
+            plan_toolchain("java", "11")
+
+    @pytest.mark.parametrize("requested", ["21.0.4", "latest", "17; id"])
+    def test_invalid_version(self, requested):
+        with pytest.raises(ToolchainError, match="Invalid Java toolchain"):
+            plan_toolchain("java", requested)
+
+
+class TestManifestToolchain:
+    def test_quoted_string(self):
+        assert parse_manifest('stack: go\ntoolchain: "1.20"\n').toolchain == "1.20"
+
+    def test_unquoted_float_rejected(self):
+        with pytest.raises(ManifestError, match="quoted"):
+            parse_manifest("stack: go\ntoolchain: 1.20\n")
@@ -77,7 +77,7 @@ class TestActivityLoggerSessionContainer:
         sc._running = True
 
         # Mock the docker command to succeed
-        with patch.object(sc, "_run_docker", new_callable=AsyncMock) as mock_docker:
+        with patch.object(sc.runtime, "_run", new_callable=AsyncMock) as mock_docker:
             mock_docker.return_value = MagicMock(stdout="hello world", stderr="", returncode=0)
             result = await sc.exec("echo hello")
 
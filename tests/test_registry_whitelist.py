@@ -64,6 +64,12 @@ class TestPerStackRegistries:
         assert "crates.io" in domains
         assert "static.crates.io" in domains
 
+    def test_java_registries(self):
+        domains = get_registry_domains("java")
+        assert "repo.maven.apache.org" in domains
+        assert "plugins.gradle.org" in domains
+        assert "api.adoptium.net" in domains
+
     def test_base_only_common(self):
         domains = get_registry_domains("base")
         assert set(domains) == set(COMMON_REGISTRY_DOMAINS)
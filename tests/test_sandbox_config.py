@@ -11,7 +11,7 @@
 # Contributions require a signed CLA. See COPYRIGHT.md and CLA.md.
 """Tests for configurable resource profiles (Phase 4B.3).
 
-Tests SC-01 through SC-08 validating profile resolution, user overrides,
+Tests SC-01 through SC-09 validating profile resolution, user overrides,
 validation, and Docker arg generation.
 """
 
@@ -27,6 +27,7 @@ from orion.security.sandbox_config import (
     ResourceProfile,
     get_profile,
     list_profiles,
+    parse_memory_bytes,
     validate_cpus,
     validate_memory,
 )
@@ -274,3 +275,32 @@ class TestPidBounds:
         )
         p = get_profile("standard", settings)
         assert p.pids == 4096
+
+
+# ---------------------------------------------------------------------------
+# SC-09: Memory size parsing
+# ---------------------------------------------------------------------------
+
+
+class TestParseMemoryBytes:
+    """SC-09: Human and Docker memory strings parse to bytes."""
+
+    def test_binary_suffix(self):
+        assert parse_memory_bytes("512Mi") == 512 * 1024**2
+        assert parse_memory_bytes("2Gi") == 2 * 1024**3
+
+    def test_decimal_suffix(self):
+        assert parse_memory_bytes("512M") == 512 * 1000**2
+
+    def test_docker_lowercase_is_binary(self):
+        assert parse_memory_bytes("512m") == 512 * 1024**2
+        assert parse_memory_bytes("2g") == 2 * 1024**3
+
+    def test_plain_bytes(self):
+        assert parse_memory_bytes(1024) == 1024
+        assert parse_memory_bytes("1024") == 1024
+
+    def test_invalid(self):
+        for bad in ("", "lots", "-1Gi", "0", True):
+            with pytest.raises(ValueError):
+                parse_memory_bytes(bad)
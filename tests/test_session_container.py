@@ -134,6 +134,13 @@ class TestSessionContainerUnit:
         assert c.resource_profile["memory"] == "4g"
         assert c.resource_profile["cpus"] == "4"
 
+    def test_resource_overrides_merge_over_profile(self, tmp_path: Path):
+        """Per-job overrides replace individual profile keys."""
+        c = SessionContainer(
+            session_id="t", workspace_path=tmp_path, resources={"cpus": "0.5", "memory": "256m"}
+        )
+        assert c.resource_profile == {"memory": "256m", "cpus": "0.5", "pids": 256}
+
     def test_resource_profile_fallback(self, tmp_path: Path):
         """Unknown profile falls back to standard."""
         c = SessionContainer(session_id="t", profile="unknown", workspace_path=tmp_path)
@@ -191,6 +198,139 @@ class TestSessionContainerUnit:
             assert "cpus" in prof
             assert "pids" in prof
 
+    @pytest.mark.asyncio
+    async def test_exec_pidfile_runs_in_own_process_group(self, container: SessionContainer):
+        """pidfile= wraps the command in setsid and records the group leader."""
+        container._running = True
+        seen: list[list[str]] = []
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            seen.append(cmd)
+            return subprocess.CompletedProcess(cmd, 0, "", "")
+
+        container.runtime._run = fake_run
+        await container.exec("go test ./...", pidfile="/tmp/job.pid")
+        script = seen[0][-1]
+        assert script.startswith("exec setsid -w sh -c ")
+        assert "/tmp/job.pid" in script and "go test ./..." in script
+
+    @pytest.mark.asyncio
+    async def test_exec_argv_is_not_word_split(self, container: SessionContainer):
+        """An argv command reaches the runtime token for token, even in setsid."""
+        container._running = True
+        seen: list[list[str]] = []
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            seen.append(cmd)
+            return subprocess.CompletedProcess(cmd, 0, "", "")
+
+        container.runtime._run = fake_run
+        argv = ["go", "test", "-run", "Test Foo's $HOME"]
+        result = await container.exec(argv, pidfile="/tmp/job.pid", workdir="/workspace/api")
+        cmd = seen[0]
+        assert cmd[-len(argv) :] == argv
+        assert cmd[cmd.index("-w") + 1] == "/workspace/api"
+        assert cmd[cmd.index("-c") + 1] == 'echo $$ > /tmp/job.pid; exec "$@"'
+        assert result.command == "go test -run 'Test Foo'\"'\"'s $HOME'"
+
+        await container.exec(["true"])
+        assert seen[1][-2:] == [container.container_name, "true"]
+
+    @pytest.mark.asyncio
+    async def test_secret_env_not_in_argv(self, tmp_path: Path):
+        """Secrets are passed by name on argv and by value via the CLI env."""
+        c = SessionContainer(
+            session_id="t",
+            workspace_path=tmp_path / "ws",
+            aegis_config_dir=tmp_path / "aegis",
+            secret_env={"NPM_TOKEN": "hunter2"},
+        )
+        seen: list[tuple[list[str], dict | None]] = []
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            seen.append((cmd, env))
+            return subprocess.CompletedProcess(cmd, 0, "cid", "")
+
+        c.runtime._run = fake_run
+        c._is_docker_available = lambda: True
+        assert await c.start() is True
+        cmd, env = seen[0]
+        assert "hunter2" not in " ".join(cmd)
+        assert cmd[cmd.index("NPM_TOKEN") - 1] == "-e"
+        assert env["NPM_TOKEN"] == "hunter2"
+
+    @pytest.mark.asyncio
+    async def test_copy_from_confined_to_workspace(
+        self, container: SessionContainer, tmp_path: Path
+    ):
+        """Artifacts can only be copied out from under /workspace."""
+        seen: list[list[str]] = []
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            seen.append(cmd)
+            return subprocess.CompletedProcess(cmd, 0, "", "")
+
+        container.runtime._run = fake_run
+        assert await container.copy_from("/workspace/../etc/passwd", tmp_path / "x") is False
+        assert await container.copy_from("/workspace/dist/app", tmp_path / "app") is True
+        source = f"{container.container_name}:/workspace/dist/app"
+        assert seen == [["docker", "cp", source, str(tmp_path / "app")]]
+
+    @pytest.mark.asyncio
+    async def test_reset_clean(self, container: SessionContainer):
+        """Reset kills, wipes as root, then checks the layer against the baseline."""
+        container._running = True
+        seen: list[list[str]] = []
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            seen.append(cmd)
+            stdout = "A /etc/orion\nC /tmp\n" if "diff" in cmd else ""
+            return subprocess.CompletedProcess(cmd, 0, stdout, "")
+
+        container.runtime._run = fake_run
+        assert await container.reset({"A /etc/orion"}) == ""
+        assert seen[0][:4] == ["docker", "exec", "-u", "0"]
+        assert "kill -KILL -1" in seen[0][-1] and "/workspace" in seen[0][-1]
+        assert seen[1] == ["docker", "diff", container.container_name]
+        assert seen[2][-2:] == ["/etc/orion", "/tmp"]
+
+    @pytest.mark.asyncio
+    async def test_reset_refuses_dirty_container(self, container: SessionContainer):
+        """Files the job left outside /workspace make the container unusable."""
+        container._running = True
+        diff = "C /home/orion\nA /home/orion/.npmrc\n"
+        not_dirs = ""
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            checks_dirs = any(arg.startswith('for p in "$@"') for arg in cmd)
+            stdout = diff if "diff" in cmd else not_dirs if checks_dirs else ""
+            return subprocess.CompletedProcess(cmd, 0, stdout, "")
+
+        container.runtime._run = fake_run
+        assert "A /home/orion/.npmrc" in await container.reset(set())
+
+        # Changed, not added: only acceptable on a directory
+        diff, not_dirs = "C /home/orion\nC /home/orion/.bashrc\n", "/home/orion/.bashrc\n"
+        assert ".bashrc" in await container.reset(set())
+
+        container._egress_connected = True
+        assert "egress" in await container.reset(set())
+
+    @pytest.mark.asyncio
+    async def test_disk_usage_measured_as_root(self, container: SessionContainer):
+        """du runs as root, so the job cannot hide a directory from it."""
+        seen: list[list[str]] = []
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            seen.append(cmd)
+            return subprocess.CompletedProcess(cmd, 0, "2048\t/workspace\n", "")
+
+        container.runtime._run = fake_run
+        assert await container.disk_usage() == 2048 * 1024
+        cmd = seen[0]
+        assert cmd[cmd.index("-u") + 1] == "0"
+        assert cmd[-4:] == ["du", "-s", "-k", "/workspace"]
+
     @pytest.mark.asyncio
     async def test_exec_when_not_running(self, container: SessionContainer):
         """Exec returns error when container is not started."""
@@ -218,6 +358,56 @@ class TestSessionContainerUnit:
         result = await container.stop()
         assert result is True
 
+    @pytest.mark.asyncio
+    async def test_stop_reports_failed_removal(self, container: SessionContainer):
+        """A container the engine refuses to remove is not reported stopped."""
+        container._running = True
+        calls = []
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            calls.append(cmd[1])
+            stderr = "Error: removal of container is already in progress" if "rm" in cmd else ""
+            return subprocess.CompletedProcess(cmd, 1 if "rm" in cmd else 0, "", stderr)
+
+        container.runtime._run = fake_run
+        assert await container.stop() is False
+        assert container.is_running
+        assert calls == ["stop", "rm", "rm"]  # the forced retry
+
+    @pytest.mark.asyncio
+    async def test_remove_of_missing_container(self, container: SessionContainer):
+        """Removing a container the engine no longer has counts as removed."""
+        container._running = True
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            return subprocess.CompletedProcess(cmd, 1, "", "Error: No such container: x")
+
+        container.runtime._run = fake_run
+        assert await container.remove() is True
+        assert not container.is_running
+
+    @pytest.mark.asyncio
+    async def test_explicit_network_replaces_phases(self, tmp_path: Path):
+        """A named network is set at create and installs never join the egress network."""
+        c = SessionContainer(
+            session_id="t",
+            workspace_path=tmp_path / "ws",
+            aegis_config_dir=tmp_path / "aegis",
+            network="none",
+        )
+        seen: list[list[str]] = []
+
+        async def fake_run(cmd, timeout=60, input_data=None, env=None):
+            seen.append(cmd)
+            return subprocess.CompletedProcess(cmd, 0, "", "")
+
+        c.runtime._run = fake_run
+        c._is_docker_available = lambda: True
+        assert await c.start() is True
+        assert seen[0][seen[0].index("--network") + 1] == "none"
+        await c.exec_install("go mod download")
+        assert not any("network" in cmd for cmd in seen[1:])
+
 
 # ---------------------------------------------------------------------------
 # Integration Tests (require Docker)
@@ -401,6 +591,18 @@ class TestSessionContainerIntegration:
         files = await container.list_files("/workspace")
         assert any("hello.py" in f for f in files)
 
+    @pytest.mark.asyncio
+    async def test_disk_usage_counts_unreadable_directories(self, container: SessionContainer):
+        """A directory the job chmods 000 still counts toward the workspace quota."""
+        await container.start()
+        script = (
+            "mkdir /workspace/hidden && head -c 4194304 /dev/zero > /workspace/hidden/blob"
+            " && chmod 000 /workspace/hidden"
+        )
+        assert (await container.exec(script)).exit_code == 0
+        assert await container.disk_usage() >= 4 * 1024 * 1024
+        await container.exec("chmod 700 /workspace/hidden", user="0")
+
     @pytest.mark.asyncio
     async def test_start_idempotent(self, container: SessionContainer):
         """Starting an already-running container returns True."""
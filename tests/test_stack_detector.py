@@ -21,11 +21,16 @@ from pathlib import Path
 import pytest
 
 from orion.security.stack_detector import (
+    STACKS_DIR,
     VALID_STACKS,
+    StackResolutionError,
     detect_stack,
     detect_stack_from_goal,
+    discover_stacks,
     dockerfile_path,
     image_name,
+    parse_stack_label,
+    resolve_stack,
 )
 
 # ---------------------------------------------------------------------------
@@ -116,6 +121,24 @@ class TestDetectRust:
         assert detect_stack(tmp_path) == "rust"
 
 
+# ---------------------------------------------------------------------------
+# SD-04b: Java stack detection
+# ---------------------------------------------------------------------------
+
+
+class TestDetectJava:
+    """SD-04b: Maven and Gradle projects are detected as java."""
+
+    @pytest.mark.parametrize("marker", ["pom.xml", "build.gradle", "build.gradle.kts"])
+    def test_build_files(self, tmp_path: Path, marker):
+        (tmp_path / marker).write_text("\n")
+        assert detect_stack(tmp_path) == "java"
+
+    def test_java_extension(self, tmp_path: Path):
+        (tmp_path / "App.java").write_text("class App {}\n")
+        assert detect_stack(tmp_path) == "java"
+
+
 # ---------------------------------------------------------------------------
 # SD-05: Base fallback
 # ---------------------------------------------------------------------------
@@ -156,6 +179,12 @@ class TestDetectFromGoal:
     def test_rust_goal(self):
         assert detect_stack_from_goal("Build a CLI tool in Rust") == "rust"
 
+    def test_java_goal(self):
+        assert detect_stack_from_goal("Build a Spring Boot service with Maven") == "java"
+
+    def test_javascript_goal_is_not_java(self):
+        assert detect_stack_from_goal("Write some JavaScript") == "node"
+
     def test_unknown_goal(self):
         assert detect_stack_from_goal("Do something interesting") == "base"
 
@@ -194,6 +223,7 @@ class TestHelpers:
         assert "node" in VALID_STACKS
         assert "go" in VALID_STACKS
         assert "rust" in VALID_STACKS
+        assert "java" in VALID_STACKS
         assert "base" in VALID_STACKS
 
 
@@ -220,3 +250,74 @@ class TestMultiStackPriority:
         # Node: 10 (package.json) + 10 (yarn.lock) = 20
         # Python: 2 (app.py extension only)
         assert detect_stack(tmp_path) == "node"
+
+
+# ---------------------------------------------------------------------------
+# SD-09: Stack discovery from orion.stack LABELs
+# ---------------------------------------------------------------------------
+
+
+def _write_dockerfile(directory: Path, suffix: str, body: str) -> Path:
+    path = directory / f"Dockerfile.{suffix}"
+    path.write_text(body)
+    return path
+
+
+class TestStackDiscovery:
+    """SD-09: Stacks are discovered from docker/stacks/ by LABEL, not a fixed list."""
+
+    def test_repo_stacks_discovered(self):
+        stacks = discover_stacks()
+        for name in ("base", "python", "node", "go", "rust", "java"):
+            assert name in stacks
+        assert stacks["python"].dockerfile == STACKS_DIR / "Dockerfile.python"
+        assert stacks["python"].image == "orion-stack-python:latest"
+
+    def test_stacks_share_user_and_workdir(self):
+        """Every stack runs as the non-root orion user in /workspace."""
+        for stack in discover_stacks().values():
+            text = stack.dockerfile.read_text(encoding="utf-8")
+            assert "useradd -m -u 1000 -s /bin/bash orion" in text, stack.stack
+            assert "\nUSER orion\n" in text, stack.stack
+            assert text.rstrip().endswith("WORKDIR /workspace"), stack.stack
+
+    def test_parse_quoted_label(self, tmp_path: Path):
+        df = _write_dockerfile(tmp_path, "x", 'FROM ubuntu:22.04\nLABEL orion.stack="zig"\n')
+        assert parse_stack_label(df) == "zig"
+
+    def test_parse_label_among_other_keys(self, tmp_path: Path):
+        df = _write_dockerfile(
+            tmp_path, "x", 'FROM ubuntu:22.04\nLABEL maintainer="x" orion.stack=elixir\n'
+        )
+        assert parse_stack_label(df) == "elixir"
+
+    def test_empty_label_is_missing(self, tmp_path: Path):
+        df = _write_dockerfile(tmp_path, "x", 'FROM ubuntu:22.04\nLABEL orion.stack=""\n')
+        assert parse_stack_label(df) is None
+
+    def test_label_not_filename_decides_stack(self, tmp_path: Path):
+        _write_dockerfile(tmp_path, "py312", 'FROM ubuntu:22.04\nLABEL orion.stack="python"\n')
+        stacks = discover_stacks(tmp_path)
+        assert set(stacks) == {"python"}
+        assert stacks["python"].dockerfile.name == "Dockerfile.py312"
+
+    def test_unlabelled_dockerfile_skipped(self, tmp_path: Path):
+        _write_dockerfile(tmp_path, "misc", "FROM ubuntu:22.04\n")
+        assert discover_stacks(tmp_path) == {}
+
+    def test_missing_directory(self, tmp_path: Path):
+        assert discover_stacks(tmp_path / "nope") == {}
+
+    def test_resolve_python(self):
+        assert resolve_stack("python").image == "orion-stack-python:latest"
+
+    def test_resolve_java_by_label(self):
+        """Like go: the LABEL in Dockerfile.java, not the file name, names the stack."""
+        stack = resolve_stack("java")
+        assert stack.dockerfile == STACKS_DIR / "Dockerfile.java"
+        assert stack.image == "orion-stack-java:latest"
+
+    def test_resolve_unknown_raises(self, tmp_path: Path):
+        _write_dockerfile(tmp_path, "go", 'FROM ubuntu:22.04\nLABEL orion.stack="go"\n')
+        with pytest.raises(StackResolutionError, match="orion.stack='python'"):
+            resolve_stack("python", tmp_path)